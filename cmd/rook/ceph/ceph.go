@@ -52,6 +52,10 @@ type config struct {
 	networkInfo        clusterd.NetworkInfo
 	monEndpoints       string
 	nodeName           string
+	pvcBacked          bool
+	replaceOSDs        string
+	migrateOSDs        string
+	deviceClasses      string
 }
 
 func init() {
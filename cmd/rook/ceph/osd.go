@@ -17,13 +17,16 @@ limitations under the License.
 package ceph
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 
 	"github.com/rook/rook/cmd/rook/rook"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	osddaemon "github.com/rook/rook/pkg/daemon/ceph/osd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
@@ -59,17 +62,26 @@ var osdStartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Starts the osd daemon", // OSDs that were provisioned by ceph-volume
 }
+var openEncryptedCmd = &cobra.Command{
+	Use:   "open-encrypted",
+	Short: "Unlocks the encrypted devices of the osd",
+}
 var (
-	osdDataDeviceFilter string
-	ownerRefID          string
-	mountSourcePath     string
-	mountPath           string
-	osdID               int
-	copyBinariesPath    string
-	osdStoreType        string
-	osdStringID         string
-	osdUUID             string
-	osdIsDevice         bool
+	osdDataDeviceFilter         string
+	osdDataDevicePathFilter     string
+	osdDataDevicePropertyFilter string
+	ownerRefID                  string
+	mountSourcePath             string
+	mountPath                   string
+	osdID                       int
+	copyBinariesPath            string
+	osdStoreType                string
+	osdStringID                 string
+	osdUUID                     string
+	osdIsDevice                 bool
+	osdPVCDevice                string
+	osdEncrypted                bool
+	osdCephVolume               bool
 )
 
 func addOSDFlags(command *cobra.Command) {
@@ -79,8 +91,14 @@ func addOSDFlags(command *cobra.Command) {
 	// flags specific to provisioning
 	provisionCmd.Flags().StringVar(&cfg.devices, "data-devices", "", "comma separated list of devices to use for storage")
 	provisionCmd.Flags().StringVar(&osdDataDeviceFilter, "data-device-filter", "", "a regex filter for the device names to use, or \"all\"")
+	provisionCmd.Flags().StringVar(&osdDataDevicePathFilter, "data-device-path-filter", "", "a regex filter for the /dev/disk links (e.g. by-id or by-path) of the devices to use")
+	provisionCmd.Flags().StringVar(&osdDataDevicePropertyFilter, "data-device-property-filter", "", "the properties the devices must have to be used, in json (e.g. {\"rotational\":false,\"minSize\":\"100Gi\"})")
 	provisionCmd.Flags().StringVar(&cfg.directories, "data-directories", "", "comma separated list of directory paths to use for storage")
-	provisionCmd.Flags().StringVar(&cfg.metadataDevice, "metadata-device", "", "device to use for metadata (e.g. a high performance SSD/NVMe device)")
+	provisionCmd.Flags().StringVar(&cfg.metadataDevice, "metadata-device", "", "comma separated list of devices to use for metadata (e.g. high performance SSD/NVMe devices)")
+	provisionCmd.Flags().BoolVar(&cfg.pvcBacked, "pvc-backed-osd", false, "whether the data device is the block device of a PersistentVolumeClaim")
+	provisionCmd.Flags().StringVar(&cfg.deviceClasses, "data-device-classes", "", "comma separated list of devices and the crush device classes of their OSDs (e.g. sdb=ssd)")
+	provisionCmd.Flags().StringVar(&cfg.replaceOSDs, "replace-osds", "", "comma separated list of by-path device links and the ids of the destroyed osds to reuse on them (e.g. /dev/disk/by-path/pci-0000:00:1f.2-ata-1=3)")
+	provisionCmd.Flags().StringVar(&cfg.migrateOSDs, "migrate-osds", "", "comma separated list of legacy osd devices and the ids of their destroyed osds to prepare again with ceph-volume (e.g. /dev/sdb=3)")
 	provisionCmd.Flags().BoolVar(&cfg.forceFormat, "force-format", false,
 		"true to force the format of any specified devices, even if they already have a filesystem.  BE CAREFUL!")
 
@@ -99,13 +117,23 @@ func addOSDFlags(command *cobra.Command) {
 	osdStartCmd.Flags().StringVar(&osdStringID, "osd-id", "", "the osd ID")
 	osdStartCmd.Flags().StringVar(&osdUUID, "osd-uuid", "", "the osd UUID")
 	osdStartCmd.Flags().StringVar(&osdStoreType, "osd-store-type", "", "whether the osd is bluestore or filestore")
+	osdStartCmd.Flags().StringVar(&osdPVCDevice, "pvc-device", "", "the block device of the PersistentVolumeClaim backing the osd, if any")
+	osdStartCmd.Flags().BoolVar(&osdEncrypted, "osd-encrypted", false, "whether the devices of the osd are encrypted and were unlocked by rook")
+
+	// flags for unlocking the encrypted devices of an osd
+	openEncryptedCmd.Flags().StringVar(&osdStringID, "osd-id", "", "the osd ID")
+	openEncryptedCmd.Flags().StringVar(&osdUUID, "osd-uuid", "", "the osd UUID")
+	openEncryptedCmd.Flags().BoolVar(&osdCephVolume, "ceph-volume", false, "whether the osd was provisioned by ceph-volume")
+	openEncryptedCmd.Flags().StringVar(&osdPVCDevice, "pvc-device", "", "the block device of the PersistentVolumeClaim backing the osd, if any")
+	openEncryptedCmd.Flags().StringVar(&cfg.nodeName, "node-name", os.Getenv("HOSTNAME"), "the host name of the node")
 
 	// add the subcommands to the parent osd command
 	osdCmd.AddCommand(osdConfigCmd,
 		copyBinariesCmd,
 		provisionCmd,
 		filestoreDeviceCmd,
-		osdStartCmd)
+		osdStartCmd,
+		openEncryptedCmd)
 }
 
 func addOSDConfigFlags(command *cobra.Command) {
@@ -119,7 +147,11 @@ func addOSDConfigFlags(command *cobra.Command) {
 	command.Flags().IntVar(&cfg.storeConfig.JournalSizeMB, "osd-journal-size", osdcfg.JournalDefaultSizeMB, "default size (MB) for OSD journal (filestore)")
 	command.Flags().StringVar(&cfg.storeConfig.StoreType, "osd-store", "", "type of backing OSD store to use (bluestore or filestore)")
 	command.Flags().IntVar(&cfg.storeConfig.OSDsPerDevice, "osds-per-device", 1, "the number of OSDs per device")
+	command.Flags().IntVar(&cfg.storeConfig.OSDsPerMetadataDevice, "osds-per-metadata-device", 0, "the maximum number of OSDs with their database on each metadata device, or 0 to spread the OSDs evenly")
 	command.Flags().BoolVar(&cfg.storeConfig.EncryptedDevice, "encrypted-device", false, "whether to encrypt the OSD with dmcrypt")
+	command.Flags().StringVar(&cfg.storeConfig.EncryptionKeyStore, "encryption-key-store", osdcfg.EncryptionKeyStoreMon,
+		"where to keep the dmcrypt keys of encrypted OSDs (mon or secret)")
+	command.Flags().StringVar(&cfg.storeConfig.DeviceClass, "osd-device-class", "", "the crush device class of the OSDs (e.g. hdd, ssd or nvme), instead of the class detected by ceph")
 }
 
 func init() {
@@ -131,12 +163,14 @@ func init() {
 	flags.SetFlagsFromEnv(provisionCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(filestoreDeviceCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(osdStartCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(openEncryptedCmd.Flags(), rook.RookEnvVarPrefix)
 
 	osdConfigCmd.RunE = writeOSDConfig
 	copyBinariesCmd.RunE = copyRookBinaries
 	provisionCmd.RunE = prepareOSD
 	filestoreDeviceCmd.RunE = runFilestoreDeviceOSD
 	osdStartCmd.RunE = startOSD
+	openEncryptedCmd.RunE = openEncryptedOSD
 }
 
 // Start the osd daemon if provisioned by ceph-volume
@@ -149,13 +183,45 @@ func startOSD(cmd *cobra.Command, args []string) error {
 	commonOSDInit(osdStartCmd)
 
 	context := createContext()
-	err := osddaemon.StartOSD(context, osdStoreType, osdStringID, osdUUID, args)
+	err := osddaemon.StartOSD(context, osdStoreType, osdStringID, osdUUID, osdPVCDevice, osdEncrypted, args)
 	if err != nil {
 		rook.TerminateFatal(err)
 	}
 	return nil
 }
 
+// Unlock the encrypted devices of the osd before the osd daemon is started
+func openEncryptedOSD(cmd *cobra.Command, args []string) error {
+	required := []string{"osd-id", "osd-uuid"}
+	if err := flags.VerifyRequiredFlags(openEncryptedCmd, required); err != nil {
+		return err
+	}
+	required = []string{"cluster-name", "mon-endpoints", "admin-secret"}
+	if err := flags.VerifyRequiredFlags(osdCmd, required); err != nil {
+		return err
+	}
+
+	clientset, _, _, err := rook.GetClientset()
+	if err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to init k8s client. %+v\n", err))
+	}
+
+	context := createContext()
+	context.Clientset = clientset
+	commonOSDInit(openEncryptedCmd)
+
+	// the key may be kept in the mon config-key store
+	if err := cephconfig.GenerateAdminConnectionConfig(context, &clusterInfo); err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to write connection config. %+v", err))
+	}
+
+	kv := k8sutil.NewConfigMapKVStore(clusterInfo.Name, clientset, metav1.OwnerReference{})
+	if err := osddaemon.OpenEncryptedOSD(context, clusterInfo.Name, osdStringID, osdUUID, osdCephVolume, osdPVCDevice, kv, cfg.nodeName); err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
+
 // Start the osd daemon for filestore running on a device
 func runFilestoreDeviceOSD(cmd *cobra.Command, args []string) error {
 	required := []string{"source-path", "mount-path"}
@@ -239,13 +305,19 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 	}
 
 	var dataDevices []osddaemon.DesiredDevice
-	if osdDataDeviceFilter != "" {
-		if cfg.devices != "" {
-			return fmt.Errorf("Only one of --data-devices and --data-device-filter can be specified.")
+	if osdDataDeviceFilter != "" || osdDataDevicePathFilter != "" {
+		if cfg.devices != "" || (osdDataDeviceFilter != "" && osdDataDevicePathFilter != "") {
+			return fmt.Errorf("Only one of --data-devices, --data-device-filter and --data-device-path-filter can be specified.")
 		}
 
-		dataDevices = []osddaemon.DesiredDevice{
-			{Name: osdDataDeviceFilter, IsFilter: true, OSDsPerDevice: cfg.storeConfig.OSDsPerDevice},
+		if osdDataDeviceFilter != "" {
+			dataDevices = []osddaemon.DesiredDevice{
+				{Name: osdDataDeviceFilter, IsFilter: true, OSDsPerDevice: cfg.storeConfig.OSDsPerDevice},
+			}
+		} else {
+			dataDevices = []osddaemon.DesiredDevice{
+				{Name: osdDataDevicePathFilter, IsDevicePathFilter: true, OSDsPerDevice: cfg.storeConfig.OSDsPerDevice},
+			}
 		}
 	} else {
 		var err error
@@ -253,6 +325,30 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			rook.TerminateFatal(fmt.Errorf("failed to parse device list (%s). %+v", cfg.devices, err))
 		}
+		deviceClasses, err := parseDeviceClasses(cfg.deviceClasses)
+		if err != nil {
+			rook.TerminateFatal(fmt.Errorf("failed to parse device classes (%s). %+v", cfg.deviceClasses, err))
+		}
+		for i := range dataDevices {
+			dataDevices[i].DeviceClass = deviceClasses[dataDevices[i].Name]
+		}
+	}
+
+	var propertyFilter *rookalpha.DevicePropertyFilter
+	if osdDataDevicePropertyFilter != "" {
+		propertyFilter = &rookalpha.DevicePropertyFilter{}
+		if err := json.Unmarshal([]byte(osdDataDevicePropertyFilter), propertyFilter); err != nil {
+			rook.TerminateFatal(fmt.Errorf("failed to parse device property filter (%s). %+v", osdDataDevicePropertyFilter, err))
+		}
+	}
+
+	replaceOSDs, err := parseReplaceOSDs(cfg.replaceOSDs)
+	if err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to parse the osds to replace (%s). %+v", cfg.replaceOSDs, err))
+	}
+	migrateOSDs, err := parseReplaceOSDs(cfg.migrateOSDs)
+	if err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to parse the osds to migrate (%s). %+v", cfg.migrateOSDs, err))
 	}
 
 	clientset, _, rookClientset, err := rook.GetClientset()
@@ -274,8 +370,8 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 	forceFormat := false
 	ownerRef := cluster.ClusterOwnerRef(clusterInfo.Name, ownerRefID)
 	kv := k8sutil.NewConfigMapKVStore(clusterInfo.Name, clientset, ownerRef)
-	agent := osddaemon.NewAgent(context, dataDevices, cfg.metadataDevice, cfg.directories, forceFormat,
-		crushLocation, cfg.storeConfig, &clusterInfo, cfg.nodeName, kv)
+	agent := osddaemon.NewAgent(context, dataDevices, propertyFilter, cfg.metadataDevice, cfg.directories, forceFormat, cfg.pvcBacked,
+		replaceOSDs, migrateOSDs, crushLocation, cfg.storeConfig, &clusterInfo, cfg.nodeName, kv, ownerRef)
 
 	err = osddaemon.Provision(context, agent)
 	if err != nil {
@@ -300,21 +396,36 @@ func commonOSDInit(cmd *cobra.Command) {
 }
 
 // Parse the devices, which are comma separated. A colon indicates a non-default number of osds per device.
+// Devices can also be given by path, such as their /dev/disk/by-id or by-path links. Since the links can contain
+// colons, the number of osds of a path is after its last colon, which the operator always sets for paths.
 // For example, one osd will be created on each of sda and sdb, with 5 osds on the nvme01 device.
 //   sda,sdb,nvme01:5
 func parseDevices(devices string) ([]osddaemon.DesiredDevice, error) {
 	var result []osddaemon.DesiredDevice
 	parsed := strings.Split(devices, ",")
 	for _, device := range parsed {
-		parts := strings.Split(device, ":")
-		d := osddaemon.DesiredDevice{Name: parts[0], OSDsPerDevice: 1}
-		if len(parts) > 1 {
-			count, err := strconv.Atoi(parts[1])
+		name := device
+		countArg := ""
+		if i := strings.LastIndex(device, ":"); i != -1 {
+			name = device[:i]
+			countArg = device[i+1:]
+			if strings.HasPrefix(device, "/dev/") {
+				if _, err := strconv.Atoi(countArg); err != nil {
+					// the colon is part of the path
+					name = device
+					countArg = ""
+				}
+			}
+		}
+
+		d := osddaemon.DesiredDevice{Name: name, OSDsPerDevice: 1}
+		if countArg != "" {
+			count, err := strconv.Atoi(countArg)
 			if err != nil {
 				return nil, fmt.Errorf("error parsing count from devices (%s). %+v", devices, err)
 			}
 			if count < 1 {
-				return nil, fmt.Errorf("osds per device should be greater than 0 (%s)", parts[1])
+				return nil, fmt.Errorf("osds per device should be greater than 0 (%s)", countArg)
 			}
 			d.OSDsPerDevice = count
 		}
@@ -325,6 +436,52 @@ func parseDevices(devices string) ([]osddaemon.DesiredDevice, error) {
 	return result, nil
 }
 
+// Parse the crush device classes of the devices, which are comma separated. For example, sdb=ssd gives
+// the class ssd to the osds on sdb.
+func parseDeviceClasses(deviceClasses string) (map[string]string, error) {
+	result := map[string]string{}
+	if deviceClasses == "" {
+		return result, nil
+	}
+	for _, pair := range strings.Split(deviceClasses, ",") {
+		i := strings.LastIndex(pair, "=")
+		if i < 1 || i == len(pair)-1 {
+			return nil, fmt.Errorf("expected <device>=<device class> instead of %s", pair)
+		}
+		result[pair[:i]] = pair[i+1:]
+	}
+
+	logger.Infof("device classes of the desired devices: %+v", result)
+	return result, nil
+}
+
+// Parse the by-path device links where a destroyed osd is replaced, and the ids to reuse, which are
+// comma separated. For example, /dev/disk/by-path/pci-0000:00:1f.2-ata-1=3 gives the id 3 to the
+// osd prepared on the new device in that slot. The devices of the migrated osds are given the same way.
+func parseReplaceOSDs(replaceOSDs string) (map[string]int, error) {
+	result := map[string]int{}
+	if replaceOSDs == "" {
+		return result, nil
+	}
+	for _, pair := range strings.Split(replaceOSDs, ",") {
+		i := strings.LastIndex(pair, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("expected <device link>=<osd id> instead of %s", pair)
+		}
+		id, err := strconv.Atoi(pair[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing osd id from %s. %+v", pair, err)
+		}
+		if id < 0 {
+			return nil, fmt.Errorf("osd id should not be negative (%s)", pair)
+		}
+		result[pair[:i]] = id
+	}
+
+	logger.Infof("devices of the destroyed osds: %+v", result)
+	return result, nil
+}
+
 func copyBinaries(path string) {
 	if path != "" {
 		if err := osddaemon.CopyBinariesForDaemon(path); err != nil {
@@ -48,4 +48,54 @@ func TestParseDesiredDevices(t *testing.T) {
 	result, err = parseDevices(devices)
 	assert.Nil(t, result)
 	assert.NotNil(t, err)
+
+	// the colons of the device links are not taken for the osd count
+	devices = "/dev/disk/by-path/pci-0000:00:1f.2-ata-1,/dev/disk/by-path/pci-0000:03:00.0-scsi-0:0:2:0:1,/dev/disk/by-id/nvme-INTEL_SSDPE2KX010T8_BTLJ:3"
+	result, err = parseDevices(devices)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(result))
+	assert.Equal(t, "/dev/disk/by-path/pci-0000:00:1f.2-ata-1", result[0].Name)
+	assert.Equal(t, 1, result[0].OSDsPerDevice)
+	assert.Equal(t, "/dev/disk/by-path/pci-0000:03:00.0-scsi-0:0:2:0", result[1].Name)
+	assert.Equal(t, 1, result[1].OSDsPerDevice)
+	assert.Equal(t, "/dev/disk/by-id/nvme-INTEL_SSDPE2KX010T8_BTLJ", result[2].Name)
+	assert.Equal(t, 3, result[2].OSDsPerDevice)
+}
+
+func TestParseReplaceOSDs(t *testing.T) {
+	result, err := parseReplaceOSDs("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(result))
+
+	result, err = parseReplaceOSDs("/dev/disk/by-path/pci-0000:00:1f.2-ata-1=3,/dev/disk/by-path/pci-0000:00:1f.2-ata-2=0")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{
+		"/dev/disk/by-path/pci-0000:00:1f.2-ata-1": 3,
+		"/dev/disk/by-path/pci-0000:00:1f.2-ata-2": 0,
+	}, result)
+
+	_, err = parseReplaceOSDs("/dev/disk/by-path/pci-0000:00:1f.2-ata-1")
+	assert.NotNil(t, err)
+	_, err = parseReplaceOSDs("/dev/disk/by-path/pci-0000:00:1f.2-ata-1=x")
+	assert.NotNil(t, err)
+	_, err = parseReplaceOSDs("/dev/disk/by-path/pci-0000:00:1f.2-ata-1=-1")
+	assert.NotNil(t, err)
+}
+
+func TestParseDeviceClasses(t *testing.T) {
+	result, err := parseDeviceClasses("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(result))
+
+	result, err = parseDeviceClasses("sdb=ssd,/dev/disk/by-path/pci-0000:00:1f.2-ata-1=hdd")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"sdb": "ssd",
+		"/dev/disk/by-path/pci-0000:00:1f.2-ata-1": "hdd",
+	}, result)
+
+	_, err = parseDeviceClasses("sdb")
+	assert.NotNil(t, err)
+	_, err = parseDeviceClasses("sdb=")
+	assert.NotNil(t, err)
 }
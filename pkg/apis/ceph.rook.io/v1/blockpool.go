@@ -18,10 +18,11 @@ package v1
 import "github.com/rook/rook/pkg/daemon/ceph/model"
 
 func (p *PoolSpec) ToModel(name string) *model.Pool {
-	pool := &model.Pool{Name: name, FailureDomain: p.FailureDomain, CrushRoot: p.CrushRoot}
+	pool := &model.Pool{Name: name, FailureDomain: p.FailureDomain, CrushRoot: p.CrushRoot, DeviceClass: p.DeviceClass, CrushRule: p.CrushRule}
 	r := p.Replication()
 	if r != nil {
 		pool.ReplicatedConfig.Size = r.Size
+		pool.ReplicatedConfig.MinSize = r.MinSize
 		pool.Type = model.Replicated
 	} else {
 		ec := p.ErasureCode()
@@ -57,14 +57,24 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CephClusterList{},
 		&CephBlockPool{},
 		&CephBlockPoolList{},
+		&CephCrushMap{},
+		&CephCrushMapList{},
 		&CephFilesystem{},
 		&CephFilesystemList{},
 		&CephNFS{},
 		&CephNFSList{},
+		&CephNFSExport{},
+		&CephNFSExportList{},
 		&CephObjectStore{},
 		&CephObjectStoreList{},
 		&CephObjectStoreUser{},
 		&CephObjectStoreUserList{},
+		&CephOSDReplacement{},
+		&CephOSDReplacementList{},
+		&ObjectBucket{},
+		&ObjectBucketList{},
+		&ObjectBucketClaim{},
+		&ObjectBucketClaimList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetCondition returns the condition of the given type, or nil if the condition is not set
+func (s *ResourceStatus) GetCondition(t ConditionType) *Condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds the condition or replaces the existing condition of the same type. The
+// transition time is only moved forward when the status of the condition changes.
+func (s *ResourceStatus) SetCondition(t ConditionType, status v1.ConditionStatus, reason ConditionReason, message string) {
+	c := Condition{
+		Type:               t,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	}
+	existing := s.GetCondition(t)
+	if existing == nil {
+		s.Conditions = append(s.Conditions, c)
+		return
+	}
+	if existing.Status == status {
+		c.LastTransitionTime = existing.LastTransitionTime
+	}
+	*existing = c
+}
+
+// IsConditionTrue returns whether the condition of the given type is set and true
+func (s *ResourceStatus) IsConditionTrue(t ConditionType) bool {
+	c := s.GetCondition(t)
+	return c != nil && c.Status == v1.ConditionTrue
+}
+
+// SetPhase derives the phase from the given conditions. The phase is Failure if any of them is
+// false, Ready if all of them are true, and Progressing otherwise.
+func (s *ResourceStatus) SetPhase(types ...ConditionType) {
+	phase := ResourcePhaseReady
+	for _, t := range types {
+		c := s.GetCondition(t)
+		if c == nil || c.Status == v1.ConditionUnknown {
+			phase = ResourcePhaseProgressing
+			continue
+		}
+		if c.Status == v1.ConditionFalse {
+			s.Phase = ResourcePhaseFailure
+			return
+		}
+	}
+	s.Phase = phase
+}
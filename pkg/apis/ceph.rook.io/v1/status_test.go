@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	s := &ResourceStatus{}
+	assert.Nil(t, s.GetCondition(ConditionPoolsReady))
+
+	s.SetCondition(ConditionPoolsReady, v1.ConditionFalse, ReasonPoolFailed, "failed")
+	assert.Equal(t, 1, len(s.Conditions))
+	assert.False(t, s.IsConditionTrue(ConditionPoolsReady))
+
+	// the transition time is kept when only the reason or message changes
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	s.Conditions[0].LastTransitionTime = past
+	s.SetCondition(ConditionPoolsReady, v1.ConditionFalse, ReasonInvalidSpec, "invalid")
+	c := s.GetCondition(ConditionPoolsReady)
+	assert.Equal(t, ReasonInvalidSpec, c.Reason)
+	assert.Equal(t, "invalid", c.Message)
+	assert.Equal(t, past, c.LastTransitionTime)
+
+	// the transition time moves when the status changes
+	s.SetCondition(ConditionPoolsReady, v1.ConditionTrue, ReasonPoolCreated, "")
+	c = s.GetCondition(ConditionPoolsReady)
+	assert.True(t, s.IsConditionTrue(ConditionPoolsReady))
+	assert.True(t, c.LastTransitionTime.After(past.Time))
+
+	s.SetCondition(ConditionDaemonsReady, v1.ConditionTrue, ReasonMDSActive, "")
+	assert.Equal(t, 2, len(s.Conditions))
+}
+
+func TestSetPhase(t *testing.T) {
+	s := &ResourceStatus{}
+	s.SetPhase(ConditionPoolsReady, ConditionDaemonsReady)
+	assert.Equal(t, ResourcePhaseProgressing, s.Phase)
+
+	s.SetCondition(ConditionPoolsReady, v1.ConditionTrue, ReasonPoolCreated, "")
+	s.SetPhase(ConditionPoolsReady, ConditionDaemonsReady)
+	assert.Equal(t, ResourcePhaseProgressing, s.Phase)
+
+	s.SetCondition(ConditionDaemonsReady, v1.ConditionFalse, ReasonRGWFailed, "")
+	s.SetPhase(ConditionPoolsReady, ConditionDaemonsReady)
+	assert.Equal(t, ResourcePhaseFailure, s.Phase)
+
+	s.SetCondition(ConditionDaemonsReady, v1.ConditionTrue, ReasonRGWReady, "")
+	s.SetPhase(ConditionPoolsReady, ConditionDaemonsReady)
+	assert.Equal(t, ResourcePhaseReady, s.Phase)
+}
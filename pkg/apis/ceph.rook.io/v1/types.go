@@ -66,6 +66,10 @@ type ClusterSpec struct {
 	// Resources set resource requests and limits
 	Resources rook.ResourceSpec `json:"resources,omitempty"`
 
+	// The fraction of the memory limit of the osd and mon pods that is kept free of the memory targets
+	// of the daemons, e.g. "0.2". Defaults to 0.2.
+	MemoryHeadroomRatio string `json:"memoryHeadroomRatio,omitempty"`
+
 	// The path on the host where config and data can be persisted.
 	DataDirHostPath string `json:"dataDirHostPath,omitempty"`
 
@@ -77,6 +81,28 @@ type ClusterSpec struct {
 
 	// Dashboard settings
 	Dashboard DashboardSpec `json:"dashboard,omitempty"`
+
+	// A spec for mgr related options
+	Mgr MgrSpec `json:"mgr,omitempty"`
+
+	// Ceph config overrides keyed by config section (e.g., "global", "osd", "osd.3", "client.rgw")
+	// and then by option name. On Mimic and newer the overrides are stored in the mon config
+	// database. On Luminous they are added to the generated ceph.conf.
+	CephConfig map[string]map[string]string `json:"cephConfig,omitempty"`
+
+	// Upgrade settings that pace the update of the daemons when the ceph image is changed
+	Upgrade UpgradeSpec `json:"upgrade,omitempty"`
+}
+
+// UpgradeSpec represents the settings for the upgrades of the ceph daemons to a new ceph image
+type UpgradeSpec struct {
+	// Whether to stop the upgrade before the next type of daemons or batch of osds is updated
+	Paused bool `json:"paused,omitempty"`
+	// The number of failure domains whose osds are updated before the cluster must be clean again.
+	// Defaults to 1.
+	OSDBatchSize int `json:"osdBatchSize,omitempty"`
+	// The crush bucket type the osds are updated by, such as host, rack or zone. Defaults to host.
+	FailureDomain string `json:"failureDomain,omitempty"`
 }
 
 // VersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -104,8 +130,41 @@ type ClusterStatus struct {
 	State      ClusterState `json:"state,omitempty"`
 	Message    string       `json:"message,omitempty"`
 	CephStatus *CephStatus  `json:"ceph,omitempty"`
+	// Config overrides that had been changed outside of the CephCluster spec when they were last applied
+	CephConfigDrift []CephConfigDrift `json:"cephConfigDrift,omitempty"`
+	// Config overrides the operator last applied to the mon config database, so the overrides that are
+	// removed from the spec are removed from the database
+	AppliedCephConfig map[string]map[string]string `json:"appliedCephConfig,omitempty"`
+	// The progress of the upgrade to the ceph image of the spec
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+}
+
+// UpgradeStatus is the progress of an upgrade of the ceph daemons from one ceph image to another
+type UpgradeStatus struct {
+	FromImage string `json:"fromImage"`
+	ToImage   string `json:"toImage"`
+	// The type of daemons being updated, or Completed when all the daemons run the new image
+	Phase UpgradePhase `json:"phase,omitempty"`
+	// Whether the upgrade is stopped until spec.upgrade.paused is unset
+	Paused bool `json:"paused,omitempty"`
+	// The failure domains whose osds were updated
+	UpgradedFailureDomains []string `json:"upgradedFailureDomains,omitempty"`
+	Message                string   `json:"message,omitempty"`
 }
 
+// UpgradePhase is a step of an upgrade. The daemons are updated in the order of the phases.
+type UpgradePhase string
+
+const (
+	UpgradePhaseMon       UpgradePhase = "mon"
+	UpgradePhaseMgr       UpgradePhase = "mgr"
+	UpgradePhaseOSD       UpgradePhase = "osd"
+	UpgradePhaseMDS       UpgradePhase = "mds"
+	UpgradePhaseRGW       UpgradePhase = "rgw"
+	UpgradePhaseNFS       UpgradePhase = "nfs"
+	UpgradePhaseCompleted UpgradePhase = "Completed"
+)
+
 type CephStatus struct {
 	Health         string                       `json:"health,omitempty"`
 	Details        map[string]CephHealthMessage `json:"details,omitempty"`
@@ -114,6 +173,15 @@ type CephStatus struct {
 	PreviousHealth string                       `json:"previousHealth,omitempty"`
 }
 
+// CephConfigDrift describes a config override whose value in the mon config database differed
+// from the spec before the operator reset it
+type CephConfigDrift struct {
+	Section  string `json:"section"`
+	Option   string `json:"option"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
 type CephHealthMessage struct {
 	Severity string `json:"severity"`
 	Message  string `json:"message"`
@@ -132,20 +200,134 @@ type MonSpec struct {
 	Count                int  `json:"count"`
 	PreferredCount       int  `json:"preferredCount"`
 	AllowMultiplePerNode bool `json:"allowMultiplePerNode"`
+	// The node label the mons are spread across so that no zone holds a majority of the mons
+	ZoneTopologyKey string `json:"zoneTopologyKey,omitempty"`
+	// The template of the PVC each mon stores its data on instead of the DataDirHostPath
+	VolumeClaimTemplate *v1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+	// The two data zones and the arbiter zone of a cluster that is stretched across two data centers
+	StretchCluster *StretchClusterSpec `json:"stretchCluster,omitempty"`
+}
+
+// StretchClusterSpec represents the zones of a cluster that is stretched across two data centers
+type StretchClusterSpec struct {
+	// The zones the mons are placed in: two data zones with two mons each and the arbiter zone with the tiebreaker mon
+	Zones []StretchClusterZoneSpec `json:"zones"`
+}
+
+// StretchClusterZoneSpec represents a zone of a stretch cluster
+type StretchClusterZoneSpec struct {
+	// The name of the zone, the value of the zone label of the nodes in the zone
+	Name string `json:"name"`
+	// Whether the zone only runs the tiebreaker mon
+	Arbiter bool `json:"arbiter,omitempty"`
+}
+
+// MgrSpec represents options to configure a ceph mgr
+type MgrSpec struct {
+	// The number of mgr daemons. One mgr is active and the others are standby. Defaults to 1.
+	Count int `json:"count,omitempty"`
+	// The mgr modules to enable or disable, and their settings
+	Modules []MgrModule `json:"modules,omitempty"`
+}
+
+// MgrModule represents a mgr module that is enabled or disabled by the operator
+type MgrModule struct {
+	// The name of the mgr module, such as pg_autoscaler or balancer
+	Name string `json:"name"`
+	// Whether the module is enabled. Defaults to true.
+	Enabled *bool `json:"enabled,omitempty"`
+	// The settings of the module, without the mgr/<module>/ prefix of their config keys
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// IsEnabled returns true if the module must be enabled
+func (m *MgrModule) IsEnabled() bool {
+	return m.Enabled == nil || *m.Enabled
 }
 
 type RBDMirroringSpec struct {
 	Workers int `json:"workers"`
 }
 
+// ResourceStatus is the status reported by the operator for a pool, filesystem, object store,
+// object store user or nfs resource
+type ResourceStatus struct {
+	// The overall state of the resource
+	Phase ResourcePhase `json:"phase,omitempty"`
+	// The generation of the spec that was last reconciled by the operator
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The latest observations of the state of the resource
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+type ResourcePhase string
+
+const (
+	ResourcePhaseProgressing ResourcePhase = "Progressing"
+	ResourcePhaseReady       ResourcePhase = "Ready"
+	ResourcePhaseFailure     ResourcePhase = "Failure"
+)
+
+// Condition represents an observation of one aspect of the state of a resource
+type Condition struct {
+	Type               ConditionType      `json:"type"`
+	Status             v1.ConditionStatus `json:"status"`
+	Reason             ConditionReason    `json:"reason,omitempty"`
+	Message            string             `json:"message,omitempty"`
+	LastTransitionTime metav1.Time        `json:"lastTransitionTime,omitempty"`
+}
+
+type ConditionType string
+
+const (
+	// ConditionPoolsReady is true when the pools backing the resource were created and configured
+	ConditionPoolsReady ConditionType = "PoolsReady"
+	// ConditionDaemonsReady is true when the mds, rgw or ganesha daemons of the resource were started
+	ConditionDaemonsReady ConditionType = "DaemonsReady"
+	// ConditionUserReady is true when the object store user was created
+	ConditionUserReady ConditionType = "UserReady"
+	// ConditionExportReady is true when the nfs export was written to the ganesha config
+	ConditionExportReady ConditionType = "ExportReady"
+	// ConditionOSDDestroyed is true when the data was moved off the osd and the osd was destroyed
+	ConditionOSDDestroyed ConditionType = "OSDDestroyed"
+	// ConditionOSDReplaced is true when a new osd was prepared with the id of the destroyed osd
+	ConditionOSDReplaced ConditionType = "OSDReplaced"
+	// ConditionCrushMapApplied is true when the buckets, osds and rules were merged into the crush map
+	ConditionCrushMapApplied ConditionType = "CrushMapApplied"
+)
+
+type ConditionReason string
+
+const (
+	ReasonInvalidSpec        ConditionReason = "InvalidSpec"
+	ReasonPoolCreated        ConditionReason = "PoolCreated"
+	ReasonPoolFailed         ConditionReason = "PoolFailed"
+	ReasonMDSActive          ConditionReason = "MDSActive"
+	ReasonMDSFailed          ConditionReason = "MDSFailed"
+	ReasonRGWReady           ConditionReason = "RGWReady"
+	ReasonRGWFailed          ConditionReason = "RGWFailed"
+	ReasonGaneshaGraceJoined ConditionReason = "GaneshaGraceJoined"
+	ReasonGaneshaFailed      ConditionReason = "GaneshaFailed"
+	ReasonUserCreated        ConditionReason = "UserCreated"
+	ReasonUserFailed         ConditionReason = "UserFailed"
+	ReasonExportApplied      ConditionReason = "ExportApplied"
+	ReasonExportFailed       ConditionReason = "ExportFailed"
+	ReasonOSDDestroyed       ConditionReason = "OSDDestroyed"
+	ReasonOSDDestroyFailed   ConditionReason = "OSDDestroyFailed"
+	ReasonWaitingForDevice   ConditionReason = "WaitingForDevice"
+	ReasonOSDReplaced        ConditionReason = "OSDReplaced"
+	ReasonCrushMapApplied    ConditionReason = "CrushMapApplied"
+	ReasonCrushMapFailed     ConditionReason = "CrushMapFailed"
+)
+
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type CephBlockPool struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              PoolSpec `json:"spec"`
+	Spec              PoolSpec            `json:"spec"`
+	Status            CephBlockPoolStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -164,17 +346,115 @@ type PoolSpec struct {
 	// The root of the crush hierarchy utilized by the pool
 	CrushRoot string `json:"crushRoot"`
 
+	// The device class of the osds the pool is placed on (e.g. hdd, ssd or nvme)
+	DeviceClass string `json:"deviceClass,omitempty"`
+
+	// The existing crush rule of a replicated pool, which replaces the rule derived from the failure domain,
+	// crush root and device class
+	CrushRule string `json:"crushRule,omitempty"`
+
 	// The replication settings
 	Replicated ReplicatedSpec `json:"replicated"`
 
 	// The erasure code settings
 	ErasureCoded ErasureCodedSpec `json:"erasureCoded"`
+
+	// The quota settings
+	Quotas QuotaSpec `json:"quotas,omitempty"`
+
+	// The inline compression settings (bluestore only)
+	Compression CompressionSpec `json:"compression,omitempty"`
+
+	// The placement group settings
+	PlacementGroups PlacementGroupSpec `json:"placementGroups,omitempty"`
+
+	// The rbd mirroring settings
+	Mirroring MirroringSpec `json:"mirroring,omitempty"`
+}
+
+// CephBlockPoolStatus represents the status of a pool
+type CephBlockPoolStatus struct {
+	ResourceStatus `json:",inline"`
+	// The health of the rbd mirroring of the pool, if mirroring is enabled
+	Mirroring *MirroringStatus `json:"mirroring,omitempty"`
+}
+
+// QuotaSpec represents the quotas of a pool. A value of zero means no quota.
+type QuotaSpec struct {
+	// The maximum number of bytes stored in the pool
+	MaxBytes uint64 `json:"maxBytes,omitempty"`
+
+	// The maximum number of objects stored in the pool
+	MaxObjects uint64 `json:"maxObjects,omitempty"`
+}
+
+// CompressionSpec represents the bluestore compression settings of a pool. Unset values keep the
+// Ceph defaults.
+type CompressionSpec struct {
+	// The compression mode: none, passive, aggressive or force
+	Mode string `json:"mode,omitempty"`
+
+	// The compression algorithm: snappy, zlib, zstd or lz4
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// The ratio of the compressed size to the original size below which compressed data is kept, e.g. "0.875"
+	RequiredRatio string `json:"requiredRatio,omitempty"`
+
+	// Chunks smaller than this are never compressed
+	MinBlobSize uint64 `json:"minBlobSize,omitempty"`
+
+	// Chunks larger than this are broken up before being compressed
+	MaxBlobSize uint64 `json:"maxBlobSize,omitempty"`
+}
+
+// PlacementGroupSpec represents the placement group settings of a pool
+type PlacementGroupSpec struct {
+	// The number of placement groups (pg_num)
+	PgNum uint `json:"pgNum,omitempty"`
+
+	// The number of placement groups for placement (pgp_num). Defaults to PgNum.
+	PgpNum uint `json:"pgpNum,omitempty"`
+
+	// The placement group autoscaler mode (Nautilus or newer): on, off or warn
+	AutoscaleMode string `json:"autoscaleMode,omitempty"`
+
+	// The expected ratio of the cluster capacity used by the pool, used by the autoscaler, e.g. "0.2"
+	TargetSizeRatio string `json:"targetSizeRatio,omitempty"`
+}
+
+// MirroringSpec represents the rbd mirroring settings of a pool
+type MirroringSpec struct {
+	// The mirroring mode: disabled, pool or image. If not set, the mirroring of the pool is not managed.
+	Mode string `json:"mode,omitempty"`
+
+	// The peer clusters that the pool is mirrored from
+	Peers []MirroringPeerSpec `json:"peers,omitempty"`
+}
+
+// MirroringPeerSpec represents a remote cluster that a pool is mirrored from
+type MirroringPeerSpec struct {
+	// The name of the secret with the clusterName, monHost, key and optional client of the remote cluster
+	SecretName string `json:"secretName"`
+}
+
+// MirroringStatus represents the health of the rbd mirroring of a pool as reported by 'rbd mirror pool status'
+type MirroringStatus struct {
+	// The overall mirroring health: OK, WARNING or ERROR
+	Health string `json:"health,omitempty"`
+	// The number of images in each mirroring state, e.g. replaying or stopped
+	States map[string]int `json:"states,omitempty"`
+	// The peers that are configured on the pool in the form client@cluster
+	Peers []string `json:"peers,omitempty"`
+	// The time when the mirroring health last changed
+	LastChanged string `json:"lastChanged,omitempty"`
 }
 
 // ReplicationSpec represents the spec for replication in a pool
 type ReplicatedSpec struct {
 	// Number of copies per object in a replicated storage pool, including the object itself (required for replicated pool type)
 	Size uint `json:"size"`
+	// Number of copies that must be available for the pool to accept I/O (ceph's default if not set)
+	MinSize uint `json:"minSize,omitempty"`
 }
 
 // ErasureCodeSpec represents the spec for erasure code in a pool
@@ -190,13 +470,21 @@ type ErasureCodedSpec struct {
 }
 
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type CephFilesystem struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              FilesystemSpec `json:"spec"`
+	Spec              FilesystemSpec       `json:"spec"`
+	Status            CephFilesystemStatus `json:"status,omitempty"`
+}
+
+// CephFilesystemStatus represents the status of a Ceph Filesystem
+type CephFilesystemStatus struct {
+	ResourceStatus `json:",inline"`
+	// The max file size last applied to the filesystem, so it can be reset to the ceph default
+	// when it is removed from the spec
+	MaxFileSize int64 `json:"maxFileSize,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -217,6 +505,54 @@ type FilesystemSpec struct {
 
 	// The mds pod info
 	MetadataServer MetadataServerSpec `json:"metadataServer"`
+
+	// The subvolume groups of the filesystem
+	SubvolumeGroups []SubvolumeGroupSpec `json:"subvolumeGroups,omitempty"`
+
+	// Whether the filesystem can be created when other filesystems already exist in the cluster
+	AllowMultiple bool `json:"allowMultiple,omitempty"`
+
+	// The maximum size of a file in bytes. If zero the default of ceph applies.
+	MaxFileSize int64 `json:"maxFileSize,omitempty"`
+}
+
+// SubvolumeGroupSpec represents a group of subvolumes in a filesystem, for example the subvolumes of a tenant
+type SubvolumeGroupSpec struct {
+	// The name of the group
+	Name string `json:"name"`
+
+	// The data pool in which the files of the group are stored. If empty the first data pool of the filesystem is used.
+	DataPool string `json:"dataPool,omitempty"`
+
+	// The mds rank to which the group is pinned with the ceph.dir.pin attribute. If not set the group is not pinned.
+	PinRank *int `json:"pinRank,omitempty"`
+
+	// The subvolumes of the group
+	Subvolumes []SubvolumeSpec `json:"subvolumes,omitempty"`
+}
+
+// SubvolumeSpec represents a directory of a filesystem with a quota and its own clients
+type SubvolumeSpec struct {
+	// The name of the subvolume
+	Name string `json:"name"`
+
+	// The maximum number of bytes stored in the subvolume. Zero means no quota.
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+
+	// The maximum number of files and directories in the subvolume (ceph.quota.max_files). Zero means no quota.
+	MaxFiles int64 `json:"maxFiles,omitempty"`
+
+	// The clients that can only access the subvolume
+	Clients []SubvolumeClientSpec `json:"clients,omitempty"`
+}
+
+// SubvolumeClientSpec represents a cephx client restricted to the path of a subvolume
+type SubvolumeClientSpec struct {
+	// The name of the client. The cephx id of the client is the name with the "rook-fs-" prefix.
+	Name string `json:"name"`
+
+	// Whether the client can only read the subvolume
+	ReadOnly bool `json:"readOnly,omitempty"`
 }
 
 type MetadataServerSpec struct {
@@ -235,16 +571,23 @@ type MetadataServerSpec struct {
 
 	// The resource requirements for the rgw pods
 	Resources v1.ResourceRequirements `json:"resources"`
+
+	// The fraction of the memory limit of the mds pods that is kept free of the memory target of the
+	// mds, e.g. "0.2". Defaults to 0.2.
+	MemoryHeadroomRatio string `json:"memoryHeadroomRatio,omitempty"`
+
+	// The priority class of the mds pods
+	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type CephObjectStore struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              ObjectStoreSpec `json:"spec"`
+	Spec              ObjectStoreSpec       `json:"spec"`
+	Status            CephObjectStoreStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -265,16 +608,74 @@ type ObjectStoreSpec struct {
 
 	// The rgw pod info
 	Gateway GatewaySpec `json:"gateway"`
+
+	// The multisite settings of the zone of the object store
+	Zone ZoneSpec `json:"zone,omitempty"`
+}
+
+// ZoneSpec represents the realm and zonegroup that the zone of an object store belongs to. The zone
+// is always named after the object store.
+type ZoneSpec struct {
+	// The name of the realm. If not set, the realm and zonegroup are named after the object store.
+	Realm string `json:"realm,omitempty"`
+
+	// The name of the zonegroup. Defaults to the name of the realm.
+	ZoneGroup string `json:"zoneGroup,omitempty"`
+
+	// The endpoint of the master zone of the realm, e.g. http://10.1.2.3:80. If set, the realm is
+	// pulled from the endpoint and the object store joins it as a secondary zone.
+	PullEndpoint string `json:"pullEndpoint,omitempty"`
+
+	// The endpoint of this zone advertised to the other zones. Defaults to the rgw service.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The name of the secret with the AccessKey and SecretKey of the system user of the realm
+	SystemUserSecretName string `json:"systemUserSecretName,omitempty"`
+
+	// Whether the zone is the master zone of the zonegroup. Setting it on a secondary zone promotes
+	// the zone to master.
+	Master bool `json:"master,omitempty"`
+}
+
+// CephObjectStoreStatus represents the status of an object store
+type CephObjectStoreStatus struct {
+	ResourceStatus `json:",inline"`
+	// The sync state of the zone, if the object store is part of a multisite realm
+	Sync *ZoneSyncStatus `json:"sync,omitempty"`
+}
+
+// ZoneSyncStatus is the sync state of a zone as reported by 'radosgw-admin sync status'
+type ZoneSyncStatus struct {
+	// The metadata sync state, e.g. "caught up with master"
+	Metadata string `json:"metadata,omitempty"`
+	// The data sync state from each of the other zones
+	Data []ZoneSyncSource `json:"data,omitempty"`
+	// The time at which the sync state last changed
+	LastChanged string `json:"lastChanged,omitempty"`
+}
+
+// ZoneSyncSource is the data sync state from another zone
+type ZoneSyncSource struct {
+	Zone   string `json:"zone"`
+	Status string `json:"status"`
 }
 
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type CephObjectStoreUser struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              ObjectStoreUserSpec `json:"spec"`
+	Spec              ObjectStoreUserSpec       `json:"spec"`
+	Status            CephObjectStoreUserStatus `json:"status,omitempty"`
+}
+
+// CephObjectStoreUserStatus represents the status of an object store user
+type CephObjectStoreUserStatus struct {
+	ResourceStatus `json:",inline"`
+	// The rotateKeys value of the spec when the current keys of the user were generated, so the keys
+	// are only rotated again when the value changes
+	KeysRotation string `json:"keysRotation,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -291,8 +692,173 @@ type ObjectStoreUserSpec struct {
 	Store string `json:"store,omitempty"`
 	//The display name for the ceph users
 	DisplayName string `json:"displayName,omitempty"`
+	// The maximum number of buckets the user can own. If not set the default of rgw applies.
+	MaxBuckets *int `json:"maxBuckets,omitempty"`
+	// The quota of all the buckets of the user together
+	UserQuota ObjectUserQuotaSpec `json:"userQuota,omitempty"`
+	// The quota of each bucket of the user
+	BucketQuota ObjectUserQuotaSpec `json:"bucketQuota,omitempty"`
+	// The admin capabilities of the user
+	Capabilities ObjectUserCapSpec `json:"capabilities,omitempty"`
+	// Whether the user is suspended. A suspended user can't access the object store.
+	Suspended bool `json:"suspended,omitempty"`
+	// The swift subusers of the user
+	Subusers []ObjectSubuserSpec `json:"subusers,omitempty"`
+	// Setting a value other than the one in status.keysRotation regenerates the S3 keys of the user and
+	// updates the secret with the new keys, for example set it to the date of the rotation
+	RotateKeys string `json:"rotateKeys,omitempty"`
+}
+
+// ObjectUserQuotaSpec represents the quotas of an object store user. A value of zero means no quota.
+type ObjectUserQuotaSpec struct {
+	// The maximum number of bytes stored
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+
+	// The maximum number of objects stored
+	MaxObjects int64 `json:"maxObjects,omitempty"`
+}
+
+// ObjectUserCapSpec represents the admin capabilities of an object store user. Each capability is
+// one of "read", "write" or "*", or empty for no access.
+type ObjectUserCapSpec struct {
+	Users    string `json:"users,omitempty"`
+	Buckets  string `json:"buckets,omitempty"`
+	Metadata string `json:"metadata,omitempty"`
+	Usage    string `json:"usage,omitempty"`
+	Zone     string `json:"zone,omitempty"`
+}
+
+// ObjectSubuserSpec represents a swift subuser of an object store user
+type ObjectSubuserSpec struct {
+	// The name of the subuser, without the user ID
+	Name string `json:"name"`
+	// The access of the subuser: "read", "write", "readwrite" or "full"
+	Access string `json:"access"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ObjectBucketClaim is a request for a bucket in the object store of a storage class
+type ObjectBucketClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectBucketClaimSpec   `json:"spec"`
+	Status            ObjectBucketClaimStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ObjectBucketClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ObjectBucketClaim `json:"items"`
 }
 
+// ObjectBucketClaimSpec represents the bucket requested by a claim
+type ObjectBucketClaimSpec struct {
+	// The name of the storage class that points at the object store of the bucket
+	StorageClassName string `json:"storageClassName"`
+
+	// The name of the bucket. If not set, the name is generated from generateBucketName.
+	BucketName string `json:"bucketName,omitempty"`
+
+	// The prefix of the generated bucket name
+	GenerateBucketName string `json:"generateBucketName,omitempty"`
+}
+
+// ObjectBucketClaimStatus represents the provisioning state of a claim
+type ObjectBucketClaimStatus struct {
+	Phase ObjectBucketClaimPhase `json:"phase,omitempty"`
+
+	// The name of the object bucket bound to the claim
+	ObjectBucketName string `json:"objectBucketName,omitempty"`
+
+	// The name of the provisioned bucket
+	BucketName string `json:"bucketName,omitempty"`
+
+	// The reason why the bucket could not be provisioned
+	Message string `json:"message,omitempty"`
+}
+
+// ObjectBucketClaimPhase is the provisioning phase of a claim
+type ObjectBucketClaimPhase string
+
+const (
+	// ObjectBucketClaimPhasePending means the bucket is not provisioned yet
+	ObjectBucketClaimPhasePending ObjectBucketClaimPhase = "Pending"
+	// ObjectBucketClaimPhaseBound means the bucket is provisioned and bound to the claim
+	ObjectBucketClaimPhaseBound ObjectBucketClaimPhase = "Bound"
+	// ObjectBucketClaimPhaseFailed means the bucket could not be provisioned
+	ObjectBucketClaimPhaseFailed ObjectBucketClaimPhase = "Failed"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ObjectBucket is a bucket provisioned for a claim, in the same way as a persistent volume is
+// provisioned for a persistent volume claim
+type ObjectBucket struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectBucketSpec   `json:"spec"`
+	Status            ObjectBucketStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ObjectBucketList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ObjectBucket `json:"items"`
+}
+
+// ObjectBucketSpec represents a provisioned bucket
+type ObjectBucketSpec struct {
+	// The name of the storage class the bucket was provisioned from
+	StorageClassName string `json:"storageClassName"`
+
+	// Whether the bucket is deleted or retained when the claim is deleted
+	ReclaimPolicy v1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy"`
+
+	// The claim the bucket is bound to
+	ClaimRef *v1.ObjectReference `json:"claimRef,omitempty"`
+
+	// The object store of the bucket
+	ObjectStoreName      string `json:"objectStoreName"`
+	ObjectStoreNamespace string `json:"objectStoreNamespace"`
+
+	// The object store user that owns the bucket
+	Owner string `json:"owner"`
+
+	// How to reach the bucket
+	Endpoint ObjectBucketEndpoint `json:"endpoint"`
+}
+
+// ObjectBucketEndpoint is the S3 endpoint of a bucket
+type ObjectBucketEndpoint struct {
+	BucketHost string `json:"bucketHost"`
+	BucketPort int32  `json:"bucketPort"`
+	BucketName string `json:"bucketName"`
+	Region     string `json:"region"`
+}
+
+// ObjectBucketStatus represents the state of a provisioned bucket
+type ObjectBucketStatus struct {
+	Phase ObjectBucketPhase `json:"phase,omitempty"`
+}
+
+// ObjectBucketPhase is the phase of a provisioned bucket
+type ObjectBucketPhase string
+
+const (
+	// ObjectBucketPhaseBound means the bucket is bound to a claim
+	ObjectBucketPhaseBound ObjectBucketPhase = "Bound"
+	// ObjectBucketPhaseReleased means the claim was deleted and the bucket was retained
+	ObjectBucketPhaseReleased ObjectBucketPhase = "Released"
+)
+
 type GatewaySpec struct {
 	// The port the rgw service will be listening on (http)
 	Port int32 `json:"port"`
@@ -320,13 +886,13 @@ type GatewaySpec struct {
 }
 
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type CephNFS struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 	Spec              NFSGaneshaSpec `json:"spec"`
+	Status            ResourceStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -365,3 +931,213 @@ type GaneshaServerSpec struct {
 	// Resources set resource requests and limits
 	Resources v1.ResourceRequirements `json:"resources,omitempty"`
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephNFSExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              NFSExportSpec       `json:"spec"`
+	Status            CephNFSExportStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephNFSExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephNFSExport `json:"items"`
+}
+
+// NFSExportSpec represents the spec of an export served by the ganesha servers of a CephNFS
+type NFSExportSpec struct {
+	// Server is the name of the CephNFS in the same namespace that serves the export
+	Server string `json:"server"`
+
+	// CephFS exports a path of a filesystem. Exactly one of cephfs or rgw must be set.
+	CephFS *NFSExportCephFSSpec `json:"cephfs,omitempty"`
+
+	// RGW exports a bucket of an object store. Exactly one of cephfs or rgw must be set.
+	RGW *NFSExportRGWSpec `json:"rgw,omitempty"`
+
+	// PseudoPath is the path of the export in the NFSv4 pseudo filesystem
+	PseudoPath string `json:"pseudoPath"`
+
+	// AccessType is the access granted to the clients: RW, RO or None. Defaults to RW.
+	AccessType string `json:"accessType,omitempty"`
+
+	// Squash is the user id squashing applied to the clients: None, Root, RootId or All. Defaults to None.
+	Squash string `json:"squash,omitempty"`
+
+	// Clients are the addresses or CIDRs of the clients allowed to mount the export. All clients are
+	// allowed if empty.
+	Clients []string `json:"clients,omitempty"`
+}
+
+// NFSExportCephFSSpec represents a path of a filesystem exported over nfs
+type NFSExportCephFSSpec struct {
+	// Filesystem is the name of the CephFilesystem
+	Filesystem string `json:"filesystem"`
+
+	// Path is the directory of the filesystem to export. Defaults to the root of the filesystem.
+	Path string `json:"path,omitempty"`
+}
+
+// NFSExportRGWSpec represents a bucket of an object store exported over nfs
+type NFSExportRGWSpec struct {
+	// ObjectStore is the name of the CephObjectStore
+	ObjectStore string `json:"objectStore"`
+
+	// User is the name of the CephObjectStoreUser whose keys are used to access the bucket
+	User string `json:"user"`
+
+	// Bucket is the name of the bucket to export
+	Bucket string `json:"bucket"`
+}
+
+// CephNFSExportStatus represents the status of an nfs export
+type CephNFSExportStatus struct {
+	ResourceStatus `json:",inline"`
+	// The id of the export on the ganesha servers, assigned when the export is first created
+	ExportID int `json:"exportID,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephOSDReplacement struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              OSDReplacementSpec       `json:"spec"`
+	Status            CephOSDReplacementStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephOSDReplacementList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephOSDReplacement `json:"items"`
+}
+
+// OSDReplacementSpec represents the osd on a failed device that is to be replaced
+type OSDReplacementSpec struct {
+	// OSDID is the id of the osd to destroy. The id is given to the osd prepared on the new device.
+	OSDID int `json:"osdID"`
+
+	// DevicePath is the /dev/disk/by-path link of the failed device. Defaults to the link recorded
+	// on the osd deployment when the osd was prepared.
+	DevicePath string `json:"devicePath,omitempty"`
+
+	// Migrate is set by the operator on the replacements that migrate a legacy osd to ceph-volume.
+	// The osd is prepared again by ceph-volume on its own device, which is given by DevicePath as
+	// a /dev path instead of a by-path link, after the cluster is clean.
+	Migrate bool `json:"migrate,omitempty"`
+}
+
+// CephOSDReplacementStatus represents the status of an osd replacement
+type CephOSDReplacementStatus struct {
+	ResourceStatus `json:",inline"`
+	// The node of the destroyed osd
+	Node string `json:"node,omitempty"`
+	// The by-path link where the new device is expected
+	DevicePath string `json:"devicePath,omitempty"`
+	// The uuid of the destroyed osd, to tell it apart from the osd that replaces it
+	OSDUUID string `json:"osdUUID,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephCrushMap struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              CrushMapSpec   `json:"spec"`
+	Status            ResourceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephCrushMapList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephCrushMap `json:"items"`
+}
+
+// CrushMapSpec represents a fragment of the CRUSH map that is merged into the CRUSH map of the cluster
+type CrushMapSpec struct {
+	// The buckets to create, in order. A bucket must be listed after the buckets in its location.
+	Buckets []CrushBucketSpec `json:"buckets,omitempty"`
+
+	// The osds to place in the buckets or to give a weight
+	OSDs []CrushOSDSpec `json:"osds,omitempty"`
+
+	// The rules to add to the CRUSH map, or to replace the rules of the same name with
+	Rules []CrushRuleSpec `json:"rules,omitempty"`
+}
+
+// CrushBucketSpec represents a bucket of the CRUSH hierarchy
+type CrushBucketSpec struct {
+	// The name of the bucket
+	Name string `json:"name"`
+
+	// The type of the bucket (e.g. datacenter or rack). Missing types are added to the CRUSH map.
+	Type string `json:"type"`
+
+	// The location of the bucket as comma separated type=name pairs (e.g. "root=default,region=east").
+	// A bucket without a location is a root.
+	Location string `json:"location,omitempty"`
+}
+
+// CrushOSDSpec represents the location and weight of an osd
+type CrushOSDSpec struct {
+	// The id of the osd
+	ID int `json:"id"`
+
+	// The location of the osd as comma separated type=name pairs (e.g. "root=default,host=node1")
+	Location string `json:"location,omitempty"`
+
+	// The CRUSH weight of the osd, e.g. "1.819"
+	Weight string `json:"weight,omitempty"`
+}
+
+// CrushRuleSpec represents a CRUSH rule
+type CrushRuleSpec struct {
+	// The name of the rule
+	Name string `json:"name"`
+
+	// The type of the rule: replicated (the default) or erasure
+	Type string `json:"type,omitempty"`
+
+	// The smallest pool size the rule applies to. Defaults to 1.
+	MinSize int `json:"minSize,omitempty"`
+
+	// The largest pool size the rule applies to. Defaults to 10.
+	MaxSize int `json:"maxSize,omitempty"`
+
+	// The steps of the rule
+	Steps []CrushRuleStepSpec `json:"steps"`
+}
+
+// CrushRuleStepSpec represents a step of a CRUSH rule
+type CrushRuleStepSpec struct {
+	// The operation of the step: take, choose, chooseleaf or emit
+	Op string `json:"op"`
+
+	// The bucket a take step starts from
+	Item string `json:"item,omitempty"`
+
+	// The device class a take step is restricted to
+	Class string `json:"class,omitempty"`
+
+	// The mode of a choose or chooseleaf step: firstn (the default) or indep
+	Mode string `json:"mode,omitempty"`
+
+	// The number of buckets a choose or chooseleaf step selects. Zero selects as many as the pool
+	// size, a negative number that many less than the pool size.
+	Num int `json:"num,omitempty"`
+
+	// The bucket type a choose or chooseleaf step selects
+	Type string `json:"type,omitempty"`
+}
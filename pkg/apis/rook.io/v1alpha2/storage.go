@@ -82,6 +82,11 @@ func (s *StorageScopeSpec) resolveNodeSelection(node *Node) {
 	}
 
 	resolveString(&(node.Selection.DeviceFilter), s.Selection.DeviceFilter, "")
+	resolveString(&(node.Selection.DevicePathFilter), s.Selection.DevicePathFilter, "")
+
+	if node.Selection.DevicePropertyFilter == nil {
+		node.Selection.DevicePropertyFilter = s.Selection.DevicePropertyFilter
+	}
 
 	if len(node.Selection.Devices) == 0 {
 		node.Selection.Devices = s.Devices
@@ -119,6 +124,11 @@ func (s *StorageScopeSpec) NodeWithNameExists(name string) bool {
 	return false
 }
 
+// IsCrushManaged returns whether the operator manages the locations of the osds in the crush map
+func (s *StorageScopeSpec) IsCrushManaged() bool {
+	return s.CrushManaged == nil || *(s.CrushManaged)
+}
+
 // GetUseAllDevices return if all devices should be used.
 func (s *Selection) GetUseAllDevices() bool {
 	return s.UseAllDevices != nil && *(s.UseAllDevices)
@@ -50,9 +50,11 @@ func TestResolveNodeInherentFromCluster(t *testing.T) {
 	storageSpec := StorageScopeSpec{
 		Location: "root=default,row=a,rack=a2,chassis=a2a,host=a2a1",
 		Selection: Selection{
-			DeviceFilter: "^sd.",
-			Directories:  []Directory{{Path: "/rook/datadir1"}},
-			Devices:      []Device{{Name: "sda"}},
+			DeviceFilter:         "^sd.",
+			DevicePathFilter:     "^/dev/disk/by-path/pci-0000:00:1f.2-.*",
+			DevicePropertyFilter: &DevicePropertyFilter{Rotational: newBool(false)},
+			Directories:          []Directory{{Path: "/rook/datadir1"}},
+			Devices:              []Device{{Name: "sda"}},
 		},
 		Config: map[string]string{
 			"foo": "bar",
@@ -65,6 +67,8 @@ func TestResolveNodeInherentFromCluster(t *testing.T) {
 	node := storageSpec.ResolveNode("node1")
 	assert.NotNil(t, node)
 	assert.Equal(t, "^sd.", node.Selection.DeviceFilter)
+	assert.Equal(t, "^/dev/disk/by-path/pci-0000:00:1f.2-.*", node.Selection.DevicePathFilter)
+	assert.False(t, *node.Selection.DevicePropertyFilter.Rotational)
 	assert.False(t, node.Selection.GetUseAllDevices())
 	assert.Equal(t, "root=default,row=a,rack=a2,chassis=a2a,host=a2a1", node.Location)
 	assert.Equal(t, "bar", node.Config["foo"])
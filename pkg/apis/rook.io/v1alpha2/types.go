@@ -17,6 +17,7 @@ package v1alpha2
 
 import (
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -32,6 +33,15 @@ type StorageScopeSpec struct {
 	NodeCount   int               `json:"nodeCount,omitempty"`
 	Location    string            `json:"location,omitempty"`
 	Config      map[string]string `json:"config"`
+	// TopologyLabels maps CRUSH bucket types (such as zone or rack) to the node labels the bucket names are taken from,
+	// in addition to the well-known topology labels
+	TopologyLabels map[string]string `json:"topologyLabels,omitempty"`
+	// CrushManaged is whether the operator moves the osds and hosts in the CRUSH map to their locations. If false, the
+	// osds are only placed when they are added and the CRUSH map is left to the user afterwards. Defaults to true.
+	CrushManaged *bool `json:"crushManaged,omitempty"`
+	// MigrateToCephVolume is whether the osds provisioned on partitions before ceph-volume was supported are
+	// migrated to ceph-volume, one osd at a time while the cluster is clean
+	MigrateToCephVolume bool `json:"migrateToCephVolume,omitempty"`
 	Selection
 }
 
@@ -44,11 +54,30 @@ type Node struct {
 }
 
 type Device struct {
-	Name     string            `json:"name,omitempty"`
-	FullPath string            // TODO: FullPath to be supported for devices
+	Name string `json:"name,omitempty"`
+	// FullPath is a /dev/disk/by-id or /dev/disk/by-path link to the device, which does not change when the device is renamed
+	FullPath string            `json:"fullpath,omitempty"`
 	Config   map[string]string `json:"config"`
 }
 
+// DevicePropertyFilter restricts the selected devices to those with the given properties. Unset properties match any device.
+type DevicePropertyFilter struct {
+	// Whether the device is rotational (hdd), or not (ssd, nvme)
+	Rotational *bool `json:"rotational,omitempty"`
+	// The minimum size of the device
+	MinSize *resource.Quantity `json:"minSize,omitempty"`
+	// The maximum size of the device
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// A regular expression matched against the model of the device
+	Model string `json:"model,omitempty"`
+	// A regular expression matched against the vendor of the device
+	Vendor string `json:"vendor,omitempty"`
+	// A regular expression matched against the serial of the device
+	Serial string `json:"serial,omitempty"`
+	// The transport of the device, such as sata, sas, nvme or usb
+	Transport string `json:"transport,omitempty"`
+}
+
 type Directory struct {
 	Path   string            `json:"path,omitempty"`
 	Config map[string]string `json:"config"`
@@ -59,6 +88,10 @@ type Selection struct {
 	UseAllDevices *bool `json:"useAllDevices,omitempty"`
 	// A regular expression to allow more fine-grained selection of devices on nodes across the cluster
 	DeviceFilter string `json:"deviceFilter,omitempty"`
+	// A regular expression matched against the /dev/disk/by-id and /dev/disk/by-path links of the devices on nodes
+	DevicePathFilter string `json:"devicePathFilter,omitempty"`
+	// The properties the selected devices must have to be used as storage devices
+	DevicePropertyFilter *DevicePropertyFilter `json:"devicePropertyFilter,omitempty"`
 	// List of devices to use as storage devices
 	Devices []Device `json:"devices,omitempty"`
 	// List of host directories to use as storage
@@ -118,6 +118,37 @@ func (in *Device) DeepCopy() *Device {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DevicePropertyFilter) DeepCopyInto(out *DevicePropertyFilter) {
+	*out = *in
+	if in.Rotational != nil {
+		in, out := &in.Rotational, &out.Rotational
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MinSize != nil {
+		in, out := &in.MinSize, &out.MinSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DevicePropertyFilter.
+func (in *DevicePropertyFilter) DeepCopy() *DevicePropertyFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(DevicePropertyFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Directory) DeepCopyInto(out *Directory) {
 	*out = *in
@@ -316,6 +347,11 @@ func (in *Selection) DeepCopyInto(out *Selection) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.DevicePropertyFilter != nil {
+		in, out := &in.DevicePropertyFilter, &out.DevicePropertyFilter
+		*out = new(DevicePropertyFilter)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Devices != nil {
 		in, out := &in.Devices, &out.Devices
 		*out = make([]Device, len(*in))
@@ -367,6 +403,18 @@ func (in *StorageScopeSpec) DeepCopyInto(out *StorageScopeSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.TopologyLabels != nil {
+		in, out := &in.TopologyLabels, &out.TopologyLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CrushManaged != nil {
+		in, out := &in.CrushManaged, &out.CrushManaged
+		*out = new(bool)
+		**out = **in
+	}
 	in.Selection.DeepCopyInto(&out.Selection)
 	return
 }
@@ -29,10 +29,15 @@ type CephV1Interface interface {
 	RESTClient() rest.Interface
 	CephBlockPoolsGetter
 	CephClustersGetter
+	CephCrushMapsGetter
 	CephFilesystemsGetter
 	CephNFSesGetter
+	CephNFSExportsGetter
+	CephOSDReplacementsGetter
 	CephObjectStoresGetter
 	CephObjectStoreUsersGetter
+	ObjectBucketsGetter
+	ObjectBucketClaimsGetter
 }
 
 // CephV1Client is used to interact with features provided by the ceph.rook.io group.
@@ -48,6 +53,10 @@ func (c *CephV1Client) CephClusters(namespace string) CephClusterInterface {
 	return newCephClusters(c, namespace)
 }
 
+func (c *CephV1Client) CephCrushMaps(namespace string) CephCrushMapInterface {
+	return newCephCrushMaps(c, namespace)
+}
+
 func (c *CephV1Client) CephFilesystems(namespace string) CephFilesystemInterface {
 	return newCephFilesystems(c, namespace)
 }
@@ -56,6 +65,14 @@ func (c *CephV1Client) CephNFSes(namespace string) CephNFSInterface {
 	return newCephNFSes(c, namespace)
 }
 
+func (c *CephV1Client) CephNFSExports(namespace string) CephNFSExportInterface {
+	return newCephNFSExports(c, namespace)
+}
+
+func (c *CephV1Client) CephOSDReplacements(namespace string) CephOSDReplacementInterface {
+	return newCephOSDReplacements(c, namespace)
+}
+
 func (c *CephV1Client) CephObjectStores(namespace string) CephObjectStoreInterface {
 	return newCephObjectStores(c, namespace)
 }
@@ -64,6 +81,14 @@ func (c *CephV1Client) CephObjectStoreUsers(namespace string) CephObjectStoreUse
 	return newCephObjectStoreUsers(c, namespace)
 }
 
+func (c *CephV1Client) ObjectBuckets() ObjectBucketInterface {
+	return newObjectBuckets(c)
+}
+
+func (c *CephV1Client) ObjectBucketClaims(namespace string) ObjectBucketClaimInterface {
+	return newObjectBucketClaims(c, namespace)
+}
+
 // NewForConfig creates a new CephV1Client for the given config.
 func NewForConfig(c *rest.Config) (*CephV1Client, error) {
 	config := *c
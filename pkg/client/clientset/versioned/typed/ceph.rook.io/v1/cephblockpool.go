@@ -39,6 +39,7 @@ type CephBlockPoolsGetter interface {
 type CephBlockPoolInterface interface {
 	Create(*v1.CephBlockPool) (*v1.CephBlockPool, error)
 	Update(*v1.CephBlockPool) (*v1.CephBlockPool, error)
+	UpdateStatus(*v1.CephBlockPool) (*v1.CephBlockPool, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (*v1.CephBlockPool, error)
@@ -132,6 +133,22 @@ func (c *cephBlockPools) Update(cephBlockPool *v1.CephBlockPool) (result *v1.Cep
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *cephBlockPools) UpdateStatus(cephBlockPool *v1.CephBlockPool) (result *v1.CephBlockPool, err error) {
+	result = &v1.CephBlockPool{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("cephblockpools").
+		Name(cephBlockPool.Name).
+		SubResource("status").
+		Body(cephBlockPool).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the cephBlockPool and deletes it. Returns an error if one occurs.
 func (c *cephBlockPools) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
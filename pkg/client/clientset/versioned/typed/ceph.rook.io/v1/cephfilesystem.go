@@ -39,6 +39,7 @@ type CephFilesystemsGetter interface {
 type CephFilesystemInterface interface {
 	Create(*v1.CephFilesystem) (*v1.CephFilesystem, error)
 	Update(*v1.CephFilesystem) (*v1.CephFilesystem, error)
+	UpdateStatus(*v1.CephFilesystem) (*v1.CephFilesystem, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (*v1.CephFilesystem, error)
@@ -132,6 +133,22 @@ func (c *cephFilesystems) Update(cephFilesystem *v1.CephFilesystem) (result *v1.
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *cephFilesystems) UpdateStatus(cephFilesystem *v1.CephFilesystem) (result *v1.CephFilesystem, err error) {
+	result = &v1.CephFilesystem{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("cephfilesystems").
+		Name(cephFilesystem.Name).
+		SubResource("status").
+		Body(cephFilesystem).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the cephFilesystem and deletes it. Returns an error if one occurs.
 func (c *cephFilesystems) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
@@ -39,6 +39,7 @@ type CephNFSesGetter interface {
 type CephNFSInterface interface {
 	Create(*v1.CephNFS) (*v1.CephNFS, error)
 	Update(*v1.CephNFS) (*v1.CephNFS, error)
+	UpdateStatus(*v1.CephNFS) (*v1.CephNFS, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (*v1.CephNFS, error)
@@ -132,6 +133,22 @@ func (c *cephNFSes) Update(cephNFS *v1.CephNFS) (result *v1.CephNFS, err error)
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *cephNFSes) UpdateStatus(cephNFS *v1.CephNFS) (result *v1.CephNFS, err error) {
+	result = &v1.CephNFS{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("cephnfses").
+		Name(cephNFS.Name).
+		SubResource("status").
+		Body(cephNFS).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the cephNFS and deletes it. Returns an error if one occurs.
 func (c *cephNFSes) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
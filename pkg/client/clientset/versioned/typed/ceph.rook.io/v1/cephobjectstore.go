@@ -39,6 +39,7 @@ type CephObjectStoresGetter interface {
 type CephObjectStoreInterface interface {
 	Create(*v1.CephObjectStore) (*v1.CephObjectStore, error)
 	Update(*v1.CephObjectStore) (*v1.CephObjectStore, error)
+	UpdateStatus(*v1.CephObjectStore) (*v1.CephObjectStore, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (*v1.CephObjectStore, error)
@@ -132,6 +133,22 @@ func (c *cephObjectStores) Update(cephObjectStore *v1.CephObjectStore) (result *
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *cephObjectStores) UpdateStatus(cephObjectStore *v1.CephObjectStore) (result *v1.CephObjectStore, err error) {
+	result = &v1.CephObjectStore{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("cephobjectstores").
+		Name(cephObjectStore.Name).
+		SubResource("status").
+		Body(cephObjectStore).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the cephObjectStore and deletes it. Returns an error if one occurs.
 func (c *cephObjectStores) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
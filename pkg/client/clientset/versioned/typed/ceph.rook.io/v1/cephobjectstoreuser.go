@@ -39,6 +39,7 @@ type CephObjectStoreUsersGetter interface {
 type CephObjectStoreUserInterface interface {
 	Create(*v1.CephObjectStoreUser) (*v1.CephObjectStoreUser, error)
 	Update(*v1.CephObjectStoreUser) (*v1.CephObjectStoreUser, error)
+	UpdateStatus(*v1.CephObjectStoreUser) (*v1.CephObjectStoreUser, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (*v1.CephObjectStoreUser, error)
@@ -132,6 +133,22 @@ func (c *cephObjectStoreUsers) Update(cephObjectStoreUser *v1.CephObjectStoreUse
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *cephObjectStoreUsers) UpdateStatus(cephObjectStoreUser *v1.CephObjectStoreUser) (result *v1.CephObjectStoreUser, err error) {
+	result = &v1.CephObjectStoreUser{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("cephobjectstoreusers").
+		Name(cephObjectStoreUser.Name).
+		SubResource("status").
+		Body(cephObjectStoreUser).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the cephObjectStoreUser and deletes it. Returns an error if one occurs.
 func (c *cephObjectStoreUsers) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
@@ -36,6 +36,10 @@ func (c *FakeCephV1) CephClusters(namespace string) v1.CephClusterInterface {
 	return &FakeCephClusters{c, namespace}
 }
 
+func (c *FakeCephV1) CephCrushMaps(namespace string) v1.CephCrushMapInterface {
+	return &FakeCephCrushMaps{c, namespace}
+}
+
 func (c *FakeCephV1) CephFilesystems(namespace string) v1.CephFilesystemInterface {
 	return &FakeCephFilesystems{c, namespace}
 }
@@ -44,6 +48,14 @@ func (c *FakeCephV1) CephNFSes(namespace string) v1.CephNFSInterface {
 	return &FakeCephNFSes{c, namespace}
 }
 
+func (c *FakeCephV1) CephNFSExports(namespace string) v1.CephNFSExportInterface {
+	return &FakeCephNFSExports{c, namespace}
+}
+
+func (c *FakeCephV1) CephOSDReplacements(namespace string) v1.CephOSDReplacementInterface {
+	return &FakeCephOSDReplacements{c, namespace}
+}
+
 func (c *FakeCephV1) CephObjectStores(namespace string) v1.CephObjectStoreInterface {
 	return &FakeCephObjectStores{c, namespace}
 }
@@ -52,6 +64,14 @@ func (c *FakeCephV1) CephObjectStoreUsers(namespace string) v1.CephObjectStoreUs
 	return &FakeCephObjectStoreUsers{c, namespace}
 }
 
+func (c *FakeCephV1) ObjectBuckets() v1.ObjectBucketInterface {
+	return &FakeObjectBuckets{c}
+}
+
+func (c *FakeCephV1) ObjectBucketClaims(namespace string) v1.ObjectBucketClaimInterface {
+	return &FakeObjectBucketClaims{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeCephV1) RESTClient() rest.Interface {
@@ -100,6 +100,18 @@ func (c *FakeCephBlockPools) Update(cephBlockPool *cephrookiov1.CephBlockPool) (
 	return obj.(*cephrookiov1.CephBlockPool), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeCephBlockPools) UpdateStatus(cephBlockPool *cephrookiov1.CephBlockPool) (*cephrookiov1.CephBlockPool, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(cephblockpoolsResource, "status", c.ns, cephBlockPool), &cephrookiov1.CephBlockPool{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cephrookiov1.CephBlockPool), err
+}
+
 // Delete takes name of the cephBlockPool and deletes it. Returns an error if one occurs.
 func (c *FakeCephBlockPools) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
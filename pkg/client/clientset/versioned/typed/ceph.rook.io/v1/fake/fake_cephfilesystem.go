@@ -100,6 +100,18 @@ func (c *FakeCephFilesystems) Update(cephFilesystem *cephrookiov1.CephFilesystem
 	return obj.(*cephrookiov1.CephFilesystem), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeCephFilesystems) UpdateStatus(cephFilesystem *cephrookiov1.CephFilesystem) (*cephrookiov1.CephFilesystem, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(cephfilesystemsResource, "status", c.ns, cephFilesystem), &cephrookiov1.CephFilesystem{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cephrookiov1.CephFilesystem), err
+}
+
 // Delete takes name of the cephFilesystem and deletes it. Returns an error if one occurs.
 func (c *FakeCephFilesystems) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
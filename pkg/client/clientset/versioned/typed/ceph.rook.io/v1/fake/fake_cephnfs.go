@@ -100,6 +100,18 @@ func (c *FakeCephNFSes) Update(cephNFS *cephrookiov1.CephNFS) (result *cephrooki
 	return obj.(*cephrookiov1.CephNFS), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeCephNFSes) UpdateStatus(cephNFS *cephrookiov1.CephNFS) (*cephrookiov1.CephNFS, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(cephnfsesResource, "status", c.ns, cephNFS), &cephrookiov1.CephNFS{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cephrookiov1.CephNFS), err
+}
+
 // Delete takes name of the cephNFS and deletes it. Returns an error if one occurs.
 func (c *FakeCephNFSes) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
@@ -100,6 +100,18 @@ func (c *FakeCephObjectStores) Update(cephObjectStore *cephrookiov1.CephObjectSt
 	return obj.(*cephrookiov1.CephObjectStore), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeCephObjectStores) UpdateStatus(cephObjectStore *cephrookiov1.CephObjectStore) (*cephrookiov1.CephObjectStore, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(cephobjectstoresResource, "status", c.ns, cephObjectStore), &cephrookiov1.CephObjectStore{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cephrookiov1.CephObjectStore), err
+}
+
 // Delete takes name of the cephObjectStore and deletes it. Returns an error if one occurs.
 func (c *FakeCephObjectStores) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
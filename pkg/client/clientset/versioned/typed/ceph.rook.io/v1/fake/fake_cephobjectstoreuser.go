@@ -100,6 +100,18 @@ func (c *FakeCephObjectStoreUsers) Update(cephObjectStoreUser *cephrookiov1.Ceph
 	return obj.(*cephrookiov1.CephObjectStoreUser), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeCephObjectStoreUsers) UpdateStatus(cephObjectStoreUser *cephrookiov1.CephObjectStoreUser) (*cephrookiov1.CephObjectStoreUser, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(cephobjectstoreusersResource, "status", c.ns, cephObjectStoreUser), &cephrookiov1.CephObjectStoreUser{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cephrookiov1.CephObjectStoreUser), err
+}
+
 // Delete takes name of the cephObjectStoreUser and deletes it. Returns an error if one occurs.
 func (c *FakeCephObjectStoreUsers) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
@@ -22,10 +22,20 @@ type CephBlockPoolExpansion interface{}
 
 type CephClusterExpansion interface{}
 
+type CephCrushMapExpansion interface{}
+
 type CephFilesystemExpansion interface{}
 
 type CephNFSExpansion interface{}
 
+type CephNFSExportExpansion interface{}
+
+type CephOSDReplacementExpansion interface{}
+
 type CephObjectStoreExpansion interface{}
 
 type CephObjectStoreUserExpansion interface{}
+
+type ObjectBucketExpansion interface{}
+
+type ObjectBucketClaimExpansion interface{}
@@ -28,14 +28,24 @@ type Interface interface {
 	CephBlockPools() CephBlockPoolInformer
 	// CephClusters returns a CephClusterInformer.
 	CephClusters() CephClusterInformer
+	// CephCrushMaps returns a CephCrushMapInformer.
+	CephCrushMaps() CephCrushMapInformer
 	// CephFilesystems returns a CephFilesystemInformer.
 	CephFilesystems() CephFilesystemInformer
 	// CephNFSes returns a CephNFSInformer.
 	CephNFSes() CephNFSInformer
+	// CephNFSExports returns a CephNFSExportInformer.
+	CephNFSExports() CephNFSExportInformer
+	// CephOSDReplacements returns a CephOSDReplacementInformer.
+	CephOSDReplacements() CephOSDReplacementInformer
 	// CephObjectStores returns a CephObjectStoreInformer.
 	CephObjectStores() CephObjectStoreInformer
 	// CephObjectStoreUsers returns a CephObjectStoreUserInformer.
 	CephObjectStoreUsers() CephObjectStoreUserInformer
+	// ObjectBuckets returns a ObjectBucketInformer.
+	ObjectBuckets() ObjectBucketInformer
+	// ObjectBucketClaims returns a ObjectBucketClaimInformer.
+	ObjectBucketClaims() ObjectBucketClaimInformer
 }
 
 type version struct {
@@ -59,6 +69,11 @@ func (v *version) CephClusters() CephClusterInformer {
 	return &cephClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephCrushMaps returns a CephCrushMapInformer.
+func (v *version) CephCrushMaps() CephCrushMapInformer {
+	return &cephCrushMapInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephFilesystems returns a CephFilesystemInformer.
 func (v *version) CephFilesystems() CephFilesystemInformer {
 	return &cephFilesystemInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -69,6 +84,16 @@ func (v *version) CephNFSes() CephNFSInformer {
 	return &cephNFSInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephNFSExports returns a CephNFSExportInformer.
+func (v *version) CephNFSExports() CephNFSExportInformer {
+	return &cephNFSExportInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// CephOSDReplacements returns a CephOSDReplacementInformer.
+func (v *version) CephOSDReplacements() CephOSDReplacementInformer {
+	return &cephOSDReplacementInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephObjectStores returns a CephObjectStoreInformer.
 func (v *version) CephObjectStores() CephObjectStoreInformer {
 	return &cephObjectStoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -78,3 +103,13 @@ func (v *version) CephObjectStores() CephObjectStoreInformer {
 func (v *version) CephObjectStoreUsers() CephObjectStoreUserInformer {
 	return &cephObjectStoreUserInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ObjectBuckets returns a ObjectBucketInformer.
+func (v *version) ObjectBuckets() ObjectBucketInformer {
+	return &objectBucketInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ObjectBucketClaims returns a ObjectBucketClaimInformer.
+func (v *version) ObjectBucketClaims() ObjectBucketClaimInformer {
+	return &objectBucketClaimInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
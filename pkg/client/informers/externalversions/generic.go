@@ -68,14 +68,24 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBlockPools().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephClusters().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephcrushmaps"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephCrushMaps().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephfilesystems"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephFilesystems().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephnfses"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephNFSes().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephnfsexports"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephNFSExports().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephosdreplacements"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephOSDReplacements().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephobjectstores"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephObjectStores().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephobjectstoreusers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephObjectStoreUsers().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("objectbuckets"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().ObjectBuckets().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("objectbucketclaims"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().ObjectBucketClaims().Informer()}, nil
 
 		// Group=ceph.rook.io, Version=v1beta1
 	case v1beta1.SchemeGroupVersion.WithResource("clusters"):
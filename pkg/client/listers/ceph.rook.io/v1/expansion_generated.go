@@ -34,6 +34,14 @@ type CephClusterListerExpansion interface{}
 // CephClusterNamespaceLister.
 type CephClusterNamespaceListerExpansion interface{}
 
+// CephCrushMapListerExpansion allows custom methods to be added to
+// CephCrushMapLister.
+type CephCrushMapListerExpansion interface{}
+
+// CephCrushMapNamespaceListerExpansion allows custom methods to be added to
+// CephCrushMapNamespaceLister.
+type CephCrushMapNamespaceListerExpansion interface{}
+
 // CephFilesystemListerExpansion allows custom methods to be added to
 // CephFilesystemLister.
 type CephFilesystemListerExpansion interface{}
@@ -50,6 +58,22 @@ type CephNFSListerExpansion interface{}
 // CephNFSNamespaceLister.
 type CephNFSNamespaceListerExpansion interface{}
 
+// CephNFSExportListerExpansion allows custom methods to be added to
+// CephNFSExportLister.
+type CephNFSExportListerExpansion interface{}
+
+// CephNFSExportNamespaceListerExpansion allows custom methods to be added to
+// CephNFSExportNamespaceLister.
+type CephNFSExportNamespaceListerExpansion interface{}
+
+// CephOSDReplacementListerExpansion allows custom methods to be added to
+// CephOSDReplacementLister.
+type CephOSDReplacementListerExpansion interface{}
+
+// CephOSDReplacementNamespaceListerExpansion allows custom methods to be added to
+// CephOSDReplacementNamespaceLister.
+type CephOSDReplacementNamespaceListerExpansion interface{}
+
 // CephObjectStoreListerExpansion allows custom methods to be added to
 // CephObjectStoreLister.
 type CephObjectStoreListerExpansion interface{}
@@ -65,3 +89,15 @@ type CephObjectStoreUserListerExpansion interface{}
 // CephObjectStoreUserNamespaceListerExpansion allows custom methods to be added to
 // CephObjectStoreUserNamespaceLister.
 type CephObjectStoreUserNamespaceListerExpansion interface{}
+
+// ObjectBucketListerExpansion allows custom methods to be added to
+// ObjectBucketLister.
+type ObjectBucketListerExpansion interface{}
+
+// ObjectBucketClaimListerExpansion allows custom methods to be added to
+// ObjectBucketClaimLister.
+type ObjectBucketClaimListerExpansion interface{}
+
+// ObjectBucketClaimNamespaceListerExpansion allows custom methods to be added to
+// ObjectBucketClaimNamespaceLister.
+type ObjectBucketClaimNamespaceListerExpansion interface{}
@@ -16,11 +16,14 @@ limitations under the License.
 package clusterd
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/coreos/pkg/capnslog"
 
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/util/exec"
 	"github.com/rook/rook/pkg/util/sys"
 )
@@ -49,6 +52,73 @@ func GetDeviceEmpty(device *sys.LocalDisk) bool {
 	return device.Parent == "" && (device.Type == sys.DiskType || device.Type == sys.SSDType || device.Type == sys.CryptType || device.Type == sys.LVMType) && len(device.Partitions) == 0 && device.Filesystem == ""
 }
 
+// DeviceMatchesName returns whether the device is the one with the given name in the storage spec. The name can be the
+// kernel name of the device (sdb), its path (/dev/sdb), or one of its /dev/disk links such as by-id or by-path.
+func DeviceMatchesName(device *sys.LocalDisk, name string) bool {
+	if device.Name == name || "/dev/"+device.Name == name {
+		return true
+	}
+	for _, link := range strings.Fields(device.DevLinks) {
+		if link == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceMatchesPathFilter returns whether one of the /dev/disk links of the device matches the regular expression
+func DeviceMatchesPathFilter(device *sys.LocalDisk, filter string) (bool, error) {
+	re, err := regexp.Compile(filter)
+	if err != nil {
+		return false, fmt.Errorf("invalid device path filter %s. %+v", filter, err)
+	}
+	for _, link := range strings.Fields(device.DevLinks) {
+		if re.MatchString(link) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeviceMatchesProperties returns whether the device has all the properties set in the filter. A nil filter matches
+// all the devices.
+func DeviceMatchesProperties(device *sys.LocalDisk, filter *rookalpha.DevicePropertyFilter) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+	if filter.Rotational != nil && *filter.Rotational != device.Rotational {
+		return false, nil
+	}
+	if filter.MinSize != nil && int64(device.Size) < filter.MinSize.Value() {
+		return false, nil
+	}
+	if filter.MaxSize != nil && int64(device.Size) > filter.MaxSize.Value() {
+		return false, nil
+	}
+	if filter.Transport != "" && !strings.EqualFold(filter.Transport, device.Transport) {
+		return false, nil
+	}
+
+	properties := []struct{ name, filter, value string }{
+		{"model", filter.Model, device.Model},
+		{"vendor", filter.Vendor, device.Vendor},
+		{"serial", filter.Serial, device.Serial},
+	}
+	for _, p := range properties {
+		if p.filter == "" {
+			continue
+		}
+		matched, err := regexp.MatchString(p.filter, p.value)
+		if err != nil {
+			return false, fmt.Errorf("invalid device %s filter %s. %+v", p.name, p.filter, err)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func ignoreDevice(d string) bool {
 	return isRBD.MatchString(d)
 }
@@ -120,6 +190,9 @@ func DiscoverDevices(executor exec.Executor) ([]*sys.LocalDisk, error) {
 		if val, ok := diskProps["PKNAME"]; ok {
 			disk.Parent = val
 		}
+		if val, ok := diskProps["TRAN"]; ok {
+			disk.Transport = val
+		}
 
 		// parse udev info output
 		if val, ok := udevInfo["DEVLINKS"]; ok {
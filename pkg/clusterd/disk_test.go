@@ -18,9 +18,11 @@ package clusterd
 import (
 	"testing"
 
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/rook/rook/pkg/util/sys"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestAvailableDisks(t *testing.T) {
@@ -89,3 +91,54 @@ func TestIgnoreDevice(t *testing.T) {
 		assert.Equal(t, expected, ignoreDevice(dev), dev)
 	}
 }
+
+func TestDeviceMatchesName(t *testing.T) {
+	d := &sys.LocalDisk{Name: "sdb", DevLinks: "/dev/disk/by-id/ata-ST4000NM0035_ZC11ABCD /dev/disk/by-path/pci-0000:00:1f.2-ata-2"}
+	assert.True(t, DeviceMatchesName(d, "sdb"))
+	assert.True(t, DeviceMatchesName(d, "/dev/sdb"))
+	assert.True(t, DeviceMatchesName(d, "/dev/disk/by-id/ata-ST4000NM0035_ZC11ABCD"))
+	assert.True(t, DeviceMatchesName(d, "/dev/disk/by-path/pci-0000:00:1f.2-ata-2"))
+	assert.False(t, DeviceMatchesName(d, "sdc"))
+	assert.False(t, DeviceMatchesName(d, "/dev/disk/by-path/pci-0000:00:1f.2-ata-1"))
+
+	matched, err := DeviceMatchesPathFilter(d, "^/dev/disk/by-path/pci-0000:00:1f.2-ata-[0-9]+$")
+	assert.Nil(t, err)
+	assert.True(t, matched)
+	matched, err = DeviceMatchesPathFilter(d, "^/dev/disk/by-id/nvme-")
+	assert.Nil(t, err)
+	assert.False(t, matched)
+	_, err = DeviceMatchesPathFilter(d, "[")
+	assert.NotNil(t, err)
+}
+
+func TestDeviceMatchesProperties(t *testing.T) {
+	d := &sys.LocalDisk{Name: "sdb", Size: 4000787030016, Rotational: true, Transport: "sata", Vendor: "ATA", Model: "ST4000NM0035-1V4107", Serial: "ST4000NM0035-1V4107_ZC11ABCD"}
+	rotational := true
+	notRotational := false
+	minSize := resource.MustParse("1Ti")
+	maxSize := resource.MustParse("2Ti")
+
+	tests := []struct {
+		filter  *rookalpha.DevicePropertyFilter
+		matched bool
+	}{
+		{nil, true},
+		{&rookalpha.DevicePropertyFilter{}, true},
+		{&rookalpha.DevicePropertyFilter{Rotational: &rotational}, true},
+		{&rookalpha.DevicePropertyFilter{Rotational: &notRotational}, false},
+		{&rookalpha.DevicePropertyFilter{MinSize: &minSize}, true},
+		{&rookalpha.DevicePropertyFilter{MinSize: &minSize, MaxSize: &maxSize}, false},
+		{&rookalpha.DevicePropertyFilter{Transport: "SATA"}, true},
+		{&rookalpha.DevicePropertyFilter{Transport: "nvme"}, false},
+		{&rookalpha.DevicePropertyFilter{Model: "^ST4000", Vendor: "ATA", Serial: "ZC11"}, true},
+		{&rookalpha.DevicePropertyFilter{Model: "^ST4000", Vendor: "^SEAGATE"}, false},
+	}
+	for i, test := range tests {
+		matched, err := DeviceMatchesProperties(d, test.filter)
+		assert.Nil(t, err)
+		assert.Equal(t, test.matched, matched, "test %d", i)
+	}
+
+	_, err := DeviceMatchesProperties(d, &rookalpha.DevicePropertyFilter{Serial: "("})
+	assert.NotNil(t, err)
+}
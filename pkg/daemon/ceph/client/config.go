@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+// ConfigOption is a single option stored in the mon's centralized config database
+type ConfigOption struct {
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Level   string `json:"level"`
+	Mask    string `json:"mask"`
+}
+
+// ConfigDump returns all the options stored in the mon's centralized config database. The config
+// database is only available in Mimic and newer.
+func ConfigDump(context *clusterd.Context, clusterName string) ([]ConfigOption, error) {
+	args := []string{"config", "dump"}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump the config database. %+v", err)
+	}
+
+	var options []ConfigOption
+	if err := json.Unmarshal(buf, &options); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config dump response. %+v", err)
+	}
+	return options, nil
+}
+
+// ConfigSet sets an option for the given section (e.g., "global", "osd", "osd.1") in the mon's
+// centralized config database
+func ConfigSet(context *clusterd.Context, clusterName, section, name, value string) error {
+	args := []string{"config", "set", section, name, value}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to set config %s %s=%s. %+v", section, name, value, err)
+	}
+	return nil
+}
+
+// ConfigRemove removes the option of the section from the mon's centralized config database
+func ConfigRemove(context *clusterd.Context, clusterName, section, name string) error {
+	args := []string{"config", "rm", section, name}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to remove config %s %s. %+v", section, name, err)
+	}
+	return nil
+}
+
+// ConfigKeySet stores the value under the key in the mon's config-key store. The value is passed
+// to ceph in a file so that it does not show up in the logged command line.
+func ConfigKeySet(context *clusterd.Context, clusterName, key, value string) error {
+	valueFile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for config-key %s. %+v", key, err)
+	}
+	defer os.Remove(valueFile.Name())
+	_, err = valueFile.WriteString(value)
+	valueFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write temp file for config-key %s. %+v", key, err)
+	}
+
+	args := []string{"config-key", "set", key, "-i", valueFile.Name()}
+	if _, err := ExecuteCephCommandPlain(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to set config-key %s. %+v", key, err)
+	}
+	return nil
+}
+
+// ConfigKeyGet returns the value stored under the key in the mon's config-key store
+func ConfigKeyGet(context *clusterd.Context, clusterName, key string) (string, error) {
+	args := []string{"config-key", "get", key}
+	buf, err := ExecuteCephCommandPlain(context, clusterName, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get config-key %s. %+v", key, err)
+	}
+	return string(buf), nil
+}
+
+// ConfigKeyExists returns whether a value is stored under the key in the mon's config-key store
+func ConfigKeyExists(context *clusterd.Context, clusterName, key string) (bool, error) {
+	args := []string{"config-key", "exists", key}
+	if _, err := ExecuteCephCommandPlain(context, clusterName, args); err != nil {
+		if cmdErr, ok := err.(*exec.CommandError); ok && cmdErr.ExitStatus() == int(syscall.ENOENT) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check config-key %s. %+v", key, err)
+	}
+	return true, nil
+}
+
+// ConfigKeyRemove removes the key from the mon's config-key store
+func ConfigKeyRemove(context *clusterd.Context, clusterName, key string) error {
+	args := []string{"config-key", "rm", key}
+	if _, err := ExecuteCephCommandPlain(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to remove config-key %s. %+v", key, err)
+	}
+	return nil
+}
@@ -47,8 +47,9 @@ type 5 pdu
 type 6 pod
 type 7 room
 type 8 datacenter
-type 9 region
-type 10 root
+type 9 zone
+type 10 region
+type 11 root
 
 # default bucket
 root default {
@@ -252,6 +253,152 @@ func CreateDefaultCrushMap(context *clusterd.Context, clusterName string) (strin
 	return "", nil
 }
 
+// CrushMove moves the bucket to the location in the crush map, creating the missing buckets of the location
+func CrushMove(context *clusterd.Context, clusterName, name string, location []string) (string, error) {
+	args := append([]string{"osd", "crush", "move", name}, location...)
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to crush move %s: %+v, %s", name, err, string(buf))
+	}
+
+	return string(buf), nil
+}
+
+// crushTypeHierarchy are the bucket types of the default crush map from the bottom of the hierarchy up
+var crushTypeHierarchy = []string{"osd", "host", "chassis", "rack", "row", "pdu", "pod", "room", "datacenter", "zone", "region", "root"}
+
+// AddCrushType adds the bucket type to the crush map if the crush map does not have it yet. Crush
+// maps created before the type was known to ceph (such as zone before Nautilus) need the type to be
+// added before buckets of the type can be created.
+func AddCrushType(context *clusterd.Context, clusterName, typeName string) error {
+	crushMap, err := GetCrushMap(context, clusterName)
+	if err != nil {
+		return err
+	}
+	for _, t := range crushMap.Types {
+		if t.Name == typeName {
+			return nil
+		}
+	}
+
+	err = editCrushMap(context, clusterName, func(decompiled string) (string, error) {
+		return addCrushTypeLine(decompiled, typeName)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("added crush type %s", typeName)
+	return nil
+}
+
+// addCrushTypeLine adds the type to the types of the decompiled crush map at its place in the hierarchy,
+// taking the id of the first type above it and renumbering the types above it. Types that are not in the
+// default hierarchy are added after the last type.
+func addCrushTypeLine(decompiled, typeName string) (string, error) {
+	rank := map[string]int{}
+	for i, name := range crushTypeHierarchy {
+		rank[name] = i
+	}
+	newRank, known := rank[typeName]
+
+	lines := strings.Split(decompiled, "\n")
+	insertAt, newID, lastType, maxID := -1, -1, -1, -1
+	for i, line := range lines {
+		var id int
+		var name string
+		if !strings.HasPrefix(line, "type ") {
+			continue
+		}
+		if n, _ := fmt.Sscanf(line, "type %d %s", &id, &name); n != 2 {
+			return "", fmt.Errorf("invalid crush type %q", line)
+		}
+		lastType = i
+		if id > maxID {
+			maxID = id
+		}
+		if r, ok := rank[name]; known && ok && r > newRank && (newID == -1 || id < newID) {
+			insertAt, newID = i, id
+		}
+	}
+	if lastType == -1 {
+		return "", fmt.Errorf("no types found in the crush map")
+	}
+	if newID == -1 {
+		insertAt, newID = lastType+1, maxID+1
+	}
+
+	// the buckets and rules refer to the types by name, so the types above the new type can be renumbered
+	for i, line := range lines {
+		var id int
+		var name string
+		if !strings.HasPrefix(line, "type ") {
+			continue
+		}
+		if n, _ := fmt.Sscanf(line, "type %d %s", &id, &name); n == 2 && id >= newID {
+			lines[i] = fmt.Sprintf("type %d %s", id+1, name)
+		}
+	}
+	typeLine := fmt.Sprintf("type %d %s", newID, typeName)
+	lines = append(lines[:insertAt], append([]string{typeLine}, lines[insertAt:]...)...)
+	return strings.Join(lines, "\n"), nil
+}
+
+// editCrushMap decompiles the crush map of the cluster, edits the text of the map and sets the
+// compiled result on the cluster. The crush map is not set if the edit leaves the text unchanged.
+func editCrushMap(context *clusterd.Context, clusterName string, edit func(decompiled string) (string, error)) error {
+	// get the compiled crush map
+	buf, err := ExecuteCephCommand(context, clusterName, []string{"osd", "getcrushmap"})
+	if err != nil {
+		return fmt.Errorf("failed to get crushmap. %+v", err)
+	}
+	compiledMap, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("failed to open compiled crush map temp file: %+v", err)
+	}
+	defer compiledMap.Close()
+	defer os.Remove(compiledMap.Name())
+	if _, err := compiledMap.Write(buf); err != nil {
+		return fmt.Errorf("failed to write compiled crush map to %s: %+v", compiledMap.Name(), err)
+	}
+
+	// decompile the crush map
+	decompiledMap, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("failed to open decompiled crush map temp file: %+v", err)
+	}
+	defer decompiledMap.Close()
+	defer os.Remove(decompiledMap.Name())
+	if output, err := context.Executor.ExecuteCommandWithOutput(false, "", CrushTool, "-d", compiledMap.Name(), "-o", decompiledMap.Name()); err != nil {
+		return fmt.Errorf("failed to decompile crushmap: %+v. %s", err, output)
+	}
+	decompiled, err := ioutil.ReadFile(decompiledMap.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read decompiled crush map: %+v", err)
+	}
+
+	edited, err := edit(string(decompiled))
+	if err != nil {
+		return err
+	}
+	if edited == string(decompiled) {
+		logger.Debugf("crush map not changed")
+		return nil
+	}
+	if err := ioutil.WriteFile(decompiledMap.Name(), []byte(edited), 0644); err != nil {
+		return fmt.Errorf("failed to write decompiled crush map to %s: %+v", decompiledMap.Name(), err)
+	}
+
+	// compile the crush map and set it on the cluster
+	if output, err := context.Executor.ExecuteCommandWithOutput(false, "", CrushTool, "-c", decompiledMap.Name(), "-o", compiledMap.Name()); err != nil {
+		return fmt.Errorf("failed to compile crushmap from %s: %+v. %s", decompiledMap.Name(), err, output)
+	}
+	if output, err := SetCrushMap(context, clusterName, compiledMap.Name()); err != nil {
+		return fmt.Errorf("failed to set crushmap to %s: %+v. %s", compiledMap.Name(), err, output)
+	}
+	return nil
+}
+
 func FormatLocation(location, hostName string) ([]string, error) {
 	var pairs []string
 	if location == "" {
@@ -299,3 +446,249 @@ func isCrushFieldSet(fieldName string, pairs []string) bool {
 func formatProperty(name, value string) string {
 	return fmt.Sprintf("%s=%s", name, value)
 }
+
+// DeleteCrushRule removes the crush rule, which fails if the rule is still used by a pool
+func DeleteCrushRule(context *clusterd.Context, clusterName, name string) error {
+	args := []string{"osd", "crush", "rule", "rm", name}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to remove crush rule %s: %+v, %s", name, err, string(buf))
+	}
+
+	return nil
+}
+
+// SetDeviceClass sets the device class of the osd in the crush map, replacing the class that was
+// detected by the osd
+func SetDeviceClass(context *clusterd.Context, clusterName string, osdID int, deviceClass string) error {
+	osdName := fmt.Sprintf("osd.%d", osdID)
+	args := []string{"osd", "crush", "rm-device-class", osdName}
+	if buf, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to remove the device class of %s: %+v, %s", osdName, err, string(buf))
+	}
+
+	args = []string{"osd", "crush", "set-device-class", deviceClass, osdName}
+	if buf, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to set the device class of %s to %s: %+v, %s", osdName, deviceClass, err, string(buf))
+	}
+
+	return nil
+}
+
+// CrushRule is a rule to merge into the crush map. The steps are in the syntax of the decompiled
+// crush map without the step keyword, e.g. "take default class ssd" or "chooseleaf firstn 0 type host".
+type CrushRule struct {
+	Name    string
+	Type    string
+	MinSize int
+	MaxSize int
+	Steps   []string
+}
+
+var crushRuleStart = regexp.MustCompile(`^rule (\S+) \{$`)
+
+// SetCrushRules adds the rules to the crush map, or replaces the rules of the same name. The ids of
+// the replaced rules are kept so that the pools using them are not affected.
+func SetCrushRules(context *clusterd.Context, clusterName string, rules []CrushRule) error {
+	return editCrushMap(context, clusterName, func(decompiled string) (string, error) {
+		return mergeCrushRules(decompiled, rules)
+	})
+}
+
+// mergeCrushRules replaces the rules of the decompiled crush map with the given rules of the same
+// name and appends the other rules with the next free ids
+func mergeCrushRules(decompiled string, rules []CrushRule) (string, error) {
+	lines := strings.Split(decompiled, "\n")
+
+	type ruleBlock struct {
+		start, end, id int
+	}
+	blocks := map[string]*ruleBlock{}
+	maxID := -1
+	var current *ruleBlock
+	for i, line := range lines {
+		if current == nil {
+			if m := crushRuleStart.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				current = &ruleBlock{start: i, id: -1}
+				blocks[m[1]] = current
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && (fields[0] == "id" || fields[0] == "ruleset") {
+			id, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return "", fmt.Errorf("invalid rule id %q in the crush map", fields[1])
+			}
+			if current.id == -1 {
+				current.id = id
+			}
+			if id > maxID {
+				maxID = id
+			}
+		}
+		if strings.TrimSpace(line) == "}" {
+			current.end = i
+			current = nil
+		}
+	}
+	if current != nil {
+		return "", fmt.Errorf("unterminated rule in the crush map")
+	}
+
+	for _, rule := range rules {
+		if block, ok := blocks[rule.Name]; ok {
+			ruleLines := formatCrushRule(rule, block.id)
+			lines = append(lines[:block.start], append(ruleLines, lines[block.end+1:]...)...)
+			// shift the blocks after the replaced rule
+			shift := len(ruleLines) - (block.end - block.start + 1)
+			for _, b := range blocks {
+				if b.start > block.start {
+					b.start += shift
+					b.end += shift
+				}
+			}
+			block.end = block.start + len(ruleLines) - 1
+			continue
+		}
+
+		maxID++
+		ruleLines := formatCrushRule(rule, maxID)
+		// add the rule at the end of the rules, which are the last section of the map
+		end := len(lines)
+		for i, line := range lines {
+			if strings.TrimSpace(line) == "# end crush map" {
+				end = i
+				break
+			}
+		}
+		lines = append(lines[:end], append(ruleLines, lines[end:]...)...)
+		blocks[rule.Name] = &ruleBlock{start: end, end: end + len(ruleLines) - 1, id: maxID}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func formatCrushRule(rule CrushRule, id int) []string {
+	lines := []string{
+		fmt.Sprintf("rule %s {", rule.Name),
+		fmt.Sprintf("\tid %d", id),
+		fmt.Sprintf("\ttype %s", rule.Type),
+		fmt.Sprintf("\tmin_size %d", rule.MinSize),
+		fmt.Sprintf("\tmax_size %d", rule.MaxSize),
+	}
+	for _, step := range rule.Steps {
+		lines = append(lines, "\tstep "+step)
+	}
+	return append(lines, "}")
+}
+
+// StretchCrushRuleName is the name of the rule of the pools of a cluster that is stretched across two zones
+const StretchCrushRuleName = "stretch_cluster_rule"
+
+// StretchCrushRule returns the rule of the pools of a cluster that is stretched across two data zones.
+// The rule places two replicas on different hosts of each data zone so that the data stays available
+// with two replicas when a zone is lost. The arbiter zone is never taken, even if it has osds.
+func StretchCrushRule(dataZones []string) CrushRule {
+	rule := CrushRule{Name: StretchCrushRuleName, Type: "replicated", MinSize: 1, MaxSize: 10}
+	for _, zone := range dataZones {
+		rule.Steps = append(rule.Steps, "take "+zone, "chooseleaf firstn 2 type host", "emit")
+	}
+	return rule
+}
+
+// SetStretchCrushRule adds the buckets of the data zones under the default root, so that the rule can
+// take them before their osds are created, and adds the rule of the pools of a stretch cluster to the
+// crush map
+func SetStretchCrushRule(context *clusterd.Context, clusterName string, dataZones []string) error {
+	if err := AddCrushType(context, clusterName, "zone"); err != nil {
+		return err
+	}
+	for _, zone := range dataZones {
+		if err := AddCrushBucket(context, clusterName, zone, "zone"); err != nil {
+			return err
+		}
+		if _, err := CrushMove(context, clusterName, zone, []string{"root=default"}); err != nil {
+			return err
+		}
+	}
+	return SetCrushRules(context, clusterName, []CrushRule{StretchCrushRule(dataZones)})
+}
+
+// CheckStretchCrushRule returns an error if the crush map lacks the rule of the pools of a stretch cluster
+// or the buckets of the data zones, which is the case when the crush map is left to the user and the user
+// has not added them yet
+func CheckStretchCrushRule(context *clusterd.Context, clusterName string, dataZones []string) error {
+	crushMap, err := GetCrushMap(context, clusterName)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	ruleFound := false
+	for _, r := range crushMap.Rules {
+		if r.Name == StretchCrushRuleName {
+			ruleFound = true
+		}
+	}
+	if !ruleFound {
+		missing = append(missing, fmt.Sprintf("rule %s", StretchCrushRuleName))
+	}
+	zones := map[string]bool{}
+	for _, b := range crushMap.Buckets {
+		if b.TypeName == "zone" {
+			zones[b.Name] = true
+		}
+	}
+	for _, zone := range dataZones {
+		if !zones[zone] {
+			missing = append(missing, fmt.Sprintf("zone %s", zone))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the crush map has no %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// AddCrushBucket creates a bucket of the given type that is not linked into the hierarchy yet
+func AddCrushBucket(context *clusterd.Context, clusterName, name, bucketType string) error {
+	args := []string{"osd", "crush", "add-bucket", name, bucketType}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to add crush bucket %s: %+v, %s", name, err, string(buf))
+	}
+
+	return nil
+}
+
+// CrushSetOSD moves the osd to the location in the crush map and sets its weight. The weight of the
+// osd is kept if no weight is given.
+func CrushSetOSD(context *clusterd.Context, clusterName string, osdID int, weight string, location []string) error {
+	osdName := fmt.Sprintf("osd.%d", osdID)
+	var args []string
+	if weight != "" {
+		args = append([]string{"osd", "crush", "set", osdName, weight}, location...)
+	} else {
+		// the initial weight is ignored since the osd is already in the crush map
+		args = append([]string{"osd", "crush", "create-or-move", osdName, "0"}, location...)
+	}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to set the crush location of %s: %+v, %s", osdName, err, string(buf))
+	}
+
+	return nil
+}
+
+// CrushSetOSDWeight sets the crush weight of the osd without rounding the weight
+func CrushSetOSDWeight(context *clusterd.Context, clusterName string, osdID int, weight string) error {
+	osdName := fmt.Sprintf("osd.%d", osdID)
+	args := []string{"osd", "crush", "reweight", osdName, weight}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to set the crush weight of %s to %s: %+v, %s", osdName, weight, err, string(buf))
+	}
+
+	return nil
+}
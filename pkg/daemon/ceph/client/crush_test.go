@@ -17,6 +17,8 @@ package client
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/rook/rook/pkg/clusterd"
@@ -273,3 +275,232 @@ func TestCrushLocation(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "is not in a valid format")
 }
+
+func TestAddCrushType(t *testing.T) {
+	decompiled := "# types\ntype 0 osd\ntype 1 host\ntype 10 root\n\n# buckets\n"
+	setCrushMap := false
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[1] == "crush" && args[2] == "dump" {
+			return testCrushMap, nil
+		}
+		if args[1] == "getcrushmap" {
+			return "compiled", nil
+		}
+		if args[1] == "setcrushmap" {
+			setCrushMap = true
+			return "", nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		assert.Equal(t, CrushTool, command)
+		if args[0] == "-d" {
+			compiled, err := ioutil.ReadFile(args[1])
+			assert.Nil(t, err)
+			assert.Equal(t, "compiled", string(compiled))
+			return "", ioutil.WriteFile(args[3], []byte(decompiled), 0644)
+		}
+		// the type is added below root and root is renumbered
+		assert.Equal(t, "-c", args[0])
+		updated, err := ioutil.ReadFile(args[1])
+		assert.Nil(t, err)
+		assert.Equal(t, "# types\ntype 0 osd\ntype 1 host\ntype 10 zone\ntype 11 root\n\n# buckets\n", string(updated))
+		return "", nil
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := AddCrushType(context, "rook", "zone")
+	assert.Nil(t, err)
+	assert.True(t, setCrushMap)
+
+	// the crush map is not changed if it has the type
+	setCrushMap = false
+	err = AddCrushType(context, "rook", "region")
+	assert.Nil(t, err)
+	assert.False(t, setCrushMap)
+}
+
+func TestAddCrushTypeLine(t *testing.T) {
+	// the types of a luminous crush map, which has no zone type
+	luminous := `# types
+type 0 osd
+type 1 host
+type 2 chassis
+type 3 rack
+type 4 row
+type 5 pdu
+type 6 pod
+type 7 room
+type 8 datacenter
+type 9 region
+type 10 root
+
+# buckets
+root default {
+	id -1		# do not change unnecessarily
+	alg straw2
+}
+
+# rules
+rule replicated_rule {
+	id 0
+	type replicated
+	step take default
+	step chooseleaf firstn 0 type host
+	step emit
+}
+`
+	// zone is added between datacenter and region, and the types above it are renumbered
+	updated, err := addCrushTypeLine(luminous, "zone")
+	assert.Nil(t, err)
+	expected := strings.Replace(luminous, "type 9 region\ntype 10 root\n", "type 9 zone\ntype 10 region\ntype 11 root\n", 1)
+	assert.Equal(t, expected, updated)
+
+	// a type that is not in the default hierarchy is added after the last type
+	updated, err = addCrushTypeLine(luminous, "custom")
+	assert.Nil(t, err)
+	expected = strings.Replace(luminous, "type 10 root\n", "type 10 root\ntype 11 custom\n", 1)
+	assert.Equal(t, expected, updated)
+
+	// a map with only the lowest types gets the type after them
+	updated, err = addCrushTypeLine("type 0 osd\ntype 1 host\n", "rack")
+	assert.Nil(t, err)
+	assert.Equal(t, "type 0 osd\ntype 1 host\ntype 2 rack\n", updated)
+
+	_, err = addCrushTypeLine("# buckets\n", "zone")
+	assert.NotNil(t, err)
+}
+
+func TestSetDeviceClass(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[1] == "crush" && args[2] == "rm-device-class" {
+			commands = append(commands, args[2]+" "+args[3])
+			return "", nil
+		}
+		if args[1] == "crush" && args[2] == "set-device-class" {
+			commands = append(commands, args[2]+" "+args[3]+" "+args[4])
+			return "", nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the class detected by the osd is removed before the class is set
+	err := SetDeviceClass(context, "rook", 3, "nvme")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"rm-device-class osd.3", "set-device-class nvme osd.3"}, commands)
+}
+
+func TestMergeCrushRules(t *testing.T) {
+	decompiled := `# rules
+rule replicated_rule {
+	id 0
+	type replicated
+	min_size 1
+	max_size 10
+	step take default
+	step chooseleaf firstn 0 type host
+	step emit
+}
+rule stretch_rule {
+	id 2
+	type replicated
+	min_size 1
+	max_size 10
+	step take default
+	step emit
+}
+
+# end crush map
+`
+	stretch := CrushRule{Name: "stretch_rule", Type: "replicated", MinSize: 2, MaxSize: 4,
+		Steps: []string{"take dc1", "chooseleaf firstn 2 type host", "emit", "take dc2", "chooseleaf firstn 2 type host", "emit"}}
+	ssd := CrushRule{Name: "ssd_rule", Type: "replicated", MinSize: 1, MaxSize: 10,
+		Steps: []string{"take default class ssd", "chooseleaf firstn 0 type host", "emit"}}
+
+	// the rule of the same name is replaced keeping its id, the new rule gets the next id
+	merged, err := mergeCrushRules(decompiled, []CrushRule{stretch, ssd})
+	assert.Nil(t, err)
+	assert.Equal(t, `# rules
+rule replicated_rule {
+	id 0
+	type replicated
+	min_size 1
+	max_size 10
+	step take default
+	step chooseleaf firstn 0 type host
+	step emit
+}
+rule stretch_rule {
+	id 2
+	type replicated
+	min_size 2
+	max_size 4
+	step take dc1
+	step chooseleaf firstn 2 type host
+	step emit
+	step take dc2
+	step chooseleaf firstn 2 type host
+	step emit
+}
+
+rule ssd_rule {
+	id 3
+	type replicated
+	min_size 1
+	max_size 10
+	step take default class ssd
+	step chooseleaf firstn 0 type host
+	step emit
+}
+# end crush map
+`, merged)
+
+	// merging the same rules again does not change the map
+	again, err := mergeCrushRules(merged, []CrushRule{stretch, ssd})
+	assert.Nil(t, err)
+	assert.Equal(t, merged, again)
+
+	// the rules are checked to be complete
+	_, err = mergeCrushRules("rule broken {\n\tid 1\n", []CrushRule{ssd})
+	assert.NotNil(t, err)
+}
+
+func TestCheckStretchCrushRule(t *testing.T) {
+	crushMap := testCrushMap
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[1] == "crush" && args[2] == "dump" {
+			return crushMap, nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the rule and the zones that are missing are reported
+	err := CheckStretchCrushRule(context, "rook", []string{"a", "b"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "rule stretch_cluster_rule, zone a, zone b")
+
+	crushMap = `{"buckets": [
+		{"id": -1, "name": "default", "type_name": "root"},
+		{"id": -2, "name": "a", "type_name": "zone"},
+		{"id": -3, "name": "b", "type_name": "zone"}
+	], "rules": [{"rule_id": 1, "rule_name": "stretch_cluster_rule"}]}`
+	assert.Nil(t, CheckStretchCrushRule(context, "rook", []string{"a", "b"}))
+}
+
+func TestStretchCrushRule(t *testing.T) {
+	// each data zone is taken on its own, so the arbiter zone never gets replicas
+	rule := StretchCrushRule([]string{"a", "b"})
+	assert.Equal(t, "stretch_cluster_rule", rule.Name)
+	assert.Equal(t, []string{
+		"take a", "chooseleaf firstn 2 type host", "emit",
+		"take b", "chooseleaf firstn 2 type host", "emit",
+	}, rule.Steps)
+}
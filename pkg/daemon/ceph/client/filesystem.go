@@ -121,7 +121,8 @@ func AllowStandbyReplay(context *clusterd.Context, clusterName string, fsName st
 }
 
 // CreateFilesystem performs software configuration steps for Ceph to provide a new filesystem.
-func CreateFilesystem(context *clusterd.Context, clusterName, name, metadataPool string, dataPools []string) error {
+// If allowMultiple is true, the cluster is allowed to have more than one filesystem.
+func CreateFilesystem(context *clusterd.Context, clusterName, name, metadataPool string, dataPools []string, allowMultiple bool) error {
 	if len(dataPools) == 0 {
 		return fmt.Errorf("at least one data pool is required")
 	}
@@ -129,7 +130,7 @@ func CreateFilesystem(context *clusterd.Context, clusterName, name, metadataPool
 	args := []string{}
 	var err error
 
-	if IsMultiFSEnabled() {
+	if allowMultiple {
 		// enable multiple file systems in case this is not the first
 		args = []string{"fs", "flag", "set", "enable_multiple", "true", confirmFlag}
 		_, err = ExecuteCephCommand(context, clusterName, args)
@@ -159,6 +160,17 @@ func CreateFilesystem(context *clusterd.Context, clusterName, name, metadataPool
 	return nil
 }
 
+// SetMaxFileSize sets the maximum size of a file in the filesystem
+func SetMaxFileSize(context *clusterd.Context, clusterName, fsName string, maxFileSize int64) error {
+	args := []string{"fs", "set", fsName, "max_file_size", strconv.FormatInt(maxFileSize, 10)}
+	_, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to set max_file_size of filesystem %s to %d: %+v", fsName, maxFileSize, err)
+	}
+
+	return nil
+}
+
 // IsMultiFSEnabled returns true if ROOK_ALLOW_MULTIPLE_FILESYSTEMS is set to "true", allowing
 // Rook to create multiple Ceph filesystems. False if Rook is not allowed to do so.
 func IsMultiFSEnabled() bool {
@@ -322,8 +334,8 @@ func FailFilesystem(context *clusterd.Context, clusterName, fsName string) error
 }
 
 // RemoveFilesystem performs software configuration steps to remove a Ceph filesystem and its
-// backing pools.
-func RemoveFilesystem(context *clusterd.Context, clusterName, fsName string) error {
+// backing pools. The device classes of the pools are given by pool name to delete the crush rules of the pools.
+func RemoveFilesystem(context *clusterd.Context, clusterName, fsName string, deviceClasses map[string]string) error {
 	fs, err := GetFilesystem(context, clusterName, fsName)
 	if err != nil {
 		return fmt.Errorf("filesystem %s not found. %+v", fsName, err)
@@ -335,14 +347,14 @@ func RemoveFilesystem(context *clusterd.Context, clusterName, fsName string) err
 		return fmt.Errorf("Failed to delete ceph fs %s. err=%+v", fsName, err)
 	}
 
-	err = deleteFSPools(context, clusterName, fs)
+	err = deleteFSPools(context, clusterName, fs, deviceClasses)
 	if err != nil {
 		return fmt.Errorf("failed to delete fs %s pools. %+v", fsName, err)
 	}
 	return nil
 }
 
-func deleteFSPools(context *clusterd.Context, clusterName string, fs *CephFilesystemDetails) error {
+func deleteFSPools(context *clusterd.Context, clusterName string, fs *CephFilesystemDetails, deviceClasses map[string]string) error {
 	poolNames, err := GetPoolNamesByID(context, clusterName)
 	if err != nil {
 		return fmt.Errorf("failed to get pool names. %+v", err)
@@ -350,23 +362,23 @@ func deleteFSPools(context *clusterd.Context, clusterName string, fs *CephFilesy
 
 	// delete the metadata pool
 	var lastErr error
-	if err := deleteFSPool(context, clusterName, poolNames, fs.MDSMap.MetadataPool); err != nil {
+	if err := deleteFSPool(context, clusterName, poolNames, fs.MDSMap.MetadataPool, deviceClasses); err != nil {
 		lastErr = err
 	}
 
 	// delete the data pools
 	for _, poolID := range fs.MDSMap.DataPools {
-		if err := deleteFSPool(context, clusterName, poolNames, poolID); err != nil {
+		if err := deleteFSPool(context, clusterName, poolNames, poolID, deviceClasses); err != nil {
 			lastErr = err
 		}
 	}
 	return lastErr
 }
 
-func deleteFSPool(context *clusterd.Context, clusterName string, poolNames map[int]string, id int) error {
+func deleteFSPool(context *clusterd.Context, clusterName string, poolNames map[int]string, id int, deviceClasses map[string]string) error {
 	name, ok := poolNames[id]
 	if !ok {
 		return fmt.Errorf("pool %d not found", id)
 	}
-	return DeletePool(context, clusterName, name)
+	return DeletePool(context, clusterName, name, deviceClasses[name])
 }
@@ -92,7 +92,7 @@ func TestFilesystemGetMarshal(t *testing.T) {
 func TestFilesystemRemove(t *testing.T) {
 	dataDeleted := false
 	metadataDeleted := false
-	crushDeleted := false
+	var rulesDeleted []string
 	executor := &exectest.MockExecutor{}
 	context := &clusterd.Context{Executor: executor}
 	fs := CephFilesystemDetails{
@@ -143,15 +143,37 @@ func TestFilesystemRemove(t *testing.T) {
 			if args[1] == "crush" {
 				assert.Equal(t, "rule", args[2])
 				assert.Equal(t, "rm", args[3])
-				crushDeleted = true
+				rulesDeleted = append(rulesDeleted, args[4])
 				return "", nil
 			}
 		}
 		return "", fmt.Errorf("unexpected ceph command '%v'", args)
 	}
-	err := RemoveFilesystem(context, "ns", fs.MDSMap.FilesystemName)
+	// only the rule of the device class of the data pool is deleted besides the rules named after the pools
+	err := RemoveFilesystem(context, "ns", fs.MDSMap.FilesystemName, map[string]string{"mydata": "ssd"})
 	assert.Nil(t, err)
 	assert.True(t, metadataDeleted)
 	assert.True(t, dataDeleted)
-	assert.True(t, crushDeleted)
+	assert.ElementsMatch(t, []string{"mymetadata", "mydata", "mydata_ssd"}, rulesDeleted)
+}
+
+func TestCreateFilesystemAllowMultiple(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			commands = append(commands, fmt.Sprintf("%s %s", args[0], args[1]))
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the flag for multiple filesystems is only set when allowed
+	err := CreateFilesystem(context, "ns", "myfs", "myfs-metadata", []string{"myfs-data0"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fs new"}, commands)
+
+	commands = nil
+	err = CreateFilesystem(context, "ns", "myfs", "myfs-metadata", []string{"myfs-data0", "myfs-data1"}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fs flag", "fs new", "fs add_data_pool"}, commands)
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -34,6 +35,21 @@ func MgrDisableModule(context *clusterd.Context, clusterName, name string) error
 	return enableModule(context, clusterName, name, false, "disable")
 }
 
+// MgrDump returns the mgr map with the active mgr and the standby mgrs
+func MgrDump(context *clusterd.Context, clusterName string) (MgrMap, error) {
+	args := []string{"mgr", "dump"}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return MgrMap{}, fmt.Errorf("failed to get mgr dump. %+v", err)
+	}
+
+	var mgrMap MgrMap
+	if err := json.Unmarshal(buf, &mgrMap); err != nil {
+		return MgrMap{}, fmt.Errorf("failed to unmarshal mgr dump response. %+v", err)
+	}
+	return mgrMap, nil
+}
+
 // MgrSetConfig applies a setting for a single mgr daemon
 func MgrSetConfig(context *clusterd.Context, clusterName, mgrName string, cephVersion cephver.CephVersion, key, val string, force bool) (bool, error) {
 	var getArgs, setArgs []string
@@ -0,0 +1,131 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	// MirroringModeDisabled disables the mirroring of a pool
+	MirroringModeDisabled = "disabled"
+	// MirroringModePool mirrors all the images of a pool that have journaling enabled
+	MirroringModePool = "pool"
+	// MirroringModeImage mirrors only the images of a pool that have mirroring explicitly enabled
+	MirroringModeImage = "image"
+)
+
+// MirrorPoolInfo is the mirroring configuration of a pool as returned by 'rbd mirror pool info'
+type MirrorPoolInfo struct {
+	Mode  string       `json:"mode"`
+	Peers []MirrorPeer `json:"peers"`
+}
+
+// MirrorPeer is a remote cluster that a pool is mirrored from
+type MirrorPeer struct {
+	UUID        string `json:"uuid"`
+	ClusterName string `json:"cluster_name"`
+	ClientName  string `json:"client_name"`
+}
+
+// MirrorPoolStatus is the mirroring health of a pool as returned by 'rbd mirror pool status'
+type MirrorPoolStatus struct {
+	Summary struct {
+		Health string         `json:"health"`
+		States map[string]int `json:"states"`
+	} `json:"summary"`
+}
+
+// GetPoolMirroringInfo returns the mirroring mode and the peers of the pool
+func GetPoolMirroringInfo(context *clusterd.Context, clusterName, poolName string) (*MirrorPoolInfo, error) {
+	args := []string{"mirror", "pool", "info", poolName}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirroring info of pool %s. %+v", poolName, err)
+	}
+
+	var info MirrorPoolInfo
+	if err := json.Unmarshal(buf, &info); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	return &info, nil
+}
+
+// GetPoolMirroringStatus returns the summary of the mirroring health of the pool
+func GetPoolMirroringStatus(context *clusterd.Context, clusterName, poolName string) (*MirrorPoolStatus, error) {
+	args := []string{"mirror", "pool", "status", poolName}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirroring status of pool %s. %+v", poolName, err)
+	}
+
+	var status MirrorPoolStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	return &status, nil
+}
+
+// EnablePoolMirroring enables the mirroring of the pool in the given mode (pool or image)
+func EnablePoolMirroring(context *clusterd.Context, clusterName, poolName, mode string) error {
+	args := []string{"mirror", "pool", "enable", poolName, mode}
+	if _, err := ExecuteRBDCommandNoFormat(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to enable %s mirroring on pool %s. %+v", mode, poolName, err)
+	}
+	logger.Infof("enabled %s mirroring on pool %s", mode, poolName)
+	return nil
+}
+
+// DisablePoolMirroring disables the mirroring of the pool
+func DisablePoolMirroring(context *clusterd.Context, clusterName, poolName string) error {
+	args := []string{"mirror", "pool", "disable", poolName}
+	if _, err := ExecuteRBDCommandNoFormat(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to disable mirroring on pool %s. %+v", poolName, err)
+	}
+	logger.Infof("disabled mirroring on pool %s", poolName)
+	return nil
+}
+
+// AddPoolMirrorPeer adds a remote cluster to the peers of the pool. The mon hosts and the path to
+// a file with the key of the remote client are stored in the mon config-key store (Nautilus or newer).
+func AddPoolMirrorPeer(context *clusterd.Context, clusterName, poolName, remoteClient, remoteCluster, monHost, keyFile string) error {
+	args := []string{"mirror", "pool", "peer", "add", poolName, fmt.Sprintf("%s@%s", remoteClient, remoteCluster)}
+	if monHost != "" {
+		args = append(args, "--remote-mon-host", monHost)
+	}
+	if keyFile != "" {
+		args = append(args, "--remote-key-file", keyFile)
+	}
+	if _, err := ExecuteRBDCommandNoFormat(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to add peer %s@%s to pool %s. %+v", remoteClient, remoteCluster, poolName, err)
+	}
+	logger.Infof("added mirroring peer %s@%s to pool %s", remoteClient, remoteCluster, poolName)
+	return nil
+}
+
+// RemovePoolMirrorPeer removes the peer with the given uuid from the pool
+func RemovePoolMirrorPeer(context *clusterd.Context, clusterName, poolName, uuid string) error {
+	args := []string{"mirror", "pool", "peer", "remove", poolName, uuid}
+	if _, err := ExecuteRBDCommandNoFormat(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to remove peer %s from pool %s. %+v", uuid, poolName, err)
+	}
+	logger.Infof("removed mirroring peer %s from pool %s", uuid, poolName)
+	return nil
+}
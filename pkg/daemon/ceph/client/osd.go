@@ -59,9 +59,10 @@ type OSDPerfStats struct {
 
 type OSDDump struct {
 	OSDs []struct {
-		OSD json.Number `json:"osd"`
-		Up  json.Number `json:"up"`
-		In  json.Number `json:"in"`
+		OSD  json.Number `json:"osd"`
+		Up   json.Number `json:"up"`
+		In   json.Number `json:"in"`
+		UUID string      `json:"uuid"`
 	} `json:"osds"`
 }
 
@@ -91,6 +92,20 @@ func (dump *OSDDump) StatusByID(id int64) (int64, int64, error) {
 	return 0, 0, fmt.Errorf("not found osd.%d in OSDDump", id)
 }
 
+// UUIDByID returns the uuid of the given OSD id
+func (dump *OSDDump) UUIDByID(id int64) (string, error) {
+	for _, d := range dump.OSDs {
+		i, err := d.OSD.Int64()
+		if err != nil {
+			return "", err
+		}
+		if id == i {
+			return d.UUID, nil
+		}
+	}
+	return "", fmt.Errorf("not found osd.%d in OSDDump", id)
+}
+
 func GetOSDUsage(context *clusterd.Context, clusterName string) (*OSDUsage, error) {
 	args := []string{"osd", "df"}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
@@ -148,6 +163,32 @@ func OSDRemove(context *clusterd.Context, clusterName string, osdID int) (string
 	return string(buf), err
 }
 
+// OSDDestroy destroys the osd while keeping its id and crush entry so that the id can be given
+// to the osd that replaces it
+func OSDDestroy(context *clusterd.Context, clusterName string, osdID int) (string, error) {
+	args := []string{"osd", "destroy", strconv.Itoa(osdID), "--yes-i-really-mean-it"}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	return string(buf), err
+}
+
+// OSDSetFlag sets a cluster-wide osd flag such as noout or norebalance
+func OSDSetFlag(context *clusterd.Context, clusterName, flag string) error {
+	args := []string{"osd", "set", flag}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to set osd flag %s. %+v", flag, err)
+	}
+	return nil
+}
+
+// OSDUnsetFlag unsets a cluster-wide osd flag
+func OSDUnsetFlag(context *clusterd.Context, clusterName, flag string) error {
+	args := []string{"osd", "unset", flag}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to unset osd flag %s. %+v", flag, err)
+	}
+	return nil
+}
+
 func (usage *OSDUsage) ByID(osdID int) *OSDNodeUsage {
 	for i := range usage.OSDNodes {
 		if usage.OSDNodes[i].ID == osdID {
@@ -39,9 +39,12 @@ type CephStoragePoolDetails struct {
 	Name               string `json:"pool"`
 	Number             int    `json:"pool_id"`
 	Size               uint   `json:"size"`
+	MinSize            uint   `json:"min_size"`
 	ErasureCodeProfile string `json:"erasure_code_profile"`
 	FailureDomain      string `json:"failureDomain"`
 	CrushRoot          string `json:"crushRoot"`
+	DeviceClass        string `json:"deviceClass"`
+	CrushRule          string `json:"crush_rule"`
 }
 
 type CephStoragePoolStats struct {
@@ -128,7 +131,7 @@ func CreatePoolWithProfile(context *clusterd.Context, clusterName string, newPoo
 	if newPoolReq.Type == model.ErasureCoded {
 		// create a new erasure code profile for the new pool
 		if err := CreateErasureCodeProfile(context, clusterName, newPoolReq.ErasureCodedConfig, newPool.ErasureCodeProfile,
-			newPoolReq.FailureDomain, newPoolReq.CrushRoot); err != nil {
+			newPoolReq.FailureDomain, newPoolReq.CrushRoot, newPoolReq.DeviceClass); err != nil {
 
 			return fmt.Errorf("failed to create erasure code profile for pool '%s': %+v", newPoolReq.Name, err)
 		}
@@ -149,7 +152,9 @@ func CreatePoolWithProfile(context *clusterd.Context, clusterName string, newPoo
 	)
 }
 
-func DeletePool(context *clusterd.Context, clusterName string, name string) error {
+// DeletePool deletes the pool and the crush rules created for it: the rule named after the pool and, for
+// a pool on a device class, the rule of the device class recorded in the spec of the pool
+func DeletePool(context *clusterd.Context, clusterName, name, deviceClass string) error {
 	// check if the pool exists
 	pool, err := GetPoolDetails(context, clusterName, name)
 	if err != nil {
@@ -165,11 +170,15 @@ func DeletePool(context *clusterd.Context, clusterName string, name string) erro
 	}
 
 	// remove the crush rule for this pool and ignore the error in case the rule is still in use or not found
-	args = []string{"osd", "crush", "rule", "rm", name}
-	_, err = ExecuteCephCommand(context, clusterName, args)
-	if err != nil {
+	if err := DeleteCrushRule(context, clusterName, name); err != nil {
 		logger.Infof("did not delete crush rule %s. %+v", name, err)
 	}
+	// a replicated pool on a device class has a rule of its own
+	if ruleName := GetCrushRuleForPool(name, deviceClass); ruleName != name {
+		if err := DeleteCrushRule(context, clusterName, ruleName); err != nil {
+			logger.Infof("did not delete crush rule %s. %+v", ruleName, err)
+		}
+	}
 
 	logger.Infof("purge completed for pool %s", name)
 	return nil
@@ -213,23 +222,39 @@ func CreateECPoolForApp(context *clusterd.Context, clusterName string, newPool C
 }
 
 func CreateReplicatedPoolForApp(context *clusterd.Context, clusterName string, newPool CephStoragePoolDetails, appName string) error {
-	// create a crush rule for a replicated pool, if a failure domain is specified
-	if err := createReplicationCrushRule(context, clusterName, newPool, newPool.Name); err != nil {
-		return err
+	// create a crush rule for a replicated pool, unless the pool is placed with an existing rule
+	ruleName := newPool.CrushRule
+	if ruleName == "" {
+		ruleName = GetCrushRuleForPool(newPool.Name, newPool.DeviceClass)
+		if err := createReplicationCrushRule(context, clusterName, newPool, ruleName); err != nil {
+			return err
+		}
 	}
 
-	args := []string{"osd", "pool", "create", newPool.Name, strconv.Itoa(newPool.Number), "replicated", newPool.Name}
+	args := []string{"osd", "pool", "create", newPool.Name, strconv.Itoa(newPool.Number), "replicated", ruleName}
 
 	buf, err := ExecuteCephCommand(context, clusterName, args)
 	if err != nil {
 		return fmt.Errorf("failed to create replicated pool %s. %+v", newPool.Name, err)
 	}
 
+	// the pool already exists if its device class was changed, in which case it is moved to the rule of the new class
+	if err = SetPoolProperty(context, clusterName, newPool.Name, "crush_rule", ruleName); err != nil {
+		return err
+	}
+
 	// the pool is type replicated, set the size for the pool now that it's been created
 	if err = SetPoolProperty(context, clusterName, newPool.Name, "size", strconv.FormatUint(uint64(newPool.Size), 10)); err != nil {
 		return err
 	}
 
+	// ceph derives the min size from the size unless the min size is specified
+	if newPool.MinSize > 0 {
+		if err = SetPoolProperty(context, clusterName, newPool.Name, "min_size", strconv.FormatUint(uint64(newPool.MinSize), 10)); err != nil {
+			return err
+		}
+	}
+
 	// ensure that the newly created pool gets an application tag
 	err = givePoolAppTag(context, clusterName, newPool.Name, appName)
 	if err != nil {
@@ -255,6 +280,10 @@ func createReplicationCrushRule(context *clusterd.Context, clusterName string, n
 	}
 
 	args := []string{"osd", "crush", "rule", "create-simple", ruleName, crushRoot, failureDomain}
+	if newPool.DeviceClass != "" {
+		// only the osds of the device class are selected by the rule
+		args = []string{"osd", "crush", "rule", "create-replicated", ruleName, crushRoot, failureDomain, newPool.DeviceClass}
+	}
 	_, err := ExecuteCephCommand(context, clusterName, args)
 	if err != nil {
 		return fmt.Errorf("failed to create crush rule %s. %+v", ruleName, err)
@@ -263,6 +292,15 @@ func createReplicationCrushRule(context *clusterd.Context, clusterName string, n
 	return nil
 }
 
+// GetCrushRuleForPool returns the name of the crush rule of a replicated pool, which is named after the
+// pool and the device class the pool is placed on, if any
+func GetCrushRuleForPool(poolName, deviceClass string) string {
+	if deviceClass == "" {
+		return poolName
+	}
+	return fmt.Sprintf("%s_%s", poolName, deviceClass)
+}
+
 func SetPoolProperty(context *clusterd.Context, clusterName, name, propName string, propVal string) error {
 	args := []string{"osd", "pool", "set", name, propName, propVal}
 	_, err := ExecuteCephCommand(context, clusterName, args)
@@ -272,6 +310,21 @@ func SetPoolProperty(context *clusterd.Context, clusterName, name, propName stri
 	return nil
 }
 
+// SetPoolQuota sets the max bytes and max objects quotas of a pool. A value of zero removes the quota.
+func SetPoolQuota(context *clusterd.Context, clusterName, name string, maxBytes, maxObjects uint64) error {
+	quotas := []struct {
+		field string
+		value uint64
+	}{{"max_bytes", maxBytes}, {"max_objects", maxObjects}}
+	for _, q := range quotas {
+		args := []string{"osd", "pool", "set-quota", name, q.field, strconv.FormatUint(q.value, 10)}
+		if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+			return fmt.Errorf("failed to set %s quota on pool %s. %+v", q.field, name, err)
+		}
+	}
+	return nil
+}
+
 func GetPoolStats(context *clusterd.Context, clusterName string) (*CephStoragePoolStats, error) {
 	args := []string{"df", "detail"}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
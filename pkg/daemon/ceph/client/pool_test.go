@@ -98,14 +98,21 @@ func TestCreateECPoolWithoutOverwrites(t *testing.T) {
 }
 
 func TestCreateReplicaPool(t *testing.T) {
-	testCreateReplicaPool(t, "", "")
+	testCreateReplicaPool(t, "", "", "")
 }
 func TestCreateReplicaPoolWithFailureDomain(t *testing.T) {
-	testCreateReplicaPool(t, "osd", "mycrushroot")
+	testCreateReplicaPool(t, "osd", "mycrushroot", "")
+}
+func TestCreateReplicaPoolWithDeviceClass(t *testing.T) {
+	testCreateReplicaPool(t, "osd", "mycrushroot", "ssd")
 }
 
-func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
+func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot, deviceClass string) {
 	crushRuleCreated := false
+	ruleName := "mypool"
+	if deviceClass != "" {
+		ruleName = "mypool_ssd"
+	}
 	executor := &exectest.MockExecutor{}
 	context := &clusterd.Context{Executor: executor}
 	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
@@ -114,6 +121,12 @@ func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
 			if args[2] == "create" {
 				assert.Equal(t, "mypool", args[3])
 				assert.Equal(t, "replicated", args[5])
+				assert.Equal(t, ruleName, args[6])
+				return "", nil
+			}
+			if args[2] == "set" && args[4] == "crush_rule" {
+				assert.Equal(t, "mypool", args[3])
+				assert.Equal(t, ruleName, args[5])
 				return "", nil
 			}
 			if args[2] == "set" {
@@ -132,8 +145,13 @@ func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
 		if args[1] == "crush" {
 			crushRuleCreated = true
 			assert.Equal(t, "rule", args[2])
-			assert.Equal(t, "create-simple", args[3])
-			assert.Equal(t, "mypool", args[4])
+			if deviceClass == "" {
+				assert.Equal(t, "create-simple", args[3])
+			} else {
+				assert.Equal(t, "create-replicated", args[3])
+				assert.Equal(t, deviceClass, args[7])
+			}
+			assert.Equal(t, ruleName, args[4])
 			if crushRoot == "" {
 				assert.Equal(t, "default", args[5])
 			} else {
@@ -149,8 +167,36 @@ func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
 		return "", fmt.Errorf("unexpected ceph command '%v'", args)
 	}
 
-	p := CephStoragePoolDetails{Name: "mypool", Size: 12345, FailureDomain: failureDomain, CrushRoot: crushRoot}
+	p := CephStoragePoolDetails{Name: "mypool", Size: 12345, FailureDomain: failureDomain, CrushRoot: crushRoot, DeviceClass: deviceClass}
 	err := CreateReplicatedPoolForApp(context, "myns", p, "myapp")
 	assert.Nil(t, err)
 	assert.True(t, crushRuleCreated)
 }
+
+func TestCreateReplicaPoolWithCrushRule(t *testing.T) {
+	properties := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[1] == "pool" && args[2] == "create" {
+				assert.Equal(t, "stretch_cluster_rule", args[6])
+				return "", nil
+			}
+			if args[1] == "pool" && args[2] == "set" {
+				properties[args[4]] = args[5]
+				return "", nil
+			}
+			if args[1] == "pool" && args[2] == "application" {
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the pool is placed with the existing rule and no rule of its own is created
+	p := CephStoragePoolDetails{Name: "mypool", Size: 4, MinSize: 2, CrushRule: StretchCrushRuleName}
+	err := CreateReplicatedPoolForApp(context, "myns", p, "myapp")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"crush_rule": "stretch_cluster_rule", "size": "4", "min_size": "2"}, properties)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// SubvolumeNamespacePrefix is the prefix of the rados namespace of a subvolume created with an isolated namespace
+const SubvolumeNamespacePrefix = "fsvolumens_"
+
+// CreateSubvolumeGroup creates a subvolume group in the filesystem with the volumes module of the mgr.
+// Nothing is done if the group already exists. The files of the group are stored in the given data pool,
+// or in the default data pool of the filesystem if empty.
+func CreateSubvolumeGroup(context *clusterd.Context, clusterName, fsName, groupName, dataPool string) error {
+	args := []string{"fs", "subvolumegroup", "create", fsName, groupName}
+	if dataPool != "" {
+		args = append(args, "--pool_layout", dataPool)
+	}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to create subvolume group %s in filesystem %s. %+v", groupName, fsName, err)
+	}
+	return nil
+}
+
+// GetSubvolumeGroupPath returns the path of the subvolume group in the filesystem
+func GetSubvolumeGroupPath(context *clusterd.Context, clusterName, fsName, groupName string) (string, error) {
+	args := []string{"fs", "subvolumegroup", "getpath", fsName, groupName}
+	buf, err := ExecuteCephCommandPlain(context, clusterName, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the path of subvolume group %s. %+v", groupName, err)
+	}
+	path := strings.TrimSpace(string(buf))
+	if path == "" {
+		return "", fmt.Errorf("subvolume group %s has no path", groupName)
+	}
+	return path, nil
+}
+
+// CreateSubvolume creates a subvolume in the group with its own rados namespace. Nothing is done if
+// the subvolume already exists.
+func CreateSubvolume(context *clusterd.Context, clusterName, fsName, groupName, name string) error {
+	args := []string{"fs", "subvolume", "create", fsName, name, "--group_name", groupName, "--namespace-isolated"}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to create subvolume %s in group %s. %+v", name, groupName, err)
+	}
+	return nil
+}
+
+// ResizeSubvolume sets the quota of the subvolume to the given number of bytes, or removes it if zero
+func ResizeSubvolume(context *clusterd.Context, clusterName, fsName, groupName, name string, maxBytes int64) error {
+	size := "inf"
+	if maxBytes > 0 {
+		size = strconv.FormatInt(maxBytes, 10)
+	}
+	args := []string{"fs", "subvolume", "resize", fsName, name, size, "--group_name", groupName}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to resize subvolume %s to %s. %+v", name, size, err)
+	}
+	return nil
+}
+
+// GetSubvolumePath returns the path of the subvolume in the filesystem
+func GetSubvolumePath(context *clusterd.Context, clusterName, fsName, groupName, name string) (string, error) {
+	args := []string{"fs", "subvolume", "getpath", fsName, name, "--group_name", groupName}
+	buf, err := ExecuteCephCommandPlain(context, clusterName, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the path of subvolume %s. %+v", name, err)
+	}
+	path := strings.TrimSpace(string(buf))
+	if path == "" {
+		return "", fmt.Errorf("subvolume %s has no path", name)
+	}
+	return path, nil
+}
+
+// setDirAttributeScript sets a virtual extended attribute of a directory with the python bindings of
+// libcephfs, which are installed with the mgr in the ceph image. The args are the config and keyring
+// of the cluster, the filesystem, the path of the directory, and the name and value of the attribute.
+const setDirAttributeScript = `import sys, cephfs
+fs = cephfs.LibCephFS(conffile=sys.argv[1], conf={"keyring": sys.argv[2]})
+fs.mount(filesystem_name=sys.argv[3])
+try:
+    fs.setxattr(sys.argv[4], sys.argv[5], sys.argv[6].encode(), 0)
+finally:
+    fs.shutdown()
+`
+
+// SetDirAttribute sets the virtual extended attribute of the directory of the filesystem, for example the
+// ceph.quota.max_files quota or the ceph.dir.pin mds rank, which have no ceph command in Nautilus. The
+// filesystem is accessed with libcephfs so that the operator does not need to mount it.
+func SetDirAttribute(context *clusterd.Context, clusterName, fsName, dirPath, name, value string) error {
+	confFile := path.Join(context.ConfigDir, clusterName, fmt.Sprintf("%s.config", clusterName))
+	keyringFile := path.Join(context.ConfigDir, clusterName, fmt.Sprintf("%s.keyring", AdminUsername))
+	args := []string{"-c", setDirAttributeScript, confFile, keyringFile, fsName, dirPath, name, value}
+	if _, err := context.Executor.ExecuteCommandWithOutput(false, "", "python3", args...); err != nil {
+		return fmt.Errorf("failed to set %s=%s on %s in filesystem %s. %+v", name, value, dirPath, fsName, err)
+	}
+	return nil
+}
@@ -29,6 +29,8 @@ type CephDaemonsVersions struct {
 	Mon     map[string]int `json:"mon,omitempty"`
 	Mgr     map[string]int `json:"mgr,omitempty"`
 	Mds     map[string]int `json:"mds,omitempty"`
+	Osd     map[string]int `json:"osd,omitempty"`
+	Rgw     map[string]int `json:"rgw,omitempty"`
 	Overall map[string]int `json:"overall,omitempty"`
 }
 
@@ -63,3 +63,26 @@ func TestEnableMessenger2(t *testing.T) {
 	err := EnableMessenger2(context)
 	assert.Nil(t, err)
 }
+
+func TestGetCephVersions(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, name string, command string, args ...string) (string, error) {
+		return `{
+	"mon": {"ceph version 14.2.1 (d555a9489eb35f84f2e1ef49b77e19da9d113972) nautilus (stable)": 3},
+	"mgr": {"ceph version 14.2.1 (d555a9489eb35f84f2e1ef49b77e19da9d113972) nautilus (stable)": 1},
+	"osd": {
+		"ceph version 13.2.6 (7b695f835b03642f85998b2ae7b6dd093d9fbce4) mimic (stable)": 2,
+		"ceph version 14.2.1 (d555a9489eb35f84f2e1ef49b77e19da9d113972) nautilus (stable)": 4
+	},
+	"rgw": {"ceph version 13.2.6 (7b695f835b03642f85998b2ae7b6dd093d9fbce4) mimic (stable)": 1}
+}`, nil
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	versions, err := GetCephVersions(context)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, versions.Mon["ceph version 14.2.1 (d555a9489eb35f84f2e1ef49b77e19da9d113972) nautilus (stable)"])
+	assert.Equal(t, 2, len(versions.Osd))
+	assert.Equal(t, 1, len(versions.Rgw))
+	assert.Equal(t, 0, len(versions.Mds))
+}
@@ -24,7 +24,8 @@ const (
 type PoolType int
 
 type ReplicatedPoolConfig struct {
-	Size uint `json:"size"`
+	Size    uint `json:"size"`
+	MinSize uint `json:"minSize"`
 }
 
 type ErasureCodedPoolConfig struct {
@@ -39,6 +40,8 @@ type Pool struct {
 	Type               PoolType               `json:"type"`
 	FailureDomain      string                 `json:"failureDomain"`
 	CrushRoot          string                 `json:"crushRoot"`
+	DeviceClass        string                 `json:"deviceClass"`
+	CrushRule          string                 `json:"crushRule"`
 	ReplicatedConfig   ReplicatedPoolConfig   `json:"replicatedConfig"`
 	ErasureCodedConfig ErasureCodedPoolConfig `json:"erasureCodedConfig"`
 }
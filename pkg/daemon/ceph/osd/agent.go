@@ -27,6 +27,7 @@ import (
 
 	"github.com/google/uuid"
 
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
@@ -35,6 +36,7 @@ import (
 	"github.com/rook/rook/pkg/util"
 	"github.com/rook/rook/pkg/util/proc"
 	"github.com/rook/rook/pkg/util/sys"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -48,16 +50,22 @@ type OsdAgent struct {
 	cluster        *cephconfig.ClusterInfo
 	nodeName       string
 	forceFormat    bool
+	pvcBacked      bool
+	replaceOSDs    map[string]int
+	migrateOSDs    map[string]int
 	location       string
 	osdProc        map[int]*proc.MonitoredProc
 	devices        []DesiredDevice
+	propertyFilter *rookalpha.DevicePropertyFilter
 	metadataDevice string
 	directories    string
 	procMan        *proc.ProcManager
 	storeConfig    config.StoreConfig
 	kv             *k8sutil.ConfigMapKVStore
+	ownerRef       metav1.OwnerReference
 	configCounter  int32
 	osdsCompleted  chan struct{}
+	batchReports   []oposd.BatchReport
 }
 
 type device struct {
@@ -65,19 +73,25 @@ type device struct {
 	osdCount int
 }
 
-func NewAgent(context *clusterd.Context, devices []DesiredDevice, metadataDevice, directories string, forceFormat bool,
-	location string, storeConfig config.StoreConfig, cluster *cephconfig.ClusterInfo, nodeName string, kv *k8sutil.ConfigMapKVStore) *OsdAgent {
+func NewAgent(context *clusterd.Context, devices []DesiredDevice, propertyFilter *rookalpha.DevicePropertyFilter, metadataDevice, directories string, forceFormat, pvcBacked bool,
+	replaceOSDs, migrateOSDs map[string]int, location string, storeConfig config.StoreConfig, cluster *cephconfig.ClusterInfo, nodeName string, kv *k8sutil.ConfigMapKVStore,
+	ownerRef metav1.OwnerReference) *OsdAgent {
 
 	return &OsdAgent{
 		devices:        devices,
+		propertyFilter: propertyFilter,
 		metadataDevice: metadataDevice,
 		directories:    directories,
 		forceFormat:    forceFormat,
+		pvcBacked:      pvcBacked,
+		replaceOSDs:    replaceOSDs,
+		migrateOSDs:    migrateOSDs,
 		location:       location,
 		storeConfig:    storeConfig,
 		cluster:        cluster,
 		nodeName:       nodeName,
 		kv:             kv,
+		ownerRef:       ownerRef,
 		procMan:        proc.New(context.Executor),
 		osdProc:        make(map[int]*proc.MonitoredProc),
 	}
@@ -184,8 +198,13 @@ func (a *OsdAgent) configureDevices(context *clusterd.Context, devices *DeviceOs
 	succeeded := 0
 	nonCVTotal := len(scheme.Entries)
 	for _, entry := range scheme.Entries {
+		storeConfig := a.storeConfig
+		if data, ok := entry.Partitions[entry.GetDataPartitionType()]; ok {
+			storeConfig.DeviceClass = a.getDeviceClass(devices.Entries[data.Device])
+		}
 		config := &osdConfig{id: entry.ID, uuid: entry.OsdUUID, configRoot: context.ConfigDir,
-			partitionScheme: entry, storeConfig: a.storeConfig, kv: a.kv, storeName: config.GetConfigStoreName(a.nodeName)}
+			partitionScheme: entry, storeConfig: storeConfig, kv: a.kv, storeName: config.GetConfigStoreName(a.nodeName),
+			clusterName: a.cluster.Name, ownerRef: a.ownerRef}
 		osd, err := a.prepareOSD(context, config)
 		if err != nil {
 			return osds, fmt.Errorf("failed to config osd %d. %+v", entry.ID, err)
@@ -285,6 +304,11 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 				numDataNeeded++
 			}
 		} else if isDeviceDesiredForMetadata(mapping, perfScheme) {
+			if skipNewDevices {
+				// ceph-volume places the db of its osds on the metadata devices
+				logger.Infof("device %s to be used for metadata by ceph-volume", name)
+				continue
+			}
 			// device is desired to store metadata for other OSDs
 			logger.Infof("configuring device %s (%s) for metadata", name, nameToUUID)
 			if perfScheme.Metadata != nil {
@@ -520,6 +544,7 @@ func getOSDInfo(clusterName string, config *osdConfig, devPartInfo *devicePartIn
 		UUID:        config.uuid.String(),
 		IsFileStore: isFilestore(config),
 		IsDirectory: config.dir,
+		Encrypted:   config.partitionScheme != nil && config.partitionScheme.Encrypted,
 	}
 	if devPartInfo != nil {
 		osd.DevicePartUUID = devPartInfo.deviceUUID
@@ -397,8 +397,8 @@ func createTestAgent(t *testing.T, devices, configDir, nodeName string, storeCon
 	}
 	cluster := &cephconfig.ClusterInfo{Name: "myclust"}
 	context := &clusterd.Context{ConfigDir: configDir, Executor: executor, Clientset: testop.New(1)}
-	agent := NewAgent(context, desiredDevices, "", "", forceFormat, location, *storeConfig,
-		cluster, nodeName, mockKVStore())
+	agent := NewAgent(context, desiredDevices, nil, "", "", forceFormat, false, nil, nil, location, *storeConfig,
+		cluster, nodeName, mockKVStore(), metav1.OwnerReference{})
 
 	return agent, executor, context
 }
@@ -465,7 +465,7 @@ func TestGetPartitionPerfScheme(t *testing.T) {
 	}
 	context.Executor = executor
 
-	devices, err := getAvailableDevices(context, []DesiredDevice{{Name: "sda"}, {Name: "sdb"}}, "sdc")
+	devices, err := getAvailableDevices(context, []DesiredDevice{{Name: "sda"}, {Name: "sdb"}}, nil, "sdc")
 	assert.Nil(t, err)
 	scheme, _, err := a.getPartitionPerfScheme(context, devices, false)
 	assert.Nil(t, err)
@@ -542,7 +542,7 @@ func TestGetPartitionSchemeDiskInUse(t *testing.T) {
 
 	// get the partition scheme based on the available devices.  Since sda is already in use, the partition
 	// scheme returned should reflect that.
-	devices, err := getAvailableDevices(context, []DesiredDevice{{Name: "sda"}}, "")
+	devices, err := getAvailableDevices(context, []DesiredDevice{{Name: "sda"}}, nil, "")
 	scheme, _, err := a.getPartitionPerfScheme(context, devices, false)
 	assert.Nil(t, err)
 
@@ -610,7 +610,7 @@ func TestGetPartitionSchemeDiskNameChanged(t *testing.T) {
 
 	// get the current partition scheme.  This should notice that the device names changed and update the
 	// partition scheme to have the latest device names
-	devices, err := getAvailableDevices(context, []DesiredDevice{{Name: "sda-changed"}}, "nvme01")
+	devices, err := getAvailableDevices(context, []DesiredDevice{{Name: "sda-changed"}}, nil, "nvme01")
 	scheme, _, err := a.getPartitionPerfScheme(context, devices, false)
 	assert.Nil(t, err)
 	require.NotNil(t, scheme)
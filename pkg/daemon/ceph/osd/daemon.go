@@ -25,6 +25,7 @@ import (
 	"strings"
 
 	"github.com/coreos/pkg/capnslog"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
@@ -39,7 +40,7 @@ var (
 )
 
 // StartOSD starts an OSD on a device that was provisioned by ceph-volume
-func StartOSD(context *clusterd.Context, osdType, osdID, osdUUID string, cephArgs []string) error {
+func StartOSD(context *clusterd.Context, osdType, osdID, osdUUID, pvcDevice string, encrypted bool, cephArgs []string) error {
 
 	// ensure the config mount point exists
 	configDir := fmt.Sprintf("/var/lib/ceph/osd/ceph-%s", osdID)
@@ -48,10 +49,25 @@ func StartOSD(context *clusterd.Context, osdType, osdID, osdUUID string, cephArg
 		logger.Errorf("failed to create config dir %s. %+v", configDir, err)
 	}
 
-	// activate the osd with ceph-volume
-	storeFlag := "--" + osdType
-	if err := context.Executor.ExecuteCommand(false, "", "stdbuf", "-oL", "ceph-volume", "lvm", "activate", "--no-systemd", storeFlag, osdID, osdUUID); err != nil {
-		return fmt.Errorf("failed to activate osd. %+v", err)
+	if pvcDevice != "" {
+		// the volume group on the block device of the pvc is not active if the pvc was attached to
+		// another node when the osd last ran
+		if err := activateVolumeGroup(context, pvcDevice); err != nil {
+			return fmt.Errorf("failed to activate the volume group on pvc device %s. %+v", pvcDevice, err)
+		}
+	}
+
+	if encrypted {
+		// the devices were unlocked by the encryption init container
+		if err := activateEncryptedOSD(context, osdType, osdID, osdUUID, configDir); err != nil {
+			return fmt.Errorf("failed to activate encrypted osd. %+v", err)
+		}
+	} else {
+		// activate the osd with ceph-volume
+		storeFlag := "--" + osdType
+		if err := context.Executor.ExecuteCommand(false, "", "stdbuf", "-oL", "ceph-volume", "lvm", "activate", "--no-systemd", storeFlag, osdID, osdUUID); err != nil {
+			return fmt.Errorf("failed to activate osd. %+v", err)
+		}
 	}
 
 	// run the ceph-osd daemon
@@ -62,6 +78,19 @@ func StartOSD(context *clusterd.Context, osdType, osdID, osdUUID string, cephArg
 	return nil
 }
 
+func activateVolumeGroup(context *clusterd.Context, device string) error {
+	vg, err := context.Executor.ExecuteCommandWithOutput(false, "", "pvs", "--noheadings", "-o", "vg_name", device)
+	if err != nil {
+		return fmt.Errorf("failed to get the volume group. %+v", err)
+	}
+	vg = strings.TrimSpace(vg)
+	if vg == "" {
+		return fmt.Errorf("no volume group found")
+	}
+	logger.Infof("activating volume group %s on device %s", vg, device)
+	return context.Executor.ExecuteCommand(false, "", "vgchange", "-ay", vg)
+}
+
 func RunFilestoreOnDevice(context *clusterd.Context, mountSourcePath, mountPath string, cephArgs []string) error {
 	// start the OSD daemon in the foreground with the given config
 	logger.Infof("starting filestore osd on a device")
@@ -99,6 +128,11 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 		return fmt.Errorf("failed to write connection config. %+v", err)
 	}
 
+	if agent.pvcBacked {
+		// the block device of the pvc is the only device of the osd, there is no hardware to discover
+		return provisionPVC(context, agent)
+	}
+
 	logger.Infof("discovering hardware")
 	rawDevices, err := clusterd.DiscoverDevices(context.Executor)
 	if err != nil {
@@ -109,7 +143,7 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	logger.Infof("creating and starting the osds")
 
 	// determine the set of devices that can/should be used for OSDs.
-	devices, err := getAvailableDevices(context, agent.devices, agent.metadataDevice)
+	devices, err := getAvailableDevices(context, agent.devices, agent.propertyFilter, agent.metadataDevice)
 	if err != nil {
 		return fmt.Errorf("failed to get available devices. %+v", err)
 	}
@@ -126,12 +160,20 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 		return err
 	}
 
+	// wipe the devices of the legacy osds being migrated so that ceph-volume prepares them again
+	if err := agent.wipeMigratedOSDs(context, devices); err != nil {
+		return fmt.Errorf("failed to wipe the devices of the migrated osds. %+v", err)
+	}
+
 	// start the desired OSDs on devices
 	logger.Infof("configuring osd devices: %+v", devices)
 	deviceOSDs, err := agent.configureDevices(context, devices)
 	if err != nil {
 		return fmt.Errorf("failed to configure devices. %+v", err)
 	}
+	if err := agent.moveEncryptionKeys(context, deviceOSDs); err != nil {
+		return err
+	}
 
 	// determine the set of directories that can/should be used for OSDs, with the default dir if no devices were specified. save off the node's crush name if needed.
 	logger.Infof("devices = %+v", deviceOSDs)
@@ -168,7 +210,7 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	osds := append(deviceOSDs, dirOSDs...)
 
 	// orchestration is completed, update the status
-	status = oposd.OrchestrationStatus{OSDs: osds, Status: oposd.OrchestrationStatusCompleted}
+	status = oposd.OrchestrationStatus{OSDs: osds, Status: oposd.OrchestrationStatusCompleted, BatchReports: agent.batchReports}
 	if err := oposd.UpdateNodeStatus(agent.kv, agent.nodeName, status); err != nil {
 		return err
 	}
@@ -176,7 +218,27 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	return nil
 }
 
-func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevice, metadataDevice string) (*DeviceOsdMapping, error) {
+func provisionPVC(context *clusterd.Context, agent *OsdAgent) error {
+	status := oposd.OrchestrationStatus{Status: oposd.OrchestrationStatusOrchestrating, PVCBackedOSD: true}
+	if err := oposd.UpdateNodeStatus(agent.kv, agent.nodeName, status); err != nil {
+		return err
+	}
+
+	osds, err := agent.configurePVCDevice(context)
+	if err != nil {
+		return fmt.Errorf("failed to configure pvc device. %+v", err)
+	}
+	if err := agent.moveEncryptionKeys(context, osds); err != nil {
+		return err
+	}
+
+	logger.Infof("pvc osds: %+v", osds)
+	status = oposd.OrchestrationStatus{OSDs: osds, Status: oposd.OrchestrationStatusCompleted, PVCBackedOSD: true}
+	return oposd.UpdateNodeStatus(agent.kv, agent.nodeName, status)
+}
+
+func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevice, propertyFilter *rookalpha.DevicePropertyFilter,
+	metadataDevice string) (*DeviceOsdMapping, error) {
 
 	available := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}}
 
@@ -185,6 +247,7 @@ func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevi
 		return available, nil
 	}
 
+	metadataDevices := splitMetadataDevices(metadataDevice)
 	for _, device := range context.Devices {
 		if device.Type == sys.PartType {
 			continue
@@ -200,8 +263,13 @@ func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevi
 			continue
 		}
 
+		isMetadataDevice := false
+		for _, name := range metadataDevices {
+			isMetadataDevice = isMetadataDevice || name == device.Name
+		}
+
 		var deviceInfo *DeviceOsdIDEntry
-		if metadataDevice != "" && metadataDevice == device.Name {
+		if isMetadataDevice {
 			// current device is desired as the metadata device
 			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Metadata: []int{}}
 		} else if len(desiredDevices) == 1 && desiredDevices[0].Name == "all" {
@@ -220,7 +288,15 @@ func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevi
 						continue
 					}
 					logger.Infof("device %s matches device filter %s: %t", device.Name, desiredDevice.Name, matched)
-				} else if device.Name == desiredDevice.Name {
+				} else if desiredDevice.IsDevicePathFilter {
+					// the desired devices is a regular expression on the /dev/disk links of the device
+					matched, err = clusterd.DeviceMatchesPathFilter(device, desiredDevice.Name)
+					if err != nil {
+						logger.Errorf("regex failed on device %s. %+v", device.Name, err)
+						continue
+					}
+					logger.Infof("device %s matches device path filter %s: %t", device.Name, desiredDevice.Name, matched)
+				} else if clusterd.DeviceMatchesName(device, desiredDevice.Name) {
 					logger.Infof("%s found in the desired devices", device.Name)
 					matched = true
 				}
@@ -240,6 +316,18 @@ func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevi
 			logger.Infof("skipping device %s until the admin specifies it can be used by an osd", device.Name)
 		}
 
+		if deviceInfo != nil && !isMetadataDevice {
+			// the data devices must also have the properties requested by the admin
+			matched, err := clusterd.DeviceMatchesProperties(device, propertyFilter)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match the properties of device %s. %+v", device.Name, err)
+			}
+			if !matched {
+				logger.Infof("skipping device %s that does not match the device property filter %+v", device.Name, *propertyFilter)
+				deviceInfo = nil
+			}
+		}
+
 		if deviceInfo != nil {
 			if partCount > 0 {
 				deviceInfo.LegacyPartitionsFound = ownPartitions
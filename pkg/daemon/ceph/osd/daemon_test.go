@@ -22,6 +22,7 @@ import (
 	"strings"
 	"testing"
 
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -199,17 +200,17 @@ NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
 
 	context := &clusterd.Context{Executor: executor}
 	context.Devices = []*sys.LocalDisk{
-		{Name: "sda"},
+		{Name: "sda", Rotational: true, DevLinks: "/dev/disk/by-id/ata-ST4000NM0035_ZC11ABCD /dev/disk/by-path/pci-0000:00:1f.2-ata-1"},
 		{Name: "sdb"},
 		{Name: "sdc"},
-		{Name: "sdd"},
+		{Name: "sdd", DevLinks: "/dev/disk/by-id/ata-INTEL_SSDSC2KB480G8_PHYF8 /dev/disk/by-path/pci-0000:00:1f.2-ata-4"},
 		{Name: "nvme01"},
 		{Name: "rda"},
 		{Name: "rdb"},
 	}
 
 	// select all devices, including nvme01 for metadata
-	mapping, err := getAvailableDevices(context, []DesiredDevice{{Name: "all"}}, "nvme01")
+	mapping, err := getAvailableDevices(context, []DesiredDevice{{Name: "all"}}, nil, "nvme01")
 	assert.Nil(t, err)
 	assert.Equal(t, 5, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
@@ -220,35 +221,64 @@ NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
 	assert.NotNil(t, mapping.Entries["nvme01"].Metadata)
 	assert.Equal(t, 0, len(mapping.Entries["nvme01"].Metadata))
 
+	// the metadata devices are comma separated
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "all"}}, nil, "nvme01,rdb")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(mapping.Entries))
+	assert.NotNil(t, mapping.Entries["nvme01"].Metadata)
+	assert.NotNil(t, mapping.Entries["rdb"].Metadata)
+	assert.Nil(t, mapping.Entries["sda"].Metadata)
+
 	// select no devices both using and not using a filter
-	mapping, err = getAvailableDevices(context, nil, "")
+	mapping, err = getAvailableDevices(context, nil, nil, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(mapping.Entries))
 
-	mapping, err = getAvailableDevices(context, nil, "")
+	mapping, err = getAvailableDevices(context, nil, nil, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(mapping.Entries))
 
 	// select the sd* devices
-	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^sd.$", IsFilter: true}}, "")
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^sd.$", IsFilter: true}}, nil, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 
 	// select an exact device
-	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "sdd"}}, "")
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "sdd"}}, nil, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 
 	// select all devices except those that have a prefix of "s"
-	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^[^s]", IsFilter: true}}, "")
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^[^s]", IsFilter: true}}, nil, "")
 	assert.Nil(t, err)
 	assert.Equal(t, 3, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["rda"].Data)
 	assert.Equal(t, -1, mapping.Entries["rdb"].Data)
 	assert.Equal(t, -1, mapping.Entries["nvme01"].Data)
+
+	// select a device by its by-id link
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "/dev/disk/by-id/ata-ST4000NM0035_ZC11ABCD"}}, nil, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mapping.Entries))
+	assert.Equal(t, -1, mapping.Entries["sda"].Data)
+
+	// select the devices on a controller by their by-path links
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^/dev/disk/by-path/pci-0000:00:1f.2-", IsDevicePathFilter: true}}, nil, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mapping.Entries))
+	assert.Equal(t, -1, mapping.Entries["sda"].Data)
+	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
+
+	// select all the non rotational devices, but not the metadata device
+	rotational := false
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "all"}}, &rookalpha.DevicePropertyFilter{Rotational: &rotational}, "rdb")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(mapping.Entries))
+	assert.Nil(t, mapping.Entries["sda"])
+	assert.NotNil(t, mapping.Entries["rdb"].Metadata)
 }
 
 func TestGetRemovedDevices(t *testing.T) {
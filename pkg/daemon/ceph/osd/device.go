@@ -37,6 +37,7 @@ import (
 	"github.com/rook/rook/pkg/util/display"
 	"github.com/rook/rook/pkg/util/exec"
 	"github.com/rook/rook/pkg/util/sys"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -63,6 +64,9 @@ type osdConfig struct {
 	partitionScheme *config.PerfSchemeEntry
 	kv              *k8sutil.ConfigMapKVStore
 	storeName       string
+	// the name of the cluster and the owner of the encryption key secrets of new encrypted osds
+	clusterName string
+	ownerRef    metav1.OwnerReference
 }
 
 type Device struct {
@@ -73,9 +77,11 @@ type Device struct {
 
 // DesiredDevice keeps track of the desired settings for a device
 type DesiredDevice struct {
-	Name          string
-	OSDsPerDevice int
-	IsFilter      bool
+	Name               string
+	OSDsPerDevice      int
+	IsFilter           bool
+	IsDevicePathFilter bool
+	DeviceClass        string
 }
 
 type DeviceOsdMapping struct {
@@ -218,6 +224,12 @@ func partitionOSD(context *clusterd.Context, cfg *osdConfig) (*devicePartInfo, e
 		return nil, fmt.Errorf("failed to partition /dev/%s. %+v", dataDetails.Device, err)
 	}
 
+	if cfg.storeConfig.EncryptedDevice {
+		if err := encryptPartitions(context, cfg); err != nil {
+			return nil, fmt.Errorf("failed to encrypt the partitions of osd %d. %+v", cfg.id, err)
+		}
+	}
+
 	var devPartInfo *devicePartInfo
 	if cfg.partitionScheme.StoreType == config.Filestore {
 		// the OSD is using filestore, create a filesystem for the device (format it) and mount it under config root
@@ -264,6 +276,14 @@ func prepareFilestoreDevice(context *clusterd.Context, cfg *osdConfig, doFormat
 		return nil, fmt.Errorf("failed waiting for %s: %+v", dataPartPath, err)
 	}
 
+	if cfg.partitionScheme.Encrypted {
+		// the filesystem is on the unlocked partition
+		if err := openEncryptedPartitions(context, cfg.clusterName, cfg.partitionScheme); err != nil {
+			return nil, err
+		}
+		dataPartPath = getPartitionPath(cfg.partitionScheme, dataPartDetails)
+	}
+
 	if doFormat {
 		// perform the format and retry if needed
 		if err = sys.FormatDevice(dataPartPath, context.Executor); err != nil {
@@ -564,6 +584,13 @@ func initializeOSD(config *osdConfig, context *clusterd.Context, cluster *cephco
 		return err
 	}
 
+	// the device class replaces the class the osd would detect when it starts
+	if config.storeConfig.DeviceClass != "" {
+		if err := client.SetDeviceClass(context, cluster.Name, config.id, config.storeConfig.DeviceClass); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -678,9 +705,9 @@ func getBluestorePartitionPaths(cfg *osdConfig) (string, string, string, error)
 		return "", "", "", fmt.Errorf("failed to find block partition for osd %d", cfg.id)
 	}
 
-	return filepath.Join(diskByPartUUID, walPartition.PartitionUUID),
-		filepath.Join(diskByPartUUID, dbPartition.PartitionUUID),
-		filepath.Join(diskByPartUUID, blockPartition.PartitionUUID),
+	return getPartitionPath(cfg.partitionScheme, walPartition),
+		getPartitionPath(cfg.partitionScheme, dbPartition),
+		getPartitionPath(cfg.partitionScheme, blockPartition),
 		nil
 
 }
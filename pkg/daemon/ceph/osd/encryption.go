@@ -0,0 +1,300 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/rook/rook/pkg/clusterd"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+const (
+	cryptsetupCmd   = "cryptsetup"
+	deviceMapperDir = "/dev/mapper"
+	// the size of the random dm-crypt keys, the same as the keys created by ceph-volume
+	encryptionKeySize = 128
+)
+
+// generateEncryptionKey returns a random dm-crypt key. The key is base64 encoded like the keys of
+// ceph-volume and is passed to cryptsetup as is.
+func generateEncryptionKey() (string, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate encryption key. %+v", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// writeKeyFile writes the key to a temp file for cryptsetup. The caller must remove the file.
+func writeKeyFile(key string) (string, error) {
+	keyFile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create key file. %+v", err)
+	}
+	defer keyFile.Close()
+	if _, err := keyFile.WriteString(key); err != nil {
+		os.Remove(keyFile.Name())
+		return "", fmt.Errorf("failed to write key file. %+v", err)
+	}
+	return keyFile.Name(), nil
+}
+
+// formatEncryptedDevice formats the device with luks, protected by the key
+func formatEncryptedDevice(context *clusterd.Context, device, key string) error {
+	keyFile, err := writeKeyFile(key)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(keyFile)
+
+	logger.Infof("encrypting device %s", device)
+	if err := context.Executor.ExecuteCommand(false, "", cryptsetupCmd, "--batch-mode", "--key-file", keyFile, "luksFormat", device); err != nil {
+		return fmt.Errorf("failed to format device %s with luks. %+v", device, err)
+	}
+	return nil
+}
+
+// openEncryptedDevice unlocks the luks device under /dev/mapper/<name>, unless the device is already
+// unlocked. The key is only retrieved when the device needs to be unlocked.
+func openEncryptedDevice(context *clusterd.Context, device, name string, getKey func() (string, error)) error {
+	if _, err := os.Stat(path.Join(deviceMapperDir, name)); err == nil {
+		logger.Infof("encrypted device %s is already open as %s", device, name)
+		return nil
+	}
+
+	key, err := getKey()
+	if err != nil {
+		return err
+	}
+	keyFile, err := writeKeyFile(key)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(keyFile)
+
+	logger.Infof("opening encrypted device %s as %s", device, name)
+	if err := context.Executor.ExecuteCommand(false, "", cryptsetupCmd, "--key-file", keyFile, "--allow-discards", "luksOpen", device, name); err != nil {
+		return fmt.Errorf("failed to open encrypted device %s. %+v", device, err)
+	}
+	return nil
+}
+
+// encryptionKeyGetter returns a func that retrieves the dm-crypt key of the osd once
+func encryptionKeyGetter(context *clusterd.Context, clusterName, osdUUID string) func() (string, error) {
+	var key string
+	return func() (string, error) {
+		if key != "" {
+			return key, nil
+		}
+		var err error
+		key, err = oposd.GetEncryptionKey(context, clusterName, osdUUID)
+		return key, err
+	}
+}
+
+// encryptPartitions creates the dm-crypt key of a new legacy osd and encrypts all the partitions of
+// the osd with it. The partitions are left open.
+func encryptPartitions(context *clusterd.Context, cfg *osdConfig) error {
+	key, err := generateEncryptionKey()
+	if err != nil {
+		return err
+	}
+	osdUUID := cfg.uuid.String()
+	if err := oposd.SaveEncryptionKey(context, cfg.clusterName, cfg.ownerRef, cfg.storeConfig.EncryptionKeyStore, osdUUID, key); err != nil {
+		return fmt.Errorf("failed to save the encryption key of osd %d. %+v", cfg.id, err)
+	}
+
+	for _, part := range cfg.partitionScheme.Partitions {
+		partPath := filepath.Join(diskByPartUUID, part.PartitionUUID)
+		if err := waitForPath(partPath, context.Executor); err != nil {
+			return fmt.Errorf("failed waiting for %s: %+v", partPath, err)
+		}
+		if err := formatEncryptedDevice(context, partPath, key); err != nil {
+			return err
+		}
+	}
+	cfg.partitionScheme.Encrypted = true
+
+	return openEncryptedPartitions(context, cfg.clusterName, cfg.partitionScheme)
+}
+
+// openEncryptedPartitions unlocks the encrypted partitions of a legacy osd
+func openEncryptedPartitions(context *clusterd.Context, clusterName string, entry *config.PerfSchemeEntry) error {
+	getKey := encryptionKeyGetter(context, clusterName, entry.OsdUUID.String())
+	for _, part := range entry.Partitions {
+		partPath := filepath.Join(diskByPartUUID, part.PartitionUUID)
+		if err := openEncryptedDevice(context, partPath, oposd.EncryptedPartitionMapperName(part.PartitionUUID), getKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getPartitionPath returns the path of the partition, which is the unlocked device mapper device if
+// the partitions of the osd are encrypted
+func getPartitionPath(entry *config.PerfSchemeEntry, part *config.PerfSchemePartitionDetails) string {
+	if entry.Encrypted {
+		return path.Join(deviceMapperDir, oposd.EncryptedPartitionMapperName(part.PartitionUUID))
+	}
+	return filepath.Join(diskByPartUUID, part.PartitionUUID)
+}
+
+// OpenEncryptedOSD unlocks the encrypted devices of the osd before the osd is started. The logical
+// volumes of osds prepared by ceph-volume are unlocked under the names ceph-volume gives them,
+// the partitions of legacy osds are found in the partition scheme of the node.
+func OpenEncryptedOSD(context *clusterd.Context, clusterName, osdID, osdUUID string, cephVolume bool, pvcDevice string,
+	kv *k8sutil.ConfigMapKVStore, nodeName string) error {
+
+	if !cephVolume {
+		scheme, err := config.LoadScheme(kv, config.GetConfigStoreName(nodeName))
+		if err != nil {
+			return fmt.Errorf("failed to load partition scheme. %+v", err)
+		}
+		for _, entry := range scheme.Entries {
+			if entry.OsdUUID.String() == osdUUID {
+				return openEncryptedPartitions(context, clusterName, entry)
+			}
+		}
+		return fmt.Errorf("osd %s not found in the partition scheme of node %s", osdID, nodeName)
+	}
+
+	if pvcDevice != "" {
+		if err := activateVolumeGroup(context, pvcDevice); err != nil {
+			return fmt.Errorf("failed to activate the volume group on pvc device %s. %+v", pvcDevice, err)
+		}
+	}
+	volumes, err := getCephVolumeLogicalVolumes(context, osdID, osdUUID)
+	if err != nil {
+		return err
+	}
+	getKey := encryptionKeyGetter(context, clusterName, osdUUID)
+	for _, lv := range volumes {
+		if lv.Tags.Encrypted != "1" {
+			continue
+		}
+		if err := openEncryptedDevice(context, lv.Path, lv.UUID, getKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activateEncryptedOSD prepares the data dir of an encrypted osd prepared by ceph-volume the way
+// "ceph-volume lvm activate" does, but on the logical volumes unlocked by the encryption init
+// container so that the key does not need to be in the mon config-key store.
+func activateEncryptedOSD(context *clusterd.Context, osdType, osdID, osdUUID, dataDir string) error {
+	volumes, err := getCephVolumeLogicalVolumes(context, osdID, osdUUID)
+	if err != nil {
+		return err
+	}
+	devices := map[string]string{}
+	for _, lv := range volumes {
+		devices[lv.Type] = path.Join(deviceMapperDir, lv.UUID)
+	}
+
+	links := map[string]string{}
+	if osdType == config.Filestore {
+		data, ok := devices["data"]
+		if !ok {
+			return fmt.Errorf("data volume of osd %s not found", osdID)
+		}
+		if err := sys.MountDevice(data, dataDir, context.Executor); err != nil {
+			return fmt.Errorf("failed to mount %s. %+v", data, err)
+		}
+		links["journal"] = devices["journal"]
+	} else {
+		block, ok := devices["block"]
+		if !ok {
+			return fmt.Errorf("block volume of osd %s not found", osdID)
+		}
+		if err := sys.MountDeviceWithOptions("tmpfs", dataDir, "tmpfs", "", context.Executor); err != nil {
+			return fmt.Errorf("failed to mount tmpfs at %s. %+v", dataDir, err)
+		}
+		if err := context.Executor.ExecuteCommand(false, "", "ceph-bluestore-tool", "--cluster=ceph", "prime-osd-dir",
+			"--dev", block, "--path", dataDir, "--no-mon-config"); err != nil {
+			return fmt.Errorf("failed to prime osd dir %s. %+v", dataDir, err)
+		}
+		links["block"] = block
+		links["block.db"] = devices["db"]
+		links["block.wal"] = devices["wal"]
+	}
+
+	for name, target := range links {
+		if target == "" {
+			continue
+		}
+		link := path.Join(dataDir, name)
+		os.Remove(link)
+		if err := os.Symlink(target, link); err != nil {
+			return fmt.Errorf("failed to link %s to %s. %+v", link, target, err)
+		}
+	}
+	logger.Infof("activated encrypted osd %s", osdID)
+	return nil
+}
+
+// getCephVolumeLogicalVolumes returns the logical volumes of the osd prepared by ceph-volume
+func getCephVolumeLogicalVolumes(context *clusterd.Context, osdID, osdUUID string) ([]osdInfo, error) {
+	result, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, "lvm", "list", "--format", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve ceph-volume results. %+v", err)
+	}
+
+	var cephVolumeResult map[string][]osdInfo
+	if err := json.Unmarshal([]byte(result), &cephVolumeResult); err != nil {
+		return nil, fmt.Errorf("failed to retrieve ceph-volume results. %+v", err)
+	}
+
+	var volumes []osdInfo
+	for _, lv := range cephVolumeResult[osdID] {
+		if lv.Tags.OSDFSID == osdUUID {
+			volumes = append(volumes, lv)
+		}
+	}
+	if len(volumes) == 0 {
+		return nil, fmt.Errorf("no logical volumes found for osd %s (%s)", osdID, osdUUID)
+	}
+	return volumes, nil
+}
+
+// moveEncryptionKeys moves the dm-crypt keys of the encrypted osds to their secrets if the keys are
+// kept in secrets. ceph-volume always stores the key of a new osd in the mon config-key store.
+func (a *OsdAgent) moveEncryptionKeys(context *clusterd.Context, osds []oposd.OSDInfo) error {
+	if a.storeConfig.EncryptionKeyStore != config.EncryptionKeyStoreSecret {
+		return nil
+	}
+	for _, osd := range osds {
+		if !osd.Encrypted {
+			continue
+		}
+		if err := oposd.MoveEncryptionKeyToSecret(context, a.cluster.Name, a.ownerRef, osd.UUID); err != nil {
+			return fmt.Errorf("failed to move the encryption key of osd %d to a secret. %+v", osd.ID, err)
+		}
+	}
+	return nil
+}
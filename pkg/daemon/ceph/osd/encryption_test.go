@@ -0,0 +1,222 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	encryptedOSDUUID = "dbe407e0-c1cb-495e-b30a-02e01de6c8ae"
+	// an osd prepared by ceph-volume with --dmcrypt, with its db on a second logical volume
+	cephVolumeEncryptedResult = `{
+    "0": [
+        {
+            "devices": ["/dev/sdb"],
+            "lv_uuid": "X39Wps-Qewq-d8LV-kj2p-ZqC3-IFQn-C35sV7",
+            "name": "osd-block-dbe407e0",
+            "path": "/dev/ceph-block-93550251/osd-block-dbe407e0",
+            "tags": {
+                "ceph.encrypted": "1",
+                "ceph.osd_fsid": "dbe407e0-c1cb-495e-b30a-02e01de6c8ae",
+                "ceph.osd_id": "0",
+                "ceph.type": "block"
+            },
+            "type": "block"
+        },
+        {
+            "devices": ["/dev/nvme0n1"],
+            "lv_uuid": "ZkJ0b2-1bcv-ZS4k-gZ5i-qxm3-n3jg-9wZrTs",
+            "name": "osd-db-dbe407e0",
+            "path": "/dev/ceph-db-2f8a4b18/osd-db-dbe407e0",
+            "tags": {
+                "ceph.encrypted": "1",
+                "ceph.osd_fsid": "dbe407e0-c1cb-495e-b30a-02e01de6c8ae",
+                "ceph.osd_id": "0",
+                "ceph.type": "db"
+            },
+            "type": "db"
+        }
+    ]
+}`
+)
+
+func newEncryptionKeySecret(t *testing.T, context *clusterd.Context, osdUUID, key string) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-encryption-key-" + osdUUID, Namespace: "myclust"},
+		Data:       map[string][]byte{"dmcrypt-key": []byte(key)},
+	}
+	_, err := context.Clientset.CoreV1().Secrets("myclust").Create(secret)
+	assert.Nil(t, err)
+}
+
+func TestOpenEncryptedCephVolumeOSD(t *testing.T) {
+	var opened [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			assert.Equal(t, "ceph-volume lvm list --format json", command+" "+strings.Join(args, " "))
+			return cephVolumeEncryptedResult, nil
+		},
+		MockExecuteCommand: func(debug bool, actionName, command string, args ...string) error {
+			logger.Infof("Command: %s %v", command, args)
+			assert.Equal(t, cryptsetupCmd, command)
+			// the key is passed in a file
+			key, err := ioutil.ReadFile(args[1])
+			assert.Nil(t, err)
+			assert.Equal(t, "secretkey", string(key))
+			opened = append(opened, args[4:])
+			return nil
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor}
+	newEncryptionKeySecret(t, context, encryptedOSDUUID, "secretkey")
+
+	// every volume of the osd is unlocked under the name ceph-volume gives it
+	err := OpenEncryptedOSD(context, "myclust", "0", encryptedOSDUUID, true, "", nil, "node1")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"/dev/ceph-block-93550251/osd-block-dbe407e0", "X39Wps-Qewq-d8LV-kj2p-ZqC3-IFQn-C35sV7"},
+		{"/dev/ceph-db-2f8a4b18/osd-db-dbe407e0", "ZkJ0b2-1bcv-ZS4k-gZ5i-qxm3-n3jg-9wZrTs"},
+	}, opened)
+
+	// the osd must be found
+	err = OpenEncryptedOSD(context, "myclust", "1", encryptedOSDUUID, true, "", nil, "node1")
+	assert.NotNil(t, err)
+}
+
+func TestOpenEncryptedLegacyOSD(t *testing.T) {
+	var opened [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName, command string, args ...string) error {
+			assert.Equal(t, cryptsetupCmd, command)
+			opened = append(opened, args[4:])
+			return nil
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor}
+	kv := mockKVStore()
+
+	osdUUID := uuid.Must(uuid.NewRandom())
+	entry := config.NewPerfSchemeEntry(config.Filestore)
+	entry.ID = 2
+	entry.OsdUUID = osdUUID
+	entry.Encrypted = true
+	entry.Partitions[config.FilestoreDataPartitionType] = &config.PerfSchemePartitionDetails{Device: "sda", PartitionUUID: "part1"}
+	scheme := config.NewPerfScheme()
+	scheme.Entries = append(scheme.Entries, entry)
+	assert.Nil(t, scheme.SaveScheme(kv, config.GetConfigStoreName("node1")))
+	newEncryptionKeySecret(t, context, osdUUID.String(), "secretkey")
+
+	err := OpenEncryptedOSD(context, "myclust", "2", osdUUID.String(), false, "", kv, "node1")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"/dev/disk/by-partuuid/part1", "rook-dmcrypt-part1"}}, opened)
+
+	// the partitions are used through the device mapper
+	assert.Equal(t, "/dev/mapper/rook-dmcrypt-part1", getPartitionPath(entry, entry.Partitions[config.FilestoreDataPartitionType]))
+	entry.Encrypted = false
+	assert.Equal(t, "/dev/disk/by-partuuid/part1", getPartitionPath(entry, entry.Partitions[config.FilestoreDataPartitionType]))
+}
+
+func TestEncryptPartitions(t *testing.T) {
+	var commands []string
+	var configKeySet bool
+	executor := &exectest.MockExecutor{
+		MockExecuteStat: func(name string) (os.FileInfo, error) {
+			return nil, nil
+		},
+		MockExecuteCommand: func(debug bool, actionName, command string, args ...string) error {
+			assert.Equal(t, cryptsetupCmd, command)
+			if args[0] == "--batch-mode" {
+				commands = append(commands, "luksFormat "+args[4])
+			} else {
+				commands = append(commands, "luksOpen "+args[4]+" "+args[5])
+			}
+			return nil
+		},
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			// the key of the osd is stored in the mon config-key store
+			assert.Equal(t, "config-key", args[0])
+			assert.Equal(t, "set", args[1])
+			configKeySet = true
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor}
+
+	osdUUID := uuid.Must(uuid.NewRandom())
+	entry := config.NewPerfSchemeEntry(config.Bluestore)
+	entry.OsdUUID = osdUUID
+	entry.Partitions[config.BlockPartitionType] = &config.PerfSchemePartitionDetails{Device: "sda", PartitionUUID: "block1"}
+	cfg := &osdConfig{id: 1, uuid: osdUUID, partitionScheme: entry, clusterName: "myclust",
+		storeConfig: config.StoreConfig{EncryptedDevice: true, EncryptionKeyStore: config.EncryptionKeyStoreMon}}
+
+	err := encryptPartitions(context, cfg)
+	assert.Nil(t, err)
+	assert.True(t, configKeySet)
+	assert.True(t, entry.Encrypted)
+	assert.Equal(t, []string{
+		"luksFormat /dev/disk/by-partuuid/block1",
+		"luksOpen /dev/disk/by-partuuid/block1 rook-dmcrypt-block1",
+	}, commands)
+}
+
+func TestActivateEncryptedOSD(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "TestActivateEncryptedOSD")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dataDir)
+
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			return cephVolumeEncryptedResult, nil
+		},
+		MockExecuteCommand: func(debug bool, actionName, command string, args ...string) error {
+			commands = append(commands, command+" "+strings.Join(args, " "))
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err = activateEncryptedOSD(context, config.Bluestore, "0", encryptedOSDUUID, dataDir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"mount -t tmpfs tmpfs " + dataDir,
+		"ceph-bluestore-tool --cluster=ceph prime-osd-dir --dev /dev/mapper/X39Wps-Qewq-d8LV-kj2p-ZqC3-IFQn-C35sV7 --path " + dataDir + " --no-mon-config",
+	}, commands)
+
+	block, err := os.Readlink(path.Join(dataDir, "block"))
+	assert.Nil(t, err)
+	assert.Equal(t, "/dev/mapper/X39Wps-Qewq-d8LV-kj2p-ZqC3-IFQn-C35sV7", block)
+	db, err := os.Readlink(path.Join(dataDir, "block.db"))
+	assert.Nil(t, err)
+	assert.Equal(t, "/dev/mapper/ZkJ0b2-1bcv-ZS4k-gZ5i-qxm3-n3jg-9wZrTs", db)
+	_, err = os.Lstat(path.Join(dataDir, "block.wal"))
+	assert.True(t, os.IsNotExist(err))
+}
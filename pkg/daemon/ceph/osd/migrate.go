@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+// wipeMigratedOSDs removes the partitions and the config of the legacy osds that were destroyed to be
+// migrated to ceph-volume. Their devices are then prepared by ceph-volume with the ids of the osds.
+func (a *OsdAgent) wipeMigratedOSDs(context *clusterd.Context, devices *DeviceOsdMapping) error {
+	if len(a.migrateOSDs) == 0 {
+		return nil
+	}
+
+	cvSupported, err := getCephVolumeSupported(context)
+	if err != nil {
+		return fmt.Errorf("failed to detect if ceph-volume is available. %+v", err)
+	}
+	if !cvSupported {
+		return fmt.Errorf("ceph-volume is required to migrate osds %+v", a.migrateOSDs)
+	}
+
+	storeName := config.GetConfigStoreName(a.nodeName)
+	scheme, err := config.LoadScheme(a.kv, storeName)
+	if err != nil {
+		return fmt.Errorf("failed to load partition scheme: %+v", err)
+	}
+
+	for devicePath, id := range a.migrateOSDs {
+		name := path.Base(devicePath)
+		for _, entry := range scheme.Entries {
+			if entry.ID != id {
+				continue
+			}
+			// make sure the device was not renamed since the operator read the scheme
+			data, ok := entry.Partitions[entry.GetDataPartitionType()]
+			if !ok || data.Device != name || data.DiskUUID != getDiskUUID(context, name) {
+				return fmt.Errorf("osd.%d to migrate is not on device %s", id, name)
+			}
+
+			logger.Infof("wiping device %s of legacy osd.%d to migrate it to ceph-volume", name, id)
+			if err := sys.RemovePartitions(name, context.Executor); err != nil {
+				return fmt.Errorf("failed to wipe device %s of osd.%d. %+v", name, id, err)
+			}
+			if err := a.removeOSDConfigDir(context.ConfigDir, id); err != nil {
+				return fmt.Errorf("failed to remove osd.%d. %+v", id, err)
+			}
+			if err := config.RemoveFromScheme(entry, a.kv, storeName); err != nil {
+				return fmt.Errorf("failed to remove osd.%d from scheme. %+v", id, err)
+			}
+		}
+
+		// the wiped device is left to ceph-volume, which is also the case if an earlier attempt was
+		// interrupted after the device was wiped
+		device, ok := devices.Entries[name]
+		if !ok {
+			logger.Warningf("device %s of migrated osd.%d is not available. the osd will be prepared when the device is available.", name, id)
+			continue
+		}
+		device.LegacyPartitionsFound = false
+		device.Data = unassignedOSDID
+	}
+
+	return nil
+}
+
+func getDiskUUID(context *clusterd.Context, name string) string {
+	for _, disk := range context.Devices {
+		if disk.Name == name {
+			return disk.UUID
+		}
+	}
+	return ""
+}
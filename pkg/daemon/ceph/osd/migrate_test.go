@@ -0,0 +1,83 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWipeMigratedOSDs(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "TestWipeMigratedOSDs")
+	require.Nil(t, err)
+	defer os.RemoveAll(configDir)
+
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			logger.Infof("%s %+v", command, args)
+			commands = append(commands, append([]string{command}, args...))
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			// ceph-volume is supported
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{ConfigDir: configDir, Executor: executor}
+
+	a := &OsdAgent{kv: mockKVStore(), nodeName: "node1"}
+	_, _, sdbUUID := mockPartitionSchemeEntry(t, 3, "sdb", nil, a.kv, a.nodeName)
+	context.Devices = []*sys.LocalDisk{{Name: "sdb", UUID: sdbUUID}}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdb": {Data: unassignedOSDID, LegacyPartitionsFound: true},
+	}}
+
+	// nothing to do without migrations
+	assert.Nil(t, a.wipeMigratedOSDs(context, devices))
+	assert.Equal(t, 0, len(commands))
+
+	// the device is wiped and left to ceph-volume
+	a.migrateOSDs = map[string]int{"/dev/sdb": 3}
+	assert.Nil(t, a.wipeMigratedOSDs(context, devices))
+	require.Equal(t, 2, len(commands))
+	assert.Equal(t, []string{"sgdisk", "--zap-all", "/dev/sdb"}, commands[0])
+	assert.False(t, devices.Entries["sdb"].LegacyPartitionsFound)
+	scheme, err := config.LoadScheme(a.kv, config.GetConfigStoreName(a.nodeName))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(scheme.Entries))
+
+	// an interrupted migration does not wipe the device again
+	commands = nil
+	devices.Entries["sdb"].LegacyPartitionsFound = true
+	assert.Nil(t, a.wipeMigratedOSDs(context, devices))
+	assert.Equal(t, 0, len(commands))
+	assert.False(t, devices.Entries["sdb"].LegacyPartitionsFound)
+
+	// a renamed device is not wiped
+	mockPartitionSchemeEntry(t, 3, "sdb", nil, a.kv, a.nodeName)
+	assert.NotNil(t, a.wipeMigratedOSDs(context, devices))
+	assert.Equal(t, 0, len(commands))
+}
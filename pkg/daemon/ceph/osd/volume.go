@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"github.com/rook/rook/pkg/util/display"
 	"path"
+	"sort"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/rook/rook/pkg/clusterd"
@@ -36,6 +38,8 @@ const (
 	osdsPerDeviceFlag   = "--osds-per-device"
 	encryptedFlag       = "--dmcrypt"
 	databaseSizeFlag    = "--block-db-size"
+	walSizeFlag         = "--block-wal-size"
+	deviceClassFlag     = "--crush-device-class"
 	cephVolumeCmd       = "ceph-volume"
 	cephVolumeMinDBSize = 1024 // 1GB
 )
@@ -98,15 +102,22 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 		}
 	}
 
+	if a.storeConfig.StoreType == config.Bluestore && a.storeConfig.WalSizeMB >= cephVolumeMinDBSize {
+		// smaller wal sizes are left to ceph-volume for the same reason as the db size
+		batchArgs = append(batchArgs, walSizeFlag, strconv.FormatUint(display.MbTob(uint64(a.storeConfig.WalSizeMB)), 10))
+	}
+
+	if a.storeConfig.DeviceClass != "" {
+		batchArgs = append(batchArgs, deviceClassFlag, a.storeConfig.DeviceClass)
+	}
+
 	// When mixed hdd/ssd devices are given, ceph-volume configures db lv on the ssd.
-	metadataDeviceSpecified := false
-	if a.metadataDevice != "" {
-		logger.Infof("using %s as metadataDevice and let ceph-volume lvm batch decide how to create volumes", a.metadataDevice)
-		metadataDeviceSpecified = true
-		batchArgs = append(batchArgs, path.Join("/dev", a.metadataDevice))
+	metadataDevices := splitMetadataDevices(a.metadataDevice)
+	if len(metadataDevices) > 0 {
+		logger.Infof("using %v as metadata devices and let ceph-volume lvm batch decide how to create volumes", metadataDevices)
 	}
 
-	configured := 0
+	var batchDevices []string
 	for name, device := range devices.Entries {
 		if device.LegacyPartitionsFound {
 			logger.Infof("skipping device %s configured with legacy rook osd", name)
@@ -114,12 +125,28 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 		}
 
 		if device.Data == -1 {
-			logger.Infof("configuring new device %s", name)
 			deviceArg := path.Join("/dev", name)
-			if metadataDeviceSpecified {
+			osdID, ok := a.replaceOSDs[getDevicePathLink(context, name)]
+			if !ok {
+				osdID, ok = a.migrateOSDs[deviceArg]
+			}
+			if ok {
+				// the new device is in the slot of a destroyed osd, or is the wiped device of a migrated osd,
+				// whose id is given to the new osd
+				logger.Infof("configuring new device %s to replace destroyed osd %d", name, osdID)
+				if err := context.Executor.ExecuteCommand(false, "", baseCommand, a.lvmPrepareArgs(deviceArg, osdID, a.getDeviceClass(device))...); err != nil {
+					return fmt.Errorf("failed ceph-volume to replace osd %d. %+v", osdID, err)
+				}
+				continue
+			}
+
+			logger.Infof("configuring new device %s", name)
+			if len(metadataDevices) > 0 {
 				// the device will be configured as a batch at the end of the method
-				batchArgs = append(batchArgs, deviceArg)
-				configured++
+				if device.Config.DeviceClass != "" && device.Config.DeviceClass != a.storeConfig.DeviceClass {
+					logger.Warningf("ignoring device class %s of device %s. the devices sharing a metadata device get the device class of the node", device.Config.DeviceClass, name)
+				}
+				batchDevices = append(batchDevices, name)
 			} else {
 				// execute ceph-volume immediately with the device-specific setting instead of batching up multiple devices together
 				immediateExecuteArgs := append(baseArgs, []string{
@@ -127,6 +154,9 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 					osdsPerDeviceFlag,
 					sanitizeOSDsPerDevice(device.Config.OSDsPerDevice),
 				}...)
+				if deviceClass := a.getDeviceClass(device); deviceClass != "" {
+					immediateExecuteArgs = append(immediateExecuteArgs, deviceClassFlag, deviceClass)
+				}
 
 				if err := context.Executor.ExecuteCommand(false, "", baseCommand, immediateExecuteArgs...); err != nil {
 					return fmt.Errorf("failed ceph-volume. %+v", err)
@@ -138,20 +168,223 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 		}
 	}
 
-	if configured > 0 {
+	if len(batchDevices) == 0 {
+		return nil
+	}
+
+	groups, err := a.groupByMetadataDevice(context, metadataDevices, batchDevices)
+	if err != nil {
+		return fmt.Errorf("failed to assign the devices to the metadata devices. %+v", err)
+	}
+	for _, group := range groups {
+		args := append([]string{}, batchArgs...)
+		args = append(args, path.Join("/dev", group.metadataDevice))
+		for _, name := range group.devices {
+			args = append(args, path.Join("/dev", name))
+		}
+
 		// Reporting
-		reportArgs := append(batchArgs, []string{
-			"--report",
-		}...)
-		// Run "stdbuf -oL ceph-volume" so we can get more frequent updates in the container logs
-		if err := context.Executor.ExecuteCommand(false, "", baseCommand, reportArgs...); err != nil {
+		reportArgs := append(args, "--report", "--format", "json")
+		report, err := context.Executor.ExecuteCommandWithOutput(false, "", baseCommand, reportArgs...)
+		if err != nil {
 			return fmt.Errorf("failed ceph-volume report. %+v", err) // fail return here as validation provided by ceph-volume
 		}
+		logger.Infof("ceph-volume report for metadata device %s: %s", group.metadataDevice, report)
+		a.batchReports = append(a.batchReports, newBatchReport(group, report))
+		if err := a.saveBatchReports(); err != nil {
+			return err
+		}
+
+		// Run "stdbuf -oL ceph-volume" so we can get more frequent updates in the container logs
+		if err := context.Executor.ExecuteCommand(false, "", baseCommand, args...); err != nil {
+			return fmt.Errorf("failed ceph-volume. %+v", err)
+		}
 	}
 
 	return nil
 }
 
+// saveBatchReports saves the batch reports in the orchestration status of the node, so that the
+// planned osds can be inspected before ceph-volume creates them
+func (a *OsdAgent) saveBatchReports() error {
+	status := oposd.OrchestrationStatus{Status: oposd.OrchestrationStatusOrchestrating, BatchReports: a.batchReports}
+	if err := oposd.UpdateNodeStatus(a.kv, a.nodeName, status); err != nil {
+		return fmt.Errorf("failed to save the ceph-volume batch reports. %+v", err)
+	}
+	return nil
+}
+
+// metadataGroup is a metadata device and the new data devices whose osds get their db on it
+type metadataGroup struct {
+	metadataDevice string
+	devices        []string
+}
+
+// splitMetadataDevices returns the devices of the comma separated metadata device setting
+func splitMetadataDevices(metadataDevice string) []string {
+	var devices []string
+	for _, device := range strings.Split(metadataDevice, ",") {
+		if device = strings.TrimSpace(device); device != "" {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
+
+// groupByMetadataDevice assigns each of the data devices to the metadata device with the fewest osds,
+// counting the osds that already have their db on it. The devices that would exceed the number of
+// osds per metadata device are left unconfigured.
+func (a *OsdAgent) groupByMetadataDevice(context *clusterd.Context, metadataDevices, devices []string) ([]metadataGroup, error) {
+	usage, err := getMetadataDeviceUsage(context)
+	if err != nil {
+		return nil, err
+	}
+
+	osdsPerDevice, _ := strconv.Atoi(sanitizeOSDsPerDevice(a.storeConfig.OSDsPerDevice))
+	groups := make([]metadataGroup, len(metadataDevices))
+	osds := make([]int, len(metadataDevices))
+	for i, metadataDevice := range metadataDevices {
+		groups[i].metadataDevice = metadataDevice
+		osds[i] = usage[path.Join("/dev", metadataDevice)]
+	}
+
+	sort.Strings(devices)
+	for _, device := range devices {
+		least := 0
+		for i := range osds {
+			if osds[i] < osds[least] {
+				least = i
+			}
+		}
+		if a.storeConfig.OSDsPerMetadataDevice > 0 && osds[least]+osdsPerDevice > a.storeConfig.OSDsPerMetadataDevice {
+			logger.Warningf("skipping device %s. the metadata devices %v already hold the db of %d osds each", device, metadataDevices, a.storeConfig.OSDsPerMetadataDevice)
+			continue
+		}
+		groups[least].devices = append(groups[least].devices, device)
+		osds[least] += osdsPerDevice
+	}
+
+	var assigned []metadataGroup
+	for _, group := range groups {
+		if len(group.devices) > 0 {
+			assigned = append(assigned, group)
+		}
+	}
+	return assigned, nil
+}
+
+// getMetadataDeviceUsage returns the number of osds provisioned by ceph-volume with their db on each
+// device of the node, by device path
+func getMetadataDeviceUsage(context *clusterd.Context) (map[string]int, error) {
+	result, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, "lvm", "list", "--format", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve ceph-volume results. %+v", err)
+	}
+
+	var cephVolumeResult map[string][]osdInfo
+	if err := json.Unmarshal([]byte(result), &cephVolumeResult); err != nil {
+		return nil, fmt.Errorf("failed to retrieve ceph-volume results. %+v", err)
+	}
+
+	usage := map[string]int{}
+	for _, lvs := range cephVolumeResult {
+		for _, lv := range lvs {
+			if lv.Type != "db" {
+				continue
+			}
+			for _, device := range lv.Devices {
+				usage[device]++
+			}
+		}
+	}
+	return usage, nil
+}
+
+// newBatchReport returns the status report of the batch of a metadata group. The report is kept as a
+// string if ceph-volume did not report json.
+func newBatchReport(group metadataGroup, report string) oposd.BatchReport {
+	raw := json.RawMessage(report)
+	if !json.Valid(raw) {
+		raw, _ = json.Marshal(report)
+	}
+	return oposd.BatchReport{MetadataDevice: group.metadataDevice, Devices: group.devices, Report: raw}
+}
+
+// configurePVCDevice prepares an osd with ceph-volume on the block device of a pvc, unless the
+// device already holds an osd
+func (a *OsdAgent) configurePVCDevice(context *clusterd.Context) ([]oposd.OSDInfo, error) {
+	if len(a.devices) != 1 {
+		return nil, fmt.Errorf("expected one pvc device, got %d", len(a.devices))
+	}
+	device := a.devices[0].Name
+
+	osds, err := getCephVolumeOSDsOnDevice(context, a.cluster.Name, device)
+	if err != nil {
+		return nil, err
+	}
+	if len(osds) > 0 {
+		logger.Infof("pvc device %s already holds osd %d", device, osds[0].ID)
+		return osds, nil
+	}
+
+	if err := createOSDBootstrapKeyring(context, a.cluster.Name, cephConfigDir); err != nil {
+		return nil, fmt.Errorf("failed to generate osd keyring. %+v", err)
+	}
+
+	logger.Infof("configuring new pvc device %s", device)
+	if err := context.Executor.ExecuteCommand(false, "", "stdbuf", a.lvmPrepareArgs(device, unassignedOSDID, a.storeConfig.DeviceClass)...); err != nil {
+		return nil, fmt.Errorf("failed ceph-volume. %+v", err)
+	}
+
+	return getCephVolumeOSDsOnDevice(context, a.cluster.Name, device)
+}
+
+// lvmPrepareArgs returns the stdbuf args to prepare a single osd on the device with ceph-volume. The
+// id of a destroyed osd is reused unless the id is unassigned.
+func (a *OsdAgent) lvmPrepareArgs(device string, osdID int, deviceClass string) []string {
+	storeFlag := "--bluestore"
+	if a.storeConfig.StoreType == config.Filestore {
+		storeFlag = "--filestore"
+	}
+	args := []string{"-oL", cephVolumeCmd, "lvm", "prepare", storeFlag}
+	if a.storeConfig.EncryptedDevice {
+		args = append(args, encryptedFlag)
+	}
+	if osdID != unassignedOSDID {
+		args = append(args, "--osd-id", strconv.Itoa(osdID))
+	}
+	if deviceClass != "" {
+		args = append(args, deviceClassFlag, deviceClass)
+	}
+	return append(args, "--data", device)
+}
+
+// getDeviceClass returns the crush device class of the osds on the device, which is the class of the
+// device if one was given or else the class of the node. An empty class leaves the class to ceph.
+func (a *OsdAgent) getDeviceClass(device *DeviceOsdIDEntry) string {
+	if device != nil && device.Config.DeviceClass != "" {
+		return device.Config.DeviceClass
+	}
+	return a.storeConfig.DeviceClass
+}
+
+// getDevicePathLink returns the /dev/disk/by-path link of a discovered device, which identifies the
+// slot of the device rather than the device itself
+func getDevicePathLink(context *clusterd.Context, device string) string {
+	name := strings.TrimPrefix(device, "/dev/")
+	for _, d := range context.Devices {
+		if d.Name != name {
+			continue
+		}
+		for _, link := range strings.Fields(d.DevLinks) {
+			if strings.HasPrefix(link, "/dev/disk/by-path/") {
+				return link
+			}
+		}
+	}
+	return ""
+}
+
 func sanitizeOSDsPerDevice(count int) string {
 	if count < 1 {
 		count = 1
@@ -177,7 +410,18 @@ func getCephVolumeSupported(context *clusterd.Context) (bool, error) {
 }
 
 func getCephVolumeOSDs(context *clusterd.Context, clusterName string) ([]oposd.OSDInfo, error) {
-	result, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, "lvm", "list", "--format", "json")
+	return getCephVolumeOSDsOnDevice(context, clusterName, "")
+}
+
+// getCephVolumeOSDsOnDevice returns the osds provisioned by ceph-volume on the device, or on all
+// the devices of the node if the device is empty
+func getCephVolumeOSDsOnDevice(context *clusterd.Context, clusterName, device string) ([]oposd.OSDInfo, error) {
+	args := []string{"lvm", "list"}
+	if device != "" {
+		args = append(args, device)
+	}
+	args = append(args, "--format", "json")
+	result, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve ceph-volume results. %+v", err)
 	}
@@ -196,13 +440,20 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string) ([]oposd.O
 			logger.Errorf("bad osd returned from ceph-volume: %s", name)
 			continue
 		}
-		var osdFSID string
+		var osdFSID, devicePath string
 		isFilestore := false
+		encrypted := false
 		for _, osd := range osdInfo {
 			osdFSID = osd.Tags.OSDFSID
+			if osd.Tags.Encrypted == "1" {
+				encrypted = true
+			}
 			if osd.Type == "journal" {
 				isFilestore = true
 			}
+			if (osd.Type == "block" || osd.Type == "data") && len(osd.Devices) > 0 {
+				devicePath = getDevicePathLink(context, osd.Devices[0])
+			}
 		}
 		logger.Infof("osdInfo has %d elements. %+v", len(osdInfo), osdInfo)
 
@@ -216,6 +467,8 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string) ([]oposd.O
 			UUID:                osdFSID,
 			CephVolumeInitiated: true,
 			IsFileStore:         isFilestore,
+			DevicePath:          devicePath,
+			Encrypted:           encrypted,
 		}
 		osds = append(osds, osd)
 	}
@@ -227,9 +480,12 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string) ([]oposd.O
 type osdInfo struct {
 	Name string  `json:"name"`
 	Path string  `json:"path"`
+	UUID string  `json:"lv_uuid"`
 	Tags osdTags `json:"tags"`
 	// "data" or "journal" for filestore and "block" for bluestore
 	Type string `json:"type"`
+	// the physical devices of the logical volume
+	Devices []string `json:"devices"`
 }
 
 type osdTags struct {
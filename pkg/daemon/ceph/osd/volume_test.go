@@ -18,10 +18,13 @@ package osd
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -106,6 +109,103 @@ func TestParseCephVolumeResult(t *testing.T) {
 	assert.Nil(t, err)
 	require.NotNil(t, osds)
 	assert.Equal(t, 2, len(osds))
+
+	// the by-path link of the data device is recorded when the device was discovered
+	context.Devices = []*sys.LocalDisk{
+		{Name: "sdb", DevLinks: "/dev/disk/by-id/wwn-0x5000c500a0b1c2d3 /dev/disk/by-path/pci-0000:00:1f.2-ata-1"},
+		{Name: "sdc", DevLinks: "/dev/disk/by-id/wwn-0x5000c500a0b1c2d4"},
+	}
+	osds, err = getCephVolumeOSDs(context, "rook")
+	assert.Nil(t, err)
+	for _, osd := range osds {
+		if osd.ID == 0 {
+			assert.Equal(t, "/dev/disk/by-path/pci-0000:00:1f.2-ata-1", osd.DevicePath)
+		} else {
+			assert.Equal(t, "", osd.DevicePath)
+		}
+	}
+}
+
+func TestInitializeDevicesReplaceOSD(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			logger.Infof("%s %+v", command, args)
+			commands = append(commands, args)
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Devices: []*sys.LocalDisk{
+		{Name: "sdb", DevLinks: "/dev/disk/by-path/pci-0000:00:1f.2-ata-1"},
+		{Name: "sdc", DevLinks: "/dev/disk/by-path/pci-0000:00:1f.2-ata-2"},
+	}}
+	agent := &OsdAgent{replaceOSDs: map[string]int{"/dev/disk/by-path/pci-0000:00:1f.2-ata-1": 3}}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdb": {Data: unassignedOSDID},
+	}}
+
+	// the new device in the slot of the destroyed osd gets its id
+	err := agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(commands))
+	assert.Equal(t, []string{"-oL", "ceph-volume", "lvm", "prepare", "--bluestore", "--osd-id", "3", "--data", "/dev/sdb"}, commands[0])
+
+	// a device in another slot gets a new id
+	commands = nil
+	devices = &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdc": {Data: unassignedOSDID},
+	}}
+	err = agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(commands))
+	assert.Equal(t, "batch", commands[0][3])
+	assert.NotContains(t, commands[0], "--osd-id")
+
+	// the wiped device of a migrated osd gets its id back
+	commands = nil
+	agent.migrateOSDs = map[string]int{"/dev/sdc": 5}
+	err = agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(commands))
+	assert.Equal(t, []string{"-oL", "ceph-volume", "lvm", "prepare", "--bluestore", "--osd-id", "5", "--data", "/dev/sdc"}, commands[0])
+}
+
+func TestInitializeDevicesWithDeviceClass(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			logger.Infof("%s %+v", command, args)
+			commands = append(commands, args)
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{storeConfig: config.StoreConfig{DeviceClass: "hdd"}}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdb": {Data: unassignedOSDID, Config: DesiredDevice{Name: "sdb", DeviceClass: "ssd"}},
+	}}
+
+	// the class of the device takes precedence over the class of the node
+	err := agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(commands))
+	assert.Equal(t, "--crush-device-class ssd", strings.Join(commands[0][len(commands[0])-2:], " "))
+
+	// the devices without a class get the class of the node
+	commands = nil
+	devices.Entries["sdb"].Config.DeviceClass = ""
+	err = agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(commands))
+	assert.Equal(t, "--crush-device-class hdd", strings.Join(commands[0][len(commands[0])-2:], " "))
+
+	// the class is left to ceph if none is given
+	commands = nil
+	agent.storeConfig.DeviceClass = ""
+	err = agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(commands))
+	assert.NotContains(t, commands[0], "--crush-device-class")
 }
 
 func TestSanitizeOSDsPerDevice(t *testing.T) {
@@ -114,3 +214,73 @@ func TestSanitizeOSDsPerDevice(t *testing.T) {
 	assert.Equal(t, "1", sanitizeOSDsPerDevice(1))
 	assert.Equal(t, "2", sanitizeOSDsPerDevice(2))
 }
+
+func TestInitializeDevicesWithMetadataDevices(t *testing.T) {
+	var commands [][]string
+	kv := mockKVStore()
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			logger.Infof("%s %+v", command, args)
+			commands = append(commands, args)
+
+			// the report of the batch is in the status of the node before the osds are created
+			status, err := kv.GetValue("rook-ceph-osd-node1-status", "status")
+			assert.Nil(t, err)
+			assert.Contains(t, status, `"`+strings.TrimPrefix(args[len(args)-1], "/dev/")+`"`)
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			logger.Infof("%s %+v", command, args)
+			if command == cephVolumeCmd && args[0] == "lvm" && args[1] == "list" {
+				// osd 0 already has its db on nvme0n1
+				return `{"0": [{"type": "block", "devices": ["/dev/sda"]}, {"type": "db", "devices": ["/dev/nvme0n1"]}]}`, nil
+			}
+			assert.Equal(t, "--report --format json", strings.Join(args[len(args)-3:], " "))
+			return `{"changed": true, "osds": []}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{
+		kv:             kv,
+		nodeName:       "node1",
+		metadataDevice: "nvme0n1, nvme1n1",
+		storeConfig:    config.StoreConfig{StoreType: config.Bluestore, DatabaseSizeMB: 20480, WalSizeMB: 576, OSDsPerMetadataDevice: 2},
+	}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdb": {Data: unassignedOSDID},
+		"sdc": {Data: unassignedOSDID},
+		"sdd": {Data: unassignedOSDID},
+		"sde": {Data: unassignedOSDID},
+	}}
+
+	// the devices are spread across the metadata devices up to two osds each, sde is left out
+	err := agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 2, len(commands))
+	batchArgs := "-oL ceph-volume lvm batch --prepare --bluestore --yes --osds-per-device 1 --block-db-size 21474836480 "
+	assert.Equal(t, batchArgs+"/dev/nvme0n1 /dev/sdc", strings.Join(commands[0], " "))
+	assert.Equal(t, batchArgs+"/dev/nvme1n1 /dev/sdb /dev/sdd", strings.Join(commands[1], " "))
+
+	// the reports are kept for the status of the node
+	require.Equal(t, 2, len(agent.batchReports))
+	assert.Equal(t, "nvme1n1", agent.batchReports[1].MetadataDevice)
+	assert.Equal(t, []string{"sdb", "sdd"}, agent.batchReports[1].Devices)
+	assert.Equal(t, `{"changed": true, "osds": []}`, string(agent.batchReports[1].Report))
+
+	// the wal size is given to ceph-volume when it is large enough for lvcreate
+	commands = nil
+	agent.batchReports = nil
+	agent.storeConfig.WalSizeMB = 2048
+	agent.storeConfig.OSDsPerMetadataDevice = 0
+	err = agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 2, len(commands))
+	assert.Equal(t, "--block-wal-size 2147483648 /dev/nvme0n1 /dev/sdc /dev/sde", strings.Join(commands[0][11:], " "))
+	assert.Equal(t, "--block-wal-size 2147483648 /dev/nvme1n1 /dev/sdb /dev/sdd", strings.Join(commands[1][11:], " "))
+}
+
+func TestNewBatchReport(t *testing.T) {
+	group := metadataGroup{metadataDevice: "nvme0n1", devices: []string{"sdb"}}
+	assert.Equal(t, `{"osds":[]}`, string(newBatchReport(group, `{"osds":[]}`).Report))
+	assert.Equal(t, `"--> no devices to configure"`, string(newBatchReport(group, "--> no devices to configure").Report))
+}
@@ -34,6 +34,8 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	batch "k8s.io/api/batch/v1"
@@ -50,6 +52,7 @@ type cluster struct {
 	Info                 *cephconfig.ClusterInfo
 	context              *clusterd.Context
 	Namespace            string
+	crdName              string
 	Spec                 *cephv1.ClusterSpec
 	mons                 *mon.Cluster
 	stopCh               chan struct{}
@@ -58,6 +61,7 @@ type cluster struct {
 	orchestrationNeeded  bool
 	orchMux              sync.Mutex
 	childControllers     []childController
+	upgradeControllers   map[cephv1.UpgradePhase]childController // the child controllers whose daemons are upgraded after the osds
 }
 
 // ChildController is implemented by CRs that are owned by the CephCluster
@@ -74,6 +78,7 @@ func newCluster(c *cephv1.CephCluster, context *clusterd.Context) *cluster {
 		// identity can be established.
 		Info:      nil,
 		Namespace: c.Namespace,
+		crdName:   c.Name,
 		Spec:      &c.Spec,
 		context:   context,
 		stopCh:    make(chan struct{}),
@@ -166,7 +171,22 @@ func (c *cluster) createInstance(rookImage string, cephVersion cephver.CephVersi
 	return err
 }
 
+// queueOrchestration requests an orchestration of the cluster without waiting for it. If an orchestration
+// is already running, the request is served by the next run of its loop.
+func (c *cluster) queueOrchestration(rookImage, reason string) {
+	logger.Infof("queuing an orchestration of namespace %s %s", c.Namespace, reason)
+	go func() {
+		if err := c.createInstance(rookImage, c.Info.CephVersion); err != nil {
+			logger.Errorf("failed the orchestration of namespace %s %s. %+v", c.Namespace, reason, err)
+		}
+	}()
+}
+
 func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVersion, spec *cephv1.ClusterSpec) error {
+	if err := opspec.ValidateMemoryHeadroomRatio(spec.MemoryHeadroomRatio); err != nil {
+		return err
+	}
+
 	// Create a configmap for overriding ceph config settings
 	// These settings should only be modified by a user after they are initialized
 	placeholderConfig := map[string]string{
@@ -184,38 +204,77 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 		return fmt.Errorf("failed to create override configmap %s. %+v", c.Namespace, err)
 	}
 
+	// Pace the update of the daemons if the ceph image was changed
+	upgrade, err := c.loadUpgrade(spec, cephVersion)
+	if err != nil {
+		return err
+	}
+
 	// Start the mon pods
+	if paused, err := upgrade.beginPhase(cephv1.UpgradePhaseMon); paused || err != nil {
+		return err
+	}
 	clusterInfo, err := c.mons.Start(c.Info, rookImage, cephVersion, *c.Spec)
 	if err != nil {
 		return fmt.Errorf("failed to start the mons. %+v", err)
 	}
 	c.Info = clusterInfo // mons return the cluster's info
+	if err := upgrade.endPhase(cephv1.UpgradePhaseMon); err != nil {
+		return fmt.Errorf("failed to upgrade the mons. %+v", err)
+	}
 
 	// The cluster Identity must be established at this point
 	if !c.Info.IsInitialized() {
 		return fmt.Errorf("the cluster identity was not established: %+v", c.Info)
 	}
 
+	// Luminous has no centralized config store and gets the overrides from the config file
+	if !cephVersion.IsLuminous() {
+		if err := c.applyCephConfig(spec.CephConfig); err != nil {
+			logger.Warningf("failed to apply all ceph config overrides. %+v", err)
+		}
+	}
+
 	err = c.createInitialCrushMap()
 	if err != nil {
 		return fmt.Errorf("failed to create initial crushmap: %+v", err)
 	}
 
+	// The pools of a stretch cluster place two replicas in each data zone with the stretch rule
+	if spec.Mon.StretchCluster != nil {
+		var dataZones []string
+		for _, zone := range spec.Mon.StretchCluster.Zones {
+			if !zone.Arbiter {
+				dataZones = append(dataZones, zone.Name)
+			}
+		}
+		if spec.Storage.IsCrushManaged() {
+			if err := client.SetStretchCrushRule(c.context, c.Namespace, dataZones); err != nil {
+				return fmt.Errorf("failed to set the crush rule of the stretch cluster. %+v", err)
+			}
+		} else if err := client.CheckStretchCrushRule(c.context, c.Namespace, dataZones); err != nil {
+			// the crush map is left to the user, who has to add the rule and the zones
+			logger.Warningf("the pools of the stretch cluster cannot be created until the rule and the zones are added to the crush map. %+v", err)
+		}
+	}
+
+	if paused, err := upgrade.beginPhase(cephv1.UpgradePhaseMgr); paused || err != nil {
+		return err
+	}
 	mgrs := mgr.New(c.Info, c.context, c.Namespace, rookImage,
 		spec.CephVersion, cephv1.GetMgrPlacement(spec.Placement), cephv1.GetMgrAnnotations(c.Spec.Annotations),
-		spec.Network.HostNetwork, spec.Dashboard, cephv1.GetMgrResources(spec.Resources), c.ownerRef, c.Spec.DataDirHostPath)
+		spec.Network.HostNetwork, spec.Dashboard, spec.Mgr, cephv1.GetMgrResources(spec.Resources), c.ownerRef, c.Spec.DataDirHostPath)
 	err = mgrs.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the ceph mgr. %+v", err)
 	}
+	if err := upgrade.endPhase(cephv1.UpgradePhaseMgr); err != nil {
+		return fmt.Errorf("failed to upgrade the ceph mgr. %+v", err)
+	}
 
 	// Start the OSDs
-	osds := osd.New(c.Info, c.context, c.Namespace, rookImage, spec.CephVersion, spec.Storage, spec.DataDirHostPath,
-		cephv1.GetOSDPlacement(spec.Placement), cephv1.GetOSDAnnotations(spec.Annotations), spec.Network.HostNetwork,
-		cephv1.GetOSDResources(spec.Resources), c.ownerRef)
-	err = osds.Start()
-	if err != nil {
-		return fmt.Errorf("failed to start the osds. %+v", err)
+	if paused, err := c.startOSDs(rookImage, spec, upgrade); paused || err != nil {
+		return err
 	}
 
 	// Start the rbd mirroring daemon(s)
@@ -229,7 +288,31 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 
 	logger.Infof("Done creating rook instance in namespace %s", c.Namespace)
 
-	// Notify the child controllers that the cluster spec might have changed
+	if c.upgradeControllers == nil {
+		// the child controllers are started after the first orchestration and update their daemons
+		// when they start
+		if upgrade != nil {
+			logger.Infof("the upgrade of cluster %s resumes when the child controllers are started", c.Namespace)
+		}
+		return nil
+	}
+
+	// Notify the child controllers of the daemons of the filesystems, object stores and nfs servers
+	// in the order they are upgraded
+	for _, phase := range []cephv1.UpgradePhase{cephv1.UpgradePhaseMDS, cephv1.UpgradePhaseRGW, cephv1.UpgradePhaseNFS} {
+		if paused, err := upgrade.beginPhase(phase); paused || err != nil {
+			return err
+		}
+		c.upgradeControllers[phase].ParentClusterChanged(*c.Spec, clusterInfo)
+		if err := upgrade.endPhase(phase); err != nil {
+			return fmt.Errorf("failed to upgrade the %s daemons. %+v", phase, err)
+		}
+	}
+	if err := upgrade.complete(); err != nil {
+		return err
+	}
+
+	// Notify the other child controllers that the cluster spec might have changed
 	for _, child := range c.childControllers {
 		child.ParentClusterChanged(*c.Spec, clusterInfo)
 	}
@@ -237,6 +320,78 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 	return nil
 }
 
+// startOSDs starts the osds and updates them by batches of failure domains if they are being upgraded.
+// It returns true if the upgrade is paused.
+func (c *cluster) startOSDs(rookImage string, spec *cephv1.ClusterSpec, upgrade *clusterUpgrade) (bool, error) {
+	if paused, err := upgrade.beginPhase(cephv1.UpgradePhaseOSD); paused || err != nil {
+		return paused, err
+	}
+	osds := osd.New(c.Info, c.context, c.Namespace, rookImage, spec.CephVersion, spec.Storage, spec.DataDirHostPath,
+		cephv1.GetOSDPlacement(spec.Placement), cephv1.GetOSDAnnotations(spec.Annotations), spec.Network.HostNetwork,
+		cephv1.GetOSDResources(spec.Resources), spec.MemoryHeadroomRatio, c.ownerRef)
+	osds.Upgrade = upgrade.osdBatches()
+	if osds.Upgrade != nil {
+		if err := upgrade.setOSDFlags(); err != nil {
+			return false, fmt.Errorf("failed to set the osd flags for the upgrade. %+v", err)
+		}
+		defer upgrade.unsetOSDFlags()
+	}
+
+	if err := osds.Start(); err != nil {
+		return false, fmt.Errorf("failed to start the osds. %+v", err)
+	}
+	if osds.Upgrade == nil {
+		return false, nil
+	}
+
+	if paused, err := osds.Upgrade.Complete(); paused || err != nil {
+		return paused, err
+	}
+	if err := upgrade.endPhase(cephv1.UpgradePhaseOSD); err != nil {
+		return false, fmt.Errorf("failed to upgrade the osds. %+v", err)
+	}
+	return false, nil
+}
+
+// applyCephConfig sets the config overrides from the cluster CR in the mon config database, removes
+// the overrides that were removed from the spec, and records the overrides that had drifted from the
+// value last applied in the cluster CR status
+func (c *cluster) applyCephConfig(overrides map[string]map[string]string) error {
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.crdName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s to apply its config overrides. %+v", c.crdName, err)
+	}
+
+	applied := config.NewConfigFromOverrides(cluster.Status.AppliedCephConfig)
+	drift, applyErr := config.GetMonStore(c.context, c.Namespace).SetAll(config.NewConfigFromOverrides(overrides), applied)
+
+	var statusDrift []cephv1.CephConfigDrift
+	for _, d := range drift {
+		statusDrift = append(statusDrift, cephv1.CephConfigDrift{
+			Section:  d.Section,
+			Option:   d.Option,
+			Expected: d.Expected,
+			Actual:   d.Actual,
+		})
+	}
+
+	// the overrides are only recorded as applied when all of them were set, so the overrides that
+	// failed to be removed are removed again on the next orchestration
+	appliedOverrides := cluster.Status.AppliedCephConfig
+	if applyErr == nil {
+		appliedOverrides = overrides
+	}
+	if !reflect.DeepEqual(cluster.Status.CephConfigDrift, statusDrift) || !reflect.DeepEqual(cluster.Status.AppliedCephConfig, appliedOverrides) {
+		cluster.Status.CephConfigDrift = statusDrift
+		cluster.Status.AppliedCephConfig = appliedOverrides
+		if _, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Update(cluster); err != nil {
+			return fmt.Errorf("failed to update cluster %s config status. %+v", c.crdName, err)
+		}
+	}
+
+	return applyErr
+}
+
 func (c *cluster) createInitialCrushMap() error {
 	configMapExists := false
 	createCrushMap := false
@@ -265,13 +420,18 @@ func (c *cluster) createInitialCrushMap() error {
 		return nil
 	}
 
-	logger.Info("creating initial crushmap")
-	out, err := client.CreateDefaultCrushMap(c.context, c.Namespace)
-	if err != nil {
-		return fmt.Errorf("failed to create initial crushmap: %+v. output: %s", err, out)
-	}
+	if c.Spec.Storage.IsCrushManaged() {
+		logger.Info("creating initial crushmap")
+		out, err := client.CreateDefaultCrushMap(c.context, c.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to create initial crushmap: %+v. output: %s", err, out)
+		}
 
-	logger.Info("created initial crushmap")
+		logger.Info("created initial crushmap")
+	} else {
+		// the crush map is also not replaced if the crush map is managed by rook again later
+		logger.Info("crush map not managed by rook. keeping the crushmap created by ceph")
+	}
 
 	// save the fact that we've created the initial crushmap to a configmap
 	configMap := &v1.ConfigMap{
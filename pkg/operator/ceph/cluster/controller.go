@@ -30,8 +30,10 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/crush"
 	"github.com/rook/rook/pkg/operator/ceph/file"
 	"github.com/rook/rook/pkg/operator/ceph/nfs"
 	"github.com/rook/rook/pkg/operator/ceph/object"
@@ -315,7 +317,7 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	}
 
 	// Start pool CRD watcher
-	poolController := pool.NewPoolController(c.context, cluster.Namespace)
+	poolController := pool.NewPoolController(cluster.Info, c.context, cluster.Namespace)
 	poolController.StartWatch(cluster.stopCh)
 
 	// Start object store CRD watcher
@@ -334,14 +336,43 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	ganeshaController := nfs.NewCephNFSController(cluster.Info, c.context, cluster.Namespace, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network.HostNetwork, cluster.ownerRef)
 	ganeshaController.StartWatch(cluster.stopCh)
 
+	// Start osd replacement CRD watcher
+	replacementController := osd.NewReplacementController(c.context, cluster.Namespace, func() {
+		cluster.queueOrchestration(c.rookImage, "to prepare the migrated osds")
+	})
+	replacementController.StartWatch(cluster.stopCh)
+
+	// Start crush map CRD watcher
+	crushMapController := crush.NewCrushMapController(cluster.Info, c.context, cluster.Namespace)
+	crushMapController.StartWatch(cluster.stopCh)
+
 	cluster.childControllers = []childController{
-		poolController, objectStoreController, objectStoreUserController, fileController, ganeshaController,
+		poolController, objectStoreUserController, crushMapController,
+	}
+	cluster.upgradeControllers = map[cephv1.UpgradePhase]childController{
+		cephv1.UpgradePhaseMDS: fileController,
+		cephv1.UpgradePhaseRGW: objectStoreController,
+		cephv1.UpgradePhaseNFS: ganeshaController,
+	}
+
+	// complete an upgrade that was interrupted by a restart of the operator now that the child
+	// controllers are started
+	if upgrade, err := cluster.loadUpgrade(cluster.Spec, *cephVersion); err != nil {
+		logger.Errorf("failed to load the upgrade status of cluster %s. %+v", cluster.Namespace, err)
+	} else if upgrade != nil {
+		if err := cluster.createInstance(c.rookImage, *cephVersion); err != nil {
+			logger.Errorf("failed to resume the upgrade of cluster %s. %+v", cluster.Namespace, err)
+		}
 	}
 
 	// Start mon health checker
 	healthChecker := mon.NewHealthChecker(cluster.mons)
 	go healthChecker.Check(cluster.stopCh)
 
+	// Start the active mgr checker
+	mgrChecker := mgr.NewActiveChecker(c.context, cluster.Namespace)
+	go mgrChecker.Check(cluster.stopCh)
+
 	// Start the osd health checker
 	osdChecker := osd.NewMonitor(c.context, cluster.Namespace)
 	go osdChecker.Start(cluster.stopCh)
@@ -379,6 +410,17 @@ func (c *ClusterController) onK8sNodeUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
+	// move the node in the crush map when its topology labels changed
+	for _, cluster := range c.clusterMap {
+		if cluster.Info == nil || !cluster.Spec.Storage.IsCrushManaged() || !osd.NodeTopologyChanged(oldNode, newNode, cluster.Spec.Storage.TopologyLabels) {
+			continue
+		}
+		logger.Infof("topology of node %s changed. updating its crush location in cluster %s", newNode.Name, cluster.Namespace)
+		if err := osd.UpdateNodeCrushLocation(c.context, cluster.Namespace, cluster.Spec.Storage, newNode); err != nil {
+			logger.Errorf("failed to update the crush location of node %s in cluster %s. %+v", newNode.Name, cluster.Namespace, err)
+		}
+	}
+
 	// set or unset noout depending on whether nodes are schedulable.
 	newNodeSchedulable := k8sutil.GetNodeSchedulable(*newNode)
 	oldNodeSchedulable := k8sutil.GetNodeSchedulable(*oldNode)
@@ -483,6 +525,12 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 			return
 		}
 		cluster.Info.CephVersion = *version
+
+		// the daemons are updated phase by phase by the orchestration
+		if err := cluster.startUpgrade(oldClust.Spec.CephVersion.Image, newClust.Spec.CephVersion.Image); err != nil {
+			logger.Errorf("failed to start the upgrade. %+v", err)
+			return
+		}
 	} else {
 		logger.Infof("ceph version is still %s on image %s", &cluster.Info.CephVersion, cluster.Spec.CephVersion.Image)
 	}
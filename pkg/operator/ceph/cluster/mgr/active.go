@@ -0,0 +1,129 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the label of the mgr pods that tells the active mgr from the standby mgrs
+	mgrRoleLabel   = "mgr_role"
+	mgrRoleActive  = "active"
+	mgrRoleStandby = "standby"
+)
+
+// ActiveCheckInterval is the interval to check which mgr is active
+var ActiveCheckInterval = 15 * time.Second
+
+// ActiveChecker labels the active mgr pod so that the mgr services only route to the active mgr
+type ActiveChecker struct {
+	context   *clusterd.Context
+	namespace string
+}
+
+// NewActiveChecker creates a new ActiveChecker object
+func NewActiveChecker(context *clusterd.Context, namespace string) *ActiveChecker {
+	return &ActiveChecker{
+		context:   context,
+		namespace: namespace,
+	}
+}
+
+// Check periodically labels the active mgr after a mgr failover or a restart of the mgr pods
+func (ac *ActiveChecker) Check(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("Stopping monitoring of the active mgr in namespace %s", ac.namespace)
+			return
+
+		case <-time.After(ActiveCheckInterval):
+			logger.Debugf("checking the active mgr")
+			if err := labelActiveMgr(ac.context, ac.namespace); err != nil {
+				logger.Infof("failed to label the active mgr. %+v", err)
+			}
+		}
+	}
+}
+
+// activeMgrLabels selects the pod of the active mgr
+func activeMgrLabels(namespace string) map[string]string {
+	labels := opspec.AppLabels(appName, namespace)
+	labels[mgrRoleLabel] = mgrRoleActive
+	return labels
+}
+
+// labelActiveMgr labels the pod of the mgr that ceph reports as active, and the pods of the
+// other mgrs as standby
+func labelActiveMgr(context *clusterd.Context, namespace string) error {
+	mgrMap, err := client.MgrDump(context, namespace)
+	if err != nil {
+		return err
+	}
+
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, appName)
+	pods, err := context.Clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list mgr pods. %+v", err)
+	}
+
+	for _, pod := range pods.Items {
+		role := mgrRoleStandby
+		if mgrMap.ActiveName != "" && pod.Labels["mgr"] == mgrMap.ActiveName {
+			role = mgrRoleActive
+		}
+		if pod.Labels[mgrRoleLabel] == role {
+			continue
+		}
+
+		pod.Labels[mgrRoleLabel] = role
+		if _, err := context.Clientset.CoreV1().Pods(namespace).Update(&pod); err != nil {
+			return fmt.Errorf("failed to label mgr pod %s as %s. %+v", pod.Name, role, err)
+		}
+		logger.Infof("mgr pod %s is %s", pod.Name, role)
+	}
+	return nil
+}
+
+// updateServiceSelector updates the selector of an existing mgr service, which selected all the
+// mgr pods before the active mgr was labeled
+func (c *Cluster) updateServiceSelector(service *v1.Service) error {
+	existing, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get(service.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get service %s. %+v", service.Name, err)
+	}
+	if reflect.DeepEqual(existing.Spec.Selector, service.Spec.Selector) {
+		return nil
+	}
+
+	logger.Infof("updating the selector of service %s to the active mgr", service.Name)
+	existing.Spec.Selector = service.Spec.Selector
+	if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Update(existing); err != nil {
+		return fmt.Errorf("failed to update service %s. %+v", service.Name, err)
+	}
+	return nil
+}
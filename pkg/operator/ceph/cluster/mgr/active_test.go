@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLabelActiveMgr(t *testing.T) {
+	active := "a"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[0] == "mgr" && args[1] == "dump" {
+				return fmt.Sprintf(`{"epoch":12,"active_name":"%s","available":true,"standbys":[{"gid":4107,"name":"b"},{"gid":4109,"name":"c"}]}`, active), nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	c := &Cluster{context: context, Namespace: "ns"}
+	for _, id := range []string{"a", "b", "c"} {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mgr-" + id, Namespace: "ns", Labels: c.getPodLabels(id)}}
+		_, err := clientset.CoreV1().Pods("ns").Create(pod)
+		require.Nil(t, err)
+	}
+
+	// only the active mgr is selected by the services
+	assert.Nil(t, labelActiveMgr(context, "ns"))
+	assert.Equal(t, map[string]string{"a": "active", "b": "standby", "c": "standby"}, mgrRoles(t, context))
+
+	// the label follows the active mgr after a failover
+	active = "c"
+	assert.Nil(t, labelActiveMgr(context, "ns"))
+	assert.Equal(t, map[string]string{"a": "standby", "b": "standby", "c": "active"}, mgrRoles(t, context))
+
+	// the existing services select the active mgr
+	svc := c.makeMetricsService(appName)
+	svc.Spec.Selector = svc.Labels
+	_, err := clientset.CoreV1().Services("ns").Create(svc)
+	require.Nil(t, err)
+	assert.Nil(t, c.updateServiceSelector(c.makeMetricsService(appName)))
+	svc, err = clientset.CoreV1().Services("ns").Get(appName, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, activeMgrLabels("ns"), svc.Spec.Selector)
+}
+
+func mgrRoles(t *testing.T, context *clusterd.Context) map[string]string {
+	pods, err := context.Clientset.CoreV1().Pods("ns").List(metav1.ListOptions{})
+	require.Nil(t, err)
+	roles := map[string]string{}
+	for _, pod := range pods.Items {
+		roles[pod.Labels["mgr"]] = pod.Labels[mgrRoleLabel]
+	}
+	return roles
+}
@@ -77,6 +77,9 @@ func (c *Cluster) configureDashboard(m *mgrConfig) error {
 					return fmt.Errorf("failed to update dashboard mgr service. %+v", err)
 				}
 			}
+			if err := c.updateServiceSelector(dashboardService); err != nil {
+				return fmt.Errorf("failed to update dashboard mgr service. %+v", err)
+			}
 		} else {
 			logger.Infof("dashboard service started")
 		}
@@ -27,6 +27,7 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
@@ -58,6 +59,7 @@ type Cluster struct {
 	resources       v1.ResourceRequirements
 	ownerRef        metav1.OwnerReference
 	dashboard       cephv1.DashboardSpec
+	mgrSpec         cephv1.MgrSpec
 	cephVersion     cephv1.CephVersionSpec
 	rookVersion     string
 	exitCode        func(err error) (int, bool)
@@ -74,10 +76,15 @@ func New(
 	annotations rookalpha.Annotations,
 	hostNetwork bool,
 	dashboard cephv1.DashboardSpec,
+	mgrSpec cephv1.MgrSpec,
 	resources v1.ResourceRequirements,
 	ownerRef metav1.OwnerReference,
 	dataDirHostPath string,
 ) *Cluster {
+	replicas := 1
+	if mgrSpec.Count > 0 {
+		replicas = mgrSpec.Count
+	}
 	return &Cluster{
 		clusterInfo:     clusterInfo,
 		context:         context,
@@ -85,9 +92,10 @@ func New(
 		placement:       placement,
 		rookVersion:     rookVersion,
 		cephVersion:     cephVersion,
-		Replicas:        1,
+		Replicas:        replicas,
 		dataDir:         k8sutil.DataDir,
 		dashboard:       dashboard,
+		mgrSpec:         mgrSpec,
 		HostNetwork:     hostNetwork,
 		resources:       resources,
 		ownerRef:        ownerRef,
@@ -109,11 +117,6 @@ func (c *Cluster) Start() error {
 	logger.Infof("start running mgr")
 
 	for i := 0; i < c.Replicas; i++ {
-		if i >= 2 {
-			logger.Errorf("cannot have more than 2 mgrs")
-			break
-		}
-
 		daemonID := k8sutil.IndexToName(i)
 		resourceName := fmt.Sprintf("%s-%s", appName, daemonID)
 		mgrConfig := &mgrConfig{
@@ -158,6 +161,14 @@ func (c *Cluster) Start() error {
 			logger.Errorf("failed to enable mgr dashboard. %+v", err)
 		}
 
+		if err := c.configureModules(mgrConfig); err != nil {
+			logger.Errorf("failed to configure mgr modules. %+v", err)
+		}
+
+	}
+
+	if err := c.removeExtraMgrs(); err != nil {
+		return fmt.Errorf("failed to remove the mgrs beyond the count. %+v", err)
 	}
 
 	// create the metrics service
@@ -167,10 +178,51 @@ func (c *Cluster) Start() error {
 			return fmt.Errorf("failed to create mgr service. %+v", err)
 		}
 		logger.Infof("mgr metrics service already exists")
+		if err := c.updateServiceSelector(service); err != nil {
+			return fmt.Errorf("failed to update mgr service. %+v", err)
+		}
 	} else {
 		logger.Infof("mgr metrics service started")
 	}
 
+	// the services only route to the active mgr once it is labeled
+	if err := labelActiveMgr(c.context, c.Namespace); err != nil {
+		logger.Infof("active mgr not labeled yet. %+v", err)
+	}
+
+	return nil
+}
+
+// removeExtraMgrs deletes the deployments, keyrings and ceph keys of the mgrs beyond the desired count
+// after the count was lowered
+func (c *Cluster) removeExtraMgrs() error {
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, appName)
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list mgr deployments. %+v", err)
+	}
+
+	desired := map[string]bool{}
+	for i := 0; i < c.Replicas; i++ {
+		desired[k8sutil.IndexToName(i)] = true
+	}
+	for _, d := range deployments.Items {
+		daemonID := d.Labels["mgr"]
+		if daemonID == "" || desired[daemonID] {
+			continue
+		}
+
+		logger.Infof("removing mgr %s beyond the count of %d", daemonID, c.Replicas)
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, c.Namespace, d.Name); err != nil {
+			return fmt.Errorf("failed to delete mgr deployment %s. %+v", d.Name, err)
+		}
+		if err := keyring.GetSecretStore(c.context, c.Namespace, &c.ownerRef).Delete(d.Name); err != nil {
+			return fmt.Errorf("failed to delete the keyring of mgr %s. %+v", daemonID, err)
+		}
+		if err := client.AuthDelete(c.context, c.Namespace, fmt.Sprintf("mgr.%s", daemonID)); err != nil {
+			logger.Warningf("failed to delete the key of mgr %s. %+v", daemonID, err)
+		}
+	}
 	return nil
 }
 
@@ -26,6 +26,7 @@ import (
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -63,6 +64,7 @@ func TestStartMGR(t *testing.T) {
 		rookalpha.Annotations{},
 		false,
 		cephv1.DashboardSpec{Enabled: true},
+		cephv1.MgrSpec{},
 		v1.ResourceRequirements{},
 		metav1.OwnerReference{},
 		"/var/lib/rook/",
@@ -92,22 +94,29 @@ func TestStartMGR(t *testing.T) {
 	validateStart(t, c)
 	assert.ElementsMatch(t, []string{"rook-ceph-mgr-a"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
+
+	// the mgrs beyond the count are removed when it is lowered
+	c.Replicas = 1
+	err = c.Start()
+	assert.Nil(t, err)
+	validateStart(t, c)
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(deployments.Items))
+	assert.Equal(t, "rook-ceph-mgr-a", deployments.Items[0].Name)
 }
 
 func validateStart(t *testing.T, c *Cluster) {
-	mgrNames := []string{"a", "b"}
 	for i := 0; i < c.Replicas; i++ {
-		if i == 2 {
-			break
-		}
 		logger.Infof("Looking for cephmgr replica %d", i)
-		daemonName := mgrNames[i]
+		daemonName := k8sutil.IndexToName(i)
 		_, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(fmt.Sprintf("rook-ceph-mgr-%s", daemonName), metav1.GetOptions{})
 		assert.Nil(t, err)
 	}
 
-	_, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr", metav1.GetOptions{})
+	s, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr", metav1.GetOptions{})
 	assert.Nil(t, err)
+	assert.Equal(t, "active", s.Spec.Selector["mgr_role"])
 
 	ds, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
 	if c.dashboard.Enabled {
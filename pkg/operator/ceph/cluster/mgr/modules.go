@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// the configmap that records the modules enabled from the spec, so that they are disabled once
+	// they are removed from the spec
+	modulesConfigMapName = "rook-ceph-mgr-modules"
+	enabledModulesKey    = "enabled"
+)
+
+// configureModules enables or disables the mgr modules of the cluster spec and applies their settings.
+// The modules and settings that were changed outside of the spec are reset on every orchestration, and
+// the modules that were enabled from the spec are disabled when they are removed from it.
+// Ceph docs about the mgr modules: http://docs.ceph.com/docs/master/mgr/
+func (c *Cluster) configureModules(m *mgrConfig) error {
+	kv := k8sutil.NewConfigMapKVStore(c.Namespace, c.context.Clientset, c.ownerRef)
+	previous, err := kv.GetValue(modulesConfigMapName, enabledModulesKey)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get the mgr modules enabled from the spec. %+v", err)
+	}
+
+	recorded := strings.Split(previous, ",")
+	wasEnabled := map[string]bool{}
+	for _, name := range recorded {
+		wasEnabled[name] = true
+	}
+
+	var errs []string
+	var enabled []string
+	inSpec := map[string]bool{}
+	for _, module := range c.mgrSpec.Modules {
+		inSpec[module.Name] = true
+		err := c.configureModule(m, module)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		// the modules that the mgr failed to enable are not recorded, so they are not disabled later
+		if module.IsEnabled() && (err == nil || wasEnabled[module.Name]) {
+			enabled = append(enabled, module.Name)
+		}
+	}
+
+	for _, name := range recorded {
+		if name == "" || inSpec[name] {
+			continue
+		}
+		if err := client.MgrDisableModule(c.context, c.Namespace, name); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to disable mgr module %s removed from the spec. %+v", name, err))
+			// keep the module to retry on the next orchestration
+			enabled = append(enabled, name)
+			continue
+		}
+		logger.Infof("disabled mgr module %s removed from the spec", name)
+	}
+
+	if current := strings.Join(enabled, ","); current != previous {
+		if err := kv.SetValue(modulesConfigMapName, enabledModulesKey, current); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to save the mgr modules enabled from the spec. %+v", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d mgr modules failed: %s", len(errs), strings.Join(errs, ". "))
+	}
+	return nil
+}
+
+// isRookModule returns whether the module is enabled and configured by the operator itself
+func isRookModule(name string) bool {
+	switch name {
+	case dashboardModuleName, prometheusModuleName, orchestratorModuleName, rookModuleName:
+		return true
+	}
+	return false
+}
+
+func (c *Cluster) configureModule(m *mgrConfig, module cephv1.MgrModule) error {
+	if module.Name == dashboardModuleName {
+		return fmt.Errorf("mgr module %s is configured with the dashboard settings", module.Name)
+	}
+	if isRookModule(module.Name) {
+		return fmt.Errorf("mgr module %s is managed by the operator", module.Name)
+	}
+
+	if !module.IsEnabled() {
+		if err := client.MgrDisableModule(c.context, c.Namespace, module.Name); err != nil {
+			return fmt.Errorf("failed to disable mgr module %s. %+v", module.Name, err)
+		}
+		return nil
+	}
+
+	// the module is not forced so that the modules the mgr doesn't know are reported
+	if err := client.MgrEnableModule(c.context, c.Namespace, module.Name, false); err != nil {
+		return fmt.Errorf("failed to enable mgr module %s. %+v", module.Name, err)
+	}
+
+	keys := make([]string, 0, len(module.Config))
+	for key := range module.Config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		configKey := fmt.Sprintf("mgr/%s/%s", module.Name, key)
+		changed, err := client.MgrSetConfig(c.context, c.Namespace, m.DaemonID, c.clusterInfo.CephVersion, configKey, module.Config[key], false)
+		if err != nil {
+			return fmt.Errorf("failed to configure mgr module %s. %+v", module.Name, err)
+		}
+		if changed {
+			logger.Infof("mgr module setting %s set to %q", configKey, module.Config[key])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureModules(t *testing.T) {
+	var commands []string
+	config := map[string]string{"mgr/balancer/mode": "crush-compat"}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "mgr" && args[1] == "module" {
+				if args[3] == "unknown" {
+					return "", fmt.Errorf("all mgr daemons do not support module 'unknown'")
+				}
+				commands = append(commands, strings.Join(args[:4], " "))
+				return "", nil
+			}
+			if args[0] == "config" {
+				switch args[1] {
+				case "get":
+					return config[args[3]], nil
+				case "set":
+					commands = append(commands, strings.Join(args[:5], " "))
+					config[args[3]] = args[4]
+					return "", nil
+				}
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(1)}
+	disabled := false
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus},
+		context:     context,
+		Namespace:   "ns",
+		mgrSpec: cephv1.MgrSpec{Modules: []cephv1.MgrModule{
+			{Name: "pg_autoscaler"},
+			{Name: "balancer", Config: map[string]string{"mode": "upmap", "active": "true"}},
+			{Name: "telemetry", Enabled: &disabled},
+		}},
+	}
+	m := &mgrConfig{DaemonID: "a"}
+
+	// the modules are enabled or disabled and their settings are applied in order
+	assert.Nil(t, c.configureModules(m))
+	assert.Equal(t, []string{
+		"mgr module enable pg_autoscaler",
+		"mgr module enable balancer",
+		"config set mgr.a mgr/balancer/active true",
+		"config set mgr.a mgr/balancer/mode upmap",
+		"mgr module disable telemetry",
+	}, commands)
+
+	// the modules are reconciled on every orchestration
+	commands = nil
+	config["mgr/balancer/mode"] = "crush-compat"
+	assert.Nil(t, c.configureModules(m))
+	assert.Equal(t, 5, len(commands))
+	assert.Equal(t, "upmap", config["mgr/balancer/mode"])
+
+	// the failed modules don't prevent the others from being configured, and the modules enabled
+	// from the spec are disabled once they are removed from it
+	commands = nil
+	c.mgrSpec.Modules = []cephv1.MgrModule{{Name: "unknown"}, {Name: "dashboard"}, {Name: "prometheus"}, {Name: "crash"}}
+	err := c.configureModules(m)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "3 mgr modules failed")
+	assert.Contains(t, err.Error(), "mgr module prometheus is managed by the operator")
+	assert.Equal(t, []string{
+		"mgr module enable crash",
+		"mgr module disable pg_autoscaler",
+		"mgr module disable balancer",
+	}, commands)
+
+	// the modules the mgr failed to enable are not disabled when they are removed
+	commands = nil
+	c.mgrSpec.Modules = nil
+	assert.Nil(t, c.configureModules(m))
+	assert.Equal(t, []string{"mgr module disable crash"}, commands)
+}
@@ -72,6 +72,9 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig) *apps.Deployment {
 	}
 	c.annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
 	c.placement.ApplyToPodSpec(&podSpec.Spec)
+	if c.placement.PodAntiAffinity == nil {
+		podSpec.Spec.Affinity.PodAntiAffinity = c.mgrAntiAffinity()
+	}
 	if c.clusterInfo.CephVersion.IsLuminous() {
 		// prepend the keyring-copy workaround for luminous clusters
 		podSpec.Spec.InitContainers = append(
@@ -111,6 +114,33 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig) *apps.Deployment {
 	return d
 }
 
+// mgrAntiAffinity spreads the mgr pods across the nodes and the zones so that a standby mgr can take
+// over when the node or the zone of the active mgr fails. A required anti-affinity can be set in the
+// placement instead when there are enough nodes.
+func (c *Cluster) mgrAntiAffinity() *v1.PodAntiAffinity {
+	selector := &metav1.LabelSelector{
+		MatchLabels: opspec.AppLabels(appName, c.Namespace),
+	}
+	return &v1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
+			{
+				Weight: int32(100),
+				PodAffinityTerm: v1.PodAffinityTerm{
+					LabelSelector: selector,
+					TopologyKey:   v1.LabelHostname,
+				},
+			},
+			{
+				Weight: int32(50),
+				PodAffinityTerm: v1.PodAffinityTerm{
+					LabelSelector: selector,
+					TopologyKey:   v1.LabelZoneFailureDomain,
+				},
+			},
+		},
+	}
+}
+
 func (c *Cluster) needHttpBindFix() bool {
 	needed := true
 
@@ -278,7 +308,7 @@ func (c *Cluster) makeMetricsService(name string) *v1.Service {
 			Labels:    labels,
 		},
 		Spec: v1.ServiceSpec{
-			Selector: labels,
+			Selector: activeMgrLabels(c.Namespace),
 			Type:     v1.ServiceTypeClusterIP,
 			Ports: []v1.ServicePort{
 				{
@@ -303,7 +333,7 @@ func (c *Cluster) makeDashboardService(name string, port int) *v1.Service {
 			Labels:    labels,
 		},
 		Spec: v1.ServiceSpec{
-			Selector: labels,
+			Selector: activeMgrLabels(c.Namespace),
 			Type:     v1.ServiceTypeClusterIP,
 			Ports: []v1.ServicePort{
 				{
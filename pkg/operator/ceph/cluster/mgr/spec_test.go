@@ -45,6 +45,7 @@ func TestPodSpec(t *testing.T) {
 		rookalpha.Annotations{},
 		false,
 		cephv1.DashboardSpec{},
+		cephv1.MgrSpec{},
 		v1.ResourceRequirements{
 			Limits: v1.ResourceList{
 				v1.ResourceCPU:    *resource.NewQuantity(200.0, resource.BinarySI),
@@ -79,6 +80,11 @@ func TestPodSpec(t *testing.T) {
 	podTemplate.RunFullSuite(config.MgrType, "a", appName, "ns", "ceph/ceph:myceph",
 		"200", "100", "500", "250" /* resources */)
 
+	// the mgrs are spread across the nodes and the zones
+	antiAffinity := d.Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	assert.Equal(t, 2, len(antiAffinity))
+	assert.Equal(t, v1.LabelHostname, antiAffinity[0].PodAffinityTerm.TopologyKey)
+	assert.Equal(t, v1.LabelZoneFailureDomain, antiAffinity[1].PodAffinityTerm.TopologyKey)
 }
 
 func TestServiceSpec(t *testing.T) {
@@ -93,6 +99,7 @@ func TestServiceSpec(t *testing.T) {
 		rookalpha.Annotations{},
 		false,
 		cephv1.DashboardSpec{},
+		cephv1.MgrSpec{},
 		v1.ResourceRequirements{},
 		metav1.OwnerReference{},
 		"/var/lib/rook/",
@@ -102,6 +109,8 @@ func TestServiceSpec(t *testing.T) {
 	assert.NotNil(t, s)
 	assert.Equal(t, "rook-mgr", s.Name)
 	assert.Equal(t, 1, len(s.Spec.Ports))
+	assert.Equal(t, "active", s.Spec.Selector[mgrRoleLabel])
+	assert.Equal(t, "", s.Labels[mgrRoleLabel])
 }
 
 func TestHostNetwork(t *testing.T) {
@@ -116,6 +125,7 @@ func TestHostNetwork(t *testing.T) {
 		rookalpha.Annotations{},
 		true,
 		cephv1.DashboardSpec{},
+		cephv1.MgrSpec{},
 		v1.ResourceRequirements{},
 		metav1.OwnerReference{},
 		"/var/lib/rook/",
@@ -147,6 +157,7 @@ func TestHttpBindFix(t *testing.T) {
 		rookalpha.Annotations{},
 		true,
 		cephv1.DashboardSpec{},
+		cephv1.MgrSpec{},
 		v1.ResourceRequirements{},
 		metav1.OwnerReference{},
 		"/var/lib/rook/",
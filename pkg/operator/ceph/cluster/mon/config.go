@@ -82,6 +82,7 @@ func CreateOrLoadClusterInfo(context *clusterd.Context, namespace string, ownerR
 	monMapping := &Mapping{
 		Node: map[string]*NodeInfo{},
 		Port: map[string]int32{},
+		Zone: map[string]string{},
 	}
 
 	secrets, err := context.Clientset.CoreV1().Secrets(namespace).Get(appName, metav1.GetOptions{})
@@ -138,6 +139,7 @@ func loadMonConfig(clientset kubernetes.Interface, namespace string) (map[string
 	monMapping := &Mapping{
 		Node: map[string]*NodeInfo{},
 		Port: map[string]int32{},
+		Zone: map[string]string{},
 	}
 
 	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(EndpointConfigMapName, metav1.GetOptions{})
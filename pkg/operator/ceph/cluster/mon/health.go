@@ -172,6 +172,14 @@ func (c *Cluster) checkHealth() error {
 		return err
 	}
 
+	if c.zoneSpread() && allMonsInQuorum {
+		// move a mon out of a zone with too many mons when a zone with fewer mons is available
+		done, err := c.checkMonsZoneSpread(desiredMonCount)
+		if done || err != nil {
+			return err
+		}
+	}
+
 	// create/start new mons when there are fewer mons than the desired count in the CRD
 	if len(status.MonMap.Mons) < desiredMonCount {
 		logger.Infof("adding mons. currently %d mons are in quorum and the desired count is %d.", len(status.MonMap.Mons), desiredMonCount)
@@ -270,7 +278,7 @@ func (c *Cluster) failoverMon(name string) error {
 	mConf := []*monConfig{m}
 
 	// Assign the pod to a node
-	if err = c.assignMons(mConf); err != nil {
+	if err = c.assignMons(mConf, name); err != nil {
 		return fmt.Errorf("failed to place new mon on a node. %+v", err)
 	}
 
@@ -335,6 +343,13 @@ func (c *Cluster) removeMon(daemonName string) error {
 		}
 	}
 
+	delete(c.mapping.Zone, daemonName)
+
+	// Remove the pvc of the mon if the mon stored its data on a pvc
+	if err := c.deleteMonPVC(daemonName); err != nil {
+		return err
+	}
+
 	// Remove the service endpoint
 	if err := c.context.Clientset.CoreV1().Services(c.Namespace).Delete(resourceName, options); err != nil {
 		if errors.IsNotFound(err) {
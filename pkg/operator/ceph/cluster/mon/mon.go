@@ -122,6 +122,8 @@ type monConfig struct {
 type Mapping struct {
 	Node map[string]*NodeInfo `json:"node"`
 	Port map[string]int32     `json:"port"`
+	// Zone is the zone each mon is assigned to when the mons are spread across zones
+	Zone map[string]string `json:"zone,omitempty"`
 }
 
 // NodeInfo contains name and address of a node
@@ -146,6 +148,7 @@ func New(context *clusterd.Context, namespace, dataDirHostPath string, hostNetwo
 		mapping: &Mapping{
 			Node: map[string]*NodeInfo{},
 			Port: map[string]int32{},
+			Zone: map[string]string{},
 		},
 		ownerRef: ownerRef,
 	}
@@ -167,6 +170,18 @@ func (c *Cluster) Start(clusterInfo *cephconfig.ClusterInfo, rookVersion string,
 		return nil, fmt.Errorf("refusing to deploy %d monitors on the same host since hostNetwork is %v and allowMultiplePerNode is %v. Only one monitor per node is allowed", c.spec.Mon.Count, c.HostNetwork, c.spec.Mon.AllowMultiplePerNode)
 	}
 
+	// the mons on pvcs are not pinned to a node, so the node address can't be their address
+	if c.HostNetwork && c.spec.Mon.VolumeClaimTemplate != nil {
+		return nil, fmt.Errorf("refusing to deploy monitors on pvcs since hostNetwork is %v. The monitors must run on the DataDirHostPath with host networking", c.HostNetwork)
+	}
+
+	// the mons of a stretch cluster are pinned to the two data zones and the arbiter zone
+	if c.stretchCluster() {
+		if err := validateStretchCluster(c.spec.Mon); err != nil {
+			return nil, fmt.Errorf("invalid stretch cluster. %+v", err)
+		}
+	}
+
 	// Validate pod's memory if specified
 	err := opspec.CheckPodMemory(cephv1.GetMonResources(c.spec.Resources), cephMonPodMinimumMemory)
 	if err != nil {
@@ -195,7 +210,7 @@ func (c *Cluster) startMons(targetCount int) error {
 	existingCount, mons := c.initMonConfig(targetCount)
 
 	// Assign the mons to nodes
-	if err := c.assignMons(mons); err != nil {
+	if err := c.assignMons(mons, ""); err != nil {
 		return fmt.Errorf("failed to assign pods to mons. %+v", err)
 	}
 
@@ -377,13 +392,27 @@ func (c *Cluster) initMonIPs(mons []*monConfig) error {
 	return nil
 }
 
-func (c *Cluster) assignMons(mons []*monConfig) error {
+// assignMons assigns the new mons to nodes, leaving out the zone of the mon that is being replaced
+// when the mons are spread across zones
+func (c *Cluster) assignMons(mons []*monConfig, replacing string) error {
+	// the mons on pvcs are scheduled by kubernetes, only their zone is assigned
+	if c.spec.Mon.VolumeClaimTemplate != nil {
+		return c.assignMonZones(mons, replacing)
+	}
+
 	// schedule the mons on different nodes if we have enough nodes to be unique
 	availableNodes, err := c.getMonNodes()
 	if err != nil {
 		return fmt.Errorf("failed to get available nodes for mons. %+v", err)
 	}
 
+	var zoneCounts map[string]int
+	if c.zoneSpread() {
+		if zoneCounts, err = c.monZoneCounts(replacing); err != nil {
+			return err
+		}
+	}
+
 	nodeIndex := 0
 	for _, m := range mons {
 		if _, ok := c.mapping.Node[m.DaemonName]; ok {
@@ -398,6 +427,11 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 
 		// pick one of the available nodes where the mon will be assigned
 		node := availableNodes[nodeIndex%len(availableNodes)]
+		if zoneCounts != nil {
+			if node, availableNodes, err = c.assignNodeInZone(m, availableNodes, zoneCounts); err != nil {
+				return err
+			}
+		}
 		logger.Debugf("mon %s assigned to node %s", m.DaemonName, node.Name)
 		nodeInfo, err := getNodeInfoFromNode(node)
 		if err != nil {
@@ -418,8 +452,12 @@ func (c *Cluster) startDeployments(mons []*monConfig, requireAllInQuorum bool) e
 
 	// Ensure each of the mons have been created. If already created, it will be a no-op.
 	for i := 0; i < len(mons); i++ {
-		node, _ := c.mapping.Node[mons[i].DaemonName]
-		err := c.startMon(mons[i], node.Hostname)
+		// the mons on pvcs are not assigned to a node
+		hostname := ""
+		if node, ok := c.mapping.Node[mons[i].DaemonName]; ok {
+			hostname = node.Hostname
+		}
+		err := c.startMon(mons[i], hostname)
 		if err != nil {
 			return fmt.Errorf("failed to create mon %s. %+v", mons[i].DaemonName, err)
 		}
@@ -484,7 +522,7 @@ func (c *Cluster) saveMonConfig() error {
 
 	// Every time the mon config is updated, must also update the global config so that all daemons
 	// have the most updated version if they restart.
-	config.GetStore(c.context, c.Namespace, &c.ownerRef).CreateOrUpdate(c.clusterInfo)
+	config.GetStore(c.context, c.Namespace, &c.ownerRef).CreateOrUpdate(c.clusterInfo, c.spec.CephConfig)
 
 	// write the latest config to the config dir
 	if err := writeConnectionConfig(c.context, c.clusterInfo); err != nil {
@@ -497,6 +535,12 @@ func (c *Cluster) saveMonConfig() error {
 var updateDeploymentAndWait = k8sutil.UpdateDeploymentAndWait
 
 func (c *Cluster) startMon(m *monConfig, hostname string) error {
+	if c.monOnPVC(m.DaemonName) {
+		if err := c.createMonPVC(m); err != nil {
+			return err
+		}
+	}
+
 	d := c.makeDeployment(m, hostname)
 	logger.Debugf("Starting mon: %+v", d.Name)
 	_, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Create(d)
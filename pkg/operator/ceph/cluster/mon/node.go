@@ -91,7 +91,11 @@ func (c *Cluster) getNodesWithMons(nodes *v1.NodeList) (*util.Set, error) {
 	}
 	nodesInUse := util.NewSet()
 	for _, pod := range pods.Items {
-		hostname := pod.Spec.NodeSelector[v1.LabelHostname]
+		hostname, ok := pod.Spec.NodeSelector[v1.LabelHostname]
+		if !ok {
+			// the mons on pvcs are not assigned to a node
+			continue
+		}
 		logger.Debugf("mon pod on node %s", hostname)
 		name, ok := getNodeNameFromHostname(nodes, hostname)
 		if !ok {
@@ -129,6 +133,11 @@ func (c *Cluster) getTargetMonCount() (int, string, error) {
 }
 
 func calcTargetMonCount(nodes int, spec cephv1.MonSpec) (int, string) {
+	if spec.StretchCluster != nil {
+		msg := fmt.Sprintf("targeting the mon count %d of the stretch cluster", stretchMonCount)
+		return stretchMonCount, msg
+	}
+
 	minTarget := spec.Count
 	preferredTarget := spec.PreferredCount
 
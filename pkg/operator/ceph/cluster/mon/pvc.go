@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// monOnPVC returns whether the mon stores its data on a pvc. The mons that were assigned to a node before
+// the volume claim template was set keep their data on the DataDirHostPath, since moving them would start
+// them on an empty pvc. Only the new mons, including the mons that replace the failed ones, use pvcs.
+func (c *Cluster) monOnPVC(daemonName string) bool {
+	if c.spec.Mon.VolumeClaimTemplate == nil {
+		return false
+	}
+	_, onNode := c.mapping.Node[daemonName]
+	return !onNode
+}
+
+// createMonPVC creates the pvc the mon stores its data on from the volume claim template of the mons.
+// The pvc has the name of the mon resources and is kept when the mon restarts.
+func (c *Cluster) createMonPVC(m *monConfig) error {
+	template := c.spec.Mon.VolumeClaimTemplate
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        m.ResourceName,
+			Namespace:   c.Namespace,
+			Annotations: template.Annotations,
+			Labels:      c.getLabels(m.DaemonName),
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &pvc.ObjectMeta, &c.ownerRef)
+
+	if _, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(pvc); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create pvc %s for mon %s. %+v", pvc.Name, m.DaemonName, err)
+		}
+		return nil
+	}
+	logger.Infof("created pvc %s for mon %s", pvc.Name, m.DaemonName)
+	return nil
+}
+
+// deleteMonPVC deletes the pvc of a mon that was removed. A new mon never reuses the pvc of another mon.
+func (c *Cluster) deleteMonPVC(daemonName string) error {
+	name := resourceName(daemonName)
+	if err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(name, &metav1.DeleteOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete pvc %s of mon %s. %+v", name, daemonName, err)
+	}
+	logger.Infof("deleted pvc %s of mon %s", name, daemonName)
+	return nil
+}
+
+// monZoneSelector returns the node selector that keeps a mon on a pvc in its zone, or nil if the mons
+// are not spread across zones
+func (c *Cluster) monZoneSelector(daemonName string) map[string]string {
+	zone, ok := c.mapping.Zone[daemonName]
+	if !c.zoneSpread() || !ok {
+		return nil
+	}
+	return map[string]string{c.zoneKey(): zone}
+}
@@ -18,6 +18,7 @@ package mon
 
 import (
 	"os"
+	"strconv"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/config"
@@ -97,6 +98,11 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, hostname string) *v1.Pod {
 		Volumes:       opspec.DaemonVolumes(monConfig.DataPathMap, keyringStoreName),
 		HostNetwork:   c.HostNetwork,
 	}
+	if c.monOnPVC(monConfig.DaemonName) {
+		// the mon follows its pvc, within its zone if the mons are spread across zones
+		podSpec.NodeSelector = c.monZoneSelector(monConfig.DaemonName)
+		podSpec.Volumes = opspec.DaemonVolumesPVC(monConfig.DataPathMap, keyringStoreName, monConfig.ResourceName)
+	}
 	if c.HostNetwork {
 		podSpec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
@@ -107,6 +113,9 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, hostname string) *v1.Pod {
 	p.PodAffinity = nil
 	p.PodAntiAffinity = nil
 	p.ApplyToPodSpec(&podSpec)
+	if c.monOnPVC(monConfig.DaemonName) && !c.spec.Mon.AllowMultiplePerNode {
+		podSpec.Affinity.PodAntiAffinity = c.monAntiAffinity()
+	}
 
 	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -121,6 +130,28 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, hostname string) *v1.Pod {
 	return pod
 }
 
+// monAntiAffinity keeps the mons on pvcs on different nodes, which the operator does itself for the
+// mons on the DataDirHostPath
+func (c *Cluster) monAntiAffinity() *v1.PodAntiAffinity {
+	return &v1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
+			{
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: opspec.AppLabels(appName, c.Namespace),
+				},
+				TopologyKey: v1.LabelHostname,
+			},
+		},
+	}
+}
+
+func (c *Cluster) monVolumeMounts(monConfig *monConfig) []v1.VolumeMount {
+	if c.monOnPVC(monConfig.DaemonName) {
+		return opspec.DaemonVolumeMountsPVC(monConfig.DataPathMap, keyringStoreName)
+	}
+	return opspec.DaemonVolumeMounts(monConfig.DataPathMap, keyringStoreName)
+}
+
 /*
  * Container specs
  */
@@ -148,7 +179,7 @@ func (c *Cluster) makeMonFSInitContainer(monConfig *monConfig) v1.Container {
 			"--mkfs",
 		),
 		Image:           c.spec.CephVersion.Image,
-		VolumeMounts:    opspec.DaemonVolumeMounts(monConfig.DataPathMap, keyringStoreName),
+		VolumeMounts:    c.monVolumeMounts(monConfig),
 		SecurityContext: podSecurityContext(),
 		// filesystem creation does not require ports to be exposed
 		Env:       opspec.DaemonEnvVars(c.spec.CephVersion.Image),
@@ -174,7 +205,7 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 			config.NewFlag("public-bind-addr", opspec.ContainerEnvVarReference(podIPEnvVar)),
 		),
 		Image:           c.spec.CephVersion.Image,
-		VolumeMounts:    opspec.DaemonVolumeMounts(monConfig.DataPathMap, keyringStoreName),
+		VolumeMounts:    c.monVolumeMounts(monConfig),
 		SecurityContext: podSecurityContext(),
 		Ports: []v1.ContainerPort{
 			{
@@ -193,6 +224,11 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 	// If deploying Nautilus and newer we need a new port of the monitor container
 	if c.clusterInfo.CephVersion.IsAtLeastNautilus() {
 		addContainerPort(container, "msgr2", 3300)
+
+		// As of Nautilus the mon sizes its rocksdb cache to keep its memory below the memory target
+		if target := opspec.MemoryTarget(container.Resources, c.spec.MemoryHeadroomRatio); target > 0 {
+			container.Args = append(container.Args, config.NewFlag("mon-memory-target", strconv.FormatUint(target, 10)))
+		}
 	}
 
 	return container
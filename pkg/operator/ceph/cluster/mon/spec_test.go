@@ -23,6 +23,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	cephtest "github.com/rook/rook/pkg/operator/ceph/test"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	testop "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -70,3 +71,23 @@ func testPodSpec(t *testing.T, monID string) {
 	podTemplate.RunFullSuite(config.MonType, monID, appName, "ns", "ceph/ceph:myceph",
 		"200", "100", "1337", "500" /* resources */)
 }
+
+func TestMonMemoryTarget(t *testing.T) {
+	c := New(&clusterd.Context{Clientset: testop.New(1), ConfigDir: "/var/lib/rook"}, "ns", "/var/lib/rook", false, metav1.OwnerReference{})
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "rook/rook:myversion")
+	c.spec.Resources = map[string]v1.ResourceRequirements{
+		"mon": {Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}},
+	}
+	c.spec.MemoryHeadroomRatio = "0.5"
+
+	// the memory target of the mons is only known as of nautilus
+	c.clusterInfo.CephVersion = cephver.Mimic
+	container := c.makeMonDaemonContainer(testGenMonConfig("a"))
+	for _, arg := range container.Args {
+		assert.NotContains(t, arg, "mon-memory-target")
+	}
+
+	c.clusterInfo.CephVersion = cephver.Nautilus
+	container = c.makeMonDaemonContainer(testGenMonConfig("a"))
+	assert.Contains(t, container.Args, "--mon-memory-target=536870912")
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"sort"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// a stretch cluster runs two mons in each data zone and the tiebreaker mon in the arbiter zone, so
+	// that the mons of one data zone and the arbiter keep the quorum when the other data zone is lost
+	stretchMonCount         = 5
+	stretchDataZoneMonCount = 2
+	stretchArbiterMonCount  = 1
+
+	// the zone label the osds are placed in the zone buckets of the crush map with, besides the
+	// legacy zone label of kubernetes
+	topologyLabelZone = "topology.kubernetes.io/zone"
+)
+
+// stretchCluster returns true if the cluster is stretched across two data zones and an arbiter zone
+func (c *Cluster) stretchCluster() bool {
+	return c.spec.Mon.StretchCluster != nil
+}
+
+// validateStretchCluster checks that the stretch cluster has two data zones and an arbiter zone, and that
+// the mons are spread across the zones the stretch crush rule places the replicas in
+func validateStretchCluster(spec cephv1.MonSpec) error {
+	if spec.Count != stretchMonCount {
+		return fmt.Errorf("a stretch cluster runs %d mons instead of %d, two in each data zone and one in the arbiter zone", stretchMonCount, spec.Count)
+	}
+	if key := spec.ZoneTopologyKey; key != "" && key != topologyLabelZone && key != v1.LabelZoneFailureDomain {
+		return fmt.Errorf("the mons of a stretch cluster are spread across the zones of the label %s or %s, not %s", topologyLabelZone, v1.LabelZoneFailureDomain, key)
+	}
+
+	zones := []string{}
+	arbiters := 0
+	for _, zone := range spec.StretchCluster.Zones {
+		if zone.Name == "" {
+			return fmt.Errorf("the zones of a stretch cluster must have a name")
+		}
+		if containsZone(zones, zone.Name) {
+			return fmt.Errorf("zone %s of the stretch cluster is listed more than once", zone.Name)
+		}
+		zones = append(zones, zone.Name)
+		if zone.Arbiter {
+			arbiters++
+		}
+	}
+	if len(zones) != 3 || arbiters != 1 {
+		return fmt.Errorf("a stretch cluster has two data zones and one arbiter zone, found %d zones with %d arbiters", len(zones), arbiters)
+	}
+	return nil
+}
+
+// stretchZoneMonCount returns the number of mons the stretch cluster runs in the zone
+func (c *Cluster) stretchZoneMonCount(zone string) int {
+	for _, z := range c.spec.Mon.StretchCluster.Zones {
+		if z.Name == zone {
+			if z.Arbiter {
+				return stretchArbiterMonCount
+			}
+			return stretchDataZoneMonCount
+		}
+	}
+	return 0
+}
+
+// stretchMonZone returns the zone of the stretch cluster with the fewest mons among the given zones that
+// run fewer mons than they should. A mon is never placed in another zone than the zones that are missing
+// a mon, so a data zone does not hold a majority of the mons while the other data zone is lost.
+func (c *Cluster) stretchMonZone(counts map[string]int, zones []string) (string, bool) {
+	missing := []string{}
+	for _, z := range c.spec.Mon.StretchCluster.Zones {
+		if containsZone(zones, z.Name) && counts[z.Name] < c.stretchZoneMonCount(z.Name) {
+			missing = append(missing, z.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return "", false
+	}
+	least := missing[0]
+	for _, zone := range missing[1:] {
+		if counts[zone] < counts[least] {
+			least = zone
+		}
+	}
+	return least, true
+}
+
+// nextMonZone returns the zone a new mon is placed in among the given zones
+func (c *Cluster) nextMonZone(counts map[string]int, zones []string) (string, bool) {
+	if c.stretchCluster() {
+		return c.stretchMonZone(counts, zones)
+	}
+	return leastUsedZone(counts, zones)
+}
+
+// checkStretchZoneSpread fails over a mon of a zone that runs more mons than the stretch cluster places
+// in the zone when a zone with too few mons has nodes available, for example when a data zone that was
+// lost is back
+func (c *Cluster) checkStretchZoneSpread(desiredMonCount int, counts map[string]int, zones []string) (bool, error) {
+	missing, ok := c.stretchMonZone(counts, zones)
+	if !ok {
+		return false, nil
+	}
+
+	crowdedZones := []string{}
+	for zone, count := range counts {
+		if count > c.stretchZoneMonCount(zone) {
+			crowdedZones = append(crowdedZones, zone)
+		}
+	}
+	if len(crowdedZones) == 0 {
+		// the mon that is missing is added by the health check
+		return false, nil
+	}
+	sort.Strings(crowdedZones)
+
+	crowded := crowdedZones[0]
+	logger.Infof("rebalance: zone %s has %d mons and zone %s is missing a mon of the stretch cluster", crowded, counts[crowded], missing)
+	return true, c.failoverMonInZone(crowded, desiredMonCount)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateStretchCluster(t *testing.T) {
+	spec := stretchMonSpec()
+	assert.Nil(t, validateStretchCluster(spec))
+
+	// the mons of each data zone and the arbiter must keep the quorum
+	spec.Count = 3
+	assert.NotNil(t, validateStretchCluster(spec))
+
+	// the mons must be spread across the zones of the crush map
+	spec = stretchMonSpec()
+	spec.ZoneTopologyKey = "topology.rook.io/rack"
+	assert.NotNil(t, validateStretchCluster(spec))
+
+	// exactly one of the three zones is the arbiter
+	spec = stretchMonSpec()
+	spec.StretchCluster.Zones[2].Arbiter = false
+	assert.NotNil(t, validateStretchCluster(spec))
+	spec = stretchMonSpec()
+	spec.StretchCluster.Zones = spec.StretchCluster.Zones[1:]
+	assert.NotNil(t, validateStretchCluster(spec))
+	spec = stretchMonSpec()
+	spec.StretchCluster.Zones[1].Name = "z1"
+	assert.NotNil(t, validateStretchCluster(spec))
+}
+
+func TestAssignStretchMons(t *testing.T) {
+	clientset := test.New(6)
+	setNodeZones(t, clientset, "z1", "z1", "z2", "z2", "arbiter", "z3")
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", false, metav1.OwnerReference{})
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 5}, "myversion")
+	c.spec.Mon = stretchMonSpec()
+
+	// two mons are placed in each data zone and the tiebreaker in the arbiter zone
+	mons := []*monConfig{testGenMonConfig("a"), testGenMonConfig("b"), testGenMonConfig("c"), testGenMonConfig("d"), testGenMonConfig("e")}
+	require.Nil(t, c.assignMons(mons, ""))
+	assert.Equal(t, map[string]string{"a": "z1", "b": "z2", "c": "arbiter", "d": "z1", "e": "z2"}, c.mapping.Zone)
+	assert.Equal(t, "node4", c.mapping.Node["c"].Name)
+
+	// the mon of a lost data zone is not moved to another zone
+	for _, m := range mons {
+		c.clusterInfo.Monitors[m.DaemonName] = cephconfig.NewMonInfo(m.DaemonName, "1.2.3.4", DefaultMsgr1Port)
+	}
+	for _, name := range []string{"node0", "node1"} {
+		node, err := clientset.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		require.Nil(t, err)
+		node.Status.Conditions[0].Status = v1.ConditionFalse
+		_, err = clientset.CoreV1().Nodes().Update(node)
+		require.Nil(t, err)
+	}
+	assert.NotNil(t, c.assignMons([]*monConfig{testGenMonConfig("f")}, "a"))
+	assert.Equal(t, "", c.mapping.Zone["f"])
+}
+
+func TestCheckStretchZoneSpread(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			return "", nil
+		},
+	}
+	clientset := test.New(6)
+	setNodeZones(t, clientset, "z1", "z1", "z2", "z2", "arbiter", "z3")
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: clientset, ConfigDir: configDir, Executor: executor}
+	c := New(context, "ns", "", false, metav1.OwnerReference{})
+	setCommonMonProperties(c, 5, cephv1.MonSpec{Count: 5}, "myversion")
+	c.spec.Mon = stretchMonSpec()
+	c.waitForStart = false
+	c.maxMonID = 4
+
+	// a mon was placed in a zone that is not part of the stretch cluster while zone z1 was lost
+	c.mapping.Node["a"] = &NodeInfo{Name: "node0", Hostname: "node0", Address: "0.0.0.0"}
+	c.mapping.Node["b"] = &NodeInfo{Name: "node2", Hostname: "node2", Address: "2.2.2.2"}
+	c.mapping.Node["c"] = &NodeInfo{Name: "node4", Hostname: "node4", Address: "4.4.4.4"}
+	c.mapping.Node["d"] = &NodeInfo{Name: "node5", Hostname: "node5", Address: "5.5.5.5"}
+	c.mapping.Node["e"] = &NodeInfo{Name: "node3", Hostname: "node3", Address: "3.3.3.3"}
+
+	// the mon is moved back to zone z1
+	done, err := c.checkMonsZoneSpread(5)
+	assert.True(t, done)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.NotNil(t, c.clusterInfo.Monitors["f"])
+	assert.Equal(t, "z1", c.mapping.Zone["f"])
+
+	// the mons are where they belong
+	done, err = c.checkMonsZoneSpread(5)
+	assert.False(t, done)
+	assert.Nil(t, err)
+}
+
+func TestStretchMonCount(t *testing.T) {
+	target, _ := calcTargetMonCount(3, stretchMonSpec())
+	assert.Equal(t, 5, target)
+}
+
+func stretchMonSpec() cephv1.MonSpec {
+	return cephv1.MonSpec{
+		Count: 5,
+		StretchCluster: &cephv1.StretchClusterSpec{
+			Zones: []cephv1.StretchClusterZoneSpec{{Name: "z1"}, {Name: "z2"}, {Name: "arbiter", Arbiter: true}},
+		},
+	}
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"sort"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// zoneSpread returns true if the mons are spread across the zones of the zone topology key
+func (c *Cluster) zoneSpread() bool {
+	return c.zoneKey() != ""
+}
+
+// zoneKey returns the node label of the zones the mons are spread across. The mons of a stretch cluster
+// are spread across the zones of the kubernetes zone label unless another zone label is set.
+func (c *Cluster) zoneKey() string {
+	if c.spec.Mon.ZoneTopologyKey == "" && c.stretchCluster() {
+		return v1.LabelZoneFailureDomain
+	}
+	return c.spec.Mon.ZoneTopologyKey
+}
+
+// nodeZone returns the zone of the node, or an empty string if the node has no zone label
+func (c *Cluster) nodeZone(node v1.Node) string {
+	return node.Labels[c.zoneKey()]
+}
+
+// monZoneCounts returns the number of mons in each zone, leaving out the mon that is being replaced.
+// The zone of the mons that were assigned to a node before the mons were spread across zones is
+// taken from the labels of their node.
+func (c *Cluster) monZoneCounts(replacing string) (map[string]int, error) {
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes. %+v", err)
+	}
+
+	counts := map[string]int{}
+	for name := range c.clusterInfo.Monitors {
+		if name == replacing {
+			continue
+		}
+		zone := c.monZone(name, nodes)
+		if zone == "" {
+			logger.Warningf("zone of mon %s is unknown", name)
+			continue
+		}
+		counts[zone]++
+	}
+	return counts, nil
+}
+
+// monZone returns the zone the mon was assigned to
+func (c *Cluster) monZone(name string, nodes *v1.NodeList) string {
+	if zone, ok := c.mapping.Zone[name]; ok {
+		return zone
+	}
+	if nodeInfo, ok := c.mapping.Node[name]; ok && nodeInfo != nil {
+		for _, node := range nodes.Items {
+			if node.Name == nodeInfo.Name {
+				return c.nodeZone(node)
+			}
+		}
+	}
+	return ""
+}
+
+// setMonZone records the zone the mon is assigned to
+func (c *Cluster) setMonZone(name, zone string) {
+	if c.mapping.Zone == nil {
+		c.mapping.Zone = map[string]string{}
+	}
+	c.mapping.Zone[name] = zone
+}
+
+// nodeZones returns the sorted zones of the nodes
+func (c *Cluster) nodeZones(nodes []v1.Node) []string {
+	zones := []string{}
+	for _, node := range nodes {
+		zone := c.nodeZone(node)
+		if zone != "" && !containsZone(zones, zone) {
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// leastUsedZone returns the zone with the fewest mons among the given sorted zones
+func leastUsedZone(counts map[string]int, zones []string) (string, bool) {
+	if len(zones) == 0 {
+		return "", false
+	}
+	least := zones[0]
+	for _, zone := range zones[1:] {
+		if counts[zone] < counts[least] {
+			least = zone
+		}
+	}
+	return least, true
+}
+
+// mostUsedZone returns the zone with the most mons
+func mostUsedZone(counts map[string]int) string {
+	zones := []string{}
+	for zone := range counts {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	most := ""
+	for _, zone := range zones {
+		if most == "" || counts[zone] > counts[most] {
+			most = zone
+		}
+	}
+	return most
+}
+
+// assignNodeInZone picks a node for a new mon in the zone with the fewest mons. The node is removed
+// from the available nodes unless multiple mons are allowed per node.
+func (c *Cluster) assignNodeInZone(m *monConfig, availableNodes []v1.Node, counts map[string]int) (v1.Node, []v1.Node, error) {
+	zone, ok := c.nextMonZone(counts, c.nodeZones(availableNodes))
+	if !ok {
+		return v1.Node{}, nil, fmt.Errorf("no nodes with the zone label %s available for mon placement", c.zoneKey())
+	}
+
+	for i, node := range availableNodes {
+		if c.nodeZone(node) != zone {
+			continue
+		}
+		if !c.spec.Mon.AllowMultiplePerNode {
+			availableNodes = append(availableNodes[:i:i], availableNodes[i+1:]...)
+		}
+		logger.Infof("mon %s assigned to zone %s with %d other mons", m.DaemonName, zone, counts[zone])
+		counts[zone]++
+		c.setMonZone(m.DaemonName, zone)
+		return node, availableNodes, nil
+	}
+	return v1.Node{}, nil, fmt.Errorf("no node available in zone %s", zone)
+}
+
+// assignMonZones assigns the mons on pvcs to the zones with the fewest mons. Kubernetes schedules the
+// mons on a node of their zone, where their pvc is bound.
+func (c *Cluster) assignMonZones(mons []*monConfig, replacing string) error {
+	if !c.zoneSpread() {
+		return nil
+	}
+
+	counts, err := c.monZoneCounts(replacing)
+	if err != nil {
+		return err
+	}
+	zones, err := c.availableMonZones()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mons {
+		if _, ok := c.mapping.Zone[m.DaemonName]; ok {
+			logger.Debugf("mon %s already assigned to a zone, no need to assign", m.DaemonName)
+			continue
+		}
+		zone, ok := c.nextMonZone(counts, zones)
+		if !ok {
+			return fmt.Errorf("no nodes with the zone label %s available for mon placement", c.zoneKey())
+		}
+		logger.Infof("mon %s assigned to zone %s with %d other mons", m.DaemonName, zone, counts[zone])
+		counts[zone]++
+		c.setMonZone(m.DaemonName, zone)
+	}
+	return nil
+}
+
+// availableMonZones returns the zones where a new mon can be placed
+func (c *Cluster) availableMonZones() ([]string, error) {
+	if c.spec.Mon.VolumeClaimTemplate == nil {
+		availableNodes, err := c.getMonNodes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get available nodes for mons. %+v", err)
+		}
+		return c.nodeZones(availableNodes), nil
+	}
+
+	// any valid node can run a mon on a pvc
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes. %+v", err)
+	}
+	validNodes := []v1.Node{}
+	for _, node := range nodes.Items {
+		valid, err := k8sutil.ValidNode(node, cephv1.GetMonPlacement(c.spec.Placement))
+		if err != nil {
+			logger.Warningf("failed to validate node %s. %+v", node.Name, err)
+		} else if valid {
+			validNodes = append(validNodes, node)
+		}
+	}
+	return c.nodeZones(validNodes), nil
+}
+
+// checkMonsZoneSpread fails over a mon of the zone with the most mons when another zone where a mon
+// can be placed has at least two mons less, for example when a zone that was lost is back
+func (c *Cluster) checkMonsZoneSpread(desiredMonCount int) (bool, error) {
+	counts, err := c.monZoneCounts("")
+	if err != nil {
+		return true, err
+	}
+	zones, err := c.availableMonZones()
+	if err != nil {
+		return true, err
+	}
+	if c.stretchCluster() {
+		return c.checkStretchZoneSpread(desiredMonCount, counts, zones)
+	}
+
+	crowded := mostUsedZone(counts)
+	if crowded != "" && counts[crowded] > len(c.clusterInfo.Monitors)/2 && len(c.clusterInfo.Monitors) > 1 {
+		logger.Warningf("zone %s holds %d of the %d mons", crowded, counts[crowded], len(c.clusterInfo.Monitors))
+	}
+	least, ok := leastUsedZone(counts, zones)
+	if !ok || counts[crowded]-counts[least] < 2 {
+		return false, nil
+	}
+
+	logger.Infof("rebalance: zone %s has %d mons and zone %s has %d", crowded, counts[crowded], least, counts[least])
+	return true, c.failoverMonInZone(crowded, desiredMonCount)
+}
+
+// failoverMonInZone fails over the mon of the zone with the highest name
+func (c *Cluster) failoverMonInZone(zone string, desiredMonCount int) error {
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get nodes. %+v", err)
+	}
+	names := []string{}
+	for name := range c.clusterInfo.Monitors {
+		if c.monZone(name, nodes) == zone {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no mons found in zone %s", zone)
+	}
+	sort.Strings(names)
+	name := names[len(names)-1]
+	logger.Infof("rebalance: failover mon %s of zone %s", name, zone)
+	c.failMon(len(c.clusterInfo.Monitors), desiredMonCount, name)
+	return nil
+}
+
+func containsZone(zones []string, zone string) bool {
+	for _, z := range zones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
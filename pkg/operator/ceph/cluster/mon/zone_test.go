@@ -0,0 +1,172 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func TestAssignMonsAcrossZones(t *testing.T) {
+	clientset := test.New(4)
+	setNodeZones(t, clientset, "z1", "z1", "z2", "z3")
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", false, metav1.OwnerReference{})
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3}, "myversion")
+	c.spec.Mon.ZoneTopologyKey = v1.LabelZoneFailureDomain
+
+	// each mon is placed in another zone
+	mons := []*monConfig{testGenMonConfig("a"), testGenMonConfig("b"), testGenMonConfig("c")}
+	require.Nil(t, c.assignMons(mons, ""))
+	assert.Equal(t, map[string]string{"a": "z1", "b": "z2", "c": "z3"}, c.mapping.Zone)
+	assert.Equal(t, "node2", c.mapping.Node["b"].Name)
+	assert.Equal(t, "node3", c.mapping.Node["c"].Name)
+
+	// the mon that replaces a failed mon goes to the zone of the failed mon
+	for _, m := range mons {
+		c.clusterInfo.Monitors[m.DaemonName] = cephconfig.NewMonInfo(m.DaemonName, "1.2.3.4", DefaultMsgr1Port)
+	}
+	require.Nil(t, c.assignMons([]*monConfig{testGenMonConfig("d")}, "b"))
+	assert.Equal(t, "z2", c.mapping.Zone["d"])
+	assert.Equal(t, "node2", c.mapping.Node["d"].Name)
+
+	// the mons can't be spread without nodes in the zones
+	c.spec.Mon.ZoneTopologyKey = "topology.rook.io/rack"
+	assert.NotNil(t, c.assignMons([]*monConfig{testGenMonConfig("e")}, ""))
+}
+
+func TestCheckMonsZoneSpread(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			return "", nil
+		},
+	}
+	clientset := test.New(4)
+	setNodeZones(t, clientset, "z1", "z1", "z2", "z3")
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: clientset, ConfigDir: configDir, Executor: executor}
+	c := New(context, "ns", "", false, metav1.OwnerReference{})
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3}, "myversion")
+	c.spec.Mon.ZoneTopologyKey = v1.LabelZoneFailureDomain
+	c.waitForStart = false
+	c.maxMonID = 2
+
+	// the mons were placed before the zone of node3 was available
+	c.mapping.Node["a"] = &NodeInfo{Name: "node0", Hostname: "node0", Address: "0.0.0.0"}
+	c.mapping.Node["b"] = &NodeInfo{Name: "node1", Hostname: "node1", Address: "1.1.1.1"}
+	c.mapping.Node["c"] = &NodeInfo{Name: "node2", Hostname: "node2", Address: "2.2.2.2"}
+
+	// a mon of the zone with two mons is moved to the zone without mons
+	done, err := c.checkMonsZoneSpread(3)
+	assert.True(t, done)
+	assert.Nil(t, err)
+	assert.Nil(t, c.mapping.Node["b"])
+	assert.Equal(t, "node3", c.mapping.Node["d"].Name)
+	assert.Equal(t, "z3", c.mapping.Zone["d"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+	assert.Nil(t, c.clusterInfo.Monitors["b"])
+
+	// the mons are spread
+	done, err = c.checkMonsZoneSpread(3)
+	assert.False(t, done)
+	assert.Nil(t, err)
+}
+
+func TestMonsOnPVCs(t *testing.T) {
+	clientset := test.New(3)
+	setNodeZones(t, clientset, "z1", "z2", "z3")
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "/var/lib/rook", false, metav1.OwnerReference{})
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3}, "myversion")
+	c.spec.Mon.ZoneTopologyKey = v1.LabelZoneFailureDomain
+	c.spec.Mon.VolumeClaimTemplate = &v1.PersistentVolumeClaim{
+		Spec: v1.PersistentVolumeClaimSpec{StorageClassName: &[]string{"gp2"}[0]},
+	}
+
+	// the mons on pvcs are only assigned to a zone
+	mons := []*monConfig{testGenMonConfig("a"), testGenMonConfig("b"), testGenMonConfig("c")}
+	require.Nil(t, c.assignMons(mons, ""))
+	assert.Equal(t, map[string]string{"a": "z1", "b": "z2", "c": "z3"}, c.mapping.Zone)
+	assert.Equal(t, 0, len(c.mapping.Node))
+
+	// the mon store is on the pvc of the mon and the mon stays in its zone
+	require.Nil(t, c.startMon(mons[1], ""))
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims("ns").Get("rook-ceph-mon-b", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "gp2", *pvc.Spec.StorageClassName)
+	pod := c.makeMonPod(mons[1], "")
+	assert.Equal(t, map[string]string{v1.LabelZoneFailureDomain: "z2"}, pod.Spec.NodeSelector)
+	assert.Equal(t, v1.LabelHostname, pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey)
+	claim := ""
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil {
+			claim = vol.PersistentVolumeClaim.ClaimName
+		}
+		assert.Nil(t, vol.HostPath, vol.Name)
+	}
+	assert.Equal(t, "rook-ceph-mon-b", claim)
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		if mount.MountPath == mons[1].DataPathMap.ContainerDataDir {
+			assert.Equal(t, "data", mount.SubPath)
+		}
+	}
+
+	// the pvc of a removed mon is deleted
+	assert.Nil(t, c.deleteMonPVC("b"))
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Get("rook-ceph-mon-b", metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// a mon assigned to a node before the template was set keeps its data on the host
+	c.mapping.Node["d"] = &NodeInfo{Name: "node0", Hostname: "node0"}
+	monD := testGenMonConfig("d")
+	require.Nil(t, c.startMon(monD, "node0"))
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Get("rook-ceph-mon-d", metav1.GetOptions{})
+	assert.NotNil(t, err)
+	pod = c.makeMonPod(monD, "node0")
+	assert.Equal(t, map[string]string{v1.LabelHostname: "node0"}, pod.Spec.NodeSelector)
+	for _, vol := range pod.Spec.Volumes {
+		assert.Nil(t, vol.PersistentVolumeClaim, vol.Name)
+	}
+
+	// the mons on pvcs can't use the host network
+	c.HostNetwork = true
+	_, err = c.Start(c.clusterInfo, "myversion", c.clusterInfo.CephVersion, c.spec)
+	assert.NotNil(t, err)
+}
+
+func setNodeZones(t *testing.T, clientset kubernetes.Interface, zones ...string) {
+	for i, zone := range zones {
+		name := fmt.Sprintf("node%d", i)
+		node, err := clientset.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		require.Nil(t, err)
+		node.Labels = map[string]string{v1.LabelZoneFailureDomain: zone, v1.LabelHostname: name}
+		_, err = clientset.CoreV1().Nodes().Update(node)
+		require.Nil(t, err)
+	}
+}
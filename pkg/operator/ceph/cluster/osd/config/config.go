@@ -37,22 +37,37 @@ func GetConfigStoreName(nodeName string) string {
 }
 
 const (
-	StoreTypeKey       = "storeType"
-	WalSizeMBKey       = "walSizeMB"
-	DatabaseSizeMBKey  = "databaseSizeMB"
-	JournalSizeMBKey   = "journalSizeMB"
-	OSDsPerDeviceKey   = "osdsPerDevice"
-	EncryptedDeviceKey = "encryptedDevice"
-	MetadataDeviceKey  = "metadataDevice"
+	StoreTypeKey             = "storeType"
+	WalSizeMBKey             = "walSizeMB"
+	DatabaseSizeMBKey        = "databaseSizeMB"
+	JournalSizeMBKey         = "journalSizeMB"
+	OSDsPerDeviceKey         = "osdsPerDevice"
+	EncryptedDeviceKey       = "encryptedDevice"
+	EncryptionKeyStoreKey    = "encryptionKeyStore"
+	MetadataDeviceKey        = "metadataDevice"
+	DeviceClassKey           = "deviceClass"
+	OSDsPerMetadataDeviceKey = "osdsPerMetadataDevice"
+)
+
+const (
+	// EncryptionKeyStoreMon keeps the dm-crypt keys of encrypted osds in the mon config-key store
+	EncryptionKeyStoreMon = "mon"
+	// EncryptionKeyStoreSecret keeps the dm-crypt key of each encrypted osd in a kubernetes secret
+	EncryptionKeyStoreSecret = "secret"
 )
 
 type StoreConfig struct {
-	StoreType       string `json:"storeType,omitempty"`
-	WalSizeMB       int    `json:"walSizeMB,omitempty"`
-	DatabaseSizeMB  int    `json:"databaseSizeMB,omitempty"`
-	JournalSizeMB   int    `json:"journalSizeMB,omitempty"`
-	OSDsPerDevice   int    `json:"osdsPerDevice,omitempty"`
-	EncryptedDevice bool   `json:"encryptedDevice,omitempty"`
+	StoreType          string `json:"storeType,omitempty"`
+	WalSizeMB          int    `json:"walSizeMB,omitempty"`
+	DatabaseSizeMB     int    `json:"databaseSizeMB,omitempty"`
+	JournalSizeMB      int    `json:"journalSizeMB,omitempty"`
+	OSDsPerDevice      int    `json:"osdsPerDevice,omitempty"`
+	EncryptedDevice    bool   `json:"encryptedDevice,omitempty"`
+	EncryptionKeyStore string `json:"encryptionKeyStore,omitempty"`
+	DeviceClass        string `json:"deviceClass,omitempty"`
+	// OSDsPerMetadataDevice is the maximum number of osds with their db on each metadata device, or
+	// zero to spread the osds evenly across the metadata devices
+	OSDsPerMetadataDevice int `json:"osdsPerMetadataDevice,omitempty"`
 }
 
 func ToStoreConfig(config map[string]string) StoreConfig {
@@ -71,6 +86,12 @@ func ToStoreConfig(config map[string]string) StoreConfig {
 			storeConfig.OSDsPerDevice = convertToIntIgnoreErr(v)
 		case EncryptedDeviceKey:
 			storeConfig.EncryptedDevice = (v == "true")
+		case EncryptionKeyStoreKey:
+			storeConfig.EncryptionKeyStore = v
+		case DeviceClassKey:
+			storeConfig.DeviceClass = v
+		case OSDsPerMetadataDeviceKey:
+			storeConfig.OSDsPerMetadataDevice = convertToIntIgnoreErr(v)
 		}
 	}
 
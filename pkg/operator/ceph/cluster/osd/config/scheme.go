@@ -61,6 +61,7 @@ type PerfSchemeEntry struct {
 	Partitions map[PartitionType]*PerfSchemePartitionDetails `json:"partitions"` // mapping of partition name to its details
 	StoreType  string                                        `json:"storeType,omitempty"`
 	FSCreated  bool                                          `json:"fsCreated"`
+	Encrypted  bool                                          `json:"encrypted,omitempty"` // whether the partitions are encrypted with dm-crypt
 }
 
 // details for 1 OSD partition
@@ -0,0 +1,166 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	encryptionOpenContainerName = "encryption-open"
+	encryptionKeySecretNameFmt  = "rook-ceph-osd-encryption-key-%s"
+	encryptionKeySecretKey      = "dmcrypt-key"
+	// the same key ceph-volume stores the dm-crypt key of an osd under in the config-key store
+	encryptionKeyConfigKeyFmt = "dm-crypt/osd/%s/luks"
+	encryptedPartitionPrefix  = "rook-dmcrypt-"
+)
+
+// EncryptedPartitionMapperName returns the device mapper name under which the encrypted legacy
+// partition with the given partuuid is unlocked
+func EncryptedPartitionMapperName(partUUID string) string {
+	return encryptedPartitionPrefix + partUUID
+}
+
+// EncryptionKeyConfigKey returns the key of the dm-crypt key of the osd in the mon config-key store
+func EncryptionKeyConfigKey(osdUUID string) string {
+	return fmt.Sprintf(encryptionKeyConfigKeyFmt, osdUUID)
+}
+
+func encryptionKeySecretName(osdUUID string) string {
+	return fmt.Sprintf(encryptionKeySecretNameFmt, osdUUID)
+}
+
+// SaveEncryptionKey stores the dm-crypt key of the osd in the given key store, either the mon
+// config-key store or a kubernetes secret owned by the cluster
+func SaveEncryptionKey(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference, keyStore, osdUUID, key string) error {
+	if keyStore != config.EncryptionKeyStoreSecret {
+		return client.ConfigKeySet(context, namespace, EncryptionKeyConfigKey(osdUUID), key)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      encryptionKeySecretName(osdUUID),
+			Namespace: namespace,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     appName,
+				k8sutil.ClusterAttr: namespace,
+			},
+		},
+		Data: map[string][]byte{encryptionKeySecretKey: []byte(key)},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(context.Clientset, namespace, &secret.ObjectMeta, &ownerRef)
+	if _, err := context.Clientset.CoreV1().Secrets(namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create encryption key secret for osd %s. %+v", osdUUID, err)
+		}
+		if _, err := context.Clientset.CoreV1().Secrets(namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update encryption key secret for osd %s. %+v", osdUUID, err)
+		}
+	}
+	logger.Infof("saved the encryption key of osd %s in secret %s", osdUUID, secret.Name)
+	return nil
+}
+
+// GetEncryptionKey returns the dm-crypt key of the osd. The key is looked up in the secret of the
+// osd first and then in the mon config-key store, so the key is found even if the key store was
+// changed after the osd was created.
+func GetEncryptionKey(context *clusterd.Context, namespace, osdUUID string) (string, error) {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(encryptionKeySecretName(osdUUID), metav1.GetOptions{})
+	if err == nil {
+		key, ok := secret.Data[encryptionKeySecretKey]
+		if !ok {
+			return "", fmt.Errorf("secret %s has no %s", secret.Name, encryptionKeySecretKey)
+		}
+		return string(key), nil
+	}
+	if !errors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get encryption key secret for osd %s. %+v", osdUUID, err)
+	}
+
+	key, err := client.ConfigKeyGet(context, namespace, EncryptionKeyConfigKey(osdUUID))
+	if err != nil {
+		return "", fmt.Errorf("encryption key of osd %s not found in a secret or in the config-key store. %+v", osdUUID, err)
+	}
+	return strings.TrimSpace(key), nil
+}
+
+// MoveEncryptionKeyToSecret moves the dm-crypt key of the osd from the mon config-key store, where
+// ceph-volume stores it, to the secret of the osd
+func MoveEncryptionKeyToSecret(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference, osdUUID string) error {
+	configKey := EncryptionKeyConfigKey(osdUUID)
+	exists, err := client.ConfigKeyExists(context, namespace, configKey)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// the key was already moved
+		return nil
+	}
+
+	key, err := client.ConfigKeyGet(context, namespace, configKey)
+	if err != nil {
+		return err
+	}
+	if err := SaveEncryptionKey(context, namespace, ownerRef, config.EncryptionKeyStoreSecret, osdUUID, strings.TrimSpace(key)); err != nil {
+		return err
+	}
+	return client.ConfigKeyRemove(context, namespace, configKey)
+}
+
+// deleteEncryptionKeySecret deletes the secret holding the dm-crypt key of the osd, if any. The key
+// in the mon config-key store is removed by ceph when the osd is destroyed or purged.
+func deleteEncryptionKeySecret(context *clusterd.Context, namespace, osdUUID string) error {
+	if osdUUID == "" {
+		return nil
+	}
+	err := context.Clientset.CoreV1().Secrets(namespace).Delete(encryptionKeySecretName(osdUUID), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete encryption key secret for osd %s. %+v", osdUUID, err)
+	}
+	return nil
+}
+
+// getEncryptionOpenContainer returns the init container that unlocks the encrypted devices of the
+// osd before the osd daemon starts
+func (c *Cluster) getEncryptionOpenContainer(osd OSDInfo, envVars []v1.EnvVar, volumeMounts []v1.VolumeMount, securityContext *v1.SecurityContext) v1.Container {
+	args := []string{"ceph", "osd", "open-encrypted",
+		"--osd-id", fmt.Sprintf("%d", osd.ID),
+		"--osd-uuid", osd.UUID,
+	}
+	if osd.CephVolumeInitiated {
+		args = append(args, "--ceph-volume")
+	}
+
+	return v1.Container{
+		Args:            args,
+		Name:            encryptionOpenContainerName,
+		Image:           k8sutil.MakeRookImage(c.rookVersion),
+		VolumeMounts:    volumeMounts,
+		Env:             envVars,
+		SecurityContext: securityContext,
+	}
+}
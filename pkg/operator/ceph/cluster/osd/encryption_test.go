@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEncryptionKeyStore(t *testing.T) {
+	configKeys := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "config-key" {
+				switch args[1] {
+				case "set":
+					// the value is passed in a file
+					assert.Equal(t, "-i", args[3])
+					configKeys[args[2]] = "monkey"
+				case "get", "exists":
+					if _, ok := configKeys[args[2]]; !ok {
+						return "", errors.NewNotFound(v1.Resource("config-key"), args[2])
+					}
+					return configKeys[args[2]] + "\n", nil
+				case "rm":
+					delete(configKeys, args[2])
+				}
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor}
+
+	// keys in the mon config-key store are stored where ceph-volume stores them
+	err := SaveEncryptionKey(context, "ns", metav1.OwnerReference{}, config.EncryptionKeyStoreMon, "uuid1", "monkey")
+	assert.Nil(t, err)
+	assert.Equal(t, "monkey", configKeys["dm-crypt/osd/uuid1/luks"])
+	key, err := GetEncryptionKey(context, "ns", "uuid1")
+	assert.Nil(t, err)
+	assert.Equal(t, "monkey", key)
+
+	// keys in secrets are found before the config-key store is asked
+	err = SaveEncryptionKey(context, "ns", metav1.OwnerReference{}, config.EncryptionKeyStoreSecret, "uuid2", "secretkey")
+	assert.Nil(t, err)
+	secret, err := context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-osd-encryption-key-uuid2", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "secretkey", string(secret.Data["dmcrypt-key"]))
+	_, ok := configKeys["dm-crypt/osd/uuid2/luks"]
+	assert.False(t, ok)
+	key, err = GetEncryptionKey(context, "ns", "uuid2")
+	assert.Nil(t, err)
+	assert.Equal(t, "secretkey", key)
+
+	// moving the key of a ceph-volume osd to its secret removes it from the config-key store
+	err = MoveEncryptionKeyToSecret(context, "ns", metav1.OwnerReference{}, "uuid1")
+	assert.Nil(t, err)
+	_, ok = configKeys["dm-crypt/osd/uuid1/luks"]
+	assert.False(t, ok)
+	secret, err = context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-osd-encryption-key-uuid1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "monkey", string(secret.Data["dmcrypt-key"]))
+
+	// the key is not found once the secret is deleted
+	err = deleteEncryptionKeySecret(context, "ns", "uuid1")
+	assert.Nil(t, err)
+	_, err = GetEncryptionKey(context, "ns", "uuid1")
+	assert.NotNil(t, err)
+	err = deleteEncryptionKeySecret(context, "ns", "uuid1")
+	assert.Nil(t, err)
+}
+
+func TestEncryptedOSDDeployment(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "/var/lib/rook", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+	storeConfig := config.StoreConfig{EncryptedDevice: true, EncryptionKeyStore: config.EncryptionKeyStoreSecret}
+
+	// an osd prepared by ceph-volume is activated by rook on the volumes unlocked by the init container
+	osd := OSDInfo{ID: 1, UUID: "uuid1", CephVolumeInitiated: true, Encrypted: true}
+	deployment, err := c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, storeConfig, "", "", osd)
+	assert.Nil(t, err)
+	spec := deployment.Spec.Template.Spec
+	assert.True(t, spec.HostIPC)
+	assert.Equal(t, 3, len(spec.InitContainers))
+	initCont := spec.InitContainers[2]
+	assert.Equal(t, encryptionOpenContainerName, initCont.Name)
+	assert.Equal(t, "ceph osd open-encrypted --osd-id 1 --osd-uuid uuid1 --ceph-volume", strings.Join(initCont.Args, " "))
+	assert.True(t, *initCont.SecurityContext.Privileged)
+	verifyEnvVar(t, initCont.Env, encryptionStoreEnvVarName, config.EncryptionKeyStoreSecret, true)
+	mounts := map[string]string{}
+	for _, m := range initCont.VolumeMounts {
+		mounts[m.Name] = m.MountPath
+	}
+	assert.Equal(t, "/dev", mounts["devices"])
+	assert.Equal(t, "/run/udev", mounts["run-udev"])
+	verifyEnvVar(t, spec.Containers[0].Env, "ROOK_OSD_ENCRYPTED", "true", true)
+
+	// a legacy filestore osd is mounted from the unlocked partition
+	osd = OSDInfo{ID: 2, UUID: "uuid2", IsFileStore: true, DevicePartUUID: "part2", Encrypted: true}
+	deployment, err = c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, storeConfig, "", "", osd)
+	assert.Nil(t, err)
+	spec = deployment.Spec.Template.Spec
+	assert.Equal(t, 3, len(spec.InitContainers))
+	assert.Equal(t, "ceph osd open-encrypted --osd-id 2 --osd-uuid uuid2", strings.Join(spec.InitContainers[2].Args, " "))
+	assert.Contains(t, strings.Join(spec.Containers[0].Args, " "), "--source-path /dev/mapper/rook-dmcrypt-part2")
+
+	// osds that are not encrypted are not unlocked
+	osd = OSDInfo{ID: 3, UUID: "uuid3", CephVolumeInitiated: true}
+	deployment, err = c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "", osd)
+	assert.Nil(t, err)
+	spec = deployment.Spec.Template.Spec
+	assert.False(t, spec.HostIPC)
+	assert.Equal(t, 2, len(spec.InitContainers))
+	verifyEnvVar(t, spec.Containers[0].Env, "ROOK_OSD_ENCRYPTED", "", false)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package osd for the Ceph OSDs.
+package osd
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	migrateOSDsEnvVarName = "ROOK_MIGRATE_OSDS"
+	migrationNameFmt      = "migrate-osd-%d"
+)
+
+// legacyOSD is an osd provisioned on partitions before ceph-volume was supported
+type legacyOSD struct {
+	id     int
+	node   string
+	device string
+}
+
+// migrateNextOSD starts the migration to ceph-volume of the legacy osd with the lowest id, unless
+// another migration is still in progress. The replacement controller destroys the osd when the
+// cluster is clean and the prepare job of its node wipes the device and prepares it again with
+// ceph-volume, keeping the id and thus the crush location of the osd.
+func (c *Cluster) migrateNextOSD(nodes []rookalpha.Node) error {
+	if !c.clusterInfo.CephVersion.IsAtLeastMimic() {
+		logger.Warningf("the osds cannot be migrated to ceph-volume before mimic")
+		return nil
+	}
+
+	list, err := c.context.RookClientset.CephV1().CephOSDReplacements(c.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list osd replacements. %+v", err)
+	}
+	for _, r := range list.Items {
+		if !r.Spec.Migrate || r.Status.IsConditionTrue(cephv1.ConditionOSDReplaced) {
+			continue
+		}
+		if cond := r.Status.GetCondition(cephv1.ConditionOSDDestroyed); cond != nil && cond.Reason == cephv1.ReasonOSDDestroyFailed {
+			logger.Warningf("migration of osd.%d to ceph-volume failed. delete the osd replacement %s to retry. %s", r.Spec.OSDID, r.Name, cond.Message)
+		} else {
+			logger.Infof("migration of osd.%d to ceph-volume is in progress", r.Spec.OSDID)
+		}
+		return nil
+	}
+
+	osd, err := c.nextLegacyOSD(nodes)
+	if err != nil {
+		return err
+	}
+	if osd == nil {
+		logger.Infof("no legacy osds left to migrate to ceph-volume")
+		return nil
+	}
+
+	r := &cephv1.CephOSDReplacement{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf(migrationNameFmt, osd.id), Namespace: c.Namespace},
+		Spec:       cephv1.OSDReplacementSpec{OSDID: osd.id, DevicePath: path.Join("/dev", osd.device), Migrate: true},
+	}
+	logger.Infof("migrating osd.%d on device %s of node %s to ceph-volume", osd.id, osd.device, osd.node)
+	if _, err := c.context.RookClientset.CephV1().CephOSDReplacements(c.Namespace).Create(r); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create osd replacement %s. %+v", r.Name, err)
+	}
+	return nil
+}
+
+// nextLegacyOSD returns the legacy osd with the lowest id among the nodes, or nil if there is none left.
+// Only the osds with all their partitions on their own device can be migrated. The osds on directories
+// are out of scope since ceph-volume only prepares osds on devices, they are only logged.
+func (c *Cluster) nextLegacyOSD(nodes []rookalpha.Node) (*legacyOSD, error) {
+	var next *legacyOSD
+	for _, node := range nodes {
+		if n := c.DesiredStorage.ResolveNode(node.Name); n != nil && len(n.Directories) > 0 {
+			logger.Infof("not migrating the osds on the directories of node %s, ceph-volume only prepares osds on devices", node.Name)
+		}
+
+		scheme, err := osdconfig.LoadScheme(c.kv, osdconfig.GetConfigStoreName(node.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the partition scheme of node %s. %+v", node.Name, err)
+		}
+
+		for _, entry := range scheme.Entries {
+			if next != nil && next.id < entry.ID {
+				continue
+			}
+			if !entry.IsCollocated() {
+				logger.Infof("not migrating osd.%d with partitions on the metadata device", entry.ID)
+				continue
+			}
+			if entry.Encrypted {
+				logger.Infof("not migrating encrypted osd.%d", entry.ID)
+				continue
+			}
+			data, ok := entry.Partitions[entry.GetDataPartitionType()]
+			if !ok || data == nil {
+				continue
+			}
+			next = &legacyOSD{id: entry.ID, node: node.Name, device: data.Device}
+		}
+	}
+	return next, nil
+}
+
+// migrateOSDsEnvVar tells the prepare job which devices to wipe and prepare again with ceph-volume and
+// the ids of their destroyed osds
+func migrateOSDsEnvVar(replacements []cephv1.CephOSDReplacement) v1.EnvVar {
+	return v1.EnvVar{Name: migrateOSDsEnvVarName, Value: deviceOSDPairs(replacements, true)}
+}
+
+// waitForCleanCluster waits until all the placement groups are clean, which paces the migrations, or
+// until the stop channel is closed
+func waitForCleanCluster(context *clusterd.Context, namespace string, stopCh <-chan struct{}) error {
+	return util.RetryWithStop(3000, 15*time.Second, stopCh, func() error {
+		return client.IsClusterClean(context, namespace)
+	})
+}
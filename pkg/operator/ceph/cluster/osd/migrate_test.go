@@ -0,0 +1,132 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package osd for the Ceph OSDs.
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMigrateNextOSD(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "status" {
+				return `{"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+			}
+			if args[0] == "pg" && args[1] == "dump" {
+				return `[]`, nil
+			}
+			if args[0] == "osd" {
+				switch args[1] {
+				case "df":
+					return `{"nodes":[{"id":1,"name":"osd.1","kb_used":0}]}`, nil
+				case "out", "destroy":
+					return "", nil
+				}
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: executor}
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, context, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+	nodes := []rookalpha.Node{{Name: "node1"}, {Name: "node2"}}
+
+	// osd.0 has its metadata on another device, osd.1 and osd.2 are collocated
+	scheme := config.NewPerfScheme()
+	scheme.Metadata = config.NewMetadataDeviceInfo("nvme0n1")
+	for id, device := range []string{"sdb", "sdc"} {
+		entry := config.NewPerfSchemeEntry(config.Bluestore)
+		entry.ID = id
+		if id == 0 {
+			require.Nil(t, config.PopulateDistributedPerfSchemeEntry(entry, device, scheme.Metadata, config.StoreConfig{}))
+		} else {
+			require.Nil(t, config.PopulateCollocatedPerfSchemeEntry(entry, device, config.StoreConfig{}))
+		}
+		scheme.Entries = append(scheme.Entries, entry)
+	}
+	require.Nil(t, scheme.SaveScheme(c.kv, config.GetConfigStoreName("node1")))
+	scheme = config.NewPerfScheme()
+	entry := config.NewPerfSchemeEntry(config.Filestore)
+	entry.ID = 2
+	require.Nil(t, config.PopulateCollocatedPerfSchemeEntry(entry, "sdd", config.StoreConfig{StoreType: config.Filestore}))
+	scheme.Entries = append(scheme.Entries, entry)
+	require.Nil(t, scheme.SaveScheme(c.kv, config.GetConfigStoreName("node2")))
+
+	// the collocated osd with the lowest id is migrated first
+	assert.Nil(t, c.migrateNextOSD(nodes))
+	r, err := context.RookClientset.CephV1().CephOSDReplacements("ns").Get("migrate-osd-1", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, cephv1.OSDReplacementSpec{OSDID: 1, DevicePath: "/dev/sdc", Migrate: true}, r.Spec)
+
+	// the osd is destroyed without a by-path link and prepared again by the next orchestration
+	d, err := c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "", OSDInfo{ID: 1, UUID: "old-uuid"})
+	require.Nil(t, err)
+	_, err = context.Clientset.AppsV1().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+	orchestrations := 0
+	controller := NewReplacementController(context, "ns", func() { orchestrations++ })
+	controller.replace(r)
+	assert.Equal(t, 1, orchestrations)
+	replacements, err := c.pendingReplacements("node1")
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(replacements))
+	assert.Equal(t, v1.EnvVar{Name: migrateOSDsEnvVarName, Value: "/dev/sdc=1"}, migrateOSDsEnvVar(replacements))
+	assert.Equal(t, "", replaceOSDsEnvVar(replacements).Value)
+
+	// no other osd is migrated until the osd is prepared again
+	assert.Nil(t, c.migrateNextOSD(nodes))
+	list, err := context.RookClientset.CephV1().CephOSDReplacements("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list.Items))
+
+	// the osd of the next node is migrated after the osd prepared by ceph-volume completes the migration
+	assert.True(t, c.checkReplacement(OSDInfo{ID: 1, UUID: "new-uuid", CephVolumeInitiated: true}, replacements))
+	scheme, err = config.LoadScheme(c.kv, config.GetConfigStoreName("node1"))
+	require.Nil(t, err)
+	require.Nil(t, scheme.DeleteSchemeEntry(scheme.Entries[1]))
+	require.Nil(t, scheme.SaveScheme(c.kv, config.GetConfigStoreName("node1")))
+	assert.Nil(t, c.migrateNextOSD(nodes))
+	r, err = context.RookClientset.CephV1().CephOSDReplacements("ns").Get("migrate-osd-2", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "/dev/sdd", r.Spec.DevicePath)
+
+	// nothing is migrated before mimic
+	c.clusterInfo.CephVersion = cephver.Luminous
+	assert.Nil(t, context.RookClientset.CephV1().CephOSDReplacements("ns").Delete("migrate-osd-2", &metav1.DeleteOptions{}))
+	assert.Nil(t, c.migrateNextOSD(nodes))
+	list, err = context.RookClientset.CephV1().CephOSDReplacements("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list.Items))
+}
@@ -18,6 +18,7 @@ limitations under the License.
 package osd
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -69,8 +70,10 @@ type Cluster struct {
 	dataDirHostPath string
 	HostNetwork     bool
 	resources       v1.ResourceRequirements
+	memoryHeadroom  string // the fraction of the memory limit of the osd pods kept free of the osd memory target
 	ownerRef        metav1.OwnerReference
 	kv              *k8sutil.ConfigMapKVStore
+	Upgrade         *UpgradeBatches // paces the update of the osds when the ceph image changed, if set
 }
 
 // New creates an instance of the OSD manager
@@ -86,6 +89,7 @@ func New(
 	annotations rookalpha.Annotations,
 	hostNetwork bool,
 	resources v1.ResourceRequirements,
+	memoryHeadroom string,
 	ownerRef metav1.OwnerReference,
 ) *Cluster {
 	return &Cluster{
@@ -100,6 +104,7 @@ func New(
 		dataDirHostPath: dataDirHostPath,
 		HostNetwork:     hostNetwork,
 		resources:       resources,
+		memoryHeadroom:  memoryHeadroom,
 		ownerRef:        ownerRef,
 		kv:              k8sutil.NewConfigMapKVStore(namespace, context.Clientset, ownerRef),
 	}
@@ -117,12 +122,28 @@ type OSDInfo struct {
 	IsDirectory         bool   `json:"is-directory"`
 	DevicePartUUID      string `json:"device-part-uuid"`
 	CephVolumeInitiated bool   `json:"ceph-volume-initiated"`
+	// DevicePath is the /dev/disk/by-path link of the data device of the osd, if known
+	DevicePath string `json:"device-path"`
+	// Encrypted is true when the devices of the osd are encrypted with dm-crypt
+	Encrypted bool `json:"encrypted"`
 }
 
 type OrchestrationStatus struct {
 	OSDs    []OSDInfo `json:"osds"`
 	Status  string    `json:"status"`
 	Message string    `json:"message"`
+	// PVCBackedOSD is true when the status is for the osd on a pvc rather than the osds of a node
+	PVCBackedOSD bool `json:"pvc-backed-osd"`
+	// BatchReports are the dry-run reports of ceph-volume lvm batch on the osds with their db on the metadata
+	// devices. They are saved while orchestrating, before the osds of each metadata device are created.
+	BatchReports []BatchReport `json:"batch-reports,omitempty"`
+}
+
+// BatchReport is the dry-run report of ceph-volume lvm batch for the data devices sharing a metadata device
+type BatchReport struct {
+	MetadataDevice string          `json:"metadata-device"`
+	Devices        []string        `json:"devices"`
+	Report         json.RawMessage `json:"report"`
 }
 
 // Start the osd management
@@ -136,8 +157,8 @@ func (c *Cluster) Start() error {
 
 	logger.Infof("start running osds in namespace %s", c.Namespace)
 
-	if c.DesiredStorage.UseAllNodes == false && len(c.DesiredStorage.Nodes) == 0 {
-		logger.Warningf("useAllNodes is set to false and no nodes are specified, no OSD pods are going to be created")
+	if c.DesiredStorage.UseAllNodes == false && len(c.DesiredStorage.Nodes) == 0 && len(c.DesiredStorage.VolumeClaimTemplates) == 0 {
+		logger.Warningf("useAllNodes is set to false and no nodes or volume claim templates are specified, no OSD pods are going to be created")
 	}
 
 	if c.DesiredStorage.UseAllNodes {
@@ -161,26 +182,53 @@ func (c *Cluster) Start() error {
 
 	// no valid node is ready to run an osd
 	if len(validNodes) == 0 {
-		logger.Warningf("no valid node available to run an osd in namespace %s. "+
-			"Rook will not create any new OSD nodes and will skip checking for removed nodes since "+
-			"removing all OSD nodes without destroying the Rook cluster is unlikely to be intentional", c.Namespace)
-		return nil
+		if len(c.DesiredStorage.VolumeClaimTemplates) == 0 {
+			logger.Warningf("no valid node available to run an osd in namespace %s. "+
+				"Rook will not create any new OSD nodes and will skip checking for removed nodes since "+
+				"removing all OSD nodes without destroying the Rook cluster is unlikely to be intentional", c.Namespace)
+			return nil
+		}
+		logger.Infof("no valid node available to run an osd in namespace %s. only the osds on pvcs will be provisioned", c.Namespace)
+	} else {
+		logger.Infof("%d of the %d storage nodes are valid", len(validNodes), len(c.DesiredStorage.Nodes))
 	}
-	logger.Infof("%d of the %d storage nodes are valid", len(validNodes), len(c.DesiredStorage.Nodes))
 	c.ValidStorage.Nodes = validNodes
 
+	if c.DesiredStorage.IsCrushManaged() {
+		// let the osds that were kept in place while the crush map was left to the user move again
+		c.enableCrushUpdateOnStart()
+		// move the nodes in the crush map to the locations given by their topology labels
+		c.updateCrushTopology()
+	} else {
+		// keep the osds where the user placed them when they are restarted by the orchestration
+		c.disableCrushUpdateOnStart()
+	}
+
 	// start the jobs to provision the OSD devices and directories
 	config := newProvisionConfig()
 	logger.Infof("start provisioning the osds on nodes, if needed")
 	c.startProvisioning(config)
 
+	// start the jobs to provision the OSDs on the PVCs created from the volume claim templates
+	logger.Infof("start provisioning the osds on pvcs, if needed")
+	c.startProvisioningOverPVCs(config)
+
 	// start the OSD pods, waiting for the provisioning to be completed
 	logger.Infof("start osds after provisioning is completed, if needed")
 	c.completeProvision(config)
 
 	// handle the removed nodes and rebalance the PGs
-	logger.Infof("checking if any nodes were removed")
-	c.handleRemovedNodes(config)
+	if len(validNodes) > 0 {
+		logger.Infof("checking if any nodes were removed")
+		c.handleRemovedNodes(config)
+	}
+
+	// move on to the next legacy osd when the previous one was migrated to ceph-volume
+	if c.DesiredStorage.MigrateToCephVolume && len(config.errorMessages) == 0 {
+		if err := c.migrateNextOSD(validNodes); err != nil {
+			config.addError("failed to migrate the next legacy osd to ceph-volume. %+v", err)
+		}
+	}
 
 	if len(config.errorMessages) > 0 {
 		return fmt.Errorf("%d failures encountered while running osds in namespace %s: %+v",
@@ -222,6 +270,10 @@ func (c *Cluster) startProvisioning(config *provisionConfig) {
 		storeConfig := osdconfig.ToStoreConfig(n.Config)
 		metadataDevice := osdconfig.MetadataDevice(n.Config)
 		job, err := c.makeJob(n.Name, n.Devices, n.Selection, n.Resources, storeConfig, metadataDevice, n.Location)
+		if err == nil {
+			// give the ids of the destroyed osds to the new devices found at their by-path links
+			err = c.addReplacementsToJob(job, n.Name)
+		}
 		if err != nil {
 			message := fmt.Sprintf("failed to create prepare job node %s: %v", n.Name, err)
 			config.addError(message)
@@ -272,31 +324,48 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 	storeConfig := osdconfig.ToStoreConfig(n.Config)
 	metadataDevice := osdconfig.MetadataDevice(n.Config)
 
+	replacements, err := c.pendingReplacements(nodeName)
+	if err != nil {
+		logger.Warningf("failed to get the osd replacements of node %s. %+v", nodeName, err)
+	}
+
 	// start osds
 	for _, osd := range osds {
 		logger.Debugf("start osd %v", osd)
+		if !c.checkReplacement(osd, replacements) {
+			continue
+		}
 		dp, err := c.makeDeployment(n.Name, n.Selection, n.Resources, storeConfig, metadataDevice, n.Location, osd)
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to create deployment for node %s: %v", n.Name, err)
 			config.addError(errMsg)
 			continue
 		}
+		c.createOrUpdateOSDDeployment(dp, osd, n.Name, n.Location, config)
+	}
+}
 
-		_, err = c.context.Clientset.AppsV1().Deployments(c.Namespace).Create(dp)
-		if err != nil {
-			if !errors.IsAlreadyExists(err) {
-				// we failed to create job, update the orchestration status for this node
-				logger.Warningf("failed to create osd deployment for node %s, osd %v: %+v", n.Name, osd, err)
-				continue
-			}
-			logger.Infof("deployment for osd %d already exists. updating if needed", osd.ID)
-			if _, err = k8sutil.UpdateDeploymentAndWait(c.context, dp, c.Namespace); err != nil {
-				config.addError(fmt.Sprintf("failed to update osd deployment %d. %+v", osd.ID, err))
-			}
+func (c *Cluster) createOrUpdateOSDDeployment(dp *apps.Deployment, osd OSDInfo, host, location string, config *provisionConfig) {
+	_, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Create(dp)
+	if err != nil {
+		if !errors.IsAlreadyExists(err) {
+			logger.Warningf("failed to create osd deployment %s, osd %v: %+v", dp.Name, osd, err)
+			return
+		}
+		if updateNow, err := c.admitUpgrade(dp, host, location); err != nil {
+			config.addError("failed to upgrade osd %d. %+v", osd.ID, err)
+			return
+		} else if !updateNow {
+			logger.Infof("upgrade is paused. not updating osd %d", osd.ID)
+			return
+		}
+		logger.Infof("deployment for osd %d already exists. updating if needed", osd.ID)
+		if _, err = k8sutil.UpdateDeploymentAndWait(c.context, dp, c.Namespace); err != nil {
+			config.addError(fmt.Sprintf("failed to update osd deployment %d. %+v", osd.ID, err))
 		}
-
-		logger.Infof("started deployment for osd %d (dir=%t, type=%s)", osd.ID, osd.IsDirectory, storeConfig.StoreType)
 	}
+
+	logger.Infof("started deployment for osd %d (dir=%t, filestore=%t)", osd.ID, osd.IsDirectory, osd.IsFileStore)
 }
 
 func (c *Cluster) handleRemovedNodes(config *provisionConfig) {
@@ -335,7 +404,8 @@ func (c *Cluster) handleRemovedNodes(config *provisionConfig) {
 				}
 			}
 
-			if err := removeOSD(c.context, c.Namespace, dp.Name, id); err != nil {
+			osdUUID := getContainerEnv(dp.Spec.Template.Spec.Containers, "osd", osdUUIDEnvVarName)
+			if err := removeOSD(c.context, c.Namespace, dp.Name, id, osdUUID); err != nil {
 				config.addError("failed to remove osd %d. %+v", id, err)
 				errorOnCurrentNode = true
 				continue
@@ -408,6 +478,10 @@ func (c *Cluster) discoverStorageNodes() (map[string][]*apps.Deployment, error)
 	}
 	discoveredNodes := map[string][]*apps.Deployment{}
 	for _, osdDeployment := range osdDeployments.Items {
+		if _, ok := osdDeployment.Labels[pvcLabelKey]; ok {
+			// osds on pvcs are not bound to a node
+			continue
+		}
 		osdPodSpec := osdDeployment.Spec.Template.Spec
 
 		// get the node name from the node selector
@@ -505,6 +579,7 @@ func (c *Cluster) resolveNode(nodeName string) *rookalpha.Node {
 		return nil
 	}
 	rookNode.Resources = k8sutil.MergeResourceRequirements(rookNode.Resources, c.resources)
+	rookNode.Location = c.getNodeLocation(nodeName, rookNode.Location)
 
 	return rookNode
 }
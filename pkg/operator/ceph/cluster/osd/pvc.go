@@ -0,0 +1,294 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package osd for the Ceph OSDs.
+package osd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	osdPVCNameFmt          = "rook-ceph-osd-%s-%d"
+	pvcLabelKey            = "ceph.rook.io/pvc"
+	pvcVolumeName          = "osd-pvc"
+	pvcDeviceDir           = "/mnt"
+	pvcBackedOSDEnvVarName = "ROOK_PVC_BACKED_OSD"
+	pvcDeviceEnvVarName    = "ROOK_PVC_DEVICE"
+)
+
+// startProvisioningOverPVCs creates the pvcs from the volume claim templates of the storage spec
+// and starts a job for each pvc that prepares an osd on the block device of the pvc
+func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
+	if len(c.DesiredStorage.VolumeClaimTemplates) == 0 {
+		return
+	}
+	if len(c.dataDirHostPath) == 0 {
+		logger.Warningf("skipping osd provisioning on pvcs where no dataDirHostPath is set")
+		return
+	}
+
+	count := pvcCount(c.DesiredStorage)
+	for _, template := range c.DesiredStorage.VolumeClaimTemplates {
+		for i := 0; i < count; i++ {
+			pvc, err := c.createOSDPVC(template, i)
+			if err != nil {
+				config.addError("failed to create pvc %d from template %s. %+v", i, template.Name, err)
+				continue
+			}
+
+			// the status of the pvc is tracked in the same way as the status of a node
+			status := OrchestrationStatus{Status: OrchestrationStatusStarting, PVCBackedOSD: true}
+			if err := c.updateNodeStatus(pvc.Name, status); err != nil {
+				config.addError("failed to set orchestration starting status for pvc %s: %+v", pvc.Name, err)
+				continue
+			}
+
+			job, err := c.makeJobForPVC(pvc.Name, c.getPVCLocation(pvc.Name))
+			if err != nil {
+				message := fmt.Sprintf("failed to create prepare job for pvc %s: %v", pvc.Name, err)
+				config.addError(message)
+				status := OrchestrationStatus{Status: OrchestrationStatusCompleted, PVCBackedOSD: true, Message: message}
+				if err := c.updateNodeStatus(pvc.Name, status); err != nil {
+					config.addError("failed to update pvc %s status. %+v", pvc.Name, err)
+				}
+				continue
+			}
+
+			if !c.runJob(job, pvc.Name, config, "provision") {
+				status := OrchestrationStatus{Status: OrchestrationStatusCompleted, PVCBackedOSD: true, Message: fmt.Sprintf("failed to start osd provisioning on pvc %s", pvc.Name)}
+				if err := c.updateNodeStatus(pvc.Name, status); err != nil {
+					config.addError("failed to update pvc %s status. %+v", pvc.Name, err)
+				}
+			}
+		}
+	}
+}
+
+// pvcCount returns the number of pvcs created from each volume claim template. As for the other
+// rook storage providers, the count is the nodeCount of the storage spec.
+func pvcCount(storage rookalpha.StorageScopeSpec) int {
+	if storage.NodeCount < 1 {
+		return 1
+	}
+	return storage.NodeCount
+}
+
+// createOSDPVC creates the pvc with the given index from the template, or returns the existing pvc
+func (c *Cluster) createOSDPVC(template v1.PersistentVolumeClaim, index int) (*v1.PersistentVolumeClaim, error) {
+	if template.Name == "" {
+		return nil, fmt.Errorf("volume claim template has no name")
+	}
+	volumeMode := v1.PersistentVolumeBlock
+	if template.Spec.VolumeMode != nil && *template.Spec.VolumeMode != volumeMode {
+		return nil, fmt.Errorf("volume claim template %s must have the Block volume mode", template.Name)
+	}
+
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf(osdPVCNameFmt, template.Name, index),
+			Namespace:   c.Namespace,
+			Annotations: template.Annotations,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     appName,
+				k8sutil.ClusterAttr: c.Namespace,
+			},
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	pvc.Spec.VolumeMode = &volumeMode
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &pvc.ObjectMeta, &c.ownerRef)
+
+	created, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(pvc)
+	if err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		return c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(pvc.Name, metav1.GetOptions{})
+	}
+	logger.Infof("created osd pvc %s", created.Name)
+	return created, nil
+}
+
+// getPVCLocation returns the crush location of the osds on the pvc. The osds on a volume that is bound
+// to a node are placed under the host of the node. The osds on the other volumes can move between the
+// nodes, so they are placed under a host named after the pvc in the topology the volume is bound to.
+func (c *Cluster) getPVCLocation(pvcName string) string {
+	location := c.DesiredStorage.Location
+	pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(pvcName, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get pvc %s to find its crush location. %+v", pvcName, err)
+		return withCrushHost(location, pvcName)
+	}
+	if pvc.Spec.VolumeName == "" {
+		// the volume is only bound when the first pod of the pvc is scheduled
+		logger.Infof("pvc %s is not bound to a volume yet, placing it under host %s", pvcName, pvcName)
+		return withCrushHost(location, pvcName)
+	}
+	pv, err := c.context.Clientset.CoreV1().PersistentVolumes().Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get volume %s of pvc %s to find its crush location. %+v", pvc.Spec.VolumeName, pvcName, err)
+		return withCrushHost(location, pvcName)
+	}
+
+	topology := volumeTopology(pv)
+	if hostName, ok := topology[v1.LabelHostname]; ok {
+		node, err := getNodeByHostName(c.context, hostName)
+		if err == nil {
+			return withCrushHost(nodeLocation(node, c.DesiredStorage.TopologyLabels, location), hostName)
+		}
+		logger.Warningf("failed to get the topology of node %s of pvc %s. %+v", hostName, pvcName, err)
+	}
+	// the volume is only bound to a topology, so the labels of the topology are looked up in the same way as for a node
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: topology}}
+	return withCrushHost(nodeLocation(node, c.DesiredStorage.TopologyLabels, location), pvcName)
+}
+
+// volumeTopology returns the labels of the topology the volume is bound to, from the labels of the volume
+// and the node affinity of the volume where it requires a single value for a label
+func volumeTopology(pv *v1.PersistentVolume) map[string]string {
+	topology := map[string]string{}
+	for key, value := range pv.Labels {
+		topology[key] = value
+	}
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return topology
+	}
+	terms := pv.Spec.NodeAffinity.Required.NodeSelectorTerms
+	if len(terms) != 1 {
+		// the volume can be attached in more than one topology
+		return topology
+	}
+	for _, expr := range terms[0].MatchExpressions {
+		if expr.Operator == v1.NodeSelectorOpIn && len(expr.Values) == 1 {
+			topology[expr.Key] = expr.Values[0]
+		}
+	}
+	return topology
+}
+
+// withCrushHost adds the host bucket to the crush location unless the location already sets the host
+func withCrushHost(location, hostName string) string {
+	if location == "" {
+		return fmt.Sprintf("host=%s", hostName)
+	}
+	for _, pair := range strings.Split(location, ",") {
+		if strings.HasPrefix(pair, "host=") {
+			return location
+		}
+	}
+	return fmt.Sprintf("%s,host=%s", location, hostName)
+}
+
+func (c *Cluster) makeJobForPVC(pvcName, location string) (*batch.Job, error) {
+	devices := []rookalpha.Device{{Name: pvcDevicePath(pvcName)}}
+	storeConfig := osdconfig.ToStoreConfig(c.DesiredStorage.Config)
+	podSpec, err := c.provisionPodTemplateSpec(devices, rookalpha.Selection{}, c.resources, storeConfig, "", pvcName, location, v1.RestartPolicyOnFailure)
+	if err != nil {
+		return nil, err
+	}
+	addPVCToPodSpec(&podSpec.Spec, []string{"provision"}, pvcName, v1.EnvVar{Name: pvcBackedOSDEnvVarName, Value: "true"})
+	podSpec.Labels[pvcLabelKey] = pvcName
+
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k8sutil.TruncateNodeName(prepareAppNameFmt, pvcName),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     prepareAppName,
+				k8sutil.ClusterAttr: c.Namespace,
+				pvcLabelKey:         pvcName,
+			},
+		},
+		Spec: batch.JobSpec{
+			Template: *podSpec,
+		},
+	}
+	k8sutil.AddRookVersionLabelToJob(job)
+	opspec.AddCephVersionLabelToJob(c.clusterInfo.CephVersion, job)
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &job.ObjectMeta, &c.ownerRef)
+	return job, nil
+}
+
+// makeDeploymentForPVC makes the deployment of an osd on a pvc. The osd is not bound to a node, the
+// scheduler places it where the volume of the pvc can be attached.
+func (c *Cluster) makeDeploymentForPVC(pvcName, location string, osd OSDInfo) (*apps.Deployment, error) {
+	storeConfig := osdconfig.ToStoreConfig(c.DesiredStorage.Config)
+	deployment, err := c.makeDeployment(pvcName, rookalpha.Selection{}, c.resources, storeConfig, "", location, osd)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment.Spec.Template.Spec.NodeSelector = nil
+	deployment.Labels[pvcLabelKey] = pvcName
+	deployment.Spec.Template.Labels[pvcLabelKey] = pvcName
+	// the encryption init container unlocks the encrypted volumes on the block device of the pvc
+	addPVCToPodSpec(&deployment.Spec.Template.Spec, []string{"osd", encryptionOpenContainerName}, pvcName, v1.EnvVar{Name: pvcDeviceEnvVarName, Value: pvcDevicePath(pvcName)})
+	return deployment, nil
+}
+
+func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig, status *OrchestrationStatus) {
+	logger.Infof("starting %d osd daemons on pvc %s", len(status.OSDs), pvcName)
+	location := c.getPVCLocation(pvcName)
+	for _, osd := range status.OSDs {
+		dp, err := c.makeDeploymentForPVC(pvcName, location, osd)
+		if err != nil {
+			config.addError("failed to create deployment for pvc %s: %v", pvcName, err)
+			continue
+		}
+		c.createOrUpdateOSDDeployment(dp, osd, pvcName, location, config)
+	}
+}
+
+// addPVCToPodSpec adds the pvc as a block device to the containers and init containers with the
+// given names
+func addPVCToPodSpec(spec *v1.PodSpec, containerNames []string, pvcName string, env ...v1.EnvVar) {
+	spec.Volumes = append(spec.Volumes, v1.Volume{
+		Name: pvcVolumeName,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+		},
+	})
+	addDevice := func(containers []v1.Container) {
+		for i := range containers {
+			for _, name := range containerNames {
+				if containers[i].Name != name {
+					continue
+				}
+				containers[i].VolumeDevices = append(containers[i].VolumeDevices, v1.VolumeDevice{Name: pvcVolumeName, DevicePath: pvcDevicePath(pvcName)})
+				containers[i].Env = append(containers[i].Env, env...)
+			}
+		}
+	}
+	addDevice(spec.InitContainers)
+	addDevice(spec.Containers)
+}
+
+func pvcDevicePath(pvcName string) string {
+	return path.Join(pvcDeviceDir, pvcName)
+}
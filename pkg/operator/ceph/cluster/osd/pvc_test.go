@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package osd for the Ceph OSDs.
+package osd
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPVCTestCluster(storage rookalpha.StorageScopeSpec) *Cluster {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	context := &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}
+	return New(clusterInfo, context, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{Image: "ceph/ceph:v14.2.1"},
+		storage, "/var/lib/rook", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+}
+
+func pvcTemplate(name string, mode *v1.PersistentVolumeMode) v1.PersistentVolumeClaim {
+	return v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeMode: mode,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+}
+
+func TestCreateOSDPVC(t *testing.T) {
+	c := newPVCTestCluster(rookalpha.StorageScopeSpec{})
+
+	// the volume mode defaults to block
+	pvc, err := c.createOSDPVC(pvcTemplate("data", nil), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph-osd-data-2", pvc.Name)
+	assert.Equal(t, v1.PersistentVolumeBlock, *pvc.Spec.VolumeMode)
+	assert.Equal(t, appName, pvc.Labels["app"])
+
+	// the existing pvc is returned
+	pvc, err = c.createOSDPVC(pvcTemplate("data", nil), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph-osd-data-2", pvc.Name)
+
+	filesystem := v1.PersistentVolumeFilesystem
+	_, err = c.createOSDPVC(pvcTemplate("fs", &filesystem), 0)
+	assert.NotNil(t, err)
+}
+
+func TestPVCCount(t *testing.T) {
+	assert.Equal(t, 1, pvcCount(rookalpha.StorageScopeSpec{}))
+	assert.Equal(t, 3, pvcCount(rookalpha.StorageScopeSpec{NodeCount: 3}))
+}
+
+func TestMakeJobForPVC(t *testing.T) {
+	c := newPVCTestCluster(rookalpha.StorageScopeSpec{})
+
+	job, err := c.makeJobForPVC("rook-ceph-osd-data-0", "host=rook-ceph-osd-data-0")
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph-osd-prepare-rook-ceph-osd-data-0", job.Name)
+	assert.Equal(t, "rook-ceph-osd-data-0", job.Labels[pvcLabelKey])
+
+	spec := job.Spec.Template.Spec
+	assert.Nil(t, spec.NodeSelector)
+	assert.Equal(t, "rook-ceph-osd-data-0", spec.Volumes[len(spec.Volumes)-1].PersistentVolumeClaim.ClaimName)
+
+	container := spec.Containers[1]
+	assert.Equal(t, "provision", container.Name)
+	assert.Equal(t, []v1.VolumeDevice{{Name: pvcVolumeName, DevicePath: "/mnt/rook-ceph-osd-data-0"}}, container.VolumeDevices)
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "ROOK_DATA_DEVICES", Value: "/mnt/rook-ceph-osd-data-0"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: pvcBackedOSDEnvVarName, Value: "true"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "ROOK_NODE_NAME", Value: "rook-ceph-osd-data-0"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "ROOK_LOCATION", Value: "host=rook-ceph-osd-data-0"})
+	assert.True(t, *container.SecurityContext.Privileged)
+}
+
+func TestGetPVCLocation(t *testing.T) {
+	c := newPVCTestCluster(rookalpha.StorageScopeSpec{Location: "root=custom"})
+	clientset := c.context.Clientset
+
+	// the pvc is placed under its own host until it is bound
+	pvc, err := c.createOSDPVC(pvcTemplate("data", nil), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "root=custom,host=rook-ceph-osd-data-0", c.getPVCLocation(pvc.Name))
+
+	// a local volume is placed under the host of its node
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{
+		v1.LabelHostname: "node1", topologyLabelZone: "z1", topologyLabelRack: "rack1"}}}
+	_, err = clientset.CoreV1().Nodes().Create(node)
+	assert.Nil(t, err)
+	local := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "local-pv"},
+		Spec: v1.PersistentVolumeSpec{NodeAffinity: &v1.VolumeNodeAffinity{Required: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{
+				{Key: v1.LabelHostname, Operator: v1.NodeSelectorOpIn, Values: []string{"node1"}},
+			}}},
+		}}},
+	}
+	_, err = clientset.CoreV1().PersistentVolumes().Create(local)
+	assert.Nil(t, err)
+	pvc.Spec.VolumeName = local.Name
+	_, err = clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Update(pvc)
+	assert.Nil(t, err)
+	assert.Equal(t, "root=custom,zone=z1,rack=rack1,host=node1", c.getPVCLocation(pvc.Name))
+
+	// a volume that can be attached anywhere in its zone is placed under its own host in the zone
+	zonal := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "zonal-pv", Labels: map[string]string{v1.LabelZoneFailureDomain: "z2"}},
+	}
+	_, err = clientset.CoreV1().PersistentVolumes().Create(zonal)
+	assert.Nil(t, err)
+	pvc.Spec.VolumeName = zonal.Name
+	_, err = clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Update(pvc)
+	assert.Nil(t, err)
+	assert.Equal(t, "root=custom,zone=z2,host=rook-ceph-osd-data-0", c.getPVCLocation(pvc.Name))
+
+	// the host set in the location is kept
+	assert.Equal(t, "host=myhost", withCrushHost("host=myhost", "node1"))
+}
+
+func TestMakeDeploymentForPVC(t *testing.T) {
+	c := newPVCTestCluster(rookalpha.StorageScopeSpec{})
+
+	osd := OSDInfo{ID: 3, UUID: "uuid", CephVolumeInitiated: true}
+	deployment, err := c.makeDeploymentForPVC("rook-ceph-osd-data-0", "host=node1", osd)
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph-osd-3", deployment.Name)
+	assert.Equal(t, "rook-ceph-osd-data-0", deployment.Labels[pvcLabelKey])
+	assert.Equal(t, "rook-ceph-osd-data-0", deployment.Spec.Template.Labels[pvcLabelKey])
+
+	spec := deployment.Spec.Template.Spec
+	assert.Nil(t, spec.NodeSelector)
+	container := spec.Containers[0]
+	assert.Equal(t, []v1.VolumeDevice{{Name: pvcVolumeName, DevicePath: "/mnt/rook-ceph-osd-data-0"}}, container.VolumeDevices)
+	assert.Contains(t, container.Env, v1.EnvVar{Name: pvcDeviceEnvVarName, Value: "/mnt/rook-ceph-osd-data-0"})
+
+	// the osds on pvcs are not discovered as osds of a node
+	_, err = c.context.Clientset.AppsV1().Deployments(c.Namespace).Create(deployment)
+	assert.Nil(t, err)
+	nodes, err := c.discoverStorageNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(nodes))
+}
@@ -33,7 +33,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func removeOSD(context *clusterd.Context, namespace, deploymentName string, id int) error {
+func removeOSD(context *clusterd.Context, namespace, deploymentName string, id int, osdUUID string) error {
 	// get a baseline for OSD usage so we can compare usage to it later on to know when migration has started
 	initialUsage, err := client.GetOSDUsage(context, namespace)
 	if err != nil {
@@ -62,7 +62,7 @@ func removeOSD(context *clusterd.Context, namespace, deploymentName string, id i
 		}
 
 		// wait for the OSDs data to be migrated
-		if err := waitForRebalance(context, namespace, id, initialUsage); err != nil {
+		if err := waitForRebalance(context, namespace, id, initialUsage, nil); err != nil {
 			return fmt.Errorf("failed to wait for cluster rebalancing after removing osd.%d: %+v", id, err)
 		}
 	}
@@ -82,13 +82,19 @@ func removeOSD(context *clusterd.Context, namespace, deploymentName string, id i
 		logger.Warningf("failed to delete osd.%d filesystem, it may need to be cleaned up manually: %+v", id, err)
 	}
 
+	// the key of an encrypted osd is not needed anymore
+	if err := deleteEncryptionKeySecret(context, namespace, osdUUID); err != nil {
+		logger.Warningf("failed to delete the encryption key of osd.%d, it may need to be cleaned up manually: %+v", id, err)
+	}
+
 	return nil
 }
 
-func waitForRebalance(context *clusterd.Context, namespace string, osdID int, initialUsage *client.OSDUsage) error {
+// waitForRebalance waits until the data is moved off the osd, or until the stop channel is closed
+func waitForRebalance(context *clusterd.Context, namespace string, osdID int, initialUsage *client.OSDUsage, stopCh <-chan struct{}) error {
 	if initialUsage != nil {
 		// start a retry loop to wait for rebalancing to start
-		err := util.Retry(20, 5*time.Second, func() error {
+		err := util.RetryWithStop(20, 5*time.Second, stopCh, func() error {
 			currUsage, err := client.GetOSDUsage(context, namespace)
 			if err != nil {
 				return err
@@ -154,7 +160,7 @@ func waitForRebalance(context *clusterd.Context, namespace string, osdID int, in
 	}
 
 	// wait until the cluster gets fully rebalanced again
-	err := util.Retry(3000, 15*time.Second, func() error {
+	err := util.RetryWithStop(3000, 15*time.Second, stopCh, func() error {
 		// get a dump of all placement groups
 		pgDump, err := client.GetPGDumpBrief(context, namespace)
 		if err != nil {
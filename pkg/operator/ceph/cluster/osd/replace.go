@@ -0,0 +1,390 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package osd for the Ceph OSDs.
+package osd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	devicePathAnnotation   = "ceph.rook.io/device-path"
+	replaceOSDsEnvVarName  = "ROOK_REPLACE_OSDS"
+	osdUUIDEnvVarName      = "ROOK_OSD_UUID"
+	devicePathByPathPrefix = "/dev/disk/by-path/"
+)
+
+// CephOSDReplacementResource represents the osd replacement custom resource
+var CephOSDReplacementResource = opkit.CustomResource{
+	Name:    "cephosdreplacement",
+	Plural:  "cephosdreplacements",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephOSDReplacement{}).Name(),
+}
+
+// ReplacementController destroys the osds of failed devices so that their ids are reused by the
+// osds prepared on the replacement devices
+type ReplacementController struct {
+	context     *clusterd.Context
+	namespace   string
+	orchestrate func()
+	queue       chan *cephv1.CephOSDReplacement
+	stopCh      chan struct{}
+}
+
+// NewReplacementController creates a controller for the osd replacements of a cluster. The orchestrate
+// func is called after the osd of a migration is destroyed to prepare it again with ceph-volume. It must
+// only request the orchestration and not wait for it, since the replacements are processed one at a time.
+func NewReplacementController(context *clusterd.Context, namespace string, orchestrate func()) *ReplacementController {
+	return &ReplacementController{
+		context:     context,
+		namespace:   namespace,
+		orchestrate: orchestrate,
+		queue:       make(chan *cephv1.CephOSDReplacement),
+	}
+}
+
+// StartWatch watches for instances of CephOSDReplacement custom resources and acts on them
+func (c *ReplacementController) StartWatch(stopCh chan struct{}) error {
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	c.stopCh = stopCh
+	go c.processReplacements()
+
+	logger.Infof("start watching osd replacement resources in namespace %s", c.namespace)
+	watcher := opkit.NewWatcher(CephOSDReplacementResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephOSDReplacement{}, stopCh)
+	return nil
+}
+
+// onAdd queues the replacement. Moving the data off an osd can take hours, so the replacements are
+// processed one at a time outside of the informer.
+func (c *ReplacementController) onAdd(obj interface{}) {
+	r := obj.(*cephv1.CephOSDReplacement).DeepCopy()
+	if r.Status.IsConditionTrue(cephv1.ConditionOSDDestroyed) {
+		logger.Debugf("osd.%d of replacement %s was already destroyed", r.Spec.OSDID, r.Name)
+		return
+	}
+
+	go func() {
+		select {
+		case c.queue <- r:
+		case <-c.stopCh:
+		}
+	}()
+}
+
+// processReplacements processes the queued replacements until the stop channel is closed
+func (c *ReplacementController) processReplacements() {
+	for {
+		select {
+		case r := <-c.queue:
+			c.replace(r)
+		case <-c.stopCh:
+			logger.Infof("stopping the osd replacements in namespace %s", c.namespace)
+			return
+		}
+	}
+}
+
+// replace destroys the osd of the replacement. The status records how far the replacement got, so a
+// replacement that was interrupted by a stop is resumed when the operator starts again.
+func (c *ReplacementController) replace(r *cephv1.CephOSDReplacement) {
+	err := c.destroyOSD(r)
+	if err != nil && c.stopped() {
+		logger.Infof("osd replacement %s interrupted. it will be resumed when the operator restarts. %+v", r.Name, err)
+		return
+	}
+	if err != nil {
+		logger.Errorf("failed to destroy osd.%d for replacement %s. %+v", r.Spec.OSDID, r.Name, err)
+	}
+	updateReplacementStatus(c.context, r, err == nil, err)
+
+	if err == nil && r.Spec.Migrate && c.orchestrate != nil {
+		// no new device will show up to trigger the orchestration, the device of the osd is reused
+		logger.Infof("preparing osd.%d again with ceph-volume", r.Spec.OSDID)
+		c.orchestrate()
+	}
+}
+
+func (c *ReplacementController) stopped() bool {
+	select {
+	case <-c.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *ReplacementController) onUpdate(oldObj, newObj interface{}) {
+	oldReplacement := oldObj.(*cephv1.CephOSDReplacement)
+	newReplacement := newObj.(*cephv1.CephOSDReplacement)
+	if !reflect.DeepEqual(oldReplacement.Spec, newReplacement.Spec) {
+		logger.Warningf("the spec of osd replacement %s cannot be changed. create a new replacement instead.", newReplacement.Name)
+	}
+}
+
+func (c *ReplacementController) onDelete(obj interface{}) {
+	r := obj.(*cephv1.CephOSDReplacement)
+	if r.Status.IsConditionTrue(cephv1.ConditionOSDDestroyed) && !r.Status.IsConditionTrue(cephv1.ConditionOSDReplaced) {
+		logger.Warningf("osd replacement %s deleted before a new device was found. the id of the destroyed osd.%d will not be reused.", r.Name, r.Spec.OSDID)
+	}
+}
+
+// destroyOSD marks the osd out, waits for its data to be moved to the other osds and destroys it,
+// keeping its id for the osd that will be prepared on the new device
+func (c *ReplacementController) destroyOSD(r *cephv1.CephOSDReplacement) error {
+	id := r.Spec.OSDID
+	deploymentName := fmt.Sprintf(osdAppNameFmt, id)
+	dp, err := c.context.Clientset.AppsV1().Deployments(c.namespace).Get(deploymentName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) || r.Status.Node == "" {
+			return fmt.Errorf("failed to get osd deployment %s. %+v", deploymentName, err)
+		}
+		// the deployment was deleted in an earlier attempt that was interrupted
+		logger.Infof("deployment of osd.%d already deleted", id)
+	} else {
+		if _, ok := dp.Labels[pvcLabelKey]; ok {
+			return fmt.Errorf("osd.%d runs on a pvc and cannot be replaced on a device", id)
+		}
+		r.Status.Node = dp.Spec.Template.Spec.NodeSelector[v1.LabelHostname]
+		r.Status.DevicePath = r.Spec.DevicePath
+		if r.Status.DevicePath == "" {
+			r.Status.DevicePath = dp.Annotations[devicePathAnnotation]
+		}
+		r.Status.OSDUUID = getContainerEnv(dp.Spec.Template.Spec.Containers, "osd", osdUUIDEnvVarName)
+	}
+	if r.Status.Node == "" {
+		return fmt.Errorf("osd.%d has no node", id)
+	}
+	if r.Spec.Migrate {
+		if r.Status.DevicePath == "" {
+			return fmt.Errorf("the device of osd.%d to migrate is unknown", id)
+		}
+	} else if !strings.HasPrefix(r.Status.DevicePath, devicePathByPathPrefix) {
+		return fmt.Errorf("the by-path link of the device of osd.%d is unknown. set the devicePath of the replacement to a link under %s", id, devicePathByPathPrefix)
+	}
+	if r.Status.OSDUUID == "" {
+		// the uuid tells the destroyed osd apart from the osd that replaces it
+		dump, err := client.GetOSDDump(c.context, c.namespace)
+		if err != nil {
+			return fmt.Errorf("failed to get the uuid of osd.%d. %+v", id, err)
+		}
+		if r.Status.OSDUUID, err = dump.UUIDByID(int64(id)); err != nil {
+			return fmt.Errorf("failed to get the uuid of osd.%d. %+v", id, err)
+		}
+		if r.Status.OSDUUID == "" {
+			return fmt.Errorf("the uuid of osd.%d is unknown", id)
+		}
+	}
+	updateReplacementStatus(c.context, r, false, nil)
+
+	if r.Spec.Migrate {
+		// one osd at a time is migrated, after the data of the previous one was moved back to it
+		logger.Infof("waiting for the cluster to be clean before migrating osd.%d to ceph-volume", id)
+		if err := waitForCleanCluster(c.context, c.namespace, c.stopCh); err != nil {
+			return fmt.Errorf("failed to wait for the cluster to be clean before migrating osd.%d. %+v", id, err)
+		}
+	}
+
+	// get a baseline for OSD usage so we can compare usage to it later on to know when migration has started
+	initialUsage, err := client.GetOSDUsage(c.context, c.namespace)
+	if err != nil {
+		logger.Warningf("failed to get baseline OSD usage, but will still continue")
+	}
+
+	logger.Infof("marking osd.%d out to replace its device %s on node %s", id, r.Status.DevicePath, r.Status.Node)
+	if err := markOSDOut(c.context, c.namespace, id); err != nil {
+		return fmt.Errorf("failed to mark osd.%d out: %+v", id, err)
+	}
+	if err := waitForRebalance(c.context, c.namespace, id, initialUsage, c.stopCh); err != nil {
+		return fmt.Errorf("failed to wait for cluster rebalancing after marking osd.%d out: %+v", id, err)
+	}
+
+	if err := k8sutil.DeleteDeployment(c.context.Clientset, c.namespace, deploymentName); err != nil {
+		return fmt.Errorf("failed to delete deployment %s: %+v", deploymentName, err)
+	}
+
+	if o, err := client.OSDDestroy(c.context, c.namespace, id); err != nil {
+		return fmt.Errorf("failed to destroy osd.%d. %+v. %s", id, err, o)
+	}
+
+	// delete any backups of the OSD filesystem
+	if err := deleteOSDFileSystem(c.context.Clientset, c.namespace, id); err != nil {
+		logger.Warningf("failed to delete osd.%d filesystem, it may need to be cleaned up manually: %+v", id, err)
+	}
+
+	// the new osd gets a new key if it is encrypted
+	if err := deleteEncryptionKeySecret(c.context, c.namespace, r.Status.OSDUUID); err != nil {
+		logger.Warningf("failed to delete the encryption key of osd.%d, it may need to be cleaned up manually: %+v", id, err)
+	}
+
+	logger.Infof("destroyed osd.%d. waiting for a new device at %s on node %s", id, r.Status.DevicePath, r.Status.Node)
+	return nil
+}
+
+func updateReplacementStatus(context *clusterd.Context, r *cephv1.CephOSDReplacement, destroyed bool, destroyErr error) {
+	latest, err := context.RookClientset.CephV1().CephOSDReplacements(r.Namespace).Get(r.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get osd replacement %s to update its status. %+v", r.Name, err)
+		return
+	}
+
+	latest.Status.ObservedGeneration = r.Generation
+	latest.Status.Node = r.Status.Node
+	latest.Status.DevicePath = r.Status.DevicePath
+	latest.Status.OSDUUID = r.Status.OSDUUID
+	switch {
+	case destroyErr != nil:
+		latest.Status.SetCondition(cephv1.ConditionOSDDestroyed, v1.ConditionFalse, cephv1.ReasonOSDDestroyFailed, destroyErr.Error())
+	case destroyed:
+		latest.Status.SetCondition(cephv1.ConditionOSDDestroyed, v1.ConditionTrue, cephv1.ReasonOSDDestroyed, fmt.Sprintf("osd.%d was destroyed", r.Spec.OSDID))
+		message := fmt.Sprintf("waiting for a new device at %s on node %s", r.Status.DevicePath, r.Status.Node)
+		latest.Status.SetCondition(cephv1.ConditionOSDReplaced, v1.ConditionUnknown, cephv1.ReasonWaitingForDevice, message)
+	default:
+		latest.Status.SetCondition(cephv1.ConditionOSDDestroyed, v1.ConditionUnknown, "", fmt.Sprintf("moving the data off osd.%d", r.Spec.OSDID))
+	}
+	latest.Status.SetPhase(cephv1.ConditionOSDDestroyed, cephv1.ConditionOSDReplaced)
+
+	if _, err := context.RookClientset.CephV1().CephOSDReplacements(r.Namespace).UpdateStatus(latest); err != nil {
+		logger.Warningf("failed to update status of osd replacement %s. %+v", r.Name, err)
+	}
+}
+
+// pendingReplacements returns the replacements of the node whose osd was destroyed and not yet replaced
+func (c *Cluster) pendingReplacements(nodeName string) ([]cephv1.CephOSDReplacement, error) {
+	list, err := c.context.RookClientset.CephV1().CephOSDReplacements(c.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list osd replacements. %+v", err)
+	}
+
+	var pending []cephv1.CephOSDReplacement
+	for _, r := range list.Items {
+		if r.Status.Node == nodeName &&
+			r.Status.IsConditionTrue(cephv1.ConditionOSDDestroyed) &&
+			!r.Status.IsConditionTrue(cephv1.ConditionOSDReplaced) {
+			pending = append(pending, r)
+		}
+	}
+	return pending, nil
+}
+
+// replaceOSDsEnvVar tells the prepare job which osd id to give to a new device found at the by-path
+// link of a destroyed osd
+func replaceOSDsEnvVar(replacements []cephv1.CephOSDReplacement) v1.EnvVar {
+	return v1.EnvVar{Name: replaceOSDsEnvVarName, Value: deviceOSDPairs(replacements, false)}
+}
+
+// deviceOSDPairs joins the devices and osd ids of either the replacements or the migrations
+func deviceOSDPairs(replacements []cephv1.CephOSDReplacement, migrate bool) string {
+	var pairs []string
+	for _, r := range replacements {
+		if r.Spec.Migrate == migrate {
+			pairs = append(pairs, fmt.Sprintf("%s=%d", r.Status.DevicePath, r.Spec.OSDID))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// addReplacementsToJob passes the pending replacements of the node to the prepare job
+func (c *Cluster) addReplacementsToJob(job *batch.Job, nodeName string) error {
+	replacements, err := c.pendingReplacements(nodeName)
+	if err != nil {
+		return err
+	}
+	if len(replacements) == 0 {
+		return nil
+	}
+
+	var envVars []v1.EnvVar
+	for _, envVar := range []v1.EnvVar{replaceOSDsEnvVar(replacements), migrateOSDsEnvVar(replacements)} {
+		if envVar.Value != "" {
+			envVars = append(envVars, envVar)
+		}
+	}
+	containers := job.Spec.Template.Spec.Containers
+	for i := range containers {
+		if containers[i].Name == "provision" {
+			containers[i].Env = append(containers[i].Env, envVars...)
+		}
+	}
+	return nil
+}
+
+// checkReplacement returns false if the osd is the destroyed osd of a pending replacement, which
+// happens when the failed device is still readable. Otherwise, a pending replacement of the osd id
+// is completed since the osd was prepared on the new device. An osd only completes a replacement
+// that recorded the uuid of the destroyed osd.
+func (c *Cluster) checkReplacement(osd OSDInfo, replacements []cephv1.CephOSDReplacement) bool {
+	for _, r := range replacements {
+		if r.Spec.OSDID != osd.ID {
+			continue
+		}
+		if r.Status.OSDUUID == "" || osd.UUID == "" {
+			logger.Warningf("not starting osd.%d since it can't be told apart from the osd destroyed by replacement %s", osd.ID, r.Name)
+			return false
+		}
+		if r.Status.OSDUUID == osd.UUID {
+			logger.Warningf("not starting osd.%d that was destroyed by replacement %s", osd.ID, r.Name)
+			return false
+		}
+
+		logger.Infof("osd.%d replaced by a new device at %s on node %s", osd.ID, r.Status.DevicePath, r.Status.Node)
+		r.Status.SetCondition(cephv1.ConditionOSDReplaced, v1.ConditionTrue, cephv1.ReasonOSDReplaced, fmt.Sprintf("osd.%d was prepared with uuid %s", osd.ID, osd.UUID))
+		r.Status.SetPhase(cephv1.ConditionOSDDestroyed, cephv1.ConditionOSDReplaced)
+		if _, err := c.context.RookClientset.CephV1().CephOSDReplacements(c.Namespace).UpdateStatus(&r); err != nil {
+			logger.Warningf("failed to update status of osd replacement %s. %+v", r.Name, err)
+		}
+	}
+	return true
+}
+
+func getContainerEnv(containers []v1.Container, containerName, envName string) string {
+	for _, c := range containers {
+		if c.Name != containerName {
+			continue
+		}
+		for _, e := range c.Env {
+			if e.Name == envName {
+				return e.Value
+			}
+		}
+	}
+	return ""
+}
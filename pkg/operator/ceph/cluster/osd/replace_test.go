@@ -0,0 +1,203 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package osd for the Ceph OSDs.
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testDevicePath = "/dev/disk/by-path/pci-0000:00:1f.2-ata-1"
+
+func TestDestroyOSDForReplacement(t *testing.T) {
+	var destroyed []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "status" {
+				return `{"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+			}
+			if args[0] == "pg" && args[1] == "dump" {
+				return `[]`, nil
+			}
+			if args[0] == "osd" {
+				switch args[1] {
+				case "df":
+					return `{"nodes":[{"id":3,"name":"osd.3","kb_used":0}]}`, nil
+				case "out":
+					assert.Equal(t, "3", args[2])
+					return "", nil
+				case "destroy":
+					destroyed = append(destroyed, args[2])
+					return "", nil
+				}
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: executor}
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, context, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+
+	// the deployment records the by-path link of the device of the osd
+	osd := OSDInfo{ID: 3, UUID: "old-uuid", CephVolumeInitiated: true, DevicePath: testDevicePath}
+	d, err := c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "", osd)
+	require.Nil(t, err)
+	assert.Equal(t, testDevicePath, d.Annotations[devicePathAnnotation])
+	_, err = context.Clientset.AppsV1().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+
+	r := &cephv1.CephOSDReplacement{
+		ObjectMeta: metav1.ObjectMeta{Name: "replace-osd-3", Namespace: "ns"},
+		Spec:       cephv1.OSDReplacementSpec{OSDID: 3},
+	}
+	_, err = context.RookClientset.CephV1().CephOSDReplacements("ns").Create(r)
+	assert.Nil(t, err)
+
+	controller := NewReplacementController(context, "ns", nil)
+	controller.replace(r)
+	assert.Equal(t, []string{"3"}, destroyed)
+	_, err = context.Clientset.AppsV1().Deployments("ns").Get(d.Name, metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	r, err = context.RookClientset.CephV1().CephOSDReplacements("ns").Get("replace-osd-3", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "node1", r.Status.Node)
+	assert.Equal(t, testDevicePath, r.Status.DevicePath)
+	assert.Equal(t, "old-uuid", r.Status.OSDUUID)
+	assert.True(t, r.Status.IsConditionTrue(cephv1.ConditionOSDDestroyed))
+	assert.Equal(t, cephv1.ResourcePhaseProgressing, r.Status.Phase)
+
+	// the prepare job of the node is told which id to give to the new device
+	replacements, err := c.pendingReplacements("node1")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(replacements))
+	assert.Equal(t, v1.EnvVar{Name: replaceOSDsEnvVarName, Value: testDevicePath + "=3"}, replaceOSDsEnvVar(replacements))
+	replacements, err = c.pendingReplacements("node2")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(replacements))
+
+	// the destroyed osd is not started again if the failed device is still found
+	replacements, _ = c.pendingReplacements("node1")
+	assert.False(t, c.checkReplacement(osd, replacements))
+
+	// an osd without a uuid does not complete the replacement
+	assert.False(t, c.checkReplacement(OSDInfo{ID: 3}, replacements))
+	r, err = context.RookClientset.CephV1().CephOSDReplacements("ns").Get("replace-osd-3", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.False(t, r.Status.IsConditionTrue(cephv1.ConditionOSDReplaced))
+
+	// the osd prepared on the new device completes the replacement
+	assert.True(t, c.checkReplacement(OSDInfo{ID: 3, UUID: "new-uuid"}, replacements))
+	r, err = context.RookClientset.CephV1().CephOSDReplacements("ns").Get("replace-osd-3", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, r.Status.IsConditionTrue(cephv1.ConditionOSDReplaced))
+	assert.Equal(t, cephv1.ResourcePhaseReady, r.Status.Phase)
+	replacements, err = c.pendingReplacements("node1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(replacements))
+}
+
+func TestDestroyOSDWithoutDevicePath(t *testing.T) {
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset(), Executor: &exectest.MockExecutor{}}
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, context, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+	d, err := c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "", OSDInfo{ID: 4, CephVolumeInitiated: true})
+	require.Nil(t, err)
+	_, err = context.Clientset.AppsV1().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+
+	// the osd is not touched when the slot of the new device is unknown
+	controller := NewReplacementController(context, "ns", nil)
+	r := &cephv1.CephOSDReplacement{ObjectMeta: metav1.ObjectMeta{Name: "r", Namespace: "ns"}, Spec: cephv1.OSDReplacementSpec{OSDID: 4}}
+	assert.NotNil(t, controller.destroyOSD(r))
+
+	// the missing slot can be given in the spec
+	r.Spec.DevicePath = "/dev/sdb"
+	assert.NotNil(t, controller.destroyOSD(r))
+}
+
+func TestStopReplacement(t *testing.T) {
+	var destroyed []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "pg" && args[1] == "dump" {
+				// the data is never moved off the osd
+				return `[{"pgid":"1.0","up_primary":5,"acting_primary":5,"up":[5],"acting":[5]}]`, nil
+			}
+			if args[0] == "osd" {
+				switch args[1] {
+				case "df":
+					return `{"nodes":[]}`, nil
+				case "dump":
+					return `{"osds":[{"osd":5,"up":1,"in":1,"uuid":"uuid-5"}]}`, nil
+				case "out":
+					return "", nil
+				case "destroy":
+					destroyed = append(destroyed, args[2])
+					return "", nil
+				}
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset(), Executor: executor}
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, context, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+	d, err := c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "", OSDInfo{ID: 5, CephVolumeInitiated: true, DevicePath: testDevicePath})
+	require.Nil(t, err)
+	_, err = context.Clientset.AppsV1().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+	r := &cephv1.CephOSDReplacement{ObjectMeta: metav1.ObjectMeta{Name: "r", Namespace: "ns"}, Spec: cephv1.OSDReplacementSpec{OSDID: 5}}
+	_, err = context.RookClientset.CephV1().CephOSDReplacements("ns").Create(r)
+	assert.Nil(t, err)
+
+	// the wait for the data to move off the osd ends when the controller is stopped
+	controller := NewReplacementController(context, "ns", nil)
+	controller.stopCh = make(chan struct{})
+	close(controller.stopCh)
+	controller.replace(r)
+	assert.Equal(t, 0, len(destroyed))
+
+	// the status keeps the progress of the replacement, including the uuid from the osd map
+	r, err = context.RookClientset.CephV1().CephOSDReplacements("ns").Get("r", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "uuid-5", r.Status.OSDUUID)
+	assert.Equal(t, "node1", r.Status.Node)
+	assert.False(t, r.Status.IsConditionTrue(cephv1.ConditionOSDDestroyed))
+	assert.NotEqual(t, cephv1.ResourcePhaseFailure, r.Status.Phase)
+}
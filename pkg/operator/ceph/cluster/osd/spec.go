@@ -18,6 +18,7 @@ limitations under the License.
 package osd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -38,17 +39,20 @@ import (
 )
 
 const (
-	dataDirsEnvVarName                  = "ROOK_DATA_DIRECTORIES"
-	osdStoreEnvVarName                  = "ROOK_OSD_STORE"
-	osdDatabaseSizeEnvVarName           = "ROOK_OSD_DATABASE_SIZE"
-	osdWalSizeEnvVarName                = "ROOK_OSD_WAL_SIZE"
-	osdJournalSizeEnvVarName            = "ROOK_OSD_JOURNAL_SIZE"
-	osdsPerDeviceEnvVarName             = "ROOK_OSDS_PER_DEVICE"
-	encryptedDeviceEnvVarName           = "ROOK_ENCRYPTED_DEVICE"
-	osdMetadataDeviceEnvVarName         = "ROOK_METADATA_DEVICE"
-	rookBinariesMountPath               = "/rook"
-	rookBinariesVolumeName              = "rook-binaries"
-	osdMemoryTargetSafetyFactor float32 = 0.8
+	dataDirsEnvVarName          = "ROOK_DATA_DIRECTORIES"
+	osdStoreEnvVarName          = "ROOK_OSD_STORE"
+	osdDatabaseSizeEnvVarName   = "ROOK_OSD_DATABASE_SIZE"
+	osdWalSizeEnvVarName        = "ROOK_OSD_WAL_SIZE"
+	osdJournalSizeEnvVarName    = "ROOK_OSD_JOURNAL_SIZE"
+	osdsPerDeviceEnvVarName     = "ROOK_OSDS_PER_DEVICE"
+	encryptedDeviceEnvVarName   = "ROOK_ENCRYPTED_DEVICE"
+	encryptionStoreEnvVarName   = "ROOK_ENCRYPTION_KEY_STORE"
+	osdMetadataDeviceEnvVarName = "ROOK_METADATA_DEVICE"
+	osdsPerMetadataEnvVarName   = "ROOK_OSDS_PER_METADATA_DEVICE"
+	osdDeviceClassEnvVarName    = "ROOK_OSD_DEVICE_CLASS"
+	dataDeviceClassesEnvVarName = "ROOK_DATA_DEVICE_CLASSES"
+	rookBinariesMountPath       = "/rook"
+	rookBinariesVolumeName      = "rook-binaries"
 )
 
 func (c *Cluster) makeJob(nodeName string, devices []rookalpha.Device,
@@ -88,6 +92,7 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 	volumes := opspec.PodVolumes(c.dataDirHostPath, c.Namespace)
 
 	var dataDir string
+	var devMount v1.VolumeMount
 	if osd.IsDirectory {
 		// Mount the path to the directory-based osd
 		// osd.DataPath includes the osd subdirectory, so we want to mount the parent directory
@@ -108,7 +113,7 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 		// Create volume config for /dev so the pod can access devices on the host
 		devVolume := v1.Volume{Name: "devices", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/dev"}}}
 		volumes = append(volumes, devVolume)
-		devMount := v1.VolumeMount{Name: "devices", MountPath: "/dev"}
+		devMount = v1.VolumeMount{Name: "devices", MountPath: "/dev"}
 		volumeMounts = append(volumeMounts, devMount)
 	}
 
@@ -155,14 +160,15 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 		"--osd-uuid", osd.UUID,
 	}
 
-	// Set osd memory target to the best appropriate value
+	// bluestore sizes its caches to keep the memory of the osd below the memory target, which must
+	// leave room within the memory limit of the pod for the osd not to be killed
+	var memoryTargetArgs []string
 	if !osd.IsFileStore {
-		// As of Nautilus Ceph auto-tunes its osd_memory_target on the fly so we don't need to force it
-		if !c.clusterInfo.CephVersion.IsAtLeastNautilus() && !c.resources.Limits.Memory().IsZero() {
-			osdMemoryTargetValue := float32(c.resources.Limits.Memory().Value()) * osdMemoryTargetSafetyFactor
-			commonArgs = append(commonArgs, fmt.Sprintf("--osd-memory-target=%f", osdMemoryTargetValue))
+		if target := opspec.MemoryTarget(resources, c.memoryHeadroom); target > 0 {
+			memoryTargetArgs = append(memoryTargetArgs, fmt.Sprintf("--osd-memory-target=%d", target))
 		}
 	}
+	commonArgs = append(commonArgs, memoryTargetArgs...)
 
 	if osd.IsFileStore {
 		commonArgs = append(commonArgs, fmt.Sprintf("--osd-journal=%s", osd.Journal))
@@ -186,6 +192,10 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 		// for this scenario, we will copy the binaries necessary to a mount, which will then be mounted
 		// to the daemon container.
 		sourcePath := path.Join("/dev/disk/by-partuuid", osd.DevicePartUUID)
+		if osd.Encrypted {
+			// the partition is unlocked by the encryption init container
+			sourcePath = path.Join("/dev/mapper", EncryptedPartitionMapperName(osd.DevicePartUUID))
+		}
 		command = []string{path.Join(k8sutil.BinariesMountPath, "tini")}
 		args = append([]string{
 			"--", path.Join(k8sutil.BinariesMountPath, "rook"),
@@ -208,15 +218,12 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 			"--conf", osd.Config,
 			"--cluster", "ceph",
 		}
-
-		// Set osd memory target to the best appropriate value
-		if !osd.IsFileStore {
-			// As of Nautilus Ceph auto-tunes its osd_memory_target on the fly so we don't need to force it
-			if !c.clusterInfo.CephVersion.IsAtLeastNautilus() && !c.resources.Limits.Memory().IsZero() {
-				osdMemoryTargetValue := float32(c.resources.Limits.Memory().Value()) * osdMemoryTargetSafetyFactor
-				commonArgs = append(commonArgs, fmt.Sprintf("--osd-memory-target=%f", osdMemoryTargetValue))
-			}
+		if osd.Encrypted {
+			// the devices are unlocked by the encryption init container, rook activates the osd
+			// without asking the mons for the key
+			envVars = append(envVars, v1.EnvVar{Name: "ROOK_OSD_ENCRYPTED", Value: "true"})
 		}
+		args = append(args, memoryTargetArgs...)
 
 		if c.clusterInfo.CephVersion.IsAtLeast(version.CephVersion{Major: 14, Minor: 2, Extra: 1}) {
 			args = append(args, "--default-log-to-file", "false")
@@ -248,8 +255,29 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 		ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
 	}
 
+	initContainers := []v1.Container{
+		{
+			Args:            []string{"ceph", "osd", "init"},
+			Name:            opspec.ConfigInitContainerName,
+			Image:           k8sutil.MakeRookImage(c.rookVersion),
+			VolumeMounts:    configVolumeMounts,
+			Env:             configEnvVars,
+			SecurityContext: securityContext,
+		},
+		*copyBinariesContainer,
+	}
+
 	// needed for luksOpen synchronization when devices are encrypted
 	hostIPC := storeConfig.EncryptedDevice
+	if osd.Encrypted && !osd.IsDirectory {
+		hostIPC = true
+		encryptionMounts := append(configVolumeMounts, devMount)
+		if osd.CephVolumeInitiated {
+			// the logical volumes of the osd are found with lvm, which needs the udev database
+			encryptionMounts = append(encryptionMounts, v1.VolumeMount{Name: "run-udev", MountPath: "/run/udev"})
+		}
+		initContainers = append(initContainers, c.getEncryptionOpenContainer(osd, configEnvVars, encryptionMounts, securityContext))
+	}
 
 	DNSPolicy := v1.DNSClusterFirst
 	if c.HostNetwork {
@@ -293,17 +321,7 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 					HostPID:            true,
 					HostIPC:            hostIPC,
 					DNSPolicy:          DNSPolicy,
-					InitContainers: []v1.Container{
-						{
-							Args:            []string{"ceph", "osd", "init"},
-							Name:            opspec.ConfigInitContainerName,
-							Image:           k8sutil.MakeRookImage(c.rookVersion),
-							VolumeMounts:    configVolumeMounts,
-							Env:             configEnvVars,
-							SecurityContext: securityContext,
-						},
-						*copyBinariesContainer,
-					},
+					InitContainers:     initContainers,
 					Containers: []v1.Container{
 						{
 							Command:         command,
@@ -325,6 +343,10 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 	k8sutil.AddRookVersionLabelToDeployment(deployment)
 	c.annotations.ApplyToObjectMeta(&deployment.ObjectMeta)
 	c.annotations.ApplyToObjectMeta(&deployment.Spec.Template.ObjectMeta)
+	if osd.DevicePath != "" {
+		// the by-path link of the device is where a new device is expected when the osd is replaced
+		deployment.Annotations[devicePathAnnotation] = osd.DevicePath
+	}
 	opspec.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, deployment)
 	opspec.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, deployment)
 	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &deployment.ObjectMeta, &c.ownerRef)
@@ -356,7 +378,7 @@ func (c *Cluster) provisionPodTemplateSpec(devices []rookalpha.Device, selection
 	volumes := append(opspec.PodVolumes(c.dataDirHostPath, c.Namespace), copyBinariesVolume)
 
 	// by default, don't define any volume config unless it is required
-	if len(devices) > 0 || selection.DeviceFilter != "" || selection.GetUseAllDevices() || metadataDevice != "" {
+	if len(devices) > 0 || selection.DeviceFilter != "" || selection.DevicePathFilter != "" || selection.GetUseAllDevices() || metadataDevice != "" {
 		// create volume config for the data dir and /dev so the pod can access devices on the host
 		devVolume := v1.Volume{Name: "devices", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/dev"}}}
 		volumes = append(volumes, devVolume)
@@ -457,10 +479,22 @@ func (c *Cluster) getConfigEnvVars(storeConfig config.StoreConfig, dataDir, node
 		envVars = append(envVars, v1.EnvVar{Name: osdsPerDeviceEnvVarName, Value: strconv.Itoa(storeConfig.OSDsPerDevice)})
 	}
 
+	if storeConfig.OSDsPerMetadataDevice != 0 {
+		envVars = append(envVars, v1.EnvVar{Name: osdsPerMetadataEnvVarName, Value: strconv.Itoa(storeConfig.OSDsPerMetadataDevice)})
+	}
+
 	if storeConfig.EncryptedDevice {
 		envVars = append(envVars, v1.EnvVar{Name: encryptedDeviceEnvVarName, Value: "true"})
 	}
 
+	if storeConfig.EncryptionKeyStore != "" {
+		envVars = append(envVars, v1.EnvVar{Name: encryptionStoreEnvVarName, Value: storeConfig.EncryptionKeyStore})
+	}
+
+	if storeConfig.DeviceClass != "" {
+		envVars = append(envVars, v1.EnvVar{Name: osdDeviceClassEnvVarName, Value: storeConfig.DeviceClass})
+	}
+
 	if location != "" {
 		envVars = append(envVars, rookalpha.LocationEnvVar(location))
 	}
@@ -475,27 +509,54 @@ func (c *Cluster) provisionOSDContainer(devices []rookalpha.Device, selection ro
 	devMountNeeded := false
 	privileged := false
 
-	// only 1 of device list, device filter and use all devices can be specified.  We prioritize in that order.
+	// only 1 of device list, device filter, device path filter and use all devices can be specified.  We prioritize in that order.
 	if len(devices) > 0 {
 		deviceNames := make([]string, len(devices))
+		var deviceClasses []string
 		for i, device := range devices {
+			name := device.Name
+			if device.FullPath != "" {
+				name = device.FullPath
+			}
 			countSuffix := ""
 			if count, ok := device.Config[config.OSDsPerDeviceKey]; ok {
-				logger.Infof("%s osds requested on device %s (node %s)", count, device.Name, nodeName)
+				logger.Infof("%s osds requested on device %s (node %s)", count, name, nodeName)
 				countSuffix = ":" + count
+			} else if strings.HasPrefix(name, "/dev/") {
+				// the device links can contain colons, so the count is always given for paths
+				countSuffix = ":1"
+			}
+			deviceNames[i] = name + countSuffix
+			if class, ok := device.Config[config.DeviceClassKey]; ok {
+				deviceClasses = append(deviceClasses, fmt.Sprintf("%s=%s", name, class))
 			}
-			deviceNames[i] = device.Name + countSuffix
 		}
 		envVars = append(envVars, dataDevicesEnvVar(strings.Join(deviceNames, ",")))
+		if len(deviceClasses) > 0 {
+			// the device class of the node is overridden for these devices
+			envVars = append(envVars, v1.EnvVar{Name: dataDeviceClassesEnvVarName, Value: strings.Join(deviceClasses, ",")})
+		}
 		devMountNeeded = true
 	} else if selection.DeviceFilter != "" {
 		envVars = append(envVars, deviceFilterEnvVar(selection.DeviceFilter))
 		devMountNeeded = true
+	} else if selection.DevicePathFilter != "" {
+		envVars = append(envVars, devicePathFilterEnvVar(selection.DevicePathFilter))
+		devMountNeeded = true
 	} else if selection.GetUseAllDevices() {
 		envVars = append(envVars, deviceFilterEnvVar("all"))
 		devMountNeeded = true
 	}
 
+	if devMountNeeded && selection.DevicePropertyFilter != nil {
+		filter, err := json.Marshal(selection.DevicePropertyFilter)
+		if err != nil {
+			logger.Errorf("failed to marshal the device property filter of node %s. %+v", nodeName, err)
+		} else {
+			envVars = append(envVars, devicePropertyFilterEnvVar(string(filter)))
+		}
+	}
+
 	if metadataDevice != "" {
 		envVars = append(envVars, metadataDeviceEnvVar(metadataDevice))
 		devMountNeeded = true
@@ -570,6 +631,14 @@ func deviceFilterEnvVar(filter string) v1.EnvVar {
 	return v1.EnvVar{Name: "ROOK_DATA_DEVICE_FILTER", Value: filter}
 }
 
+func devicePathFilterEnvVar(filter string) v1.EnvVar {
+	return v1.EnvVar{Name: "ROOK_DATA_DEVICE_PATH_FILTER", Value: filter}
+}
+
+func devicePropertyFilterEnvVar(filter string) v1.EnvVar {
+	return v1.EnvVar{Name: "ROOK_DATA_DEVICE_PROPERTY_FILTER", Value: filter}
+}
+
 func metadataDeviceEnvVar(metadataDevice string) v1.EnvVar {
 	return v1.EnvVar{Name: osdMetadataDeviceEnvVarName, Value: metadataDevice}
 }
@@ -614,6 +683,8 @@ func getConfigFromContainer(osdContainer v1.Container) map[string]string {
 			cfg[config.JournalSizeMBKey] = envVar.Value
 		case osdMetadataDeviceEnvVarName:
 			cfg[config.MetadataDeviceKey] = envVar.Value
+		case osdsPerMetadataEnvVarName:
+			cfg[config.OSDsPerMetadataDeviceKey] = envVar.Value
 		}
 	}
 
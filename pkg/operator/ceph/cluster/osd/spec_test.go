@@ -22,6 +22,7 @@ import (
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
@@ -77,8 +78,8 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	clusterInfo := &cephconfig.ClusterInfo{
 		CephVersion: cephver.Nautilus,
 	}
-	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephVersion,
-		storageSpec, dataDir, rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephVersion,
+		storageSpec, dataDir, rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
 
 	devMountNeeded := deviceName != "" || allDevices
 
@@ -163,8 +164,8 @@ func TestStorageSpecDevicesAndDirectories(t *testing.T) {
 	clusterInfo := &cephconfig.ClusterInfo{
 		CephVersion: cephver.Nautilus,
 	}
-	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
-		storageSpec, "/var/lib/rook", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
+		storageSpec, "/var/lib/rook", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
 
 	n := c.DesiredStorage.ResolveNode(storageSpec.Nodes[0].Name)
 	osd := OSDInfo{
@@ -231,11 +232,12 @@ func TestStorageSpecConfig(t *testing.T) {
 				Name:     "node1",
 				Location: "rack=foo",
 				Config: map[string]string{
-					"storeType":      "bluestore",
-					"databaseSizeMB": "10",
-					"walSizeMB":      "20",
-					"journalSizeMB":  "30",
-					"metadataDevice": "nvme093",
+					"storeType":             "bluestore",
+					"databaseSizeMB":        "10",
+					"walSizeMB":             "20",
+					"journalSizeMB":         "30",
+					"metadataDevice":        "nvme093,nvme094",
+					"osdsPerMetadataDevice": "6",
 				},
 				Selection: rookalpha.Selection{
 					Directories: []rookalpha.Directory{{Path: "/rook/storageDir472"}},
@@ -258,8 +260,8 @@ func TestStorageSpecConfig(t *testing.T) {
 	clusterInfo := &cephconfig.ClusterInfo{
 		CephVersion: cephver.Nautilus,
 	}
-	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
-		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
+		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
 
 	n := c.DesiredStorage.ResolveNode(storageSpec.Nodes[0].Name)
 	storeConfig := config.ToStoreConfig(storageSpec.Nodes[0].Config)
@@ -279,7 +281,8 @@ func TestStorageSpecConfig(t *testing.T) {
 	verifyEnvVar(t, container.Env, "ROOK_OSD_WAL_SIZE", "20", true)
 	verifyEnvVar(t, container.Env, "ROOK_OSD_JOURNAL_SIZE", "30", true)
 	verifyEnvVar(t, container.Env, "ROOK_LOCATION", "rack=foo", true)
-	verifyEnvVar(t, container.Env, "ROOK_METADATA_DEVICE", "nvme093", true)
+	verifyEnvVar(t, container.Env, "ROOK_METADATA_DEVICE", "nvme093,nvme094", true)
+	verifyEnvVar(t, container.Env, "ROOK_OSDS_PER_METADATA_DEVICE", "6", true)
 
 	assert.Equal(t, "1Ki", container.Resources.Limits.Cpu().String(), "limit cpu is: %s", container.Resources.Limits.Cpu().String())
 	assert.Equal(t, "500", container.Resources.Requests.Cpu().String())
@@ -293,6 +296,56 @@ func TestStorageSpecConfig(t *testing.T) {
 	assert.Equal(t, n.Directories, discoveredDirs)
 }
 
+func TestStorageSpecDeviceSelection(t *testing.T) {
+	rotational := false
+	minSize := resource.MustParse("100Gi")
+	storageSpec := rookalpha.StorageScopeSpec{
+		Selection: rookalpha.Selection{
+			DevicePathFilter:     "^/dev/disk/by-path/pci-0000:00:1f.2-",
+			DevicePropertyFilter: &rookalpha.DevicePropertyFilter{Rotational: &rotational, MinSize: &minSize},
+		},
+		Nodes: []rookalpha.Node{
+			{Name: "node1"},
+			{
+				Name: "node2",
+				Selection: rookalpha.Selection{
+					Devices: []rookalpha.Device{
+						{Name: "sda"},
+						{FullPath: "/dev/disk/by-id/nvme-INTEL_SSDPE2KX010T8_BTLJ", Config: map[string]string{config.OSDsPerDeviceKey: "2", config.DeviceClassKey: "nvme"}},
+						{FullPath: "/dev/disk/by-path/pci-0000:00:1f.2-ata-3"},
+					},
+				},
+			},
+		},
+	}
+
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, &clusterd.Context{Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
+		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+
+	// the node inherits the path and property filters of the cluster
+	n := c.DesiredStorage.ResolveNode("node1")
+	job, err := c.makeJob(n.Name, n.Devices, n.Selection, n.Resources, config.StoreConfig{}, "", n.Location)
+	assert.Nil(t, err)
+	container := job.Spec.Template.Spec.Containers[1]
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICE_PATH_FILTER", "^/dev/disk/by-path/pci-0000:00:1f.2-", true)
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICE_PROPERTY_FILTER", `{"rotational":false,"minSize":"100Gi"}`, true)
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICES", "", false)
+
+	// the devices are given by their full path if they have one, always with the osd count
+	n = c.DesiredStorage.ResolveNode("node2")
+	job, err = c.makeJob(n.Name, n.Devices, n.Selection, n.Resources, config.StoreConfig{DeviceClass: "hdd"}, "", n.Location)
+	assert.Nil(t, err)
+	container = job.Spec.Template.Spec.Containers[1]
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICES", "sda,/dev/disk/by-id/nvme-INTEL_SSDPE2KX010T8_BTLJ:2,/dev/disk/by-path/pci-0000:00:1f.2-ata-3:1", true)
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICE_PATH_FILTER", "", false)
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICE_PROPERTY_FILTER", `{"rotational":false,"minSize":"100Gi"}`, true)
+
+	// the device class of a device overrides the class of the node
+	verifyEnvVar(t, container.Env, osdDeviceClassEnvVarName, "hdd", true)
+	verifyEnvVar(t, container.Env, dataDeviceClassesEnvVarName, "/dev/disk/by-id/nvme-INTEL_SSDPE2KX010T8_BTLJ=nvme", true)
+}
+
 func TestHostNetwork(t *testing.T) {
 	storageSpec := rookalpha.StorageScopeSpec{
 		Nodes: []rookalpha.Node{
@@ -313,8 +366,8 @@ func TestHostNetwork(t *testing.T) {
 	clusterInfo := &cephconfig.ClusterInfo{
 		CephVersion: cephver.Nautilus,
 	}
-	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
-		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, true, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
+		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, true, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
 
 	n := c.DesiredStorage.ResolveNode(storageSpec.Nodes[0].Name)
 	osd := OSDInfo{
@@ -328,3 +381,40 @@ func TestHostNetwork(t *testing.T) {
 	assert.Equal(t, true, r.Spec.Template.Spec.HostNetwork)
 	assert.Equal(t, v1.DNSClusterFirstWithHostNet, r.Spec.Template.Spec.DNSPolicy)
 }
+
+func TestOSDMemoryTarget(t *testing.T) {
+	storageSpec := rookalpha.StorageScopeSpec{Nodes: []rookalpha.Node{{Name: "node1"}}}
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, &clusterd.Context{Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
+		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+	n := c.DesiredStorage.ResolveNode(storageSpec.Nodes[0].Name)
+	resources := v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+
+	// the memory target of the osd leaves the default headroom within the memory limit of the pod
+	d, err := c.makeDeployment(n.Name, n.Selection, resources, config.StoreConfig{}, "", n.Location, OSDInfo{ID: 0})
+	assert.Nil(t, err)
+	assert.Contains(t, d.Spec.Template.Spec.Containers[0].Args, "--osd-memory-target=3435973836")
+
+	// the osds provisioned by ceph-volume are passed the memory target with the configured headroom
+	c.memoryHeadroom = "0.5"
+	d, err = c.makeDeployment(n.Name, n.Selection, resources, config.StoreConfig{}, "", n.Location, OSDInfo{ID: 0, CephVolumeInitiated: true})
+	assert.Nil(t, err)
+	assert.Contains(t, d.Spec.Template.Spec.Containers[0].Args, "--osd-memory-target=2147483648")
+
+	// filestore osds and osds without a memory limit have no memory target
+	for _, test := range []struct {
+		resources v1.ResourceRequirements
+		osd       OSDInfo
+	}{
+		{resources, OSDInfo{ID: 0, IsFileStore: true, IsDirectory: true}},
+		{v1.ResourceRequirements{}, OSDInfo{ID: 0}},
+	} {
+		d, err = c.makeDeployment(n.Name, n.Selection, test.resources, config.StoreConfig{}, "", n.Location, test.osd)
+		assert.Nil(t, err)
+		for _, arg := range d.Spec.Template.Spec.Containers[0].Args {
+			assert.NotContains(t, arg, "osd-memory-target")
+		}
+	}
+}
@@ -252,7 +252,9 @@ func (c *Cluster) handleStatusConfigMapStatus(nodeName string, config *provision
 
 	logger.Infof("osd orchestration status for node %s is %s", nodeName, status.Status)
 	if status.Status == OrchestrationStatusCompleted {
-		if configOSDs {
+		if configOSDs && status.PVCBackedOSD {
+			c.startOSDDaemonsOnPVC(nodeName, config, status)
+		} else if configOSDs {
 			c.startOSDDaemonsOnNode(nodeName, config, configMap, status)
 		}
 		// remove the status configmap that indicated the progress
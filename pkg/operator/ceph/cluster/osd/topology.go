@@ -0,0 +1,352 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	topologyLabelRegion  = "topology.kubernetes.io/region"
+	topologyLabelZone    = "topology.kubernetes.io/zone"
+	topologyLabelRow     = "topology.rook.io/row"
+	topologyLabelRack    = "topology.rook.io/rack"
+	topologyLabelChassis = "topology.rook.io/chassis"
+
+	crushUpdateOnStartOption      = "osd_crush_update_on_start"
+	crushConfigMapName            = "rook-ceph-osd-crush"
+	crushUpdateOnStartDisabledKey = "update-on-start-disabled"
+)
+
+var (
+	// the crush bucket types the nodes can be placed under, from the top of the hierarchy down to the host
+	crushTopologyTypes = []string{"region", "zone", "datacenter", "room", "pod", "pdu", "row", "rack", "chassis"}
+
+	// the well-known labels the topology of the nodes is taken from, in order of precedence
+	defaultTopologyLabels = map[string][]string{
+		"region":  {topologyLabelRegion, v1.LabelZoneRegion},
+		"zone":    {topologyLabelZone, v1.LabelZoneFailureDomain},
+		"row":     {topologyLabelRow},
+		"rack":    {topologyLabelRack},
+		"chassis": {topologyLabelChassis},
+	}
+)
+
+// getNodeTopology returns the crush bucket types mapped to the bucket names found in the labels of the
+// node. The labels given by the user take precedence over the well-known labels.
+func getNodeTopology(node *v1.Node, topologyLabels map[string]string) map[string]string {
+	topology := map[string]string{}
+	for _, crushType := range crushTopologyTypes {
+		labels := defaultTopologyLabels[crushType]
+		if label, ok := topologyLabels[crushType]; ok {
+			labels = append([]string{label}, labels...)
+		}
+		for _, label := range labels {
+			if value := node.Labels[label]; value != "" {
+				topology[crushType] = value
+				break
+			}
+		}
+	}
+	return topology
+}
+
+func isTopologyType(crushType string) bool {
+	for _, t := range crushTopologyTypes {
+		if t == crushType {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTopologyLabels returns an error if the topology labels name a bucket type the nodes can't be placed under
+func validateTopologyLabels(topologyLabels map[string]string) error {
+	for crushType := range topologyLabels {
+		if !isTopologyType(crushType) {
+			return fmt.Errorf("invalid topology label type %s. must be one of %v", crushType, crushTopologyTypes)
+		}
+	}
+	return nil
+}
+
+// NodeTopologyChanged returns true if the labels of the node that the crush location of the node is
+// taken from have changed
+func NodeTopologyChanged(oldNode, newNode *v1.Node, topologyLabels map[string]string) bool {
+	oldTopology := getNodeTopology(oldNode, topologyLabels)
+	newTopology := getNodeTopology(newNode, topologyLabels)
+	if len(oldTopology) != len(newTopology) {
+		return true
+	}
+	for crushType, name := range newTopology {
+		if oldTopology[crushType] != name {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeLocation adds the topology of the node to the crush location. The buckets that are set in the
+// location take precedence over the topology of the node.
+func nodeLocation(node *v1.Node, topologyLabels map[string]string, location string) string {
+	var pairs []string
+	set := map[string]bool{}
+	if location != "" {
+		pairs = strings.Split(location, ",")
+		for _, p := range pairs {
+			set[strings.Split(p, "=")[0]] = true
+		}
+	}
+
+	topology := getNodeTopology(node, topologyLabels)
+	for _, crushType := range crushTopologyTypes {
+		if name, ok := topology[crushType]; ok && !set[crushType] {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", crushType, name))
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// getNodeLocation returns the crush location of the storage node with the given host name
+func (c *Cluster) getNodeLocation(hostName, location string) string {
+	node, err := getNodeByHostName(c.context, hostName)
+	if err != nil {
+		logger.Warningf("failed to get the topology of node %s. %+v", hostName, err)
+		return location
+	}
+	return nodeLocation(node, c.DesiredStorage.TopologyLabels, location)
+}
+
+func getNodeByHostName(context *clusterd.Context, hostName string) (*v1.Node, error) {
+	nodeName, err := k8sutil.GetNodeNameFromHostname(context.Clientset, hostName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find node with host name %s. %+v", hostName, err)
+	}
+	return context.Clientset.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+}
+
+// updateCrushTopology makes sure the crush map has the bucket types of the locations of the storage
+// nodes and moves the host buckets of the nodes to their current locations. The osds keep running
+// at their previous locations if the crush map can't be updated.
+func (c *Cluster) updateCrushTopology() {
+	if err := validateTopologyLabels(c.DesiredStorage.TopologyLabels); err != nil {
+		logger.Warningf("skipping crush topology update. %+v", err)
+		return
+	}
+
+	for _, node := range c.ValidStorage.Nodes {
+		n := c.resolveNode(node.Name)
+		if n == nil || n.Name == "" {
+			continue
+		}
+		if err := updateHostCrushLocation(c.context, c.Namespace, n.Name, n.Location); err != nil {
+			logger.Warningf("failed to update the crush location of node %s. %+v", n.Name, err)
+		}
+	}
+}
+
+// UpdateNodeCrushLocation moves the host bucket of the node to the crush location given by the storage
+// spec and the topology labels of the node
+func UpdateNodeCrushLocation(context *clusterd.Context, namespace string, storage rookalpha.StorageScopeSpec, node *v1.Node) error {
+	if err := validateTopologyLabels(storage.TopologyLabels); err != nil {
+		return err
+	}
+
+	hostName := node.Labels[v1.LabelHostname]
+	if hostName == "" {
+		hostName = node.Name
+	}
+	location := storage.Location
+	if n := storage.ResolveNode(hostName); n != nil {
+		location = n.Location
+	} else if !storage.UseAllNodes {
+		// the node is not a storage node
+		return nil
+	}
+	return updateHostCrushLocation(context, namespace, hostName, nodeLocation(node, storage.TopologyLabels, location))
+}
+
+// updateHostCrushLocation adds the missing topology bucket types of the location to the crush map and
+// moves the host bucket to the location. The host bucket is created with the first osd of the host, so
+// the location of hosts without osds is left to the osds when they are added.
+func updateHostCrushLocation(context *clusterd.Context, namespace, hostName, location string) error {
+	locArgs, err := client.FormatLocation(location, hostName)
+	if err != nil {
+		return fmt.Errorf("invalid location %s. %+v", location, err)
+	}
+
+	crushMap, err := client.GetCrushMap(context, namespace)
+	if err != nil {
+		return err
+	}
+	crushTypes := map[string]bool{}
+	for _, t := range crushMap.Types {
+		crushTypes[t.Name] = true
+	}
+
+	var hostBucket string
+	var parentLocation []string
+	for _, arg := range locArgs {
+		kv := strings.Split(arg, "=")
+		if kv[0] == "host" {
+			hostBucket = kv[1]
+			continue
+		}
+		parentLocation = append(parentLocation, arg)
+		if !crushTypes[kv[0]] && isTopologyType(kv[0]) {
+			if err := client.AddCrushType(context, namespace, kv[0]); err != nil {
+				return fmt.Errorf("failed to add crush type %s. %+v", kv[0], err)
+			}
+		}
+	}
+
+	for _, bucket := range crushMap.Buckets {
+		if bucket.Name == hostBucket {
+			logger.Debugf("moving host %s to crush location %v", hostBucket, parentLocation)
+			_, err := client.CrushMove(context, namespace, hostBucket, parentLocation)
+			return err
+		}
+	}
+	return nil
+}
+
+// disableCrushUpdateOnStart stops the osds that are already in the crush hierarchy from moving
+// themselves to the location of their node when they start, so the crush map is left to the user.
+// The osds that are not placed yet are placed at the location of their node on their first start.
+// The osds that are kept in place are recorded, so the setting is removed again from them when the
+// crush map is managed again.
+func (c *Cluster) disableCrushUpdateOnStart() {
+	if c.clusterInfo.CephVersion.IsLuminous() {
+		logger.Warningf("the osds can only be kept from updating their crush location on start in mimic or newer. " +
+			"set osd_crush_update_on_start to false in the ceph config to leave the crush map to the user.")
+		return
+	}
+
+	crushMap, err := client.GetCrushMap(c.context, c.Namespace)
+	if err != nil {
+		logger.Warningf("failed to get the osds in the crush map. %+v", err)
+		return
+	}
+	options, err := client.ConfigDump(c.context, c.Namespace)
+	if err != nil {
+		logger.Warningf("failed to get the osds kept from updating their crush location on start. %+v", err)
+		return
+	}
+	// the osds that were kept in place before, such as by a crush map resource, are left alone
+	disabled := map[string]bool{}
+	for _, o := range options {
+		if o.Name == crushUpdateOnStartOption && o.Value == "false" {
+			disabled[o.Section] = true
+		}
+	}
+	recorded := c.crushUpdateOnStartDisabled()
+	var osdIDs []int
+	for _, bucket := range crushMap.Buckets {
+		for _, item := range bucket.Items {
+			if item.ID >= 0 && !disabled[fmt.Sprintf("osd.%d", item.ID)] {
+				osdIDs = append(osdIDs, item.ID)
+				recorded = append(recorded, item.ID)
+			}
+		}
+	}
+	if len(osdIDs) == 0 {
+		return
+	}
+	if err := DisableCrushUpdateOnStart(c.context, c.Namespace, osdIDs); err != nil {
+		logger.Warningf("failed to keep the osds from updating their crush location on start. %+v", err)
+	}
+	// the osds are recorded even if the setting failed for some of them, removing a setting that is not set is harmless
+	c.setCrushUpdateOnStartDisabled(recorded)
+}
+
+// enableCrushUpdateOnStart removes the setting that keeps the osds from updating their crush location
+// on start from the osds it was set on while the crush map was left to the user
+func (c *Cluster) enableCrushUpdateOnStart() {
+	recorded := c.crushUpdateOnStartDisabled()
+	if len(recorded) == 0 {
+		return
+	}
+
+	var failed []int
+	for _, id := range recorded {
+		if err := client.ConfigRemove(c.context, c.Namespace, fmt.Sprintf("osd.%d", id), crushUpdateOnStartOption); err != nil {
+			logger.Warningf("failed to let osd.%d update its crush location on start. %+v", id, err)
+			failed = append(failed, id)
+		}
+	}
+	logger.Infof("the osds update their crush location on start again")
+	c.setCrushUpdateOnStartDisabled(failed)
+}
+
+// crushUpdateOnStartDisabled returns the osds the operator kept from updating their crush location on start
+func (c *Cluster) crushUpdateOnStartDisabled() []int {
+	value, err := c.kv.GetValue(crushConfigMapName, crushUpdateOnStartDisabledKey)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Warningf("failed to get the osds kept from updating their crush location on start. %+v", err)
+		}
+		return nil
+	}
+	var osdIDs []int
+	for _, id := range strings.Split(value, ",") {
+		if osdID, err := strconv.Atoi(id); err == nil {
+			osdIDs = append(osdIDs, osdID)
+		}
+	}
+	return osdIDs
+}
+
+func (c *Cluster) setCrushUpdateOnStartDisabled(osdIDs []int) {
+	unique := map[int]bool{}
+	var ids []string
+	sort.Ints(osdIDs)
+	for _, id := range osdIDs {
+		if !unique[id] {
+			unique[id] = true
+			ids = append(ids, strconv.Itoa(id))
+		}
+	}
+	if err := c.kv.SetValue(crushConfigMapName, crushUpdateOnStartDisabledKey, strings.Join(ids, ",")); err != nil {
+		logger.Warningf("failed to save the osds kept from updating their crush location on start. %+v", err)
+	}
+}
+
+// DisableCrushUpdateOnStart sets osd_crush_update_on_start to false for the given osds in the mon
+// config database, which is only available in mimic or newer
+func DisableCrushUpdateOnStart(context *clusterd.Context, namespace string, osdIDs []int) error {
+	if len(osdIDs) == 0 {
+		return nil
+	}
+	overrides := map[string]map[string]string{}
+	for _, id := range osdIDs {
+		overrides[fmt.Sprintf("osd.%d", id)] = map[string]string{crushUpdateOnStartOption: "false"}
+	}
+	_, err := cephconfig.GetMonStore(context, namespace).SetAll(cephconfig.NewConfigFromOverrides(overrides), nil)
+	return err
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const topologyCrushMap = `{
+    "types": [
+        {"type_id": 0, "name": "osd"},
+        {"type_id": 1, "name": "host"},
+        {"type_id": 3, "name": "rack"},
+        {"type_id": 10, "name": "root"}
+    ],
+    "buckets": [
+        {"id": -1, "name": "default", "type_id": 10, "type_name": "root"},
+        {"id": -2, "name": "node1", "type_id": 1, "type_name": "host"}
+    ]
+}`
+
+func topologyNode(name string, labels map[string]string) *v1.Node {
+	labels[v1.LabelHostname] = name
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestNodeTopology(t *testing.T) {
+	node := topologyNode("node1", map[string]string{
+		v1.LabelZoneRegion:        "region1",
+		v1.LabelZoneFailureDomain: "zone1",
+		topologyLabelZone:         "zone2",
+		topologyLabelRack:         "rack1",
+		"example.com/row":         "row1",
+	})
+
+	// the newer labels take precedence over the beta labels
+	topology := getNodeTopology(node, nil)
+	assert.Equal(t, map[string]string{"region": "region1", "zone": "zone2", "rack": "rack1"}, topology)
+
+	// the labels of the user take precedence over the well-known labels
+	topology = getNodeTopology(node, map[string]string{"row": "example.com/row", "zone": "example.com/zone"})
+	assert.Equal(t, map[string]string{"region": "region1", "zone": "zone2", "row": "row1", "rack": "rack1"}, topology)
+	topology = getNodeTopology(node, map[string]string{"region": topologyLabelRack})
+	assert.Equal(t, "rack1", topology["region"])
+
+	// the topology is added to the location from the top of the hierarchy down
+	assert.Equal(t, "region=region1,zone=zone2,rack=rack1", nodeLocation(node, nil, ""))
+	assert.Equal(t, "root=ssd,zone=zone3,region=region1,rack=rack1", nodeLocation(node, nil, "root=ssd,zone=zone3"))
+
+	// only the types the nodes can be placed under are valid
+	assert.Nil(t, validateTopologyLabels(map[string]string{"datacenter": "example.com/dc"}))
+	assert.NotNil(t, validateTopologyLabels(map[string]string{"host": "example.com/host"}))
+
+	// changes to unrelated labels are not topology changes
+	newNode := topologyNode("node1", map[string]string{v1.LabelZoneRegion: "region1", v1.LabelZoneFailureDomain: "zone1", topologyLabelZone: "zone2", topologyLabelRack: "rack1", "foo": "bar"})
+	assert.False(t, NodeTopologyChanged(node, newNode, nil))
+	newNode.Labels[topologyLabelRack] = "rack2"
+	assert.True(t, NodeTopologyChanged(node, newNode, nil))
+	delete(newNode.Labels, topologyLabelRack)
+	assert.True(t, NodeTopologyChanged(node, newNode, nil))
+	assert.True(t, NodeTopologyChanged(node, topologyNode("node1", map[string]string{}), nil))
+}
+
+func TestUpdateNodeCrushLocation(t *testing.T) {
+	var moved []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "dump" {
+				return topologyCrushMap, nil
+			}
+			if args[0] == "osd" && args[1] == "getcrushmap" {
+				return "", fmt.Errorf("mock getcrushmap")
+			}
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "move" {
+				moved = append(moved, fmt.Sprintf("%s %v", args[3], args[4:6]))
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor}
+
+	// the host bucket is moved under the rack
+	node := topologyNode("node1", map[string]string{topologyLabelRack: "rack1"})
+	storage := rookalpha.StorageScopeSpec{UseAllNodes: true}
+	err := UpdateNodeCrushLocation(context, "ns", storage, node)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"node1 [rack=rack1 root=default]"}, moved)
+
+	// the missing zone type must be added to the crush map before the host is moved
+	moved = nil
+	node = topologyNode("node1", map[string]string{topologyLabelZone: "zone1"})
+	err = UpdateNodeCrushLocation(context, "ns", storage, node)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed to add crush type zone")
+	assert.Nil(t, moved)
+
+	// unknown types in the location are left to ceph
+	node = topologyNode("node1", map[string]string{})
+	storage.Location = "dc=dc1"
+	err = UpdateNodeCrushLocation(context, "ns", storage, node)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"node1 [dc=dc1 root=default]"}, moved)
+
+	// hosts without osds are not in the crush map yet
+	moved = nil
+	node = topologyNode("node2", map[string]string{topologyLabelRack: "rack1"})
+	err = UpdateNodeCrushLocation(context, "ns", storage, node)
+	assert.Nil(t, err)
+	assert.Nil(t, moved)
+
+	// nodes that are not storage nodes are skipped
+	storage = rookalpha.StorageScopeSpec{Nodes: []rookalpha.Node{{Name: "node3"}}}
+	node = topologyNode("node1", map[string]string{topologyLabelRack: "rack1"})
+	err = UpdateNodeCrushLocation(context, "ns", storage, node)
+	assert.Nil(t, err)
+	assert.Nil(t, moved)
+}
+
+func TestResolveNodeLocation(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	node := topologyNode("node1", map[string]string{topologyLabelZone: "zone1", "example.com/rack": "rack1"})
+	_, err := clientset.CoreV1().Nodes().Create(node)
+	assert.Nil(t, err)
+
+	c := &Cluster{context: &clusterd.Context{Clientset: clientset}, DesiredStorage: rookalpha.StorageScopeSpec{
+		Nodes:          []rookalpha.Node{{Name: "node1", Location: "root=ssd"}, {Name: "node2"}},
+		TopologyLabels: map[string]string{"rack": "example.com/rack"},
+	}}
+
+	// the location of the node is passed to the osds with the topology of the node
+	n := c.resolveNode("node1")
+	assert.Equal(t, "root=ssd,zone=zone1,rack=rack1", n.Location)
+
+	// the location is kept if the node is not found
+	n = c.resolveNode("node2")
+	assert.Equal(t, "", n.Location)
+}
+
+func TestDisableCrushUpdateOnStart(t *testing.T) {
+	var configSet, configRemoved []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "dump" {
+				return `{"buckets": [
+					{"id": -1, "name": "default", "items": [{"id": -2}]},
+					{"id": -2, "name": "node1", "items": [{"id": 0}, {"id": 3}]}
+				]}`, nil
+			}
+			if args[0] == "config" && args[1] == "dump" {
+				return `[{"section": "osd.0", "name": "osd_crush_update_on_start", "value": "false"}]`, nil
+			}
+			if args[0] == "config" && args[1] == "set" {
+				configSet = append(configSet, fmt.Sprintf("%s %s=%s", args[2], args[3], args[4]))
+				return "", nil
+			}
+			if args[0] == "config" && args[1] == "rm" {
+				configRemoved = append(configRemoved, fmt.Sprintf("%s %s", args[2], args[3]))
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Executor: executor}, Namespace: "ns",
+		clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus},
+		kv:          k8sutil.NewConfigMapKVStore("ns", fake.NewSimpleClientset(), metav1.OwnerReference{})}
+
+	// only the osds in the hierarchy that are not configured yet are kept from moving on start
+	c.disableCrushUpdateOnStart()
+	assert.Equal(t, []string{"osd.3 osd_crush_update_on_start=false"}, configSet)
+	assert.Equal(t, []int{3}, c.crushUpdateOnStartDisabled())
+
+	// when the crush map is managed again, the setting is only removed from the osds it was set on
+	c.enableCrushUpdateOnStart()
+	assert.Equal(t, []string{"osd.3 osd_crush_update_on_start"}, configRemoved)
+	assert.Nil(t, c.crushUpdateOnStartDisabled())
+	configRemoved = nil
+	c.enableCrushUpdateOnStart()
+	assert.Nil(t, configRemoved)
+
+	// the mon config database is not available in luminous
+	configSet = nil
+	c.clusterInfo.CephVersion = cephver.Luminous
+	c.disableCrushUpdateOnStart()
+	assert.Nil(t, configSet)
+
+	// the crush map is managed by default
+	assert.True(t, c.DesiredStorage.IsCrushManaged())
+	managed := false
+	c.DesiredStorage.CrushManaged = &managed
+	assert.False(t, c.DesiredStorage.IsCrushManaged())
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strings"
+
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpgradeBatches paces the update of the osds to a new ceph image. The osds of a batch of failure
+// domains are updated one after the other, and the osds of the next failure domain are only updated
+// once Wait returns for the previous batch.
+type UpgradeBatches struct {
+	// Size is the number of failure domains in a batch
+	Size int
+	// FailureDomain is the crush bucket type the osds are grouped by, host by default
+	FailureDomain string
+	// Wait is called with the failure domains of a batch that was updated and the number of osds
+	// updated so far. It returns true if the upgrade is paused and no more osds must be updated.
+	Wait func(failureDomains []string, osds int) (bool, error)
+	// Paused is true when the upgrade was paused after a batch
+	Paused bool
+
+	batch   []string
+	updated int
+	err     error
+}
+
+// admit returns true if the osd in the given failure domain can be updated now, after waiting for
+// the previous batch if the current batch is full
+func (b *UpgradeBatches) admit(failureDomain string) (bool, error) {
+	if b.err != nil || b.Paused {
+		return false, b.err
+	}
+
+	if !contains(b.batch, failureDomain) {
+		size := b.Size
+		if size <= 0 {
+			size = 1
+		}
+		if len(b.batch) >= size {
+			if !b.wait() {
+				return false, b.err
+			}
+		}
+		b.batch = append(b.batch, failureDomain)
+	}
+	b.updated++
+	return true, nil
+}
+
+// Complete waits for the last batch of osds that were updated. It returns true if the upgrade is paused.
+func (b *UpgradeBatches) Complete() (bool, error) {
+	if b.err == nil && !b.Paused && len(b.batch) > 0 {
+		b.wait()
+	}
+	return b.Paused, b.err
+}
+
+// wait returns true if the next batch can be started
+func (b *UpgradeBatches) wait() bool {
+	logger.Infof("waiting for the %d osds updated so far after updating the osds of %s %v", b.updated, b.crushType(), b.batch)
+	b.Paused, b.err = b.Wait(b.batch, b.updated)
+	if b.err != nil {
+		b.err = fmt.Errorf("failed waiting for the osds of %s %v. %+v", b.crushType(), b.batch, b.err)
+		return false
+	}
+	b.batch = nil
+	return !b.Paused
+}
+
+func (b *UpgradeBatches) crushType() string {
+	if b.FailureDomain == "" {
+		return "host"
+	}
+	return b.FailureDomain
+}
+
+// failureDomain returns the name of the crush bucket of the batch type in the location of the host.
+// The host bucket is only set in the location of the osds on pvcs.
+func (b *UpgradeBatches) failureDomain(host, location string) string {
+	if location != "" {
+		for _, pair := range strings.Split(location, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 && kv[0] == b.crushType() {
+				return kv[1]
+			}
+		}
+	}
+	return host
+}
+
+// admitUpgrade returns true if the existing deployment of the osd on the host can be updated now.
+// Deployments that don't change the image are always updated.
+func (c *Cluster) admitUpgrade(dp *apps.Deployment, host, location string) (bool, error) {
+	if c.Upgrade == nil {
+		return true, nil
+	}
+	upgrade, err := c.needsUpgrade(dp)
+	if err != nil {
+		return false, err
+	}
+	if !upgrade {
+		return true, nil
+	}
+	return c.Upgrade.admit(c.Upgrade.failureDomain(host, location))
+}
+
+// needsUpgrade returns true if the existing deployment of the osd runs another image than the
+// given deployment
+func (c *Cluster) needsUpgrade(dp *apps.Deployment) (bool, error) {
+	existing, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(dp.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get osd deployment %s. %+v", dp.Name, err)
+	}
+	return deploymentImage(existing) != deploymentImage(dp), nil
+}
+
+func deploymentImage(dp *apps.Deployment) string {
+	if len(dp.Spec.Template.Spec.Containers) == 0 {
+		return ""
+	}
+	return dp.Spec.Template.Spec.Containers[0].Image
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestUpgradeBatches(t *testing.T) {
+	var waits [][]string
+	var updated []int
+	paused := false
+	var waitErr error
+	b := &UpgradeBatches{Size: 2, FailureDomain: "rack", Wait: func(failureDomains []string, osds int) (bool, error) {
+		waits = append(waits, failureDomains)
+		updated = append(updated, osds)
+		return paused, waitErr
+	}}
+	assert.Equal(t, "rack1", b.failureDomain("node1", "zone=z1,rack=rack1"))
+	assert.Equal(t, "node1", b.failureDomain("node1", "zone=z1"))
+	assert.Equal(t, "node1", (&UpgradeBatches{}).failureDomain("node1", "rack=rack1"))
+	assert.Equal(t, "node2", (&UpgradeBatches{}).failureDomain("rook-ceph-osd-data-0", "rack=rack1,host=node2"))
+
+	// the osds of two racks are updated before waiting
+	for _, rack := range []string{"rack1", "rack1", "rack2"} {
+		ok, err := b.admit(rack)
+		assert.True(t, ok)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 0, len(waits))
+
+	// a rack that is updated again after another batch is part of a new batch
+	for _, rack := range []string{"rack3", "rack1"} {
+		ok, err := b.admit(rack)
+		assert.True(t, ok)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, [][]string{{"rack1", "rack2"}}, waits)
+	assert.Equal(t, []int{3}, updated)
+
+	// no more osds are updated once the upgrade is paused
+	paused = true
+	ok, err := b.admit("rack4")
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	ok, err = b.admit("rack5")
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"rack1", "rack2"}, {"rack3", "rack1"}}, waits)
+	paused, err = b.Complete()
+	assert.True(t, paused)
+	assert.Nil(t, err)
+
+	// the last batch is waited for when the osds were updated
+	paused = false
+	waitErr = fmt.Errorf("not clean")
+	b = &UpgradeBatches{Wait: b.Wait}
+	ok, err = b.admit("node1")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	paused, err = b.Complete()
+	assert.False(t, paused)
+	assert.NotNil(t, err)
+	ok, err = b.admit("node2")
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+}
+
+func TestAdmitUpgrade(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook"}
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, context, "ns", "myversion", cephv1.CephVersionSpec{Image: "ceph/ceph:v14.2.1"},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, false, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+	dp, err := c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "rack=rack1", OSDInfo{ID: 1})
+	require.Nil(t, err)
+	_, err = clientset.AppsV1().Deployments("ns").Create(dp)
+	require.Nil(t, err)
+
+	var waits [][]string
+	c.Upgrade = &UpgradeBatches{FailureDomain: "rack", Wait: func(failureDomains []string, osds int) (bool, error) {
+		waits = append(waits, failureDomains)
+		return false, nil
+	}}
+
+	// the osd keeps running the same image
+	ok, err := c.admitUpgrade(dp, "node1", "rack=rack1")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, c.Upgrade.updated)
+
+	// the osds of the next rack are updated after waiting for the first rack
+	c.cephVersion.Image = "ceph/ceph:v14.2.2"
+	dp, err = c.makeDeployment("node1", rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "rack=rack1", OSDInfo{ID: 1})
+	require.Nil(t, err)
+	ok, err = c.admitUpgrade(dp, "node1", "rack=rack1")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	ok, err = c.admitUpgrade(dp, "node2", "rack=rack2")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"rack1"}}, waits)
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	// the phases of an upgrade in the order the daemons are updated
+	upgradePhases = []cephv1.UpgradePhase{
+		cephv1.UpgradePhaseMon, cephv1.UpgradePhaseMgr, cephv1.UpgradePhaseOSD,
+		cephv1.UpgradePhaseMDS, cephv1.UpgradePhaseRGW, cephv1.UpgradePhaseNFS,
+	}
+
+	// the osd flags set while the osds are updated so that the restarted osds are not marked out and
+	// no data is moved
+	upgradeOSDFlags = []string{"noout", "norebalance"}
+
+	// how long to wait for the cluster to be clean and for the daemons to report the new version
+	upgradeCleanRetries   = 3000
+	upgradeVersionRetries = 40
+	upgradeWaitInterval   = 15 * time.Second
+)
+
+// clusterUpgrade moves an upgrade through its phases and records its progress in the cluster CR status.
+// Before each phase the cluster must be clean and the upgrade not paused. After each phase all the
+// daemons of the phase must run the new ceph version.
+type clusterUpgrade struct {
+	c       *cluster
+	spec    cephv1.UpgradeSpec
+	version cephver.CephVersion
+	status  cephv1.UpgradeStatus
+}
+
+// startUpgrade records in the cluster CR status that the daemons must be upgraded to the new image.
+// The next orchestration then updates the daemons phase by phase.
+func (c *cluster) startUpgrade(fromImage, toImage string) error {
+	status := &cephv1.UpgradeStatus{FromImage: fromImage, ToImage: toImage, Message: "upgrade not started yet"}
+	logger.Infof("upgrading cluster %s from image %s to %s", c.Namespace, fromImage, toImage)
+	return c.updateUpgradeStatus(status)
+}
+
+// loadUpgrade returns the upgrade to the image of the spec that is in progress, or nil if the daemons
+// are not being upgraded
+func (c *cluster) loadUpgrade(spec *cephv1.ClusterSpec, version cephver.CephVersion) (*clusterUpgrade, error) {
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.crdName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cluster %s to load its upgrade status. %+v", c.crdName, err)
+	}
+
+	status := cluster.Status.Upgrade
+	if status == nil || status.ToImage != spec.CephVersion.Image || status.Phase == cephv1.UpgradePhaseCompleted {
+		return nil, nil
+	}
+	return &clusterUpgrade{c: c, spec: spec.Upgrade, version: version, status: *status.DeepCopy()}, nil
+}
+
+// beginPhase waits until the daemons of the phase can be updated. It returns true if the upgrade is
+// paused, in which case the daemons of the phase and the following phases must not be updated.
+// The phases that were completed before the upgrade was resumed are not gated again.
+func (u *clusterUpgrade) beginPhase(phase cephv1.UpgradePhase) (bool, error) {
+	if u == nil || phaseIndex(phase) < phaseIndex(u.status.Phase) {
+		return false, nil
+	}
+
+	if paused, err := u.waitToProceed(fmt.Sprintf("before updating the %s daemons", phase)); paused || err != nil {
+		return paused, err
+	}
+
+	logger.Infof("upgrade of cluster %s to image %s: updating the %s daemons", u.c.Namespace, u.status.ToImage, phase)
+	u.status.Phase = phase
+	u.status.Message = fmt.Sprintf("updating the %s daemons", phase)
+	return false, u.c.updateUpgradeStatus(&u.status)
+}
+
+// endPhase waits until all the daemons of the phase report the new ceph version
+func (u *clusterUpgrade) endPhase(phase cephv1.UpgradePhase) error {
+	if u == nil || phaseIndex(phase) < phaseIndex(u.status.Phase) {
+		return nil
+	}
+
+	return util.Retry(upgradeVersionRetries, upgradeWaitInterval, func() error {
+		versions, err := client.GetCephVersions(u.c.context)
+		if err != nil {
+			return err
+		}
+		daemons, ok := phaseVersions(versions, phase)
+		if !ok {
+			// the version of the nfs daemons is not reported by ceph
+			return nil
+		}
+		for v, count := range daemons {
+			if !u.isTargetVersion(v) {
+				return fmt.Errorf("%d %s daemons still run %s", count, phase, v)
+			}
+		}
+		logger.Infof("all %s daemons run ceph version %s", phase, u.version.String())
+		return nil
+	})
+}
+
+// complete records that all the daemons run the new image
+func (u *clusterUpgrade) complete() error {
+	if u == nil {
+		return nil
+	}
+	logger.Infof("completed the upgrade of cluster %s to image %s", u.c.Namespace, u.status.ToImage)
+	u.status.Phase = cephv1.UpgradePhaseCompleted
+	u.status.Paused = false
+	u.status.Message = fmt.Sprintf("all daemons run ceph version %s", u.version.String())
+	return u.c.updateUpgradeStatus(&u.status)
+}
+
+// osdBatches returns the batches the osds are updated by during the osd phase, or nil if the osds
+// are not being upgraded
+func (u *clusterUpgrade) osdBatches() *osd.UpgradeBatches {
+	if u == nil || phaseIndex(cephv1.UpgradePhaseOSD) < phaseIndex(u.status.Phase) {
+		return nil
+	}
+	return &osd.UpgradeBatches{Size: u.spec.OSDBatchSize, FailureDomain: u.spec.FailureDomain, Wait: u.osdBatchUpdated}
+}
+
+// osdBatchUpdated records the failure domains whose osds were updated and waits until the updated
+// osds run the new version and the cluster is clean before the next batch is updated
+func (u *clusterUpgrade) osdBatchUpdated(failureDomains []string, osds int) (bool, error) {
+	u.status.UpgradedFailureDomains = append(u.status.UpgradedFailureDomains, failureDomains...)
+	u.status.Message = fmt.Sprintf("updated the osds of %v", failureDomains)
+	if err := u.c.updateUpgradeStatus(&u.status); err != nil {
+		return false, err
+	}
+
+	err := util.Retry(upgradeVersionRetries, upgradeWaitInterval, func() error {
+		versions, err := client.GetCephVersions(u.c.context)
+		if err != nil {
+			return err
+		}
+		upgraded := 0
+		for v, count := range versions.Osd {
+			if u.isTargetVersion(v) {
+				upgraded += count
+			}
+		}
+		if upgraded < osds {
+			return fmt.Errorf("%d of the %d updated osds run ceph version %s", upgraded, osds, u.version.String())
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return u.waitToProceed("before updating the osds of the next batch")
+}
+
+// setOSDFlags keeps the osds in and the data in place while the osds restart
+func (u *clusterUpgrade) setOSDFlags() error {
+	if u == nil {
+		return nil
+	}
+	for _, flag := range upgradeOSDFlags {
+		if err := client.OSDSetFlag(u.c.context, u.c.Namespace, flag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsetOSDFlags lets ceph mark the osds out and rebalance the data again
+func (u *clusterUpgrade) unsetOSDFlags() {
+	if u == nil {
+		return
+	}
+	for _, flag := range upgradeOSDFlags {
+		if err := client.OSDUnsetFlag(u.c.context, u.c.Namespace, flag); err != nil {
+			logger.Errorf("failed to unset osd flag %s after updating the osds. %+v", flag, err)
+		}
+	}
+}
+
+// waitToProceed records in the status that the upgrade is paused and returns true if the upgrade is
+// paused in the cluster CR. Otherwise it waits for all the placement groups to be clean.
+func (u *clusterUpgrade) waitToProceed(step string) (bool, error) {
+	cluster, err := u.c.context.RookClientset.CephV1().CephClusters(u.c.Namespace).Get(u.c.crdName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get cluster %s to check if the upgrade is paused. %+v", u.c.crdName, err)
+	}
+	if cluster.Spec.Upgrade.Paused {
+		logger.Infof("upgrade of cluster %s to image %s is paused %s", u.c.Namespace, u.status.ToImage, step)
+		u.status.Paused = true
+		u.status.Message = fmt.Sprintf("paused %s", step)
+		return true, u.c.updateUpgradeStatus(&u.status)
+	}
+	if u.status.Paused {
+		logger.Infof("resuming the upgrade of cluster %s to image %s", u.c.Namespace, u.status.ToImage)
+		u.status.Paused = false
+	}
+
+	logger.Infof("waiting for the placement groups to be clean %s", step)
+	err = util.Retry(upgradeCleanRetries, upgradeWaitInterval, func() error {
+		return client.IsClusterClean(u.c.context, u.c.Namespace)
+	})
+	if err != nil {
+		return false, fmt.Errorf("the cluster did not become clean %s. %+v", step, err)
+	}
+	return false, nil
+}
+
+func (u *clusterUpgrade) isTargetVersion(daemonVersion string) bool {
+	v, err := cephver.ExtractCephVersion(daemonVersion)
+	return err == nil && *v == u.version
+}
+
+// updateUpgradeStatus sets the upgrade status of the cluster CR
+func (c *cluster) updateUpgradeStatus(status *cephv1.UpgradeStatus) error {
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.crdName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s to update its upgrade status. %+v", c.crdName, err)
+	}
+	cluster.Status.Upgrade = status.DeepCopy()
+	if _, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Update(cluster); err != nil {
+		return fmt.Errorf("failed to update cluster %s upgrade status. %+v", c.crdName, err)
+	}
+	return nil
+}
+
+// phaseIndex returns the position of the phase in the upgrade. The upgrade that was not started yet is
+// before the first phase.
+func phaseIndex(phase cephv1.UpgradePhase) int {
+	if phase == cephv1.UpgradePhaseCompleted {
+		return len(upgradePhases)
+	}
+	for i, p := range upgradePhases {
+		if p == phase {
+			return i
+		}
+	}
+	return -1
+}
+
+// phaseVersions returns the versions of the daemons of the phase, or false if ceph doesn't report them
+func phaseVersions(versions *client.CephDaemonsVersions, phase cephv1.UpgradePhase) (map[string]int, bool) {
+	switch phase {
+	case cephv1.UpgradePhaseMon:
+		return versions.Mon, true
+	case cephv1.UpgradePhaseMgr:
+		return versions.Mgr, true
+	case cephv1.UpgradePhaseOSD:
+		return versions.Osd, true
+	case cephv1.UpgradePhaseMDS:
+		return versions.Mds, true
+	case cephv1.UpgradePhaseRGW:
+		return versions.Rgw, true
+	}
+	return nil, false
+}
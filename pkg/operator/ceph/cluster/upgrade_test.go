@@ -0,0 +1,188 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	mimicVersion    = "ceph version 13.2.6 (7b695f835b03642f85998b2ae7b6dd093d9fbce4) mimic (stable)"
+	nautilusVersion = "ceph version 14.2.1 (d555a9489eb35f84f2e1ef49b77e19da9d113972) nautilus (stable)"
+)
+
+func TestUpgradePhases(t *testing.T) {
+	upgradeVersionRetries = 1
+	upgradeCleanRetries = 1
+	upgradeWaitInterval = 0
+
+	clean := true
+	versions := `{"mon":{"` + nautilusVersion + `":3},"mgr":{"` + mimicVersion + `":1}}`
+	var flags []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[0] == "status" {
+				if clean {
+					return `{"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+				}
+				return `{"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+undersized+degraded","count":100}]}}`, nil
+			}
+			if args[0] == "osd" && (args[1] == "set" || args[1] == "unset") {
+				flags = append(flags, args[1]+" "+args[2])
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			if args[0] == "versions" {
+				return versions, nil
+			}
+			return "", fmt.Errorf("unexpected command '%v'", args)
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset(), Executor: executor}
+	clusterCR := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"},
+		Spec:       cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v14.2.1"}},
+	}
+	_, err := context.RookClientset.CephV1().CephClusters("ns").Create(clusterCR)
+	require.Nil(t, err)
+	c := newCluster(clusterCR, context)
+	version := cephver.CephVersion{Major: 14, Minor: 2, Extra: 1}
+
+	// no upgrade is in progress
+	u, err := c.loadUpgrade(c.Spec, version)
+	assert.Nil(t, err)
+	assert.Nil(t, u)
+	paused, err := u.beginPhase(cephv1.UpgradePhaseMon)
+	assert.False(t, paused)
+	assert.Nil(t, err)
+	assert.Nil(t, u.endPhase(cephv1.UpgradePhaseMon))
+
+	// the upgrade starts with the mons
+	require.Nil(t, c.startUpgrade("ceph/ceph:v13.2.6", "ceph/ceph:v14.2.1"))
+	u, err = c.loadUpgrade(c.Spec, version)
+	require.Nil(t, err)
+	require.NotNil(t, u)
+	paused, err = u.beginPhase(cephv1.UpgradePhaseMon)
+	assert.False(t, paused)
+	assert.Nil(t, err)
+	assert.Nil(t, u.endPhase(cephv1.UpgradePhaseMon))
+	status := getUpgradeStatus(t, context)
+	assert.Equal(t, cephv1.UpgradePhaseMon, status.Phase)
+	assert.Equal(t, "ceph/ceph:v13.2.6", status.FromImage)
+
+	// the mgr phase fails until the mgr runs the new version
+	paused, err = u.beginPhase(cephv1.UpgradePhaseMgr)
+	assert.False(t, paused)
+	assert.Nil(t, err)
+	assert.NotNil(t, u.endPhase(cephv1.UpgradePhaseMgr))
+	versions = `{"mon":{"` + nautilusVersion + `":3},"mgr":{"` + nautilusVersion + `":1},"osd":{"` + mimicVersion + `":4}}`
+	assert.Nil(t, u.endPhase(cephv1.UpgradePhaseMgr))
+
+	// the osds are not updated while the cluster is not clean
+	clean = false
+	paused, err = u.beginPhase(cephv1.UpgradePhaseOSD)
+	assert.False(t, paused)
+	assert.NotNil(t, err)
+	assert.Equal(t, cephv1.UpgradePhaseMgr, getUpgradeStatus(t, context).Phase)
+	clean = true
+
+	// the osds are not updated while the upgrade is paused
+	cr, err := context.RookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	require.Nil(t, err)
+	cr.Spec.Upgrade.Paused = true
+	_, err = context.RookClientset.CephV1().CephClusters("ns").Update(cr)
+	require.Nil(t, err)
+	paused, err = u.beginPhase(cephv1.UpgradePhaseOSD)
+	assert.True(t, paused)
+	assert.Nil(t, err)
+	status = getUpgradeStatus(t, context)
+	assert.True(t, status.Paused)
+	assert.Equal(t, cephv1.UpgradePhaseMgr, status.Phase)
+
+	// the upgrade resumes with the osds, skipping the gates of the completed phases
+	cr, err = context.RookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	require.Nil(t, err)
+	cr.Spec.Upgrade = cephv1.UpgradeSpec{OSDBatchSize: 2, FailureDomain: "rack"}
+	_, err = context.RookClientset.CephV1().CephClusters("ns").Update(cr)
+	require.Nil(t, err)
+	c.Spec = &cr.Spec
+	u, err = c.loadUpgrade(c.Spec, version)
+	assert.Nil(t, err)
+	require.NotNil(t, u)
+	clean = false
+	paused, err = u.beginPhase(cephv1.UpgradePhaseMon)
+	assert.False(t, paused)
+	assert.Nil(t, err)
+	clean = true
+	paused, err = u.beginPhase(cephv1.UpgradePhaseOSD)
+	assert.False(t, paused)
+	assert.Nil(t, err)
+	assert.False(t, getUpgradeStatus(t, context).Paused)
+
+	// the next batch of osds waits for the updated osds to run the new version
+	batches := u.osdBatches()
+	require.NotNil(t, batches)
+	assert.Equal(t, 2, batches.Size)
+	assert.Equal(t, "rack", batches.FailureDomain)
+	assert.Nil(t, u.setOSDFlags())
+	assert.Equal(t, []string{"set noout", "set norebalance"}, flags)
+	paused, err = batches.Wait([]string{"rack1", "rack2"}, 2)
+	assert.False(t, paused)
+	assert.NotNil(t, err)
+	versions = `{"osd":{"` + mimicVersion + `":2,"` + nautilusVersion + `":2}}`
+	paused, err = batches.Wait([]string{"rack3"}, 2)
+	assert.False(t, paused)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"rack1", "rack2", "rack3"}, getUpgradeStatus(t, context).UpgradedFailureDomains)
+	assert.NotNil(t, u.endPhase(cephv1.UpgradePhaseOSD))
+	u.unsetOSDFlags()
+	assert.Equal(t, []string{"set noout", "set norebalance", "unset noout", "unset norebalance"}, flags)
+
+	// the nfs daemons don't report their version
+	paused, err = u.beginPhase(cephv1.UpgradePhaseNFS)
+	assert.False(t, paused)
+	assert.Nil(t, err)
+	assert.Nil(t, u.endPhase(cephv1.UpgradePhaseNFS))
+	assert.Nil(t, u.osdBatches())
+
+	// the completed upgrade is not loaded again
+	assert.Nil(t, u.complete())
+	assert.Equal(t, cephv1.UpgradePhaseCompleted, getUpgradeStatus(t, context).Phase)
+	u, err = c.loadUpgrade(c.Spec, version)
+	assert.Nil(t, err)
+	assert.Nil(t, u)
+}
+
+func getUpgradeStatus(t *testing.T, context *clusterd.Context) *cephv1.UpgradeStatus {
+	cr, err := context.RookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	require.Nil(t, err)
+	require.NotNil(t, cr.Status.Upgrade)
+	return cr.Status.Upgrade
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+// MonStore manages Ceph config options stored in the mon's centralized config database. The
+// config database is only available in Mimic and newer.
+type MonStore struct {
+	context   *clusterd.Context
+	namespace string
+}
+
+// Drift is a config option whose value in the mon config database did not match the value Rook was
+// asked to set, most likely because it was changed out of band with 'ceph config set'.
+type Drift struct {
+	Section  string
+	Option   string
+	Expected string
+	Actual   string
+}
+
+// GetMonStore returns the MonStore for the cluster.
+func GetMonStore(context *clusterd.Context, namespace string) *MonStore {
+	return &MonStore{
+		context:   context,
+		namespace: namespace,
+	}
+}
+
+// SetAll sets every option in the given Config in the mon config database. Options which already
+// have the desired value are left alone. The options that were applied the last time are given so
+// that options which are no longer in the overrides are removed from the config database, and so
+// that only the options which were changed out of band since they were applied are returned as
+// drift. A changed value in the overrides is not drift. The applied options may be nil.
+func (m *MonStore) SetAll(overrides, applied *Config) ([]Drift, error) {
+	options, err := client.ConfigDump(m.context, m.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current config options. %+v", err)
+	}
+	current := map[string]string{}
+	for _, o := range options {
+		current[o.Section+"/"+normalizeKey(o.Name)] = o.Value
+	}
+	if applied == nil {
+		applied = NewConfig()
+	}
+
+	drift := []Drift{}
+	failed := []string{}
+	for _, hdr := range overrides.sectionOrder {
+		s := overrides.sections[hdr]
+		for _, k := range s.configOrder {
+			v := s.configs[k]
+			actual, ok := current[hdr+"/"+k]
+			if ok && actual == v {
+				continue
+			}
+			if last, wasApplied := applied.value(hdr, k); wasApplied && last == v {
+				logger.Infof("config option %s %s has drifted from %q to %q. resetting it", hdr, k, v, actual)
+				drift = append(drift, Drift{Section: hdr, Option: k, Expected: v, Actual: actual})
+			}
+			if err := client.ConfigSet(m.context, m.namespace, hdr, k, v); err != nil {
+				logger.Warningf("%+v", err)
+				failed = append(failed, hdr+"/"+k)
+			}
+		}
+	}
+
+	for _, hdr := range applied.sectionOrder {
+		s := applied.sections[hdr]
+		for _, k := range s.configOrder {
+			if _, ok := overrides.value(hdr, k); ok {
+				continue
+			}
+			if _, ok := current[hdr+"/"+k]; !ok {
+				continue
+			}
+			logger.Infof("config option %s %s was removed from the overrides. removing it", hdr, k)
+			if err := client.ConfigRemove(m.context, m.namespace, hdr, k); err != nil {
+				logger.Warningf("%+v", err)
+				failed = append(failed, hdr+"/"+k)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return drift, fmt.Errorf("failed to set config options %s", strings.Join(failed, ", "))
+	}
+	return drift, nil
+}
+
+// value returns the value of the option in the section of the config and whether it is set
+func (c *Config) value(section, key string) (string, bool) {
+	s, ok := c.sections[section]
+	if !ok {
+		return "", false
+	}
+	v, ok := s.configs[key]
+	return v, ok
+}
+
+// NewConfigFromOverrides returns a Config built from user-specified overrides keyed by section
+// name and then by option name. The 'global' section is always ordered first, and the remaining
+// sections and options are sorted so that the generated config is stable.
+func NewConfigFromOverrides(overrides map[string]map[string]string) *Config {
+	c := NewConfig()
+	sections := make([]string, 0, len(overrides))
+	for hdr := range overrides {
+		sections = append(sections, hdr)
+	}
+	sort.Slice(sections, func(i, j int) bool {
+		if sections[i] == "global" || sections[j] == "global" {
+			return sections[i] == "global" && sections[j] != "global"
+		}
+		return sections[i] < sections[j]
+	})
+
+	for _, hdr := range sections {
+		keys := make([]string, 0, len(overrides[hdr]))
+		for k := range overrides[hdr] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		s := c.Section(hdr)
+		for _, k := range keys {
+			s.Set(k, overrides[hdr][k])
+		}
+	}
+	return c
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfigFromOverrides(t *testing.T) {
+	c := NewConfigFromOverrides(map[string]map[string]string{
+		"osd":    {"osd_max_backfills": "2", "bluestore cache size": "1G"},
+		"global": {"mon_max_pg_per_osd": "500"},
+		"client": {"rbd_cache": "true"},
+	})
+	assert.Equal(t, []string{"global", "client", "osd"}, c.sectionOrder)
+	assert.Equal(t, []string{"bluestore_cache_size", "osd_max_backfills"}, c.Section("osd").configOrder)
+	assert.Equal(t, "1G", c.Section("osd").configs["bluestore_cache_size"])
+
+	c = NewConfigFromOverrides(nil)
+	assert.Equal(t, 0, len(c.sectionOrder))
+}
+
+func TestMonStoreSetAll(t *testing.T) {
+	setArgs := [][]string{}
+	rmArgs := [][]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outfileArg string, args ...string) (string, error) {
+			if args[0] == "config" && args[1] == "dump" {
+				return `[
+					{"section":"global","name":"mon_max_pg_per_osd","value":"500","level":"advanced","mask":""},
+					{"section":"osd","name":"osd_max_backfills","value":"8","level":"advanced","mask":""},
+					{"section":"osd","name":"osd_recovery_sleep_hdd","value":"0.2","level":"advanced","mask":""},
+					{"section":"mon","name":"mon_data_avail_warn","value":"10","level":"advanced","mask":""}]`, nil
+			}
+			if args[0] == "config" && args[1] == "set" {
+				setArgs = append(setArgs, args[2:5])
+				return "", nil
+			}
+			if args[0] == "config" && args[1] == "rm" {
+				rmArgs = append(rmArgs, args[2:4])
+				return "", nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	s := GetMonStore(context, "rook-ceph")
+
+	overrides := NewConfigFromOverrides(map[string]map[string]string{
+		"global": {"mon max pg per osd": "500"},
+		"osd":    {"osd_max_backfills": "2", "osd_recovery_sleep": "0.1", "osd_recovery_sleep_hdd": "0.3"},
+	})
+	applied := NewConfigFromOverrides(map[string]map[string]string{
+		"global": {"mon_max_pg_per_osd": "500"},
+		"osd":    {"osd_max_backfills": "2", "osd_recovery_sleep_hdd": "0.2"},
+		"mon":    {"mon_data_avail_warn": "10"},
+	})
+	drift, err := s.SetAll(overrides, applied)
+	assert.NoError(t, err)
+
+	// the option already at the desired value is not set again
+	assert.Equal(t, [][]string{
+		{"osd", "osd_max_backfills", "2"},
+		{"osd", "osd_recovery_sleep", "0.1"},
+		{"osd", "osd_recovery_sleep_hdd", "0.3"},
+	}, setArgs)
+
+	// the option that was removed from the overrides is removed from the config database
+	assert.Equal(t, [][]string{{"mon", "mon_data_avail_warn"}}, rmArgs)
+
+	// only the option that was changed since it was applied is reported as drift, not the option
+	// whose value was changed in the overrides
+	assert.Equal(t, []Drift{{Section: "osd", Option: "osd_max_backfills", Expected: "2", Actual: "8"}}, drift)
+
+	// nothing is reported as drift or removed without the options that were applied before
+	setArgs = [][]string{}
+	rmArgs = [][]string{}
+	drift, err = s.SetAll(overrides, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(setArgs))
+	assert.Equal(t, 0, len(rmArgs))
+	assert.Equal(t, 0, len(drift))
+}
@@ -67,15 +67,19 @@ func GetStore(context *clusterd.Context, namespace string, ownerRef *metav1.Owne
 	}
 }
 
-// CreateOrUpdate creates or updates the stored Ceph config based on the cluster info.
-func (s *Store) CreateOrUpdate(clusterInfo *cephconfig.ClusterInfo) error {
+// CreateOrUpdate creates or updates the stored Ceph config based on the cluster info. The overrides
+// from the cluster CR are only written to the config file for Luminous, which has no centralized
+// config store. Mimic and newer get them from the mon config database via MonStore instead.
+func (s *Store) CreateOrUpdate(clusterInfo *cephconfig.ClusterInfo, overrides map[string]map[string]string) error {
 	c := DefaultCentralizedConfigs(clusterInfo.CephVersion)
 
 	// DefaultLegacyConfigs need to be added to the Ceph config file until the integration tests can be
 	// made to override these options for the Ceph clusters it creates.
 	c.Merge(DefaultLegacyConfigs())
 
-	/* TODO: config overrides from the CRD will go here */
+	if clusterInfo.CephVersion.IsLuminous() {
+		c.Merge(NewConfigFromOverrides(overrides))
+	}
 
 	f, err := c.IniFile()
 	if err != nil {
@@ -107,15 +111,6 @@ func (s *Store) CreateOrUpdate(clusterInfo *cephconfig.ClusterInfo) error {
 		return fmt.Errorf("failed to store mon host configs. %+v", err)
 	}
 
-	/*
-		TODO:
-		if Luminous {
-			store config file in config map as above
-		} else if Mimic + {
-			set config values with `ceph config assimilage-conf t` after at least one mon is active
-		}
-	*/
-
 	return nil
 }
 
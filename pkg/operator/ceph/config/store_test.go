@@ -26,6 +26,7 @@ import (
 
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testop "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
@@ -83,10 +84,10 @@ func TestStore(t *testing.T) {
 	i1 := testop.CreateConfigDir(1) // cluster w/ one mon
 	i3 := testop.CreateConfigDir(3) // same cluster w/ 3 mons
 
-	s.CreateOrUpdate(i1)
+	s.CreateOrUpdate(i1, nil)
 	assertConfigStore(i1)
 
-	s.CreateOrUpdate(i3)
+	s.CreateOrUpdate(i3, nil)
 	assertConfigStore(i3)
 	// the config should be the same regardless of how many mons there are
 	assert.Equal(t, previousConfigText, recentConfigText)
@@ -95,19 +96,19 @@ func TestStore(t *testing.T) {
 	// test overrides
 	//
 	createOverrideMap(t, ctx, ns, &owner)
-	s.CreateOrUpdate(i3)
+	s.CreateOrUpdate(i3, nil)
 	assertConfigStore(i3)
 	// configs should still be equal since the created map doesn't have data in it
 	assert.Equal(t, previousConfigText, recentConfigText)
 
 	updateOverrideMap(t, "", ctx, ns, &owner)
-	s.CreateOrUpdate(i3)
+	s.CreateOrUpdate(i3, nil)
 	assertConfigStore(i3)
 	// configs should still be equal since the created map's data is blank
 	assert.Equal(t, previousConfigText, recentConfigText)
 
 	updateOverrideMap(t, "this is not valid ini file text", ctx, ns, &owner)
-	s.CreateOrUpdate(i3)
+	s.CreateOrUpdate(i3, nil)
 	assertConfigStore(i3)
 	// configs should still be equal since the created map's data is invalid ini
 	assert.Equal(t, previousConfigText, recentConfigText)
@@ -121,7 +122,7 @@ log stderr prefix = '"debug  "'
 [mon]
 debug_mon = makehaste
 `, ctx, ns, &owner)
-	s.CreateOrUpdate(i3)
+	s.CreateOrUpdate(i3, nil)
 	assertConfigStore(i3)
 	// Verify some simple truths about the overridden config vs the original
 	assert.NotEqual(t, previousConfigText, recentConfigText)                       // the new config has changed (finally)
@@ -145,6 +146,37 @@ debug_mon = makehaste
 
 }
 
+func TestStoreCephConfigOverrides(t *testing.T) {
+	clientset := testop.New(1)
+	ctx := &clusterd.Context{
+		Clientset: clientset,
+	}
+	ns := "rook-ceph"
+	owner := metav1.OwnerReference{}
+	overrides := map[string]map[string]string{
+		"osd": {"osd max backfills": "2"},
+	}
+
+	configText := func() string {
+		c, e := clientset.CoreV1().ConfigMaps(ns).Get(storeName, metav1.GetOptions{})
+		assert.NoError(t, e)
+		return c.Data[confFileName]
+	}
+
+	// mimic stores the overrides in the mon config database, not the config file
+	s := GetStore(ctx, ns, &owner)
+	i := testop.CreateConfigDir(1)
+	i.CephVersion = cephver.Mimic
+	s.CreateOrUpdate(i, overrides)
+	assert.NotContains(t, configText(), "osd_max_backfills")
+
+	// luminous has no config database, so the overrides go in the config file
+	i.CephVersion = cephver.Luminous
+	s.CreateOrUpdate(i, overrides)
+	assert.Contains(t, configText(), "[osd]")
+	assert.Regexp(t, "osd_max_backfills[[:space:]]+= 2", configText())
+}
+
 func createOverrideMap(t *testing.T,
 	context *clusterd.Context, namespace string, ownerRef *metav1.OwnerReference,
 ) {
@@ -181,7 +213,7 @@ func TestFileVolumeAndMount(t *testing.T) {
 	owner := metav1.OwnerReference{}
 
 	s := GetStore(ctx, ns, &owner)
-	s.CreateOrUpdate(testop.CreateConfigDir(3))
+	s.CreateOrUpdate(testop.CreateConfigDir(3), nil)
 
 	v := StoredFileVolume()
 	m := StoredFileVolumeMount()
@@ -201,7 +233,7 @@ func TestEnvVarsAndFlags(t *testing.T) {
 	owner := metav1.OwnerReference{}
 
 	s := GetStore(ctx, ns, &owner)
-	s.CreateOrUpdate(testop.CreateConfigDir(3))
+	s.CreateOrUpdate(testop.CreateConfigDir(3), nil)
 
 	v := StoredMonHostEnvVars()
 	f := StoredMonHostEnvVarReferences().GlobalFlags()
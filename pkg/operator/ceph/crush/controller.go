@@ -0,0 +1,396 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crush merges the crush map fragments of the users into the crush map of the cluster.
+package crush
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	replicatedRuleType = "replicated"
+	erasureRuleType    = "erasure"
+	defaultMinSize     = 1
+	defaultMaxSize     = 10
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-crush")
+
+// CrushMapResource represents the crush map custom resource
+var CrushMapResource = opkit.CustomResource{
+	Name:    "cephcrushmap",
+	Plural:  "cephcrushmaps",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephCrushMap{}).Name(),
+}
+
+// CrushMapController merges the buckets, osd locations and rules of the crush map custom resources
+// into the crush map of the cluster
+type CrushMapController struct {
+	clusterInfo *cephconfig.ClusterInfo
+	context     *clusterd.Context
+	namespace   string
+}
+
+// NewCrushMapController creates a controller for the crush map fragments of a cluster
+func NewCrushMapController(clusterInfo *cephconfig.ClusterInfo, context *clusterd.Context, namespace string) *CrushMapController {
+	return &CrushMapController{
+		clusterInfo: clusterInfo,
+		context:     context,
+		namespace:   namespace,
+	}
+}
+
+// StartWatch watches for instances of CephCrushMap custom resources and acts on them
+func (c *CrushMapController) StartWatch(stopCh chan struct{}) error {
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching crush map resources in namespace %s", c.namespace)
+	watcher := opkit.NewWatcher(CrushMapResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephCrushMap{}, stopCh)
+	return nil
+}
+
+func (c *CrushMapController) onAdd(obj interface{}) {
+	crushMap := obj.(*cephv1.CephCrushMap).DeepCopy()
+
+	err := c.applyCrushMap(crushMap)
+	if err != nil {
+		logger.Errorf("failed to apply crush map %s. %+v", crushMap.Name, err)
+	}
+	updateCrushMapStatus(c.context, crushMap, err)
+}
+
+func (c *CrushMapController) onUpdate(oldObj, newObj interface{}) {
+	oldCrushMap := oldObj.(*cephv1.CephCrushMap)
+	crushMap := newObj.(*cephv1.CephCrushMap).DeepCopy()
+	if reflect.DeepEqual(oldCrushMap.Spec, crushMap.Spec) {
+		logger.Debugf("crush map %s not changed", crushMap.Name)
+		return
+	}
+
+	logger.Infof("updating crush map %s", crushMap.Name)
+	err := c.applyCrushMap(crushMap)
+	if err != nil {
+		logger.Errorf("failed to apply crush map %s. %+v", crushMap.Name, err)
+	} else {
+		// remove the rules and buckets that were dropped from the crush map resource
+		c.removeCrushMap(removedFromCrushMap(oldCrushMap.Spec, crushMap.Spec))
+	}
+	updateCrushMapStatus(c.context, crushMap, err)
+}
+
+func (c *CrushMapController) onDelete(obj interface{}) {
+	crushMap := obj.(*cephv1.CephCrushMap)
+	logger.Infof("removing the rules and buckets of crush map %s", crushMap.Name)
+	c.removeCrushMap(crushMap.Spec)
+}
+
+// ParentClusterChanged applies the crush maps again after the orchestration of the cluster, which may
+// have added osds or moved the osds of the nodes
+func (c *CrushMapController) ParentClusterChanged(cluster cephv1.ClusterSpec, clusterInfo *cephconfig.ClusterInfo) {
+	c.clusterInfo = clusterInfo
+
+	crushMaps, err := c.context.RookClientset.CephV1().CephCrushMaps(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Warningf("failed to list the crush maps in namespace %s. %+v", c.namespace, err)
+		return
+	}
+	for i := range crushMaps.Items {
+		crushMap := &crushMaps.Items[i]
+		err := c.applyCrushMap(crushMap)
+		if err != nil {
+			logger.Errorf("failed to apply crush map %s. %+v", crushMap.Name, err)
+		}
+		updateCrushMapStatus(c.context, crushMap, err)
+	}
+}
+
+// applyCrushMap creates the buckets of the crush map, moves the osds and merges the rules into the
+// crush map of the cluster
+func (c *CrushMapController) applyCrushMap(crushMap *cephv1.CephCrushMap) error {
+	spec := crushMap.Spec
+	if err := validateCrushMapSpec(spec); err != nil {
+		return fmt.Errorf("invalid crush map %s. %+v", crushMap.Name, err)
+	}
+
+	current, err := client.GetCrushMap(c.context, c.namespace)
+	if err != nil {
+		return err
+	}
+	crushTypes := map[string]bool{}
+	for _, t := range current.Types {
+		crushTypes[t.Name] = true
+	}
+	buckets := map[string]bool{}
+	for _, b := range current.Buckets {
+		buckets[b.Name] = true
+	}
+
+	for _, bucket := range spec.Buckets {
+		if !crushTypes[bucket.Type] {
+			if err := client.AddCrushType(c.context, c.namespace, bucket.Type); err != nil {
+				return fmt.Errorf("failed to add crush type %s. %+v", bucket.Type, err)
+			}
+			crushTypes[bucket.Type] = true
+		}
+		if !buckets[bucket.Name] {
+			logger.Infof("adding crush bucket %s of type %s", bucket.Name, bucket.Type)
+			if err := client.AddCrushBucket(c.context, c.namespace, bucket.Name, bucket.Type); err != nil {
+				return err
+			}
+			buckets[bucket.Name] = true
+		}
+		if bucket.Location != "" {
+			if _, err := client.CrushMove(c.context, c.namespace, bucket.Name, strings.Split(bucket.Location, ",")); err != nil {
+				return err
+			}
+		}
+	}
+
+	var placedOSDs []int
+	for _, o := range spec.OSDs {
+		if o.Location != "" {
+			if err := client.CrushSetOSD(c.context, c.namespace, o.ID, o.Weight, strings.Split(o.Location, ",")); err != nil {
+				return err
+			}
+			placedOSDs = append(placedOSDs, o.ID)
+		} else if o.Weight != "" {
+			if err := client.CrushSetOSDWeight(c.context, c.namespace, o.ID, o.Weight); err != nil {
+				return err
+			}
+		}
+	}
+	if len(placedOSDs) > 0 {
+		// keep the osds from moving back to the location of their node when they restart
+		if c.clusterInfo.CephVersion.IsLuminous() {
+			logger.Warningf("osds %v will move back to the location of their node when they restart. "+
+				"set osd_crush_update_on_start to false in the ceph config to keep them in place", placedOSDs)
+		} else if err := osd.DisableCrushUpdateOnStart(c.context, c.namespace, placedOSDs); err != nil {
+			return fmt.Errorf("failed to keep osds %v from updating their crush location on start. %+v", placedOSDs, err)
+		}
+	}
+
+	if len(spec.Rules) > 0 {
+		rules := make([]client.CrushRule, 0, len(spec.Rules))
+		for _, r := range spec.Rules {
+			rules = append(rules, toCrushRule(r))
+		}
+		if err := client.SetCrushRules(c.context, c.namespace, rules); err != nil {
+			return fmt.Errorf("failed to set crush rules. %+v", err)
+		}
+	}
+
+	logger.Infof("applied crush map %s", crushMap.Name)
+	return nil
+}
+
+// removeCrushMap removes the rules and buckets of the crush map from the crush map of the cluster.
+// Rules that are used by pools and buckets that are not empty are left in the crush map.
+func (c *CrushMapController) removeCrushMap(spec cephv1.CrushMapSpec) {
+	for _, rule := range spec.Rules {
+		if err := client.DeleteCrushRule(c.context, c.namespace, rule.Name); err != nil {
+			logger.Infof("did not delete crush rule %s. %+v", rule.Name, err)
+		}
+	}
+
+	// remove the buckets in reverse order so the buckets are removed before the buckets they are in
+	for i := len(spec.Buckets) - 1; i >= 0; i-- {
+		name := spec.Buckets[i].Name
+		if _, err := client.CrushRemove(c.context, c.namespace, name); err != nil {
+			logger.Infof("did not remove crush bucket %s. %+v", name, err)
+		}
+	}
+}
+
+// removedFromCrushMap returns the rules and buckets of the old spec that are not in the new spec
+func removedFromCrushMap(old, new cephv1.CrushMapSpec) cephv1.CrushMapSpec {
+	removed := cephv1.CrushMapSpec{}
+	buckets := map[string]bool{}
+	for _, b := range new.Buckets {
+		buckets[b.Name] = true
+	}
+	for _, b := range old.Buckets {
+		if !buckets[b.Name] {
+			removed.Buckets = append(removed.Buckets, b)
+		}
+	}
+	rules := map[string]bool{}
+	for _, r := range new.Rules {
+		rules[r.Name] = true
+	}
+	for _, r := range old.Rules {
+		if !rules[r.Name] {
+			removed.Rules = append(removed.Rules, r)
+		}
+	}
+	return removed
+}
+
+// validateCrushMapSpec checks the settings of the crush map that ceph would not report clearly
+func validateCrushMapSpec(spec cephv1.CrushMapSpec) error {
+	for _, b := range spec.Buckets {
+		if b.Name == "" || b.Type == "" {
+			return fmt.Errorf("buckets must have a name and a type")
+		}
+		if b.Type == "osd" {
+			return fmt.Errorf("bucket %s cannot be of type osd", b.Name)
+		}
+		if err := validateLocation(b.Location); err != nil {
+			return fmt.Errorf("invalid location of bucket %s. %+v", b.Name, err)
+		}
+	}
+
+	for _, o := range spec.OSDs {
+		if o.ID < 0 {
+			return fmt.Errorf("invalid osd id %d", o.ID)
+		}
+		if o.Location == "" && o.Weight == "" {
+			return fmt.Errorf("osd.%d must have a location or a weight", o.ID)
+		}
+		if err := validateLocation(o.Location); err != nil {
+			return fmt.Errorf("invalid location of osd.%d. %+v", o.ID, err)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, r := range spec.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rules must have a name")
+		}
+		if names[r.Name] {
+			return fmt.Errorf("rule %s is defined more than once", r.Name)
+		}
+		names[r.Name] = true
+		if r.Type != "" && r.Type != replicatedRuleType && r.Type != erasureRuleType {
+			return fmt.Errorf("invalid type %s of rule %s. must be %s or %s", r.Type, r.Name, replicatedRuleType, erasureRuleType)
+		}
+		if len(r.Steps) == 0 {
+			return fmt.Errorf("rule %s has no steps", r.Name)
+		}
+		for _, step := range r.Steps {
+			if _, err := formatRuleStep(step); err != nil {
+				return fmt.Errorf("invalid step of rule %s. %+v", r.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateLocation(location string) error {
+	if location == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(location, ",") {
+		kv := strings.Split(pair, "=")
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return fmt.Errorf("%q is not a type=name pair", pair)
+		}
+	}
+	return nil
+}
+
+func toCrushRule(r cephv1.CrushRuleSpec) client.CrushRule {
+	rule := client.CrushRule{Name: r.Name, Type: r.Type, MinSize: r.MinSize, MaxSize: r.MaxSize}
+	if rule.Type == "" {
+		rule.Type = replicatedRuleType
+	}
+	if rule.MinSize == 0 {
+		rule.MinSize = defaultMinSize
+	}
+	if rule.MaxSize == 0 {
+		rule.MaxSize = defaultMaxSize
+	}
+	for _, step := range r.Steps {
+		// the steps were validated already
+		s, _ := formatRuleStep(step)
+		rule.Steps = append(rule.Steps, s)
+	}
+	return rule
+}
+
+// formatRuleStep returns the step in the syntax of the decompiled crush map
+func formatRuleStep(step cephv1.CrushRuleStepSpec) (string, error) {
+	switch step.Op {
+	case "take":
+		if step.Item == "" {
+			return "", fmt.Errorf("take step needs an item")
+		}
+		if step.Class != "" {
+			return fmt.Sprintf("take %s class %s", step.Item, step.Class), nil
+		}
+		return "take " + step.Item, nil
+	case "choose", "chooseleaf":
+		mode := step.Mode
+		if mode == "" {
+			mode = "firstn"
+		}
+		if mode != "firstn" && mode != "indep" {
+			return "", fmt.Errorf("invalid mode %s of %s step. must be firstn or indep", mode, step.Op)
+		}
+		if step.Type == "" {
+			return "", fmt.Errorf("%s step needs a type", step.Op)
+		}
+		return fmt.Sprintf("%s %s %d type %s", step.Op, mode, step.Num, step.Type), nil
+	case "emit":
+		return "emit", nil
+	case "set_choose_tries", "set_chooseleaf_tries":
+		return fmt.Sprintf("%s %d", step.Op, step.Num), nil
+	}
+	return "", fmt.Errorf("unknown op %q", step.Op)
+}
+
+// updateCrushMapStatus sets the CrushMapApplied condition of the crush map from the result of applyCrushMap
+func updateCrushMapStatus(context *clusterd.Context, m *cephv1.CephCrushMap, applyErr error) {
+	status, reason, message := v1.ConditionTrue, cephv1.ReasonCrushMapApplied, "the crush map was applied"
+	if applyErr != nil {
+		status, reason, message = v1.ConditionFalse, cephv1.ReasonCrushMapFailed, applyErr.Error()
+	}
+
+	// get the latest version of the crush map so the status update does not conflict with spec updates
+	crushMap, err := context.RookClientset.CephV1().CephCrushMaps(m.Namespace).Get(m.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get crush map %s to update its status. %+v", m.Name, err)
+		return
+	}
+	crushMap.Status.ObservedGeneration = m.Generation
+	crushMap.Status.SetCondition(cephv1.ConditionCrushMapApplied, status, reason, message)
+	crushMap.Status.SetPhase(cephv1.ConditionCrushMapApplied)
+	if _, err := context.RookClientset.CephV1().CephCrushMaps(m.Namespace).UpdateStatus(crushMap); err != nil {
+		logger.Warningf("failed to update status of crush map %s. %+v", m.Name, err)
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crush
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const crushMapJSON = `{
+    "types": [
+        {"type_id": 0, "name": "osd"},
+        {"type_id": 1, "name": "host"},
+        {"type_id": 8, "name": "datacenter"},
+        {"type_id": 11, "name": "root"}
+    ],
+    "buckets": [
+        {"id": -1, "name": "default", "type_id": 11, "type_name": "root"},
+        {"id": -2, "name": "dc1", "type_id": 8, "type_name": "datacenter"}
+    ]
+}`
+
+func stretchCrushMap() *cephv1.CephCrushMap {
+	return &cephv1.CephCrushMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "stretch", Namespace: "ns"},
+		Spec: cephv1.CrushMapSpec{
+			Buckets: []cephv1.CrushBucketSpec{
+				{Name: "dc1", Type: "datacenter", Location: "root=default"},
+				{Name: "dc2", Type: "datacenter", Location: "root=default"},
+			},
+			OSDs: []cephv1.CrushOSDSpec{
+				{ID: 1, Location: "root=default,datacenter=dc2,host=node2", Weight: "1.819"},
+				{ID: 2, Weight: "0.5"},
+			},
+			Rules: []cephv1.CrushRuleSpec{{
+				Name: "stretch_rule",
+				Steps: []cephv1.CrushRuleStepSpec{
+					{Op: "take", Item: "dc1"},
+					{Op: "chooseleaf", Num: 2, Type: "host"},
+					{Op: "emit"},
+					{Op: "take", Item: "dc2"},
+					{Op: "chooseleaf", Num: 2, Type: "host"},
+					{Op: "emit"},
+				},
+			}},
+		},
+	}
+}
+
+func TestApplyCrushMap(t *testing.T) {
+	var commands []string
+	var updated string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			switch {
+			case args[0] == "osd" && args[1] == "crush" && args[2] == "dump":
+				return crushMapJSON, nil
+			case args[0] == "config" && args[1] == "dump":
+				return "[]", nil
+			case args[0] == "osd" && args[1] == "getcrushmap":
+				return "compiled", nil
+			case args[0] == "osd" && args[1] == "setcrushmap":
+				commands = append(commands, "setcrushmap")
+				return "", nil
+			}
+			end := len(args)
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--") {
+					end = i
+					break
+				}
+			}
+			commands = append(commands, strings.Join(args[:end], " "))
+			return "", nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			assert.Equal(t, client.CrushTool, command)
+			if args[0] == "-d" {
+				return "", ioutil.WriteFile(args[3], []byte("# rules\n\n# end crush map\n"), 0644)
+			}
+			buf, err := ioutil.ReadFile(args[1])
+			assert.Nil(t, err)
+			updated = string(buf)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, RookClientset: rookfake.NewSimpleClientset()}
+	c := NewCrushMapController(&cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}, context, "ns")
+
+	// the missing buckets are added and the buckets and osds are moved to their locations
+	err := c.applyCrushMap(stretchCrushMap())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"osd crush move dc1 root=default",
+		"osd crush add-bucket dc2 datacenter",
+		"osd crush move dc2 root=default",
+		"osd crush set osd.1 1.819 root=default datacenter=dc2 host=node2",
+		"osd crush reweight osd.2 0.5",
+		"config set osd.1 osd_crush_update_on_start false",
+		"setcrushmap",
+	}, commands)
+	assert.Equal(t, "# rules\n\nrule stretch_rule {\n\tid 0\n\ttype replicated\n\tmin_size 1\n\tmax_size 10\n"+
+		"\tstep take dc1\n\tstep chooseleaf firstn 2 type host\n\tstep emit\n"+
+		"\tstep take dc2\n\tstep chooseleaf firstn 2 type host\n\tstep emit\n}\n# end crush map\n", updated)
+
+	// invalid crush maps are not applied
+	commands = nil
+	invalid := stretchCrushMap()
+	invalid.Spec.Rules[0].Steps[1].Mode = "random"
+	err = c.applyCrushMap(invalid)
+	assert.NotNil(t, err)
+	assert.Nil(t, commands)
+
+	// the rules and buckets are removed when the crush map is deleted, the osds stay where they are
+	c.onDelete(stretchCrushMap())
+	assert.Equal(t, []string{
+		"osd crush rule rm stretch_rule",
+		"osd crush rm dc2",
+		"osd crush rm dc1",
+	}, commands)
+}
+
+func TestValidateCrushMapSpec(t *testing.T) {
+	assert.Nil(t, validateCrushMapSpec(stretchCrushMap().Spec))
+
+	// buckets need a name and a type other than osd
+	assert.NotNil(t, validateCrushMapSpec(cephv1.CrushMapSpec{Buckets: []cephv1.CrushBucketSpec{{Name: "dc1"}}}))
+	assert.NotNil(t, validateCrushMapSpec(cephv1.CrushMapSpec{Buckets: []cephv1.CrushBucketSpec{{Name: "dc1", Type: "osd"}}}))
+	assert.NotNil(t, validateCrushMapSpec(cephv1.CrushMapSpec{Buckets: []cephv1.CrushBucketSpec{{Name: "dc1", Type: "datacenter", Location: "default"}}}))
+
+	// osds need a location or a weight
+	assert.NotNil(t, validateCrushMapSpec(cephv1.CrushMapSpec{OSDs: []cephv1.CrushOSDSpec{{ID: 1}}}))
+
+	// rules need unique names, a valid type and valid steps
+	rule := cephv1.CrushRuleSpec{Name: "r", Steps: []cephv1.CrushRuleStepSpec{{Op: "emit"}}}
+	assert.Nil(t, validateCrushMapSpec(cephv1.CrushMapSpec{Rules: []cephv1.CrushRuleSpec{rule}}))
+	assert.NotNil(t, validateCrushMapSpec(cephv1.CrushMapSpec{Rules: []cephv1.CrushRuleSpec{rule, rule}}))
+	rule.Type = "mirrored"
+	assert.NotNil(t, validateCrushMapSpec(cephv1.CrushMapSpec{Rules: []cephv1.CrushRuleSpec{rule}}))
+	rule = cephv1.CrushRuleSpec{Name: "r", Steps: []cephv1.CrushRuleStepSpec{{Op: "take"}}}
+	assert.NotNil(t, validateCrushMapSpec(cephv1.CrushMapSpec{Rules: []cephv1.CrushRuleSpec{rule}}))
+}
+
+func TestFormatRuleStep(t *testing.T) {
+	tests := []struct {
+		step     cephv1.CrushRuleStepSpec
+		expected string
+	}{
+		{cephv1.CrushRuleStepSpec{Op: "take", Item: "default", Class: "ssd"}, "take default class ssd"},
+		{cephv1.CrushRuleStepSpec{Op: "choose", Mode: "indep", Num: 3, Type: "datacenter"}, "choose indep 3 type datacenter"},
+		{cephv1.CrushRuleStepSpec{Op: "chooseleaf", Num: -1, Type: "host"}, "chooseleaf firstn -1 type host"},
+		{cephv1.CrushRuleStepSpec{Op: "set_chooseleaf_tries", Num: 5}, "set_chooseleaf_tries 5"},
+		{cephv1.CrushRuleStepSpec{Op: "emit"}, "emit"},
+	}
+	for _, test := range tests {
+		step, err := formatRuleStep(test.step)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, step)
+	}
+
+	_, err := formatRuleStep(cephv1.CrushRuleStepSpec{Op: "chooseleaf", Num: 2})
+	assert.NotNil(t, err)
+	_, err = formatRuleStep(cephv1.CrushRuleStepSpec{Op: "split"})
+	assert.NotNil(t, err)
+}
+
+func TestRemovedFromCrushMap(t *testing.T) {
+	old := stretchCrushMap().Spec
+	new := stretchCrushMap().Spec
+	new.Buckets = new.Buckets[:1]
+	new.Rules = nil
+
+	removed := removedFromCrushMap(old, new)
+	assert.Equal(t, []cephv1.CrushBucketSpec{{Name: "dc2", Type: "datacenter", Location: "root=default"}}, removed.Buckets)
+	assert.Equal(t, 1, len(removed.Rules))
+	assert.Equal(t, "stretch_rule", removed.Rules[0].Name)
+	assert.Nil(t, removed.OSDs)
+}
+
+func TestCrushMapStatus(t *testing.T) {
+	crushMap := stretchCrushMap()
+	context := &clusterd.Context{RookClientset: rookfake.NewSimpleClientset(crushMap)}
+
+	updateCrushMapStatus(context, crushMap, fmt.Errorf("mock failure"))
+	m, err := context.RookClientset.CephV1().CephCrushMaps("ns").Get("stretch", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ResourcePhaseFailure, m.Status.Phase)
+	assert.Equal(t, "mock failure", m.Status.GetCondition(cephv1.ConditionCrushMapApplied).Message)
+
+	updateCrushMapStatus(context, crushMap, nil)
+	m, err = context.RookClientset.CephV1().CephCrushMaps("ns").Get("stretch", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ResourcePhaseReady, m.Status.Phase)
+	assert.Equal(t, v1.ConditionTrue, m.Status.GetCondition(cephv1.ConditionCrushMapApplied).Status)
+}
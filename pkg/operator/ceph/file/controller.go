@@ -27,8 +27,10 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	cephbeta "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -132,6 +134,7 @@ func (c *FilesystemController) onAdd(obj interface{}) {
 	if err != nil {
 		logger.Errorf("failed to create filesystem %s: %+v", filesystem.Name, err)
 	}
+	updateFilesystemStatus(c.context, filesystem, err)
 }
 
 func (c *FilesystemController) onUpdate(oldObj, newObj interface{}) {
@@ -163,10 +166,12 @@ func (c *FilesystemController) onUpdate(oldObj, newObj interface{}) {
 
 	// if the filesystem is modified, allow the filesystem to be created if it wasn't already
 	logger.Infof("updating filesystem %s", newFS.Name)
+	removeSubvolumeClients(c.context, *oldFS, &newFS.Spec)
 	err = createFilesystem(c.clusterInfo, c.context, *newFS, c.rookVersion, c.cephVersion, c.hostNetwork, c.filesystemOwners(newFS), c.dataDirHostPath)
 	if err != nil {
 		logger.Errorf("failed to create (modify) filesystem %s: %+v", newFS.Name, err)
 	}
+	updateFilesystemStatus(c.context, newFS, err)
 }
 
 func (c *FilesystemController) ParentClusterChanged(cluster cephv1.ClusterSpec, clusterInfo *cephconfig.ClusterInfo) {
@@ -193,6 +198,40 @@ func (c *FilesystemController) ParentClusterChanged(cluster cephv1.ClusterSpec,
 		} else {
 			logger.Infof("updated filesystem %s to ceph version %s", fs.Name, c.cephVersion.Image)
 		}
+		updateFilesystemStatus(c.context, &fs, err)
+	}
+}
+
+// updateFilesystemStatus sets the conditions of the filesystem from the result of createFilesystem.
+// The pools are ready if the ceph filesystem exists, and the daemons are ready if the mdses were
+// started without error.
+func updateFilesystemStatus(context *clusterd.Context, fs *cephv1.CephFilesystem, createErr error) {
+	latest, err := context.RookClientset.CephV1().CephFilesystems(fs.Namespace).Get(fs.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get filesystem %s to update its status. %+v", fs.Name, err)
+		return
+	}
+
+	status := &latest.Status
+	status.ObservedGeneration = fs.Generation
+	if _, err := client.GetFilesystem(context, fs.Namespace, fs.Name); err != nil {
+		message := fmt.Sprintf("filesystem %s does not exist. %+v", fs.Name, createErr)
+		status.SetCondition(cephv1.ConditionPoolsReady, v1.ConditionFalse, cephv1.ReasonPoolFailed, message)
+		status.SetCondition(cephv1.ConditionDaemonsReady, v1.ConditionFalse, cephv1.ReasonMDSFailed, message)
+	} else {
+		status.SetCondition(cephv1.ConditionPoolsReady, v1.ConditionTrue, cephv1.ReasonPoolCreated, fmt.Sprintf("pools of filesystem %s were created", fs.Name))
+		if createErr != nil {
+			status.SetCondition(cephv1.ConditionDaemonsReady, v1.ConditionFalse, cephv1.ReasonMDSFailed, createErr.Error())
+		} else {
+			message := fmt.Sprintf("%d active mds started", fs.Spec.MetadataServer.ActiveCount)
+			status.SetCondition(cephv1.ConditionDaemonsReady, v1.ConditionTrue, cephv1.ReasonMDSActive, message)
+			status.MaxFileSize = fs.Spec.MaxFileSize
+		}
+	}
+	status.SetPhase(cephv1.ConditionPoolsReady, cephv1.ConditionDaemonsReady)
+
+	if _, err := context.RookClientset.CephV1().CephFilesystems(fs.Namespace).UpdateStatus(latest); err != nil {
+		logger.Warningf("failed to update status of filesystem %s. %+v", fs.Name, err)
 	}
 }
 
@@ -238,6 +277,18 @@ func filesystemChanged(oldFS, newFS cephv1.FilesystemSpec) bool {
 		logger.Infof("mds active standby changed from %t to %t", oldFS.MetadataServer.ActiveStandby, newFS.MetadataServer.ActiveStandby)
 		return true
 	}
+	if oldFS.MaxFileSize != newFS.MaxFileSize {
+		logger.Infof("max file size changed from %d to %d", oldFS.MaxFileSize, newFS.MaxFileSize)
+		return true
+	}
+	if oldFS.MetadataServer.PriorityClassName != newFS.MetadataServer.PriorityClassName {
+		logger.Infof("mds priority class changed from %q to %q", oldFS.MetadataServer.PriorityClassName, newFS.MetadataServer.PriorityClassName)
+		return true
+	}
+	if !reflect.DeepEqual(oldFS.SubvolumeGroups, newFS.SubvolumeGroups) {
+		logger.Infof("subvolume groups changed")
+		return true
+	}
 	return false
 }
 
@@ -26,6 +26,7 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/model"
 	"github.com/rook/rook/pkg/operator/ceph/file/mds"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -34,6 +35,8 @@ const (
 	dataPoolSuffix     = "data"
 	metadataPoolSuffix = "metadata"
 	appName            = "cephfs"
+	// the ceph default of max_file_size, 1TiB
+	defaultMaxFileSize = int64(1099511627776)
 )
 
 // Filesystem represents an instance of a Ceph filesystem (CephFS)
@@ -42,6 +45,7 @@ type Filesystem struct {
 	metadataPool   *model.Pool
 	dataPools      []*model.Pool
 	activeMDSCount int32
+	allowMultiple  bool
 }
 
 // createFilesystem creates a Ceph filesystem with metadata servers
@@ -55,7 +59,7 @@ func createFilesystem(
 	ownerRefs []metav1.OwnerReference,
 	dataDirHostPath string,
 ) error {
-	if err := validateFilesystem(context, fs); err != nil {
+	if err := validateFilesystem(context, clusterInfo.CephVersion, &fs); err != nil {
 		return err
 	}
 
@@ -65,6 +69,7 @@ func createFilesystem(
 			dataPools = append(dataPools, p.ToModel(""))
 		}
 		f := newFS(fs.Name, fs.Spec.MetadataPool.ToModel(""), dataPools, fs.Spec.MetadataServer.ActiveCount)
+		f.allowMultiple = fs.Spec.AllowMultiple || client.IsMultiFSEnabled()
 		if err := f.doFilesystemCreate(context, clusterInfo.CephVersion, fs.Namespace); err != nil {
 			return fmt.Errorf("failed to create filesystem %s: %+v", fs.Name, err)
 		}
@@ -84,6 +89,17 @@ func createFilesystem(
 		}
 	}
 
+	// Reset the max file size to the ceph default when it was removed from the spec
+	if fs.Spec.MaxFileSize > 0 {
+		if err = client.SetMaxFileSize(context, fs.Namespace, fs.Name, fs.Spec.MaxFileSize); err != nil {
+			return err
+		}
+	} else if fs.Status.MaxFileSize > 0 {
+		if err = client.SetMaxFileSize(context, fs.Namespace, fs.Name, defaultMaxFileSize); err != nil {
+			return err
+		}
+	}
+
 	// set the number of active mds instances
 	if fs.Spec.MetadataServer.ActiveCount > 1 {
 		if err = client.SetNumMDSRanks(context, clusterInfo.CephVersion, fs.Namespace, fs.Name, fs.Spec.MetadataServer.ActiveCount); err != nil {
@@ -97,7 +113,7 @@ func createFilesystem(
 		return err
 	}
 
-	return nil
+	return configureSubvolumeGroups(context, fs, ownerRefs)
 }
 
 // deleteFileSystem deletes the filesystem and the metadata servers
@@ -108,9 +124,11 @@ func deleteFilesystem(context *clusterd.Context, cephVersion cephver.CephVersion
 		return fmt.Errorf("failed to down filesystem %s: %+v", fs.Name, err)
 	}
 
+	removeSubvolumeClients(context, fs, nil)
+
 	// Permanently remove the filesystem if it was created by rook
 	if len(fs.Spec.DataPools) != 0 {
-		if err := client.RemoveFilesystem(context, fs.Namespace, fs.Name); err != nil {
+		if err := client.RemoveFilesystem(context, fs.Namespace, fs.Name, poolDeviceClasses(fs)); err != nil {
 			return fmt.Errorf("failed to remove filesystem %s: %+v", fs.Name, err)
 		}
 	}
@@ -118,7 +136,16 @@ func deleteFilesystem(context *clusterd.Context, cephVersion cephver.CephVersion
 	return mds.DeleteCluster(context, fs.Namespace, fs.Name)
 }
 
-func validateFilesystem(context *clusterd.Context, f cephv1.CephFilesystem) error {
+// poolDeviceClasses returns the device classes of the pools of the filesystem by pool name
+func poolDeviceClasses(fs cephv1.CephFilesystem) map[string]string {
+	deviceClasses := map[string]string{fmt.Sprintf("%s-%s", fs.Name, metadataPoolSuffix): fs.Spec.MetadataPool.DeviceClass}
+	for i, pool := range fs.Spec.DataPools {
+		deviceClasses[fmt.Sprintf("%s-%s%d", fs.Name, dataPoolSuffix, i)] = pool.DeviceClass
+	}
+	return deviceClasses
+}
+
+func validateFilesystem(context *clusterd.Context, cephVersion cephver.CephVersion, f *cephv1.CephFilesystem) error {
 	if f.Name == "" {
 		return fmt.Errorf("missing name")
 	}
@@ -128,15 +155,21 @@ func validateFilesystem(context *clusterd.Context, f cephv1.CephFilesystem) erro
 	if f.Spec.MetadataServer.ActiveCount < 1 {
 		return fmt.Errorf("MetadataServer.ActiveCount must be at least 1")
 	}
+	if err := validateSubvolumeGroups(cephVersion, f.Spec.SubvolumeGroups); err != nil {
+		return err
+	}
+	if err := opspec.ValidateMemoryHeadroomRatio(f.Spec.MetadataServer.MemoryHeadroomRatio); err != nil {
+		return err
+	}
 	// No data pool means that we expect the fs to exist already
 	if len(f.Spec.DataPools) == 0 {
 		return nil
 	}
-	if err := pool.ValidatePoolSpec(context, f.Namespace, &f.Spec.MetadataPool); err != nil {
+	if err := pool.ValidatePoolSpec(context, f.Namespace, cephVersion, &f.Spec.MetadataPool); err != nil {
 		return fmt.Errorf("invalid metadata pool: %+v", err)
 	}
-	for _, p := range f.Spec.DataPools {
-		if err := pool.ValidatePoolSpec(context, f.Namespace, &p); err != nil {
+	for i := range f.Spec.DataPools {
+		if err := pool.ValidatePoolSpec(context, f.Namespace, cephVersion, &f.Spec.DataPools[i]); err != nil {
 			return fmt.Errorf("Invalid data pool: %+v", err)
 		}
 	}
@@ -185,8 +218,8 @@ func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion c
 	if err != nil {
 		return fmt.Errorf("Unable to list existing filesystem: %+v", err)
 	}
-	if len(fslist) > 0 && !client.IsMultiFSEnabled() {
-		return fmt.Errorf("Cannot create multiple filesystems. Enable %s env variable to create more than one", client.MultiFsEnv)
+	if len(fslist) > 0 && !f.allowMultiple {
+		return fmt.Errorf("Cannot create multiple filesystems. Set allowMultiple in the filesystem spec or enable %s env variable to create more than one", client.MultiFsEnv)
 	}
 
 	logger.Infof("Creating filesystem %s", f.Name)
@@ -211,7 +244,7 @@ func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion c
 	}
 
 	// create the filesystem
-	if err := client.CreateFilesystem(context, clusterName, f.Name, f.metadataPool.Name, dataPoolNames); err != nil {
+	if err := client.CreateFilesystem(context, clusterName, f.Name, f.metadataPool.Name, dataPoolNames, f.allowMultiple); err != nil {
 		return err
 	}
 
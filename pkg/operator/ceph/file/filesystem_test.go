@@ -25,10 +25,12 @@ import (
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
 	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -40,32 +42,32 @@ import (
 )
 
 func TestValidateSpec(t *testing.T) {
-	context := &clusterd.Context{Executor: &exectest.MockExecutor{}}
+	context := &clusterd.Context{Executor: &exectest.MockExecutor{}, RookClientset: rookfake.NewSimpleClientset()}
 	fs := cephv1.CephFilesystem{}
 
 	// missing name
-	assert.NotNil(t, validateFilesystem(context, fs))
+	assert.NotNil(t, validateFilesystem(context, cephver.Nautilus, &fs))
 	fs.Name = "myfs"
 
 	// missing namespace
-	assert.NotNil(t, validateFilesystem(context, fs))
+	assert.NotNil(t, validateFilesystem(context, cephver.Nautilus, &fs))
 	fs.Namespace = "myns"
 
 	// missing data pools
-	assert.NotNil(t, validateFilesystem(context, fs))
+	assert.NotNil(t, validateFilesystem(context, cephver.Nautilus, &fs))
 	p := cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}}
 	fs.Spec.DataPools = append(fs.Spec.DataPools, p)
 
 	// missing metadata pool
-	assert.NotNil(t, validateFilesystem(context, fs))
+	assert.NotNil(t, validateFilesystem(context, cephver.Nautilus, &fs))
 	fs.Spec.MetadataPool = p
 
 	// missing mds count
-	assert.NotNil(t, validateFilesystem(context, fs))
+	assert.NotNil(t, validateFilesystem(context, cephver.Nautilus, &fs))
 	fs.Spec.MetadataServer.ActiveCount = 1
 
 	// valid!
-	assert.Nil(t, validateFilesystem(context, fs))
+	assert.Nil(t, validateFilesystem(context, cephver.Nautilus, &fs))
 }
 
 func TestCreateFilesystem(t *testing.T) {
@@ -76,8 +78,12 @@ func TestCreateFilesystem(t *testing.T) {
 	// Output to check multiple filesystem creation
 	fses := `[{"name":"myfs","metadata_pool":"myfs-metadata","metadata_pool_id":1,"data_pool_ids":[2],"data_pools":["myfs-data0"]}]`
 
+	maxFileSizes := []string{}
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if len(args) > 4 && args[3] == "max_file_size" {
+				maxFileSizes = append(maxFileSizes, args[4])
+			}
 			return "{\"key\":\"mysecurekey\"}", nil
 		},
 		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
@@ -90,9 +96,10 @@ func TestCreateFilesystem(t *testing.T) {
 	}
 	defer os.RemoveAll(configDir)
 	context := &clusterd.Context{
-		Executor:  executor,
-		ConfigDir: configDir,
-		Clientset: testop.New(3)}
+		Executor:      executor,
+		ConfigDir:     configDir,
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset()}
 	fs := cephv1.CephFilesystem{
 		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
 		Spec: cephv1.FilesystemSpec{
@@ -126,6 +133,14 @@ func TestCreateFilesystem(t *testing.T) {
 	validateStart(t, context, fs)
 	assert.ElementsMatch(t, []string{"rook-ceph-mds-myfs-a", "rook-ceph-mds-myfs-b"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
+	assert.Equal(t, 0, len(maxFileSizes))
+
+	// the max file size is reset to the ceph default after it was removed from the spec
+	fs.Status.MaxFileSize = 2048
+	err = createFilesystem(clusterInfo, context, fs, "v0.1", cephv1.CephVersionSpec{}, false, []metav1.OwnerReference{}, "/var/lib/rook/")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1099511627776"}, maxFileSizes)
+	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 
 	// Test multiple filesystem creation
 	executor = &exectest.MockExecutor{
@@ -137,13 +152,14 @@ func TestCreateFilesystem(t *testing.T) {
 		},
 	}
 	context = &clusterd.Context{
-		Executor:  executor,
-		ConfigDir: configDir,
-		Clientset: testop.New(3)}
+		Executor:      executor,
+		ConfigDir:     configDir,
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset()}
 
 	//Create another filesystem which should fail
 	err = createFilesystem(clusterInfo, context, fs, "v0.1", cephv1.CephVersionSpec{}, false, []metav1.OwnerReference{}, "/var/lib/rook/")
-	assert.Equal(t, "failed to create filesystem myfs: Cannot create multiple filesystems. Enable ROOK_ALLOW_MULTIPLE_FILESYSTEMS env variable to create more than one", err.Error())
+	assert.Equal(t, "failed to create filesystem myfs: Cannot create multiple filesystems. Set allowMultiple in the filesystem spec or enable ROOK_ALLOW_MULTIPLE_FILESYSTEMS env variable to create more than one", err.Error())
 }
 
 func TestCreateNopoolFilesystem(t *testing.T) {
@@ -31,10 +31,11 @@ import (
 
 const (
 	mdsDaemonCommand = "ceph-mds"
-	// MDS cache memory limit should be set to 50-60% of RAM reserved for the MDS container
+	// MDS cache memory limit is set to a fraction of the memory target of the MDS container, which
+	// gives 50% of the memory limit with the default headroom.
 	// MDS uses approximately 125% of the value of mds_cache_memory_limit in RAM.
 	// Eventually we will tune this automatically: http://tracker.ceph.com/issues/36663
-	mdsCacheMemoryLimitFactor = 0.5
+	mdsCacheMemoryLimitFactor = 0.625
 )
 
 func (c *Cluster) makeDeployment(mdsConfig *mdsConfig) *apps.Deployment {
@@ -56,8 +57,14 @@ func (c *Cluster) makeDeployment(mdsConfig *mdsConfig) *apps.Deployment {
 	if c.HostNetwork {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
+	if c.fs.Spec.MetadataServer.PriorityClassName != "" {
+		podSpec.Spec.PriorityClassName = c.fs.Spec.MetadataServer.PriorityClassName
+	}
 	c.fs.Spec.MetadataServer.Annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
 	c.fs.Spec.MetadataServer.Placement.ApplyToPodSpec(&podSpec.Spec)
+	if c.fs.Spec.MetadataServer.Placement.PodAntiAffinity == nil {
+		podSpec.Spec.Affinity.PodAntiAffinity = c.mdsAntiAffinity()
+	}
 
 	replicas := int32(1)
 	d := &apps.Deployment{
@@ -84,6 +91,29 @@ func (c *Cluster) makeDeployment(mdsConfig *mdsConfig) *apps.Deployment {
 	return d
 }
 
+// mdsAntiAffinity spreads the mds pods of the filesystem across the nodes. Ceph chooses which
+// standby follows which active rank, so keeping each active mds away from its standby means keeping
+// all the mds pods of the filesystem apart. A required anti-affinity can be set in the placement
+// instead when there are enough nodes.
+func (c *Cluster) mdsAntiAffinity() *v1.PodAntiAffinity {
+	return &v1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
+			{
+				Weight: int32(100),
+				PodAffinityTerm: v1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							k8sutil.AppAttr:    AppName,
+							"rook_file_system": c.fs.Name,
+						},
+					},
+					TopologyKey: v1.LabelHostname,
+				},
+			},
+		},
+	}
+}
+
 func (c *Cluster) makeMdsDaemonContainer(mdsConfig *mdsConfig) v1.Container {
 	args := append(
 		opspec.DaemonFlags(c.clusterInfo, mdsConfig.DaemonID),
@@ -100,8 +130,9 @@ func (c *Cluster) makeMdsDaemonContainer(mdsConfig *mdsConfig) v1.Container {
 
 	// Set mds cache memory limit to the best appropriate value
 	// This is new in Luminous so there is no need to check for a Ceph version
-	if !c.fs.Spec.MetadataServer.Resources.Limits.Memory().IsZero() {
-		mdsCacheMemoryLimit := float64(c.fs.Spec.MetadataServer.Resources.Limits.Memory().Value()) * mdsCacheMemoryLimitFactor
+	memoryTarget := opspec.MemoryTarget(c.fs.Spec.MetadataServer.Resources, c.fs.Spec.MetadataServer.MemoryHeadroomRatio)
+	if memoryTarget > 0 {
+		mdsCacheMemoryLimit := float64(memoryTarget) * mdsCacheMemoryLimitFactor
 		args = append(args, config.NewFlag("mds-cache-memory-limit", strconv.Itoa(int(mdsCacheMemoryLimit))))
 	}
 
@@ -101,3 +101,72 @@ func TestHostNetwork(t *testing.T) {
 	assert.Equal(t, true, d.Spec.Template.Spec.HostNetwork)
 	assert.Equal(t, v1.DNSClusterFirstWithHostNet, d.Spec.Template.Spec.DNSPolicy)
 }
+
+func TestMdsPlacement(t *testing.T) {
+	// the mds pods of the filesystem are spread across the nodes by default
+	d := testDeploymentObject(false)
+	antiAffinity := d.Spec.Template.Spec.Affinity.PodAntiAffinity
+	assert.NotNil(t, antiAffinity)
+	assert.Equal(t, 1, len(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution))
+	term := antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	assert.Equal(t, v1.LabelHostname, term.TopologyKey)
+	assert.Equal(t, map[string]string{"app": AppName, "rook_file_system": "myfs"}, term.LabelSelector.MatchLabels)
+	assert.Equal(t, "", d.Spec.Template.Spec.PriorityClassName)
+
+	// the anti-affinity of the placement and the priority class are applied
+	fs := cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer: cephv1.MetadataServerSpec{
+				ActiveCount:       1,
+				ActiveStandby:     true,
+				PriorityClassName: "mds-critical",
+			},
+		},
+	}
+	required := &v1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{TopologyKey: v1.LabelHostname}},
+	}
+	fs.Spec.MetadataServer.Placement.PodAntiAffinity = required
+	clusterInfo := &cephconfig.ClusterInfo{FSID: "myfsid", CephVersion: cephver.Nautilus}
+	c := NewCluster(clusterInfo, &clusterd.Context{Clientset: testop.New(1)}, "rook/rook:myversion", cephv1.CephVersionSpec{Image: "ceph/ceph:testversion"},
+		false, fs, &client.CephFilesystemDetails{ID: 15}, []metav1.OwnerReference{{}}, "/var/lib/rook/")
+	mdsTestConfig := &mdsConfig{
+		DaemonID:     "myfs-a",
+		ResourceName: "rook-ceph-mds-myfs-a",
+		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MdsType, "myfs-a", "rook-ceph", "/var/lib/rook/"),
+	}
+	d = c.makeDeployment(mdsTestConfig)
+	assert.Equal(t, required, d.Spec.Template.Spec.Affinity.PodAntiAffinity)
+	assert.Equal(t, "mds-critical", d.Spec.Template.Spec.PriorityClassName)
+}
+
+func TestMdsCacheMemoryLimit(t *testing.T) {
+	// the cache limit is half of the memory limit of the mds with the default headroom
+	d := testDeploymentObject(false)
+	assert.Contains(t, d.Spec.Template.Spec.Containers[0].Args, "--mds-cache-memory-limit=2168")
+
+	// the cache limit shrinks as the headroom grows
+	fs := cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer: cephv1.MetadataServerSpec{
+				ActiveCount: 1,
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+				},
+				MemoryHeadroomRatio: "0.5",
+			},
+		},
+	}
+	clusterInfo := &cephconfig.ClusterInfo{FSID: "myfsid", CephVersion: cephver.Nautilus}
+	c := NewCluster(clusterInfo, &clusterd.Context{Clientset: testop.New(1)}, "rook/rook:myversion", cephv1.CephVersionSpec{Image: "ceph/ceph:testversion"},
+		false, fs, &client.CephFilesystemDetails{ID: 15}, []metav1.OwnerReference{{}}, "/var/lib/rook/")
+	mdsTestConfig := &mdsConfig{
+		DaemonID:     "myfs-a",
+		ResourceName: "rook-ceph-mds-myfs-a",
+		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MdsType, "myfs-a", "rook-ceph", "/var/lib/rook/"),
+	}
+	d = c.makeDeployment(mdsTestConfig)
+	assert.Contains(t, d.Spec.Template.Spec.Containers[0].Args, "--mds-cache-memory-limit=1342177280")
+}
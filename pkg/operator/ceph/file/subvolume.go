@@ -0,0 +1,307 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+	"strconv"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// keys of the secret of a subvolume client, which match the keys expected by the cephfs csi driver
+	clientIDKey   = "userID"
+	clientKeyKey  = "userKey"
+	clientFSKey   = "fsName"
+	clientPathKey = "path"
+
+	// the prefix of the cephx id of the subvolume clients, so that the clients of the spec never
+	// match the keys of the cluster daemons or keys created outside of the operator
+	subvolumeClientPrefix = "rook-fs-"
+
+	// the virtual extended attributes of the directories of a subvolume group and a subvolume
+	pinAttribute      = "ceph.dir.pin"
+	maxFilesAttribute = "ceph.quota.max_files"
+)
+
+// configureSubvolumeGroups creates the subvolume groups and subvolumes of the filesystem, and the
+// clients of each subvolume. The groups and subvolumes that are removed from the spec are left
+// in the filesystem since they may contain data.
+func configureSubvolumeGroups(context *clusterd.Context, fs cephv1.CephFilesystem, ownerRefs []metav1.OwnerReference) error {
+	if len(fs.Spec.SubvolumeGroups) == 0 {
+		return nil
+	}
+	defaultPool, err := getDefaultDataPool(context, fs)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range fs.Spec.SubvolumeGroups {
+		if err := client.CreateSubvolumeGroup(context, fs.Namespace, fs.Name, group.Name, group.DataPool); err != nil {
+			return err
+		}
+		if err := pinSubvolumeGroup(context, fs, group); err != nil {
+			return err
+		}
+
+		pool := group.DataPool
+		if pool == "" {
+			pool = defaultPool
+		}
+		for _, subvolume := range group.Subvolumes {
+			if err := configureSubvolume(context, fs, group.Name, pool, subvolume, ownerRefs); err != nil {
+				return err
+			}
+		}
+		logger.Infof("configured subvolume group %s with %d subvolume(s) in filesystem %s", group.Name, len(group.Subvolumes), fs.Name)
+	}
+	return nil
+}
+
+// pinSubvolumeGroup pins the directory of the group to its mds rank, or unpins it if no rank is set
+func pinSubvolumeGroup(context *clusterd.Context, fs cephv1.CephFilesystem, group cephv1.SubvolumeGroupSpec) error {
+	rank := -1
+	if group.PinRank != nil {
+		rank = *group.PinRank
+	}
+	path, err := client.GetSubvolumeGroupPath(context, fs.Namespace, fs.Name, group.Name)
+	if err != nil {
+		return err
+	}
+	return client.SetDirAttribute(context, fs.Namespace, fs.Name, path, pinAttribute, strconv.Itoa(rank))
+}
+
+// validateSubvolumeGroups checks the subvolume groups, which are created with the volumes module of the
+// mgr that is only available in nautilus or newer
+func validateSubvolumeGroups(cephVersion cephver.CephVersion, groups []cephv1.SubvolumeGroupSpec) error {
+	if len(groups) > 0 && !cephVersion.IsAtLeastNautilus() {
+		return fmt.Errorf("subvolume groups require nautilus or newer, the cluster runs ceph %s", cephVersion.String())
+	}
+	clients := map[string]bool{}
+	for _, group := range groups {
+		if group.Name == "" {
+			return fmt.Errorf("missing subvolume group name")
+		}
+		if group.PinRank != nil && *group.PinRank < 0 {
+			return fmt.Errorf("the pin rank %d of subvolume group %s must not be negative", *group.PinRank, group.Name)
+		}
+		for _, subvolume := range group.Subvolumes {
+			if subvolume.Name == "" {
+				return fmt.Errorf("missing name of subvolume in group %s", group.Name)
+			}
+			if subvolume.MaxBytes < 0 || subvolume.MaxFiles < 0 {
+				return fmt.Errorf("the quotas of subvolume %s must not be negative", subvolume.Name)
+			}
+			for _, c := range subvolume.Clients {
+				if c.Name == "" {
+					return fmt.Errorf("missing name of client of subvolume %s", subvolume.Name)
+				}
+				// the client names are global to the cluster
+				if clients[c.Name] {
+					return fmt.Errorf("client %s is used by more than one subvolume", c.Name)
+				}
+				clients[c.Name] = true
+			}
+		}
+	}
+	return nil
+}
+
+func configureSubvolume(context *clusterd.Context, fs cephv1.CephFilesystem, groupName, pool string, subvolume cephv1.SubvolumeSpec, ownerRefs []metav1.OwnerReference) error {
+	if err := client.CreateSubvolume(context, fs.Namespace, fs.Name, groupName, subvolume.Name); err != nil {
+		return err
+	}
+	if err := client.ResizeSubvolume(context, fs.Namespace, fs.Name, groupName, subvolume.Name, subvolume.MaxBytes); err != nil {
+		return err
+	}
+	path, err := client.GetSubvolumePath(context, fs.Namespace, fs.Name, groupName, subvolume.Name)
+	if err != nil {
+		return err
+	}
+	// the volumes module only sets the quota of bytes. zero removes the quota of files.
+	if err := client.SetDirAttribute(context, fs.Namespace, fs.Name, path, maxFilesAttribute, strconv.FormatInt(subvolume.MaxFiles, 10)); err != nil {
+		return err
+	}
+
+	for _, c := range subvolume.Clients {
+		if err := checkClientOwner(context, fs, c); err != nil {
+			return err
+		}
+		caps := subvolumeClientCaps(path, pool, subvolume.Name, c.ReadOnly)
+		name := "client." + clientID(c)
+		key, err := client.AuthGetOrCreateKey(context, fs.Namespace, name, caps)
+		if err != nil {
+			return fmt.Errorf("failed to create client %s of subvolume %s. %+v", c.Name, subvolume.Name, err)
+		}
+		// get-or-create does not change the caps of an existing client
+		if err := client.AuthUpdateCaps(context, fs.Namespace, name, caps); err != nil {
+			return err
+		}
+		if err := saveClientSecret(context, fs, c, key, path, ownerRefs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subvolumeClientCaps returns the caps that restrict a client to the path of the subvolume and the
+// rados namespace of the subvolume in the data pool
+func subvolumeClientCaps(path, pool, subvolume string, readOnly bool) []string {
+	access := "rw"
+	if readOnly {
+		access = "r"
+	}
+	return []string{
+		"mon", "allow r",
+		"mds", fmt.Sprintf("allow %s path=%s", access, path),
+		"osd", fmt.Sprintf("allow %s pool=%s namespace=%s%s", access, pool, client.SubvolumeNamespacePrefix, subvolume),
+	}
+}
+
+func getDefaultDataPool(context *clusterd.Context, fs cephv1.CephFilesystem) (string, error) {
+	filesystems, err := client.ListFilesystems(context, fs.Namespace)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range filesystems {
+		if f.Name == fs.Name && len(f.DataPools) > 0 {
+			return f.DataPools[0], nil
+		}
+	}
+	return "", fmt.Errorf("no data pool found for filesystem %s", fs.Name)
+}
+
+// clientID returns the cephx id of the client, without the "client." prefix
+func clientID(c cephv1.SubvolumeClientSpec) string {
+	return subvolumeClientPrefix + c.Name
+}
+
+func clientSecretName(c cephv1.SubvolumeClientSpec) string {
+	return fmt.Sprintf("rook-ceph-fs-client-%s", c.Name)
+}
+
+// ownsClientSecret returns whether the secret of the client was created by the operator for the filesystem.
+// The secret records which filesystem the key of the client belongs to.
+func ownsClientSecret(secret *v1.Secret, fs cephv1.CephFilesystem) bool {
+	return secret.Labels["rook_cluster"] == fs.Namespace && secret.Labels["rook_file"] == fs.Name
+}
+
+// checkClientOwner returns an error if the client was created for another filesystem of the cluster
+func checkClientOwner(context *clusterd.Context, fs cephv1.CephFilesystem, c cephv1.SubvolumeClientSpec) error {
+	secret, err := context.Clientset.CoreV1().Secrets(fs.Namespace).Get(clientSecretName(c), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get secret of client %s. %+v", c.Name, err)
+	}
+	if !ownsClientSecret(secret, fs) {
+		return fmt.Errorf("client %s is not a client of filesystem %s", c.Name, fs.Name)
+	}
+	return nil
+}
+
+// saveClientSecret stores the key of the client and the path of its subvolume in a secret in the
+// namespace of the filesystem
+func saveClientSecret(context *clusterd.Context, fs cephv1.CephFilesystem, c cephv1.SubvolumeClientSpec, key, path string, ownerRefs []metav1.OwnerReference) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clientSecretName(c),
+			Namespace: fs.Namespace,
+			Labels: map[string]string{
+				"app":          appName,
+				"rook_cluster": fs.Namespace,
+				"rook_file":    fs.Name,
+			},
+		},
+		StringData: map[string]string{
+			clientIDKey:   clientID(c),
+			clientKeyKey:  key,
+			clientFSKey:   fs.Name,
+			clientPathKey: path,
+		},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRefs(context.Clientset, fs.Namespace, &secret.ObjectMeta, ownerRefs)
+
+	if _, err := context.Clientset.CoreV1().Secrets(fs.Namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create secret of client %s. %+v", c.Name, err)
+		}
+		if _, err := context.Clientset.CoreV1().Secrets(fs.Namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update secret of client %s. %+v", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// subvolumeClients returns the clients of all the subvolumes of the filesystem
+func subvolumeClients(spec cephv1.FilesystemSpec) []cephv1.SubvolumeClientSpec {
+	var clients []cephv1.SubvolumeClientSpec
+	for _, group := range spec.SubvolumeGroups {
+		for _, subvolume := range group.Subvolumes {
+			clients = append(clients, subvolume.Clients...)
+		}
+	}
+	return clients
+}
+
+// removeSubvolumeClients deletes the clients that are in the old spec but not in the new spec, or all
+// the clients of the old spec if the new spec is nil. Only the clients whose secret records that the
+// operator created them for the filesystem are deleted.
+func removeSubvolumeClients(context *clusterd.Context, fs cephv1.CephFilesystem, newSpec *cephv1.FilesystemSpec) {
+	current := map[string]bool{}
+	if newSpec != nil {
+		for _, c := range subvolumeClients(*newSpec) {
+			current[c.Name] = true
+		}
+	}
+
+	for _, c := range subvolumeClients(fs.Spec) {
+		if current[c.Name] {
+			continue
+		}
+		secret, err := context.Clientset.CoreV1().Secrets(fs.Namespace).Get(clientSecretName(c), metav1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				logger.Warningf("failed to get secret of client %s. %+v", c.Name, err)
+			}
+			continue
+		}
+		if !ownsClientSecret(secret, fs) {
+			logger.Warningf("not removing client %s that is not a client of filesystem %s", c.Name, fs.Name)
+			continue
+		}
+
+		logger.Infof("removing client %s of filesystem %s", c.Name, fs.Name)
+		if err := client.AuthDelete(context, fs.Namespace, "client."+clientID(c)); err != nil {
+			logger.Warningf("failed to delete client %s. %+v", c.Name, err)
+		}
+		err = context.Clientset.CoreV1().Secrets(fs.Namespace).Delete(clientSecretName(c), &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			logger.Warningf("failed to delete secret of client %s. %+v", c.Name, err)
+		}
+	}
+}
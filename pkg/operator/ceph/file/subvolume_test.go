@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// cephArgs returns the args of the ceph command without the cluster and format args
+func cephArgs(args []string) string {
+	var result []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") && strings.Contains(arg, "=") {
+			break
+		}
+		result = append(result, arg)
+	}
+	return strings.Join(result, " ")
+}
+
+func TestConfigureSubvolumeGroups(t *testing.T) {
+	var commands []string
+	var attributes []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			// the attribute is set with the python script: -c <script> <conf> <keyring> <fs> <path> <name> <value>
+			assert.Equal(t, "python3", command)
+			attributes = append(attributes, strings.Join(args[4:], " "))
+			return "", nil
+		},
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			cmd := cephArgs(args)
+			commands = append(commands, cmd)
+			switch {
+			case cmd == "fs ls":
+				return `[{"name":"myfs","metadata_pool":"myfs-metadata","data_pools":["myfs-data0","myfs-data1"]}]`, nil
+			case strings.HasPrefix(cmd, "fs subvolume getpath"):
+				return "/volumes/tenant-a/home/0f3a\n", nil
+			case strings.HasPrefix(cmd, "fs subvolumegroup getpath"):
+				return "/volumes/tenant-a\n", nil
+			case strings.HasPrefix(cmd, "auth get-or-create-key"):
+				return `{"key":"clientkey"}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(1)}
+	rank := 1
+	fs := cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{SubvolumeGroups: []cephv1.SubvolumeGroupSpec{{
+			Name:    "tenant-a",
+			PinRank: &rank,
+			Subvolumes: []cephv1.SubvolumeSpec{{
+				Name:     "home",
+				MaxBytes: 1024,
+				MaxFiles: 100,
+				Clients:  []cephv1.SubvolumeClientSpec{{Name: "tenant-a"}},
+			}},
+		}}},
+	}
+	assert.Nil(t, validateSubvolumeGroups(cephver.Nautilus, fs.Spec.SubvolumeGroups))
+
+	err := configureSubvolumeGroups(context, fs, nil)
+	assert.Nil(t, err)
+	caps := "mon allow r mds allow rw path=/volumes/tenant-a/home/0f3a osd allow rw pool=myfs-data0 namespace=fsvolumens_home"
+	assert.Equal(t, []string{
+		"fs ls",
+		"fs subvolumegroup create myfs tenant-a",
+		"fs subvolumegroup getpath myfs tenant-a",
+		"fs subvolume create myfs home --group_name tenant-a --namespace-isolated",
+		"fs subvolume resize myfs home 1024 --group_name tenant-a",
+		"fs subvolume getpath myfs home --group_name tenant-a",
+		"auth get-or-create-key client.rook-fs-tenant-a " + caps,
+		"auth caps client.rook-fs-tenant-a " + caps,
+	}, commands)
+
+	// the group is pinned and the quota of files is set with the attributes of the directories
+	assert.Equal(t, []string{
+		"myfs /volumes/tenant-a ceph.dir.pin 1",
+		"myfs /volumes/tenant-a/home/0f3a ceph.quota.max_files 100",
+	}, attributes)
+
+	// the secret is created in the namespace of the filesystem with the prefixed id of the client
+	secret, err := context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-fs-client-tenant-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-fs-tenant-a", secret.StringData[clientIDKey])
+	assert.Equal(t, "clientkey", secret.StringData[clientKeyKey])
+	assert.Equal(t, "/volumes/tenant-a/home/0f3a", secret.StringData[clientPathKey])
+
+	// the group is unpinned and the quota of files removed when they are not set, and a read-only client
+	// only gets read access to its data pool
+	commands = nil
+	attributes = nil
+	fs.Spec.SubvolumeGroups[0].PinRank = nil
+	fs.Spec.SubvolumeGroups[0].Subvolumes[0].MaxFiles = 0
+	fs.Spec.SubvolumeGroups[0].DataPool = "myfs-data1"
+	fs.Spec.SubvolumeGroups[0].Subvolumes[0].Clients[0].ReadOnly = true
+	err = configureSubvolumeGroups(context, fs, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"myfs /volumes/tenant-a ceph.dir.pin -1",
+		"myfs /volumes/tenant-a/home/0f3a ceph.quota.max_files 0",
+	}, attributes)
+	assert.Contains(t, commands, "fs subvolumegroup create myfs tenant-a --pool_layout myfs-data1")
+	assert.Contains(t, commands, "auth caps client.rook-fs-tenant-a mon allow r mds allow r path=/volumes/tenant-a/home/0f3a osd allow r pool=myfs-data1 namespace=fsvolumens_home")
+
+	// the client of another filesystem is neither configured nor removed
+	commands = nil
+	otherFS := fs
+	otherFS.Name = "otherfs"
+	err = configureSubvolumeGroups(context, otherFS, nil)
+	assert.NotNil(t, err)
+	for _, cmd := range commands {
+		assert.False(t, strings.HasPrefix(cmd, "auth"), cmd)
+	}
+	commands = nil
+	removeSubvolumeClients(context, otherFS, nil)
+	assert.Equal(t, 0, len(commands))
+
+	// the clients removed from the spec are deleted with their secret
+	commands = nil
+	newSpec := fs.Spec.DeepCopy()
+	newSpec.SubvolumeGroups[0].Subvolumes[0].Clients = nil
+	removeSubvolumeClients(context, fs, newSpec)
+	assert.Equal(t, []string{"auth del client.rook-fs-tenant-a"}, commands)
+	_, err = context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-fs-client-tenant-a", metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// a client without the secret of the operator is not removed
+	commands = nil
+	removeSubvolumeClients(context, fs, newSpec)
+	assert.Equal(t, 0, len(commands))
+}
+
+func TestValidateSubvolumeGroups(t *testing.T) {
+	groups := []cephv1.SubvolumeGroupSpec{
+		{Name: "a", Subvolumes: []cephv1.SubvolumeSpec{{Name: "x", Clients: []cephv1.SubvolumeClientSpec{{Name: "c"}}}}},
+		{Name: "b", Subvolumes: []cephv1.SubvolumeSpec{{Name: "y", Clients: []cephv1.SubvolumeClientSpec{{Name: "c"}}}}},
+	}
+	// the client names must be unique
+	assert.NotNil(t, validateSubvolumeGroups(cephver.Nautilus, groups))
+
+	groups[1].Subvolumes[0].Clients[0].Name = "d"
+	assert.Nil(t, validateSubvolumeGroups(cephver.Nautilus, groups))
+
+	// the volumes module of the mgr is only available in nautilus
+	assert.NotNil(t, validateSubvolumeGroups(cephver.Mimic, groups))
+	assert.Nil(t, validateSubvolumeGroups(cephver.Mimic, nil))
+
+	// the quotas and the pin rank must not be negative
+	groups[1].Subvolumes[0].MaxFiles = -1
+	assert.NotNil(t, validateSubvolumeGroups(cephver.Nautilus, groups))
+	groups[1].Subvolumes[0].MaxFiles = 0
+	rank := -1
+	groups[1].PinRank = &rank
+	assert.NotNil(t, validateSubvolumeGroups(cephver.Nautilus, groups))
+	groups[1].PinRank = nil
+
+	groups[1].Name = ""
+	assert.NotNil(t, validateSubvolumeGroups(cephver.Nautilus, groups))
+}
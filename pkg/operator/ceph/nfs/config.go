@@ -37,13 +37,17 @@ func getGaneshaConfigObject(nodeID string) string {
 }
 
 func getRadosURL(n cephv1.CephNFS, nodeID string) string {
+	return getRadosObjectURL(n, getGaneshaConfigObject(nodeID))
+}
+
+func getRadosObjectURL(n cephv1.CephNFS, object string) string {
 	url := fmt.Sprintf("rados://%s/", n.Spec.RADOS.Pool)
 
 	if n.Spec.RADOS.Namespace != "" {
 		url += n.Spec.RADOS.Namespace + "/"
 	}
 
-	url += getGaneshaConfigObject(nodeID)
+	url += object
 	return url
 }
 
@@ -87,6 +91,11 @@ RADOS_URLS {
 	watch_url = '` + url + `';
 }
 
+RGW {
+	ceph_conf = '` + cephConfigPath + `';
+	name = "client.` + userID + `";
+}
+
 %url	` + url + `
 `
 }
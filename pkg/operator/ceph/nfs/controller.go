@@ -18,6 +18,7 @@ limitations under the License.
 package nfs
 
 import (
+	"fmt"
 	"reflect"
 	"sync"
 
@@ -26,6 +27,7 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
@@ -68,7 +70,7 @@ func NewCephNFSController(clusterInfo *cephconfig.ClusterInfo, context *clusterd
 	}
 }
 
-// StartWatch watches for instances of CephNFS custom resources and acts on them
+// StartWatch watches for instances of CephNFS and CephNFSExport custom resources and acts on them
 func (c *CephNFSController) StartWatch(stopCh chan struct{}) error {
 
 	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
@@ -81,6 +83,16 @@ func (c *CephNFSController) StartWatch(stopCh chan struct{}) error {
 	watcher := opkit.NewWatcher(CephNFSResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
 	go watcher.Watch(&cephv1.CephNFS{}, stopCh)
 
+	exportHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onExportAdd,
+		UpdateFunc: c.onExportUpdate,
+		DeleteFunc: c.onExportDelete,
+	}
+
+	logger.Infof("start watching ceph nfs export resource in namespace %s", c.namespace)
+	exportWatcher := opkit.NewWatcher(CephNFSExportResource, c.namespace, exportHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go exportWatcher.Watch(&cephv1.CephNFSExport{}, stopCh)
+
 	return nil
 }
 
@@ -88,6 +100,7 @@ func (c *CephNFSController) onAdd(obj interface{}) {
 	nfs := obj.(*cephv1.CephNFS).DeepCopy()
 	if !c.clusterInfo.CephVersion.IsAtLeastNautilus() {
 		logger.Errorf("Ceph NFS is only supported with Nautilus or newer. CRD %s will be ignored.", nfs.Name)
+		updateNFSStatus(c.context, nfs, fmt.Errorf("ceph nfs is only supported with nautilus or newer"))
 		return
 	}
 
@@ -98,6 +111,7 @@ func (c *CephNFSController) onAdd(obj interface{}) {
 	if err != nil {
 		logger.Errorf("failed to create NFS Ganesha %s. %+v", nfs.Name, err)
 	}
+	updateNFSStatus(c.context, nfs, err)
 }
 
 func (c *CephNFSController) onUpdate(oldObj, newObj interface{}) {
@@ -117,18 +131,19 @@ func (c *CephNFSController) onUpdate(oldObj, newObj interface{}) {
 	defer c.releaseOrchestrationLock()
 
 	logger.Infof("Updating the ganesha server from %d to %d active count", oldNFS.Spec.Server.Active, newNFS.Spec.Server.Active)
+	var err error
 	if oldNFS.Spec.Server.Active < newNFS.Spec.Server.Active {
-		err := c.upCephNFS(*newNFS, oldNFS.Spec.Server.Active)
+		err = c.upCephNFS(*newNFS, oldNFS.Spec.Server.Active)
 		if err != nil {
 			logger.Errorf("Failed to start daemons for CephNFS %s. %+v", newNFS.Name, err)
 		}
 	} else {
-		err := c.downCephNFS(*oldNFS, newNFS.Spec.Server.Active)
+		err = c.downCephNFS(*oldNFS, newNFS.Spec.Server.Active)
 		if err != nil {
 			logger.Errorf("Failed to stop daemons for CephNFS %s. %+v", newNFS.Name, err)
 		}
 	}
-
+	updateNFSStatus(c.context, newNFS, err)
 }
 
 func (c *CephNFSController) onDelete(obj interface{}) {
@@ -171,6 +186,30 @@ func (c *CephNFSController) ParentClusterChanged(cluster cephv1.ClusterSpec, clu
 		} else {
 			logger.Infof("updated nfs %s to ceph version %s", nfs.Name, c.cephVersion.Image)
 		}
+		updateNFSStatus(c.context, &nfs, err)
+	}
+}
+
+// updateNFSStatus sets the DaemonsReady condition of the nfs from the result of starting or
+// stopping the ganesha servers
+func updateNFSStatus(context *clusterd.Context, n *cephv1.CephNFS, reconcileErr error) {
+	latest, err := context.RookClientset.CephV1().CephNFSes(n.Namespace).Get(n.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get nfs %s to update its status. %+v", n.Name, err)
+		return
+	}
+
+	latest.Status.ObservedGeneration = n.Generation
+	if reconcileErr != nil {
+		latest.Status.SetCondition(cephv1.ConditionDaemonsReady, v1.ConditionFalse, cephv1.ReasonGaneshaFailed, reconcileErr.Error())
+	} else {
+		message := fmt.Sprintf("%d ganesha servers joined the grace db", n.Spec.Server.Active)
+		latest.Status.SetCondition(cephv1.ConditionDaemonsReady, v1.ConditionTrue, cephv1.ReasonGaneshaGraceJoined, message)
+	}
+	latest.Status.SetPhase(cephv1.ConditionDaemonsReady)
+
+	if _, err := context.RookClientset.CephV1().CephNFSes(n.Namespace).UpdateStatus(latest); err != nil {
+		logger.Warningf("failed to update status of nfs %s. %+v", n.Name, err)
 	}
 }
 
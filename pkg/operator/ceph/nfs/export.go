@@ -0,0 +1,431 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nfs for NFS ganesha
+package nfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CephNFSExportResource represents the nfs export custom resource
+var CephNFSExportResource = opkit.CustomResource{
+	Name:    "cephnfsexport",
+	Plural:  "cephnfsexports",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephNFSExport{}).Name(),
+}
+
+var (
+	exportAccessTypes = map[string]bool{"RW": true, "RO": true, "None": true}
+	exportSquash      = map[string]string{
+		"None":   "No_Root_Squash",
+		"Root":   "Root_Squash",
+		"RootId": "Root_Id_Squash",
+		"All":    "All_Squash",
+	}
+)
+
+func (c *CephNFSController) onExportAdd(obj interface{}) {
+	export := obj.(*cephv1.CephNFSExport).DeepCopy()
+
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	err := c.reconcileExport(export)
+	if err != nil {
+		logger.Errorf("failed to create nfs export %s. %+v", export.Name, err)
+	}
+	updateExportStatus(c.context, export, err)
+}
+
+func (c *CephNFSController) onExportUpdate(oldObj, newObj interface{}) {
+	oldExport := oldObj.(*cephv1.CephNFSExport).DeepCopy()
+	newExport := newObj.(*cephv1.CephNFSExport).DeepCopy()
+	if reflect.DeepEqual(oldExport.Spec, newExport.Spec) {
+		logger.Debugf("nfs export %s not updated", newExport.Name)
+		return
+	}
+
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	if oldExport.Spec.Server != newExport.Spec.Server {
+		// the export moves to another server where it will get a new export id
+		logger.Infof("moving nfs export %s from server %s to %s", newExport.Name, oldExport.Spec.Server, newExport.Spec.Server)
+		if err := c.removeExport(oldExport); err != nil {
+			logger.Warningf("failed to remove nfs export %s from server %s. %+v", oldExport.Name, oldExport.Spec.Server, err)
+		}
+		newExport.Status.ExportID = 0
+	}
+
+	err := c.reconcileExport(newExport)
+	if err != nil {
+		logger.Errorf("failed to update nfs export %s. %+v", newExport.Name, err)
+	}
+	updateExportStatus(c.context, newExport, err)
+}
+
+func (c *CephNFSController) onExportDelete(obj interface{}) {
+	export := obj.(*cephv1.CephNFSExport).DeepCopy()
+
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	if err := c.removeExport(export); err != nil {
+		logger.Errorf("failed to delete nfs export %s. %+v", export.Name, err)
+	}
+}
+
+// reconcileExport writes the export to the RADOS pool of its nfs server and reloads the servers
+func (c *CephNFSController) reconcileExport(e *cephv1.CephNFSExport) error {
+	n, err := c.context.RookClientset.CephV1().CephNFSes(e.Namespace).Get(e.Spec.Server, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get nfs server %s. %+v", e.Spec.Server, err)
+	}
+
+	exports, err := c.listExports(*n)
+	if err != nil {
+		return err
+	}
+	exports = replaceExport(exports, *e)
+
+	if err := c.applyExport(*n, e, exports); err != nil {
+		return err
+	}
+	return c.updateExportIndex(*n, replaceExport(exports, *e))
+}
+
+// reconcileExports writes all the exports of the nfs server to its RADOS pool. A failure of a
+// single export is reported in the status of the export.
+func (c *CephNFSController) reconcileExports(n cephv1.CephNFS) error {
+	exports, err := c.listExports(n)
+	if err != nil {
+		return err
+	}
+
+	for i := range exports {
+		err := c.applyExport(n, &exports[i], exports)
+		if err != nil {
+			logger.Errorf("failed to configure nfs export %s. %+v", exports[i].Name, err)
+		}
+		updateExportStatus(c.context, &exports[i], err)
+	}
+	return c.updateExportIndex(n, exports)
+}
+
+// removeExport removes the export from the RADOS pool of its nfs server and reloads the servers
+func (c *CephNFSController) removeExport(e *cephv1.CephNFSExport) error {
+	n, err := c.context.RookClientset.CephV1().CephNFSes(e.Namespace).Get(e.Spec.Server, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Infof("nfs server %s of export %s not found. nothing to remove", e.Spec.Server, e.Name)
+			return nil
+		}
+		return fmt.Errorf("failed to get nfs server %s. %+v", e.Spec.Server, err)
+	}
+
+	exports, err := c.listExports(*n)
+	if err != nil {
+		return err
+	}
+	var remaining []cephv1.CephNFSExport
+	for _, export := range exports {
+		if export.Name != e.Name {
+			remaining = append(remaining, export)
+		}
+	}
+	if err := c.updateExportIndex(*n, remaining); err != nil {
+		return err
+	}
+
+	object := getExportObject(*n, e.Name)
+	if err := c.context.Executor.ExecuteCommand(false, "", "rados", "--pool", n.Spec.RADOS.Pool, "--namespace", n.Spec.RADOS.Namespace, "rm", object); err != nil {
+		logger.Warningf("failed to remove export object %s. %+v", object, err)
+	}
+	return nil
+}
+
+// listExports returns the exports served by the nfs server
+func (c *CephNFSController) listExports(n cephv1.CephNFS) ([]cephv1.CephNFSExport, error) {
+	list, err := c.context.RookClientset.CephV1().CephNFSExports(n.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nfs exports. %+v", err)
+	}
+	var exports []cephv1.CephNFSExport
+	for _, export := range list.Items {
+		if export.Spec.Server == n.Name {
+			exports = append(exports, export)
+		}
+	}
+	return exports, nil
+}
+
+// applyExport validates the export, assigns it an export id if it doesn't have one yet and writes
+// its EXPORT block to a RADOS object in the pool of the nfs server
+func (c *CephNFSController) applyExport(n cephv1.CephNFS, e *cephv1.CephNFSExport, exports []cephv1.CephNFSExport) error {
+	if err := validateExport(e, exports); err != nil {
+		return fmt.Errorf("invalid nfs export %s. %+v", e.Name, err)
+	}
+
+	exportID := e.Status.ExportID
+	if exportID == 0 {
+		exportID = nextExportID(exports)
+	}
+
+	var accessKey, secretKey string
+	if e.Spec.RGW != nil {
+		var err error
+		accessKey, secretKey, err = getObjectUserKeys(c.context, e.Namespace, e.Spec.RGW)
+		if err != nil {
+			return err
+		}
+	}
+
+	object := getExportObject(n, e.Name)
+	logger.Infof("writing nfs export %s with id %d to object %s", e.Name, exportID, object)
+	if err := c.putRADOSObject(n, object, getExportConfig(e, exportID, accessKey, secretKey)); err != nil {
+		return fmt.Errorf("failed to write nfs export %s. %+v", e.Name, err)
+	}
+	e.Status.ExportID = exportID
+	return nil
+}
+
+// updateExportIndex includes the objects of the configured exports in the config object of each
+// ganesha server and notifies the servers to reload their config
+func (c *CephNFSController) updateExportIndex(n cephv1.CephNFS, exports []cephv1.CephNFSExport) error {
+	var urls []string
+	for _, export := range exports {
+		if export.Status.ExportID == 0 {
+			// the export has never been written
+			continue
+		}
+		urls = append(urls, fmt.Sprintf("%%url \"%s\"\n", getRadosObjectURL(n, getExportObject(n, export.Name))))
+	}
+	sort.Strings(urls)
+	index := strings.Join(urls, "")
+
+	for i := 0; i < n.Spec.Server.Active; i++ {
+		config := getGaneshaConfigObject(getNFSNodeID(n, k8sutil.IndexToName(i)))
+		if err := c.putRADOSObject(n, config, index); err != nil {
+			return fmt.Errorf("failed to write the exports to config object %s. %+v", config, err)
+		}
+		if err := c.context.Executor.ExecuteCommand(false, "", "rados", "--pool", n.Spec.RADOS.Pool, "--namespace", n.Spec.RADOS.Namespace, "notify", config, "reload"); err != nil {
+			// the server picks up the exports the next time it starts
+			logger.Warningf("failed to notify the ganesha server watching %s. %+v", config, err)
+		}
+	}
+	return nil
+}
+
+// putRADOSObject replaces the content of the object in the pool of the nfs server. The content is
+// passed to rados in a temporary file that is removed afterward.
+func (c *CephNFSController) putRADOSObject(n cephv1.CephNFS, object, content string) error {
+	f, err := ioutil.TempFile(c.context.ConfigDir, object)
+	if err != nil {
+		return fmt.Errorf("failed to create file for object %s. %+v", object, err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(content)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write file for object %s. %+v", object, err)
+	}
+	return c.context.Executor.ExecuteCommand(false, "", "rados", "--pool", n.Spec.RADOS.Pool, "--namespace", n.Spec.RADOS.Namespace, "put", object, f.Name())
+}
+
+func validateExport(e *cephv1.CephNFSExport, exports []cephv1.CephNFSExport) error {
+	if e.Spec.Server == "" {
+		return fmt.Errorf("missing server")
+	}
+	if (e.Spec.CephFS == nil) == (e.Spec.RGW == nil) {
+		return fmt.Errorf("exactly one of cephfs or rgw must be set")
+	}
+	if e.Spec.CephFS != nil {
+		if e.Spec.CephFS.Filesystem == "" {
+			return fmt.Errorf("missing cephfs.filesystem")
+		}
+		if e.Spec.CephFS.Path != "" && !strings.HasPrefix(e.Spec.CephFS.Path, "/") {
+			return fmt.Errorf("cephfs.path %s must be absolute", e.Spec.CephFS.Path)
+		}
+	}
+	if e.Spec.RGW != nil {
+		if e.Spec.RGW.ObjectStore == "" || e.Spec.RGW.User == "" || e.Spec.RGW.Bucket == "" {
+			return fmt.Errorf("rgw.objectStore, rgw.user and rgw.bucket are required")
+		}
+	}
+	if !strings.HasPrefix(e.Spec.PseudoPath, "/") {
+		return fmt.Errorf("pseudoPath %q must be absolute", e.Spec.PseudoPath)
+	}
+	if e.Spec.AccessType != "" && !exportAccessTypes[e.Spec.AccessType] {
+		return fmt.Errorf("invalid accessType %s. must be RW, RO or None", e.Spec.AccessType)
+	}
+	if _, ok := exportSquash[e.Spec.Squash]; e.Spec.Squash != "" && !ok {
+		return fmt.Errorf("invalid squash %s. must be None, Root, RootId or All", e.Spec.Squash)
+	}
+	for _, client := range e.Spec.Clients {
+		if net.ParseIP(client) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(client); err != nil {
+			return fmt.Errorf("invalid client %s. must be an IP address or CIDR", client)
+		}
+	}
+
+	for _, export := range exports {
+		if export.Name != e.Name && export.Spec.PseudoPath == e.Spec.PseudoPath {
+			return fmt.Errorf("pseudoPath %s is already used by export %s", e.Spec.PseudoPath, export.Name)
+		}
+	}
+	return nil
+}
+
+// nextExportID returns an export id that is not used by any of the exports
+func nextExportID(exports []cephv1.CephNFSExport) int {
+	max := 0
+	for _, export := range exports {
+		if export.Status.ExportID > max {
+			max = export.Status.ExportID
+		}
+	}
+	return max + 1
+}
+
+// replaceExport returns the exports with the export of the same name replaced, or the export
+// appended if it is not in the list yet
+func replaceExport(exports []cephv1.CephNFSExport, e cephv1.CephNFSExport) []cephv1.CephNFSExport {
+	for i := range exports {
+		if exports[i].Name == e.Name {
+			exports[i] = e
+			return exports
+		}
+	}
+	return append(exports, e)
+}
+
+// getObjectUserKeys returns the s3 keys of the object store user from the secret created by the
+// object store user controller
+func getObjectUserKeys(context *clusterd.Context, namespace string, rgw *cephv1.NFSExportRGWSpec) (string, string, error) {
+	name := fmt.Sprintf("rook-ceph-object-user-%s-%s", rgw.ObjectStore, rgw.User)
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get keys of object store user %s. %+v", rgw.User, err)
+	}
+	return string(secret.Data["AccessKey"]), string(secret.Data["SecretKey"]), nil
+}
+
+func getExportObject(n cephv1.CephNFS, exportName string) string {
+	return fmt.Sprintf("export-%s.%s", n.Name, exportName)
+}
+
+// getExportConfig renders the ganesha EXPORT block of the export
+func getExportConfig(e *cephv1.CephNFSExport, exportID int, accessKey, secretKey string) string {
+	accessType := e.Spec.AccessType
+	if accessType == "" {
+		accessType = "RW"
+	}
+	squash := exportSquash[e.Spec.Squash]
+	if squash == "" {
+		squash = exportSquash["None"]
+	}
+
+	var config strings.Builder
+	config.WriteString("EXPORT {\n")
+	config.WriteString(fmt.Sprintf("\tExport_ID = %d;\n", exportID))
+	if e.Spec.CephFS != nil {
+		path := e.Spec.CephFS.Path
+		if path == "" {
+			path = "/"
+		}
+		config.WriteString(fmt.Sprintf("\tPath = %q;\n", path))
+	} else {
+		config.WriteString(fmt.Sprintf("\tPath = %q;\n", e.Spec.RGW.Bucket))
+	}
+	config.WriteString(fmt.Sprintf("\tPseudo = %q;\n", e.Spec.PseudoPath))
+	config.WriteString("\tProtocols = 4;\n")
+	config.WriteString("\tTransports = TCP;\n")
+	config.WriteString(fmt.Sprintf("\tSquash = %s;\n", squash))
+	if len(e.Spec.Clients) == 0 {
+		config.WriteString(fmt.Sprintf("\tAccess_Type = %s;\n", accessType))
+	} else {
+		// only the listed clients get access to the export
+		config.WriteString("\tAccess_Type = None;\n")
+		var clients []string
+		for _, client := range e.Spec.Clients {
+			clients = append(clients, fmt.Sprintf("%q", client))
+		}
+		config.WriteString("\tCLIENT {\n")
+		config.WriteString(fmt.Sprintf("\t\tClients = %s;\n", strings.Join(clients, ", ")))
+		config.WriteString(fmt.Sprintf("\t\tAccess_Type = %s;\n", accessType))
+		config.WriteString(fmt.Sprintf("\t\tSquash = %s;\n", squash))
+		config.WriteString("\t}\n")
+	}
+	config.WriteString("\tFSAL {\n")
+	if e.Spec.CephFS != nil {
+		config.WriteString("\t\tName = CEPH;\n")
+		config.WriteString(fmt.Sprintf("\t\tUser_Id = %q;\n", userID))
+		config.WriteString(fmt.Sprintf("\t\tFilesystem = %q;\n", e.Spec.CephFS.Filesystem))
+	} else {
+		config.WriteString("\t\tName = RGW;\n")
+		config.WriteString(fmt.Sprintf("\t\tUser_Id = %q;\n", e.Spec.RGW.User))
+		config.WriteString(fmt.Sprintf("\t\tAccess_Key_Id = %q;\n", accessKey))
+		config.WriteString(fmt.Sprintf("\t\tSecret_Access_Key = %q;\n", secretKey))
+	}
+	config.WriteString("\t}\n")
+	config.WriteString("}\n")
+	return config.String()
+}
+
+// updateExportStatus sets the ExportReady condition and the export id of the export from the
+// result of writing it to the ganesha config
+func updateExportStatus(context *clusterd.Context, e *cephv1.CephNFSExport, reconcileErr error) {
+	latest, err := context.RookClientset.CephV1().CephNFSExports(e.Namespace).Get(e.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get nfs export %s to update its status. %+v", e.Name, err)
+		return
+	}
+
+	latest.Status.ObservedGeneration = e.Generation
+	latest.Status.ExportID = e.Status.ExportID
+	if reconcileErr != nil {
+		latest.Status.SetCondition(cephv1.ConditionExportReady, v1.ConditionFalse, cephv1.ReasonExportFailed, reconcileErr.Error())
+	} else {
+		message := fmt.Sprintf("export %d is served at %s by nfs %s", e.Status.ExportID, e.Spec.PseudoPath, e.Spec.Server)
+		latest.Status.SetCondition(cephv1.ConditionExportReady, v1.ConditionTrue, cephv1.ReasonExportApplied, message)
+	}
+	latest.Status.SetPhase(cephv1.ConditionExportReady)
+
+	if _, err := context.RookClientset.CephV1().CephNFSExports(e.Namespace).UpdateStatus(latest); err != nil {
+		logger.Warningf("failed to update status of nfs export %s. %+v", e.Name, err)
+	}
+}
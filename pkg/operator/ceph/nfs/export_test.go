@@ -0,0 +1,183 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateExport(t *testing.T) {
+	e := &cephv1.CephNFSExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "e1"},
+		Spec: cephv1.NFSExportSpec{
+			Server:     "nfs",
+			CephFS:     &cephv1.NFSExportCephFSSpec{Filesystem: "myfs", Path: "/shared"},
+			PseudoPath: "/shared",
+			AccessType: "RO",
+			Squash:     "Root",
+			Clients:    []string{"10.0.0.0/24", "192.168.1.12"},
+		},
+	}
+	assert.Nil(t, validateExport(e, nil))
+
+	// the source must be cephfs or rgw, not both
+	e.Spec.RGW = &cephv1.NFSExportRGWSpec{ObjectStore: "store", User: "alice", Bucket: "b"}
+	assert.NotNil(t, validateExport(e, nil))
+	e.Spec.CephFS = nil
+	assert.Nil(t, validateExport(e, nil))
+	e.Spec.RGW.User = ""
+	assert.NotNil(t, validateExport(e, nil))
+	e.Spec.RGW.User = "alice"
+
+	e.Spec.AccessType = "rw"
+	assert.NotNil(t, validateExport(e, nil))
+	e.Spec.AccessType = ""
+
+	e.Spec.Squash = "root_squash"
+	assert.NotNil(t, validateExport(e, nil))
+	e.Spec.Squash = ""
+
+	e.Spec.Clients = []string{"10.0.0.0/33"}
+	assert.NotNil(t, validateExport(e, nil))
+	e.Spec.Clients = nil
+
+	e.Spec.PseudoPath = "shared"
+	assert.NotNil(t, validateExport(e, nil))
+	e.Spec.PseudoPath = "/shared"
+
+	// the pseudo path must be unique among the exports of the server
+	other := cephv1.CephNFSExport{ObjectMeta: metav1.ObjectMeta{Name: "e2"}, Spec: cephv1.NFSExportSpec{PseudoPath: "/shared"}}
+	assert.NotNil(t, validateExport(e, []cephv1.CephNFSExport{*e, other}))
+	other.Spec.PseudoPath = "/other"
+	assert.Nil(t, validateExport(e, []cephv1.CephNFSExport{*e, other}))
+}
+
+func TestGetExportConfig(t *testing.T) {
+	e := &cephv1.CephNFSExport{
+		Spec: cephv1.NFSExportSpec{
+			CephFS:     &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"},
+			PseudoPath: "/myfs",
+		},
+	}
+	config := getExportConfig(e, 3, "", "")
+	assert.Contains(t, config, "\tExport_ID = 3;\n")
+	assert.Contains(t, config, "\tPath = \"/\";\n")
+	assert.Contains(t, config, "\tPseudo = \"/myfs\";\n")
+	assert.Contains(t, config, "\tAccess_Type = RW;\n")
+	assert.Contains(t, config, "\tSquash = No_Root_Squash;\n")
+	assert.Contains(t, config, "\t\tName = CEPH;\n")
+	assert.Contains(t, config, "\t\tFilesystem = \"myfs\";\n")
+	assert.NotContains(t, config, "CLIENT")
+
+	// only the listed clients get the access type
+	e.Spec.AccessType = "RO"
+	e.Spec.Squash = "All"
+	e.Spec.Clients = []string{"10.0.0.0/24", "192.168.1.12"}
+	config = getExportConfig(e, 3, "", "")
+	assert.Contains(t, config, "\tAccess_Type = None;\n")
+	assert.Contains(t, config, "\t\tClients = \"10.0.0.0/24\", \"192.168.1.12\";\n")
+	assert.Contains(t, config, "\t\tAccess_Type = RO;\n")
+	assert.Contains(t, config, "\t\tSquash = All_Squash;\n")
+
+	e.Spec.CephFS = nil
+	e.Spec.RGW = &cephv1.NFSExportRGWSpec{ObjectStore: "store", User: "alice", Bucket: "photos"}
+	config = getExportConfig(e, 4, "access", "secret")
+	assert.Contains(t, config, "\tPath = \"photos\";\n")
+	assert.Contains(t, config, "\t\tName = RGW;\n")
+	assert.Contains(t, config, "\t\tUser_Id = \"alice\";\n")
+	assert.Contains(t, config, "\t\tAccess_Key_Id = \"access\";\n")
+	assert.Contains(t, config, "\t\tSecret_Access_Key = \"secret\";\n")
+}
+
+func TestReconcileExports(t *testing.T) {
+	objects := map[string]string{}
+	var notified []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			assert.Equal(t, "rados", command)
+			assert.Equal(t, []string{"--pool", "pool", "--namespace", "ns"}, args[:4])
+			switch args[4] {
+			case "put":
+				content, err := ioutil.ReadFile(args[6])
+				assert.Nil(t, err)
+				objects[args[5]] = string(content)
+			case "notify":
+				notified = append(notified, args[5])
+			}
+			return nil
+		},
+	}
+	configDir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(1), RookClientset: rookfake.NewSimpleClientset(), ConfigDir: configDir}
+	c := NewCephNFSController(nil, context, "rook-ceph", "", cephv1.CephVersionSpec{}, false, metav1.OwnerReference{})
+
+	n := cephv1.CephNFS{
+		ObjectMeta: metav1.ObjectMeta{Name: "nfs", Namespace: "rook-ceph"},
+		Spec: cephv1.NFSGaneshaSpec{
+			RADOS:  cephv1.GaneshaRADOSSpec{Pool: "pool", Namespace: "ns"},
+			Server: cephv1.GaneshaServerSpec{Active: 2},
+		},
+	}
+	e1 := &cephv1.CephNFSExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "e1", Namespace: "rook-ceph"},
+		Spec:       cephv1.NFSExportSpec{Server: "nfs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}, PseudoPath: "/a"},
+		Status:     cephv1.CephNFSExportStatus{ExportID: 5},
+	}
+	e2 := &cephv1.CephNFSExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "e2", Namespace: "rook-ceph"},
+		Spec:       cephv1.NFSExportSpec{Server: "nfs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}, PseudoPath: "/b"},
+	}
+	other := &cephv1.CephNFSExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "rook-ceph"},
+		Spec:       cephv1.NFSExportSpec{Server: "other-nfs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}, PseudoPath: "/a"},
+	}
+	for _, e := range []*cephv1.CephNFSExport{e1, e2, other} {
+		_, err := context.RookClientset.CephV1().CephNFSExports("rook-ceph").Create(e)
+		assert.Nil(t, err)
+	}
+
+	err = c.reconcileExports(n)
+	assert.Nil(t, err)
+
+	// the existing export keeps its id and the new export gets the next one
+	assert.Contains(t, objects["export-nfs.e1"], "\tExport_ID = 5;\n")
+	assert.Contains(t, objects["export-nfs.e2"], "\tExport_ID = 6;\n")
+	_, ok := objects["export-nfs.other"]
+	assert.False(t, ok)
+	e2, err = context.RookClientset.CephV1().CephNFSExports("rook-ceph").Get("e2", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 6, e2.Status.ExportID)
+	assert.Equal(t, cephv1.ResourcePhaseReady, e2.Status.Phase)
+
+	// every server includes both exports and is notified
+	index := "%url \"rados://pool/ns/export-nfs.e1\"\n%url \"rados://pool/ns/export-nfs.e2\"\n"
+	assert.Equal(t, index, objects["conf-nfs.a"])
+	assert.Equal(t, index, objects["conf-nfs.b"])
+	assert.Equal(t, []string{"conf-nfs.a", "conf-nfs.b"}, notified)
+}
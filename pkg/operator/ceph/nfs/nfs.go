@@ -47,6 +47,9 @@ func (c *CephNFSController) upCephNFS(n cephv1.CephNFS, oldActive int) error {
 	logger.Infof("Starting cephNFS %s(%d-%d)", n.Name, oldActive,
 		n.Spec.Server.Active-1)
 
+	// the servers that failed to join the grace db keep running, but are reported in the error
+	var graceFailures []string
+
 	for i := oldActive; i < n.Spec.Server.Active; i++ {
 		name := k8sutil.IndexToName(i)
 
@@ -81,9 +84,20 @@ func (c *CephNFSController) upCephNFS(n cephv1.CephNFS, oldActive int) error {
 			return fmt.Errorf("failed to create ganesha service. %+v", err)
 		}
 
-		c.addServerToDatabase(n, name)
+		if err := c.addServerToDatabase(n, name); err != nil {
+			logger.Errorf("failed to add %s to grace db. %+v", name, err)
+			graceFailures = append(graceFailures, name)
+		}
+	}
+
+	// write the exports to the config objects of the servers, including the servers just added
+	if err := c.reconcileExports(n); err != nil {
+		return fmt.Errorf("failed to configure the exports. %+v", err)
 	}
 
+	if len(graceFailures) > 0 {
+		return fmt.Errorf("ganesha servers %v failed to join the grace db", graceFailures)
+	}
 	return nil
 }
 
@@ -100,12 +114,9 @@ func (c *CephNFSController) addRADOSConfigFile(n cephv1.CephNFS, name string) er
 	return c.context.Executor.ExecuteCommand(false, "", "rados", "--pool", n.Spec.RADOS.Pool, "--namespace", n.Spec.RADOS.Namespace, "create", config)
 }
 
-func (c *CephNFSController) addServerToDatabase(n cephv1.CephNFS, name string) {
+func (c *CephNFSController) addServerToDatabase(n cephv1.CephNFS, name string) error {
 	logger.Infof("Adding ganesha %s to grace db", name)
-
-	if err := c.runGaneshaRadosGraceJob(n, name, "add", 10*time.Minute); err != nil {
-		logger.Errorf("failed to add %s to grace db. %+v", name, err)
-	}
+	return c.runGaneshaRadosGraceJob(n, name, "add", 10*time.Minute)
 }
 
 func (c *CephNFSController) removeServerFromDatabase(n cephv1.CephNFS, name string) {
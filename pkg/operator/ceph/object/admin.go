@@ -19,8 +19,10 @@ package object
 import (
 	"fmt"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Context holds the context for the object store.
@@ -28,11 +30,38 @@ type Context struct {
 	context     *clusterd.Context
 	Name        string
 	ClusterName string
+	// The realm and zonegroup of the zone. The zone is named after the object store.
+	Realm     string
+	ZoneGroup string
 }
 
 // NewContext creates a new object store context.
 func NewContext(context *clusterd.Context, name, clusterName string) *Context {
-	return &Context{context: context, Name: name, ClusterName: clusterName}
+	return &Context{context: context, Name: name, ClusterName: clusterName, Realm: name, ZoneGroup: name}
+}
+
+// NewStoreContext creates a new object store context with the realm and zonegroup of the store
+func NewStoreContext(context *clusterd.Context, store *cephv1.CephObjectStore) *Context {
+	c := NewContext(context, store.Name, store.Namespace)
+	if store.Spec.Zone.Realm != "" {
+		c.Realm = store.Spec.Zone.Realm
+		c.ZoneGroup = store.Spec.Zone.Realm
+	}
+	if store.Spec.Zone.ZoneGroup != "" {
+		c.ZoneGroup = store.Spec.Zone.ZoneGroup
+	}
+	return c
+}
+
+// GetStoreContext creates a new object store context for the object store with the given name. If
+// the object store cannot be found, the realm and zonegroup are assumed to be named after the store.
+func GetStoreContext(context *clusterd.Context, name, namespace string) *Context {
+	store, err := context.RookClientset.CephV1().CephObjectStores(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		logger.Debugf("failed to get object store %s, using its name for the realm. %+v", name, err)
+		return NewContext(context, name, namespace)
+	}
+	return NewStoreContext(context, store)
 }
 
 func runAdminCommandNoRealm(c *Context, args ...string) (string, error) {
@@ -49,8 +78,8 @@ func runAdminCommandNoRealm(c *Context, args ...string) (string, error) {
 
 func runAdminCommand(c *Context, args ...string) (string, error) {
 	options := []string{
-		fmt.Sprintf("--rgw-realm=%s", c.Name),
-		fmt.Sprintf("--rgw-zonegroup=%s", c.Name),
+		fmt.Sprintf("--rgw-realm=%s", c.Realm),
+		fmt.Sprintf("--rgw-zonegroup=%s", c.ZoneGroup),
 	}
 	return runAdminCommandNoRealm(c, append(args, options...)...)
 }
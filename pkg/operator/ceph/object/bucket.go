@@ -21,6 +21,12 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 type ObjectBucketMetadata struct {
@@ -211,3 +217,25 @@ func DeleteBucket(c *Context, bucketName string, purge bool) (int, error) {
 
 	return RGWErrorUnknown, fmt.Errorf("failed to delete bucket: %+v", err)
 }
+
+// CreateBucket creates a bucket through the S3 API of the object store with the keys of the user
+// that will own the bucket, since radosgw-admin cannot create buckets. Nothing is done if the user
+// already owns the bucket.
+func CreateBucket(endpoint, region, accessKey, secretKey, bucketName string) error {
+	config := aws.NewConfig().
+		WithRegion(region).
+		WithCredentials(credentials.NewStaticCredentials(accessKey, secretKey, "")).
+		WithEndpoint(endpoint).
+		WithS3ForcePathStyle(true).
+		WithDisableSSL(true)
+	client := s3.New(session.New(), config)
+
+	_, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(bucketName)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeBucketAlreadyOwnedByYou {
+			return nil
+		}
+		return fmt.Errorf("failed to create bucket %s. %+v", bucketName, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package objectbucket provisions buckets for object bucket claims.
+package objectbucket
+
+import (
+	"reflect"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-bucket")
+
+// ObjectBucketClaimResource represents the object bucket claim custom resource
+var ObjectBucketClaimResource = opkit.CustomResource{
+	Name:    "objectbucketclaim",
+	Plural:  "objectbucketclaims",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.ObjectBucketClaim{}).Name(),
+}
+
+// ObjectBucketResource represents the object bucket custom resource
+var ObjectBucketResource = opkit.CustomResource{
+	Name:    "objectbucket",
+	Plural:  "objectbuckets",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.ClusterScoped,
+	Kind:    reflect.TypeOf(cephv1.ObjectBucket{}).Name(),
+}
+
+// ObjectBucketClaimController represents a controller object for object bucket claim custom resources
+type ObjectBucketClaimController struct {
+	context   *clusterd.Context
+	namespace string
+}
+
+// NewObjectBucketClaimController create controller for watching object bucket claim custom resources created
+func NewObjectBucketClaimController(context *clusterd.Context, namespace string) *ObjectBucketClaimController {
+	return &ObjectBucketClaimController{
+		context:   context,
+		namespace: namespace,
+	}
+}
+
+// StartWatch watches for instances of ObjectBucketClaim custom resources and acts on them
+func (c *ObjectBucketClaimController) StartWatch(stopCh chan struct{}) error {
+
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching object bucket claims in namespace %s", c.namespace)
+	watcher := opkit.NewWatcher(ObjectBucketClaimResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.ObjectBucketClaim{}, stopCh)
+
+	return nil
+}
+
+func (c *ObjectBucketClaimController) onAdd(obj interface{}) {
+	claim := obj.(*cephv1.ObjectBucketClaim).DeepCopy()
+	if claim.Status.Phase == cephv1.ObjectBucketClaimPhaseBound {
+		logger.Debugf("object bucket claim %s/%s is already bound", claim.Namespace, claim.Name)
+		return
+	}
+	provision(c.context, claim)
+}
+
+func (c *ObjectBucketClaimController) onUpdate(oldObj, newObj interface{}) {
+	oldClaim := oldObj.(*cephv1.ObjectBucketClaim)
+	newClaim := newObj.(*cephv1.ObjectBucketClaim).DeepCopy()
+
+	// a claim that failed to be provisioned is retried when its spec changes
+	if newClaim.Status.Phase == cephv1.ObjectBucketClaimPhaseBound || reflect.DeepEqual(oldClaim.Spec, newClaim.Spec) {
+		logger.Debugf("object bucket claim %s/%s did not change", newClaim.Namespace, newClaim.Name)
+		return
+	}
+	provision(c.context, newClaim)
+}
+
+func (c *ObjectBucketClaimController) onDelete(obj interface{}) {
+	claim := obj.(*cephv1.ObjectBucketClaim).DeepCopy()
+	if err := deprovision(c.context, claim); err != nil {
+		logger.Errorf("failed to delete the bucket of object bucket claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+	}
+}
+
+// updateClaimStatus sets the phase of the latest version of the claim
+func updateClaimStatus(context *clusterd.Context, claim *cephv1.ObjectBucketClaim, status cephv1.ObjectBucketClaimStatus) {
+	latest, err := context.RookClientset.CephV1().ObjectBucketClaims(claim.Namespace).Get(claim.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get object bucket claim %s/%s to update its status. %+v", claim.Namespace, claim.Name, err)
+		return
+	}
+	latest.Status = status
+	if _, err := context.RookClientset.CephV1().ObjectBucketClaims(claim.Namespace).UpdateStatus(latest); err != nil {
+		logger.Warningf("failed to update status of object bucket claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+	}
+}
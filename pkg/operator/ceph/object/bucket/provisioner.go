@@ -0,0 +1,326 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectbucket
+
+import (
+	"fmt"
+	"strconv"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	// ProvisionerName is the provisioner of the storage classes of object bucket claims
+	ProvisionerName = "ceph.rook.io/bucket"
+
+	// storage class parameters
+	storeNameParam      = "objectStoreName"
+	storeNamespaceParam = "objectStoreNamespace"
+	regionParam         = "region"
+
+	// the ceph object store only supports the default aws region
+	defaultRegion = "us-east-1"
+
+	// keys of the secret and configmap written into the namespace of the claim
+	accessKeyIDKey     = "AWS_ACCESS_KEY_ID"
+	secretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+	bucketHostKey      = "BUCKET_HOST"
+	bucketPortKey      = "BUCKET_PORT"
+	bucketNameKey      = "BUCKET_NAME"
+	bucketRegionKey    = "BUCKET_REGION"
+)
+
+// createBucket creates the bucket through the S3 API of the object store. Tests replace it since
+// there is no object store to talk to.
+var createBucket = object.CreateBucket
+
+// provision creates the bucket of the claim if the storage class of the claim belongs to this
+// provisioner, and sets the claim status with the result
+func provision(context *clusterd.Context, claim *cephv1.ObjectBucketClaim) {
+	class, err := context.Clientset.StorageV1().StorageClasses().Get(claim.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("failed to get storage class %s of object bucket claim %s/%s. %+v", claim.Spec.StorageClassName, claim.Namespace, claim.Name, err)
+		updateClaimStatus(context, claim, cephv1.ObjectBucketClaimStatus{Phase: cephv1.ObjectBucketClaimPhaseFailed, Message: err.Error()})
+		return
+	}
+	if class.Provisioner != ProvisionerName {
+		logger.Debugf("object bucket claim %s/%s is not provisioned by %s", claim.Namespace, claim.Name, ProvisionerName)
+		return
+	}
+
+	bucketName, err := getBucketName(claim)
+	if err != nil {
+		logger.Errorf("invalid object bucket claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+		updateClaimStatus(context, claim, cephv1.ObjectBucketClaimStatus{Phase: cephv1.ObjectBucketClaimPhaseFailed, Message: err.Error()})
+		return
+	}
+
+	logger.Infof("provisioning bucket %s for object bucket claim %s/%s", bucketName, claim.Namespace, claim.Name)
+	ob, err := provisionBucket(context, claim, class, bucketName)
+	if err != nil {
+		logger.Errorf("failed to provision bucket for object bucket claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+		updateClaimStatus(context, claim, cephv1.ObjectBucketClaimStatus{Phase: cephv1.ObjectBucketClaimPhaseFailed, BucketName: bucketName, Message: err.Error()})
+		return
+	}
+
+	updateClaimStatus(context, claim, cephv1.ObjectBucketClaimStatus{
+		Phase:            cephv1.ObjectBucketClaimPhaseBound,
+		ObjectBucketName: ob.Name,
+		BucketName:       ob.Spec.Endpoint.BucketName,
+	})
+	logger.Infof("bucket %s bound to object bucket claim %s/%s", ob.Spec.Endpoint.BucketName, claim.Namespace, claim.Name)
+}
+
+// provisionBucket creates the owner user and the bucket in the object store of the storage class,
+// writes the secret and configmap for the claim and creates the object bucket bound to the claim
+func provisionBucket(context *clusterd.Context, claim *cephv1.ObjectBucketClaim, class *storagev1.StorageClass, bucketName string) (*cephv1.ObjectBucket, error) {
+	storeName := class.Parameters[storeNameParam]
+	storeNamespace := class.Parameters[storeNamespaceParam]
+	if storeName == "" || storeNamespace == "" {
+		return nil, fmt.Errorf("storage class %s must set the %s and %s parameters", class.Name, storeNameParam, storeNamespaceParam)
+	}
+	region := class.Parameters[regionParam]
+	if region == "" {
+		region = defaultRegion
+	}
+
+	store, err := context.RookClientset.CephV1().CephObjectStores(storeNamespace).Get(storeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object store %s/%s. %+v", storeNamespace, storeName, err)
+	}
+	objContext := object.NewStoreContext(context, store)
+
+	// the bucket is owned by a user dedicated to the claim. The user of an object bucket bound to
+	// another claim is never reused.
+	owner := objectBucketName(claim)
+	existing, err := context.RookClientset.CephV1().ObjectBuckets().Get(owner, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get object bucket %s. %+v", owner, err)
+	}
+	if err == nil {
+		if err := checkClaimRef(existing, claim); err != nil {
+			return nil, err
+		}
+	}
+	user, _, err := object.GetUser(objContext, owner)
+	if err != nil {
+		displayName := fmt.Sprintf("Owner of object bucket claim %s/%s", claim.Namespace, claim.Name)
+		user, _, err = object.CreateUser(objContext, object.ObjectUser{UserID: owner, DisplayName: &displayName})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user %s. %+v", owner, err)
+		}
+	}
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return nil, fmt.Errorf("user %s has no keys", owner)
+	}
+
+	endpoint := cephv1.ObjectBucketEndpoint{
+		BucketHost: fmt.Sprintf("%s-%s.%s", object.AppName, store.Name, store.Namespace),
+		BucketPort: store.Spec.Gateway.Port,
+		BucketName: bucketName,
+		Region:     region,
+	}
+	url := fmt.Sprintf("http://%s:%d", endpoint.BucketHost, endpoint.BucketPort)
+	if err := createBucket(url, region, *user.AccessKey, *user.SecretKey, bucketName); err != nil {
+		return nil, err
+	}
+
+	if err := writeClaimSecret(context, claim, *user.AccessKey, *user.SecretKey); err != nil {
+		return nil, err
+	}
+	if err := writeClaimConfigMap(context, claim, endpoint); err != nil {
+		return nil, err
+	}
+
+	reclaimPolicy := v1.PersistentVolumeReclaimDelete
+	if class.ReclaimPolicy != nil {
+		reclaimPolicy = *class.ReclaimPolicy
+	}
+	ob := &cephv1.ObjectBucket{
+		ObjectMeta: metav1.ObjectMeta{Name: owner},
+		Spec: cephv1.ObjectBucketSpec{
+			StorageClassName: class.Name,
+			ReclaimPolicy:    reclaimPolicy,
+			ClaimRef: &v1.ObjectReference{
+				APIVersion: cephv1.SchemeGroupVersion.String(),
+				Kind:       ObjectBucketClaimResource.Kind,
+				Namespace:  claim.Namespace,
+				Name:       claim.Name,
+				UID:        claim.UID,
+			},
+			ObjectStoreName:      store.Name,
+			ObjectStoreNamespace: store.Namespace,
+			Owner:                owner,
+			Endpoint:             endpoint,
+		},
+	}
+	created, err := context.RookClientset.CephV1().ObjectBuckets().Create(ob)
+	if err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create object bucket %s. %+v", ob.Name, err)
+		}
+		existing, err := context.RookClientset.CephV1().ObjectBuckets().Get(ob.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get object bucket %s. %+v", ob.Name, err)
+		}
+		if err := checkClaimRef(existing, claim); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	created.Status.Phase = cephv1.ObjectBucketPhaseBound
+	if _, err := context.RookClientset.CephV1().ObjectBuckets().UpdateStatus(created); err != nil {
+		logger.Warningf("failed to update status of object bucket %s. %+v", created.Name, err)
+	}
+	return created, nil
+}
+
+// getBucketName returns the bucket name of the claim. A name is only generated once since it is kept
+// in the claim status, even if provisioning fails.
+func getBucketName(claim *cephv1.ObjectBucketClaim) (string, error) {
+	if claim.Spec.BucketName != "" {
+		return claim.Spec.BucketName, nil
+	}
+	if claim.Status.BucketName != "" {
+		return claim.Status.BucketName, nil
+	}
+	if claim.Spec.GenerateBucketName == "" {
+		return "", fmt.Errorf("either bucketName or generateBucketName must be set")
+	}
+	return fmt.Sprintf("%s-%s", claim.Spec.GenerateBucketName, rand.String(8)), nil
+}
+
+// objectBucketName returns the name of the object bucket of the claim, which is also the name of
+// the user that owns the bucket. The name is built from the uid of the claim, since the namespace and
+// name of different claims can join into the same name.
+func objectBucketName(claim *cephv1.ObjectBucketClaim) string {
+	return fmt.Sprintf("obc-%s", claim.UID)
+}
+
+// checkClaimRef returns an error if the object bucket is bound to another claim
+func checkClaimRef(ob *cephv1.ObjectBucket, claim *cephv1.ObjectBucketClaim) error {
+	if ob.Spec.ClaimRef == nil || ob.Spec.ClaimRef.UID != claim.UID {
+		return fmt.Errorf("object bucket %s is not bound to object bucket claim %s/%s", ob.Name, claim.Namespace, claim.Name)
+	}
+	return nil
+}
+
+func claimOwnerRef(claim *cephv1.ObjectBucketClaim) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: cephv1.SchemeGroupVersion.String(),
+		Kind:       ObjectBucketClaimResource.Kind,
+		Name:       claim.Name,
+		UID:        claim.UID,
+	}
+}
+
+// writeClaimSecret writes the keys of the owner of the bucket in a secret named after the claim.
+// The secret is deleted with the claim.
+func writeClaimSecret(context *clusterd.Context, claim *cephv1.ObjectBucketClaim, accessKey, secretKey string) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            claim.Name,
+			Namespace:       claim.Namespace,
+			OwnerReferences: []metav1.OwnerReference{claimOwnerRef(claim)},
+		},
+		StringData: map[string]string{
+			accessKeyIDKey:     accessKey,
+			secretAccessKeyKey: secretKey,
+		},
+	}
+	_, err := context.Clientset.CoreV1().Secrets(claim.Namespace).Create(secret)
+	if err != nil && errors.IsAlreadyExists(err) {
+		_, err = context.Clientset.CoreV1().Secrets(claim.Namespace).Update(secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write secret of object bucket claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+	}
+	return nil
+}
+
+// writeClaimConfigMap writes the endpoint of the bucket in a configmap named after the claim. The
+// configmap is deleted with the claim.
+func writeClaimConfigMap(context *clusterd.Context, claim *cephv1.ObjectBucketClaim, endpoint cephv1.ObjectBucketEndpoint) error {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            claim.Name,
+			Namespace:       claim.Namespace,
+			OwnerReferences: []metav1.OwnerReference{claimOwnerRef(claim)},
+		},
+		Data: map[string]string{
+			bucketHostKey:   endpoint.BucketHost,
+			bucketPortKey:   strconv.Itoa(int(endpoint.BucketPort)),
+			bucketNameKey:   endpoint.BucketName,
+			bucketRegionKey: endpoint.Region,
+		},
+	}
+	_, err := context.Clientset.CoreV1().ConfigMaps(claim.Namespace).Create(configMap)
+	if err != nil && errors.IsAlreadyExists(err) {
+		_, err = context.Clientset.CoreV1().ConfigMaps(claim.Namespace).Update(configMap)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write configmap of object bucket claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+	}
+	return nil
+}
+
+// deprovision releases the bucket of a deleted claim. With the Delete reclaim policy the bucket is
+// purged with its objects and its owner is deleted, with the Retain policy the bucket and its owner
+// are kept and the object bucket is marked as released.
+func deprovision(context *clusterd.Context, claim *cephv1.ObjectBucketClaim) error {
+	name := objectBucketName(claim)
+	ob, err := context.RookClientset.CephV1().ObjectBuckets().Get(name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Debugf("object bucket claim %s/%s has no bucket", claim.Namespace, claim.Name)
+			return nil
+		}
+		return fmt.Errorf("failed to get object bucket %s. %+v", name, err)
+	}
+	if err := checkClaimRef(ob, claim); err != nil {
+		return err
+	}
+
+	if ob.Spec.ReclaimPolicy == v1.PersistentVolumeReclaimRetain {
+		logger.Infof("retaining bucket %s of object bucket claim %s/%s", ob.Spec.Endpoint.BucketName, claim.Namespace, claim.Name)
+		ob.Status.Phase = cephv1.ObjectBucketPhaseReleased
+		if _, err := context.RookClientset.CephV1().ObjectBuckets().UpdateStatus(ob); err != nil {
+			return fmt.Errorf("failed to release object bucket %s. %+v", name, err)
+		}
+		return nil
+	}
+
+	objContext := object.GetStoreContext(context, ob.Spec.ObjectStoreName, ob.Spec.ObjectStoreNamespace)
+	if code, err := object.DeleteBucket(objContext, ob.Spec.Endpoint.BucketName, true); err != nil && code != object.RGWErrorNotFound {
+		return fmt.Errorf("failed to delete bucket %s. %+v", ob.Spec.Endpoint.BucketName, err)
+	}
+	if _, code, err := object.DeleteUser(objContext, ob.Spec.Owner); err != nil && code != object.RGWErrorNotFound {
+		logger.Warningf("failed to delete owner %s of bucket %s. %+v", ob.Spec.Owner, ob.Spec.Endpoint.BucketName, err)
+	}
+	if err := context.RookClientset.CephV1().ObjectBuckets().Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete object bucket %s. %+v", name, err)
+	}
+	logger.Infof("deleted bucket %s of object bucket claim %s/%s", ob.Spec.Endpoint.BucketName, claim.Namespace, claim.Name)
+	return nil
+}
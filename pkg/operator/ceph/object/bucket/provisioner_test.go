@@ -0,0 +1,208 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectbucket
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestContext(t *testing.T, reclaimPolicy v1.PersistentVolumeReclaimPolicy, commands *[]string) *clusterd.Context {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			assert.Equal(t, "radosgw-admin", command)
+			cmd := strings.Join(args[:2], " ")
+			// leave out the realm, zonegroup and cluster config args
+			*commands = append(*commands, strings.Join(args[:len(args)-5], " "))
+			switch cmd {
+			case "user info":
+				return "", fmt.Errorf("exit status 22")
+			case "user create":
+				return `{"user_id":"obc-1234","display_name":"owner","keys":[{"access_key":"access","secret_key":"secret"}]}`, nil
+			}
+			return "", nil
+		},
+	}
+	class := &storagev1.StorageClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "bucket-class"},
+		Provisioner:   ProvisionerName,
+		Parameters:    map[string]string{storeNameParam: "my-store", storeNamespaceParam: "rook-ceph"},
+		ReclaimPolicy: &reclaimPolicy,
+	}
+	clientset := testop.New(1)
+	_, err := clientset.StorageV1().StorageClasses().Create(class)
+	assert.Nil(t, err)
+
+	store := &cephv1.CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"},
+		Spec:       cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{Port: 80}},
+	}
+	claim := &cephv1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "photos", Namespace: "apps", UID: "1234"},
+		Spec:       cephv1.ObjectBucketClaimSpec{StorageClassName: "bucket-class", GenerateBucketName: "photos"},
+	}
+	return &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(store, claim)}
+}
+
+func TestProvisionBucket(t *testing.T) {
+	var commands []string
+	context := newTestContext(t, v1.PersistentVolumeReclaimDelete, &commands)
+	var createdBucket string
+	createBucket = func(endpoint, region, accessKey, secretKey, bucketName string) error {
+		assert.Equal(t, "http://rook-ceph-rgw-my-store.rook-ceph:80", endpoint)
+		assert.Equal(t, "access", accessKey)
+		assert.Equal(t, "secret", secretKey)
+		createdBucket = bucketName
+		return nil
+	}
+
+	claim, err := context.RookClientset.CephV1().ObjectBucketClaims("apps").Get("photos", metav1.GetOptions{})
+	assert.Nil(t, err)
+	provision(context, claim)
+
+	// the owner was created and the bucket name was generated from the prefix
+	assert.Equal(t, []string{"user info --uid obc-1234", "user create --uid obc-1234 --display-name Owner of object bucket claim apps/photos"}, commands)
+	assert.True(t, strings.HasPrefix(createdBucket, "photos-"))
+
+	claim, err = context.RookClientset.CephV1().ObjectBucketClaims("apps").Get("photos", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ObjectBucketClaimPhaseBound, claim.Status.Phase)
+	assert.Equal(t, "obc-1234", claim.Status.ObjectBucketName)
+	assert.Equal(t, createdBucket, claim.Status.BucketName)
+
+	secret, err := context.Clientset.CoreV1().Secrets("apps").Get("photos", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "access", secret.StringData[accessKeyIDKey])
+	assert.Equal(t, "secret", secret.StringData[secretAccessKeyKey])
+	configMap, err := context.Clientset.CoreV1().ConfigMaps("apps").Get("photos", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph-rgw-my-store.rook-ceph", configMap.Data[bucketHostKey])
+	assert.Equal(t, "80", configMap.Data[bucketPortKey])
+	assert.Equal(t, createdBucket, configMap.Data[bucketNameKey])
+	assert.Equal(t, defaultRegion, configMap.Data[bucketRegionKey])
+
+	ob, err := context.RookClientset.CephV1().ObjectBuckets().Get("obc-1234", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, v1.PersistentVolumeReclaimDelete, ob.Spec.ReclaimPolicy)
+	assert.Equal(t, "photos", ob.Spec.ClaimRef.Name)
+	assert.Equal(t, cephv1.ObjectBucketPhaseBound, ob.Status.Phase)
+
+	// the bucket is purged and its owner deleted with the claim
+	commands = nil
+	err = deprovision(context, claim)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bucket rm --bucket " + createdBucket + " --purge-objects", "user rm --uid obc-1234"}, commands)
+	_, err = context.RookClientset.CephV1().ObjectBuckets().Get("obc-1234", metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestRetainBucket(t *testing.T) {
+	var commands []string
+	context := newTestContext(t, v1.PersistentVolumeReclaimRetain, &commands)
+	createBucket = func(endpoint, region, accessKey, secretKey, bucketName string) error { return nil }
+
+	claim, err := context.RookClientset.CephV1().ObjectBucketClaims("apps").Get("photos", metav1.GetOptions{})
+	assert.Nil(t, err)
+	provision(context, claim)
+
+	// the bucket and its owner are kept and the object bucket is released
+	commands = nil
+	err = deprovision(context, claim)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(commands))
+	ob, err := context.RookClientset.CephV1().ObjectBuckets().Get("obc-1234", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ObjectBucketPhaseReleased, ob.Status.Phase)
+}
+
+func TestProvisionIgnoresOtherClasses(t *testing.T) {
+	var commands []string
+	context := newTestContext(t, v1.PersistentVolumeReclaimDelete, &commands)
+	claim, err := context.RookClientset.CephV1().ObjectBucketClaims("apps").Get("photos", metav1.GetOptions{})
+	assert.Nil(t, err)
+
+	class, err := context.Clientset.StorageV1().StorageClasses().Get("bucket-class", metav1.GetOptions{})
+	assert.Nil(t, err)
+	class.Provisioner = "example.com/bucket"
+	_, err = context.Clientset.StorageV1().StorageClasses().Update(class)
+	assert.Nil(t, err)
+
+	provision(context, claim)
+	assert.Equal(t, 0, len(commands))
+	claim, err = context.RookClientset.CephV1().ObjectBucketClaims("apps").Get("photos", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ObjectBucketClaimPhase(""), claim.Status.Phase)
+}
+
+func TestProvisionCollidingClaims(t *testing.T) {
+	var commands []string
+	context := newTestContext(t, v1.PersistentVolumeReclaimDelete, &commands)
+	createBucket = func(endpoint, region, accessKey, secretKey, bucketName string) error { return nil }
+
+	// the namespaces and names of the claims join into the same string
+	first := &cephv1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "a-b", UID: "1111"},
+		Spec:       cephv1.ObjectBucketClaimSpec{StorageClassName: "bucket-class", BucketName: "first"},
+	}
+	second := &cephv1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "b-c", Namespace: "a", UID: "2222"},
+		Spec:       cephv1.ObjectBucketClaimSpec{StorageClassName: "bucket-class", BucketName: "second"},
+	}
+	for _, claim := range []*cephv1.ObjectBucketClaim{first, second} {
+		_, err := context.RookClientset.CephV1().ObjectBucketClaims(claim.Namespace).Create(claim)
+		assert.Nil(t, err)
+		provision(context, claim)
+	}
+
+	// each claim gets its own owner and object bucket
+	for _, claim := range []*cephv1.ObjectBucketClaim{first, second} {
+		ob, err := context.RookClientset.CephV1().ObjectBuckets().Get("obc-"+string(claim.UID), metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, claim.UID, ob.Spec.ClaimRef.UID)
+		assert.Equal(t, "obc-"+string(claim.UID), ob.Spec.Owner)
+	}
+
+	// an object bucket bound to another claim is not reused
+	commands = nil
+	third := first.DeepCopy()
+	third.Namespace = "other"
+	third.Spec.BucketName = "third"
+	ob, err := context.RookClientset.CephV1().ObjectBuckets().Get("obc-1111", metav1.GetOptions{})
+	assert.Nil(t, err)
+	ob.Spec.ClaimRef.UID = "3333"
+	_, err = context.RookClientset.CephV1().ObjectBuckets().Update(ob)
+	assert.Nil(t, err)
+	_, err = context.RookClientset.CephV1().ObjectBucketClaims(third.Namespace).Create(third)
+	assert.Nil(t, err)
+	provision(context, third)
+	assert.Equal(t, 0, len(commands))
+	third, err = context.RookClientset.CephV1().ObjectBucketClaims("other").Get("c", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ObjectBucketClaimPhaseFailed, third.Status.Phase)
+	assert.NotNil(t, deprovision(context, third))
+}
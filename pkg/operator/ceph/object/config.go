@@ -43,6 +43,7 @@ caps osd = "allow rwx"
 
 // TODO: these should be set in the mon's central kv store for mimic+
 func (c *clusterConfig) defaultSettings() *cephconfig.Config {
+	objContext := NewStoreContext(c.context, &c.store)
 	s := cephconfig.NewConfig()
 	s.Section("global").
 		Set("rgw log nonexistent bucket", "true").
@@ -50,7 +51,10 @@ func (c *clusterConfig) defaultSettings() *cephconfig.Config {
 		Set("rgw enable usage log", "true").
 		Set("rgw frontends", fmt.Sprintf("civetweb port=%s", c.portString())).
 		Set("rgw zone", c.store.Name).
-		Set("rgw zonegroup", c.store.Name)
+		Set("rgw zonegroup", objContext.ZoneGroup)
+	if isMultisite(c.store.Spec) {
+		s.Section("global").Set("rgw realm", objContext.Realm)
+	}
 	return s
 }
 
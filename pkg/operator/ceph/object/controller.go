@@ -29,6 +29,7 @@ import (
 	daemonconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -108,6 +109,9 @@ func (c *ObjectStoreController) StartWatch(stopCh chan struct{}) error {
 	// watch for events on all legacy types too
 	c.watchLegacyObjectStores(c.namespace, stopCh, resourceHandlerFuncs)
 
+	// report the sync state of the multisite object stores
+	go newSyncChecker(c.context, c.namespace).checkSync(stopCh)
+
 	return nil
 }
 
@@ -179,9 +183,57 @@ func (c *ObjectStoreController) createOrUpdateStore(update bool, objectstore *ce
 		ownerRefs:   c.storeOwners(objectstore),
 		DataPathMap: cephconfig.NewStatelessDaemonDataPathMap(cephconfig.RgwType, objectstore.Name, c.clusterInfo.Name, c.dataDirHostPath),
 	}
-	if err := cfg.createOrUpdate(update); err != nil {
+	err := cfg.createOrUpdate(update)
+	if err != nil {
 		logger.Errorf("failed to %s object store %s. %+v", action, objectstore.Name, err)
 	}
+	updateStoreStatus(c.context, objectstore, err)
+}
+
+// updateStoreStatus sets the conditions of the object store from the result of createOrUpdate.
+// The pools are ready if the realm of the object store exists, and the daemons are ready if the
+// rgw pods were started without error.
+func updateStoreStatus(context *clusterd.Context, store *cephv1.CephObjectStore, createErr error) {
+	latest, err := context.RookClientset.CephV1().CephObjectStores(store.Namespace).Get(store.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get object store %s to update its status. %+v", store.Name, err)
+		return
+	}
+
+	status := &latest.Status
+	status.ObservedGeneration = store.Generation
+	if !storeRealmExists(context, store) {
+		message := fmt.Sprintf("object store %s does not exist. %+v", store.Name, createErr)
+		status.SetCondition(cephv1.ConditionPoolsReady, v1.ConditionFalse, cephv1.ReasonPoolFailed, message)
+		status.SetCondition(cephv1.ConditionDaemonsReady, v1.ConditionFalse, cephv1.ReasonRGWFailed, message)
+	} else {
+		status.SetCondition(cephv1.ConditionPoolsReady, v1.ConditionTrue, cephv1.ReasonPoolCreated, fmt.Sprintf("pools of object store %s were created", store.Name))
+		if createErr != nil {
+			status.SetCondition(cephv1.ConditionDaemonsReady, v1.ConditionFalse, cephv1.ReasonRGWFailed, createErr.Error())
+		} else {
+			status.SetCondition(cephv1.ConditionDaemonsReady, v1.ConditionTrue, cephv1.ReasonRGWReady, fmt.Sprintf("rgw pods of object store %s were started", store.Name))
+		}
+	}
+	status.SetPhase(cephv1.ConditionPoolsReady, cephv1.ConditionDaemonsReady)
+
+	if _, err := context.RookClientset.CephV1().CephObjectStores(store.Namespace).UpdateStatus(latest); err != nil {
+		logger.Warningf("failed to update status of object store %s. %+v", store.Name, err)
+	}
+}
+
+func storeRealmExists(context *clusterd.Context, store *cephv1.CephObjectStore) bool {
+	objContext := NewStoreContext(context, store)
+	stores, err := getObjectStores(objContext)
+	if err != nil {
+		logger.Warningf("failed to list object stores. %+v", err)
+		return false
+	}
+	for _, s := range stores {
+		if s == objContext.Realm {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *ObjectStoreController) onDelete(obj interface{}) {
@@ -269,6 +321,10 @@ func storeChanged(oldStore, newStore cephv1.ObjectStoreSpec) bool {
 		logger.Infof("SSLCertificateRef changed from %s to %s", oldStore.Gateway.SSLCertificateRef, newStore.Gateway.SSLCertificateRef)
 		return true
 	}
+	if oldStore.Zone != newStore.Zone {
+		logger.Infof("zone settings changed from %+v to %+v", oldStore.Zone, newStore.Zone)
+		return true
+	}
 	return false
 }
 
@@ -0,0 +1,346 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// keys of the secret with the keys of the system user of a realm
+	systemUserAccessKey = "AccessKey"
+	systemUserSecretKey = "SecretKey"
+
+	defaultSyncCheckInterval = 60 * time.Second
+)
+
+// systemUser is the user that the zones of a realm use to sync with each other
+type systemUser struct {
+	accessKey string
+	secretKey string
+}
+
+type zoneType struct {
+	ID        string `json:"id"`
+	SystemKey struct {
+		AccessKey string `json:"access_key"`
+	} `json:"system_key"`
+}
+
+type zoneGroupType struct {
+	ID         string `json:"id"`
+	MasterZone string `json:"master_zone"`
+	Zones      []struct {
+		Name string `json:"name"`
+	} `json:"zones"`
+}
+
+type periodType struct {
+	PeriodMap struct {
+		ZoneGroups []zoneGroupType `json:"zonegroups"`
+	} `json:"period_map"`
+}
+
+// isMultisite returns whether the object store is a zone of a realm that is not named after it
+func isMultisite(spec cephv1.ObjectStoreSpec) bool {
+	return spec.Zone.Realm != ""
+}
+
+func getSystemUser(context *Context, secretName string) (*systemUser, error) {
+	secret, err := context.context.Clientset.CoreV1().Secrets(context.ClusterName).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system user secret %s. %+v", secretName, err)
+	}
+	user := &systemUser{
+		accessKey: string(secret.Data[systemUserAccessKey]),
+		secretKey: string(secret.Data[systemUserSecretKey]),
+	}
+	if user.accessKey == "" || user.secretKey == "" {
+		return nil, fmt.Errorf("system user secret %s must have the %s and %s", secretName, systemUserAccessKey, systemUserSecretKey)
+	}
+	return user, nil
+}
+
+func (u *systemUser) keyArgs() []string {
+	return []string{"--access-key=" + u.accessKey, "--secret=" + u.secretKey}
+}
+
+// joinRealm pulls the realm and its current period from the master zone and adds the zone of the
+// object store to the zonegroup as a secondary zone. Nothing is done if the zone already exists.
+func joinRealm(context *Context, pullEndpoint, endpoint string, user *systemUser) error {
+	if user == nil {
+		return fmt.Errorf("the system user keys are required to pull the realm")
+	}
+	zoneArg := fmt.Sprintf("--rgw-zone=%s", context.Name)
+	if _, err := runAdminCommand(context, "zone", "get", zoneArg); err == nil {
+		logger.Debugf("zone %s already joined realm %s", context.Name, context.Realm)
+		return nil
+	}
+
+	defaultArg := ""
+	stores, err := getObjectStores(context)
+	if err != nil {
+		return fmt.Errorf("failed to get object stores. %+v", err)
+	}
+	if len(stores) == 0 {
+		defaultArg = "--default"
+	}
+
+	urlArg := fmt.Sprintf("--url=%s", pullEndpoint)
+	args := append([]string{"realm", "pull", urlArg, defaultArg}, user.keyArgs()...)
+	if _, err := runAdminCommand(context, args...); err != nil {
+		return fmt.Errorf("failed to pull realm from %s. %+v", pullEndpoint, err)
+	}
+	args = append([]string{"period", "pull", urlArg}, user.keyArgs()...)
+	if _, err := runAdminCommand(context, args...); err != nil {
+		return fmt.Errorf("failed to pull period from %s. %+v", pullEndpoint, err)
+	}
+
+	endpointArg := fmt.Sprintf("--endpoints=%s", endpoint)
+	args = append([]string{"zone", "create", zoneArg, endpointArg, defaultArg}, user.keyArgs()...)
+	if _, err := runAdminCommand(context, args...); err != nil {
+		return fmt.Errorf("failed to create secondary zone %s. %+v", context.Name, err)
+	}
+	if err := commitPeriod(context); err != nil {
+		return err
+	}
+
+	logger.Infof("zone %s joined realm %s as a secondary zone", context.Name, context.Realm)
+	return nil
+}
+
+// configureSystemUser creates the system user on the master zone and sets its keys on the zone so
+// that the secondary zones can pull the realm with the same keys
+func configureSystemUser(context *Context, user *systemUser) error {
+	zoneArg := fmt.Sprintf("--rgw-zone=%s", context.Name)
+	zone, err := getZone(context)
+	if err != nil {
+		return err
+	}
+	if zone.SystemKey.AccessKey == user.accessKey {
+		return nil
+	}
+
+	uidArg := fmt.Sprintf("--uid=%s-system-user", context.Realm)
+	if _, err := runAdminCommand(context, "user", "info", uidArg); err != nil {
+		args := append([]string{"user", "create", uidArg, fmt.Sprintf("--display-name=System user of realm %s", context.Realm), "--system"}, user.keyArgs()...)
+		if _, err := runAdminCommand(context, args...); err != nil {
+			return fmt.Errorf("failed to create system user. %+v", err)
+		}
+	}
+
+	args := append([]string{"zone", "modify", zoneArg}, user.keyArgs()...)
+	if _, err := runAdminCommand(context, args...); err != nil {
+		return fmt.Errorf("failed to set the system user keys on zone %s. %+v", context.Name, err)
+	}
+	return commitPeriod(context)
+}
+
+// promoteZone makes the zone of the object store the master zone of its zonegroup, unless it is
+// already the master
+func promoteZone(context *Context) error {
+	output, err := runAdminCommand(context, "zonegroup", "get")
+	if err != nil {
+		return fmt.Errorf("failed to get zonegroup %s. %+v", context.ZoneGroup, err)
+	}
+	var zoneGroup zoneGroupType
+	if err := json.Unmarshal([]byte(output), &zoneGroup); err != nil {
+		return fmt.Errorf("failed to unmarshal zonegroup: %+v", err)
+	}
+	zone, err := getZone(context)
+	if err != nil {
+		return err
+	}
+	if zoneGroup.MasterZone == zone.ID {
+		return nil
+	}
+
+	logger.Infof("promoting zone %s to master of zonegroup %s", context.Name, context.ZoneGroup)
+	if _, err := runAdminCommand(context, "zone", "modify", fmt.Sprintf("--rgw-zone=%s", context.Name), "--master", "--default"); err != nil {
+		return fmt.Errorf("failed to set zone %s as master. %+v", context.Name, err)
+	}
+	return commitPeriod(context)
+}
+
+// realmHasOtherZones returns whether the current period of the realm of the object store has zones
+// other than the zone of the object store, whatever the realm is named
+func realmHasOtherZones(context *Context) (bool, error) {
+	output, err := runAdminCommand(context, "period", "get")
+	if err != nil {
+		return false, fmt.Errorf("failed to get the period of realm %s. %+v", context.Realm, err)
+	}
+	var period periodType
+	if err := json.Unmarshal([]byte(output), &period); err != nil {
+		return false, fmt.Errorf("failed to unmarshal period: %+v", err)
+	}
+	for _, zoneGroup := range period.PeriodMap.ZoneGroups {
+		for _, zone := range zoneGroup.Zones {
+			if zone.Name != context.Name {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// removeZone removes the zone of the object store from its zonegroup. The realm and the zonegroup
+// are left to the other zones.
+func removeZone(context *Context) {
+	zoneArg := fmt.Sprintf("--rgw-zone=%s", context.Name)
+	if _, err := runAdminCommand(context, "zonegroup", "remove", zoneArg); err != nil {
+		logger.Warningf("failed to remove zone %s from zonegroup %s. %+v", context.Name, context.ZoneGroup, err)
+	}
+	if err := commitPeriod(context); err != nil {
+		logger.Warningf("%+v", err)
+	}
+	if _, err := runAdminCommand(context, "zone", "delete", zoneArg); err != nil {
+		logger.Warningf("failed to delete rgw zone %s. %+v", context.Name, err)
+	}
+}
+
+func getZone(context *Context) (*zoneType, error) {
+	output, err := runAdminCommand(context, "zone", "get", fmt.Sprintf("--rgw-zone=%s", context.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zone %s. %+v", context.Name, err)
+	}
+	var zone zoneType
+	if err := json.Unmarshal([]byte(output), &zone); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal zone: %+v", err)
+	}
+	return &zone, nil
+}
+
+func commitPeriod(context *Context) error {
+	if _, err := runAdminCommand(context, "period", "update", "--commit"); err != nil {
+		return fmt.Errorf("failed to update period. %+v", err)
+	}
+	return nil
+}
+
+// getSyncStatus returns the sync state of the zone of the object store
+func getSyncStatus(context *Context) (*cephv1.ZoneSyncStatus, error) {
+	output, err := runAdminCommand(context, "sync", "status", fmt.Sprintf("--rgw-zone=%s", context.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync status of zone %s. %+v", context.Name, err)
+	}
+	return parseSyncStatus(output), nil
+}
+
+// parseSyncStatus parses the text output of 'radosgw-admin sync status'. The metadata state is read
+// from the "metadata is ..." line, and the data state of each source zone from the "data is ..." line
+// following its "data sync source: <id> (<zone>)" line.
+func parseSyncStatus(output string) *cephv1.ZoneSyncStatus {
+	status := &cephv1.ZoneSyncStatus{}
+	var source *cephv1.ZoneSyncSource
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "metadata sync no sync"):
+			status.Metadata = strings.TrimPrefix(line, "metadata sync ")
+		case strings.HasPrefix(line, "metadata is "):
+			status.Metadata = strings.TrimPrefix(line, "metadata is ")
+		case strings.HasPrefix(line, "data sync source:"):
+			zone := strings.TrimSpace(strings.TrimPrefix(line, "data sync source:"))
+			if i := strings.Index(zone, "("); i >= 0 {
+				zone = strings.TrimSuffix(zone[i+1:], ")")
+			}
+			status.Data = append(status.Data, cephv1.ZoneSyncSource{Zone: zone})
+			source = &status.Data[len(status.Data)-1]
+		case strings.HasPrefix(line, "data is ") && source != nil:
+			source.Status = strings.TrimPrefix(line, "data is ")
+		}
+	}
+	return status
+}
+
+// syncChecker periodically reports the sync state of the multisite object stores in their status
+type syncChecker struct {
+	context   *clusterd.Context
+	namespace string
+	interval  time.Duration
+}
+
+func newSyncChecker(context *clusterd.Context, namespace string) *syncChecker {
+	return &syncChecker{context: context, namespace: namespace, interval: defaultSyncCheckInterval}
+}
+
+func (c *syncChecker) checkSync(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("Stopping monitoring of object store sync")
+			return
+
+		case <-time.After(c.interval):
+			c.checkStores()
+		}
+	}
+}
+
+// checkStores updates the sync status of every object store that is part of a multisite realm
+func (c *syncChecker) checkStores() {
+	stores, err := c.context.RookClientset.CephV1().CephObjectStores(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Errorf("failed to list object stores to check the sync status. %+v", err)
+		return
+	}
+
+	for i := range stores.Items {
+		store := &stores.Items[i]
+		if !isMultisite(store.Spec) {
+			if store.Status.Sync != nil {
+				store.Status.Sync = nil
+				if _, err := c.context.RookClientset.CephV1().CephObjectStores(c.namespace).UpdateStatus(store); err != nil {
+					logger.Warningf("failed to clear the sync status of object store %s. %+v", store.Name, err)
+				}
+			}
+			continue
+		}
+		if err := c.updateSyncStatus(store); err != nil {
+			logger.Warningf("failed to update the sync status of object store %s. %+v", store.Name, err)
+		}
+	}
+}
+
+func (c *syncChecker) updateSyncStatus(store *cephv1.CephObjectStore) error {
+	sync, err := getSyncStatus(NewStoreContext(c.context, store))
+	if err != nil {
+		return err
+	}
+
+	// only write the status when the sync state changed to avoid updating the store every interval
+	if store.Status.Sync != nil {
+		sync.LastChanged = store.Status.Sync.LastChanged
+		if reflect.DeepEqual(store.Status.Sync, sync) {
+			return nil
+		}
+	}
+	sync.LastChanged = time.Now().UTC().Format(time.RFC3339)
+	store.Status.Sync = sync
+	if _, err := c.context.RookClientset.CephV1().CephObjectStores(c.namespace).UpdateStatus(store); err != nil {
+		return fmt.Errorf("failed to update status. %+v", err)
+	}
+	return nil
+}
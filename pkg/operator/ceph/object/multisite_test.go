@@ -0,0 +1,174 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// adminArgs returns the args of the radosgw-admin command without the realm and cluster args
+func adminArgs(args []string) string {
+	var result []string
+	for _, arg := range args {
+		if arg == "" || strings.HasPrefix(arg, "--rgw-realm") || strings.HasPrefix(arg, "--rgw-zonegroup") || strings.HasPrefix(arg, "--cluster") ||
+			strings.HasPrefix(arg, "--conf") || strings.HasPrefix(arg, "--keyring") {
+			continue
+		}
+		result = append(result, arg)
+	}
+	return strings.Join(result, " ")
+}
+
+func TestJoinRealm(t *testing.T) {
+	zoneExists := false
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			assert.Equal(t, "radosgw-admin", command)
+			cmd := adminArgs(args)
+			switch {
+			case strings.HasPrefix(cmd, "realm list"):
+				return `{"realms": []}`, nil
+			case strings.HasPrefix(cmd, "zone get"):
+				if !zoneExists {
+					return "", fmt.Errorf("zone not found")
+				}
+				return `{"id":"zone-b"}`, nil
+			case strings.HasPrefix(cmd, "zonegroup get"):
+				return `{"id":"us","master_zone":"zone-a"}`, nil
+			}
+			commands = append(commands, cmd)
+			return "", nil
+		},
+	}
+	clientset := testop.New(1)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "realm-keys", Namespace: "ns"},
+		Data:       map[string][]byte{"AccessKey": []byte("access"), "SecretKey": []byte("secret")},
+	}
+	_, err := clientset.CoreV1().Secrets("ns").Create(secret)
+	assert.Nil(t, err)
+
+	store := &cephv1.CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "us-west", Namespace: "ns"},
+		Spec: cephv1.ObjectStoreSpec{Zone: cephv1.ZoneSpec{
+			Realm:                "geo",
+			ZoneGroup:            "us",
+			PullEndpoint:         "http://10.0.0.1:80",
+			SystemUserSecretName: "realm-keys",
+		}},
+	}
+	objContext := NewStoreContext(context, store)
+	assert.Equal(t, "geo", objContext.Realm)
+	assert.Equal(t, "us", objContext.ZoneGroup)
+
+	// the realm is pulled and the zone is created as a secondary zone
+	err = createRealm(objContext, store.Spec.Zone, "10.0.0.2:80")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"realm pull --url=http://10.0.0.1:80 --default --access-key=access --secret=secret",
+		"period pull --url=http://10.0.0.1:80 --access-key=access --secret=secret",
+		"zone create --rgw-zone=us-west --endpoints=10.0.0.2:80 --default --access-key=access --secret=secret",
+		"period update --commit",
+	}, commands)
+
+	// nothing is done once the zone exists
+	commands = nil
+	zoneExists = true
+	err = createRealm(objContext, store.Spec.Zone, "10.0.0.2:80")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(commands))
+
+	// promote the zone to master
+	store.Spec.Zone.Master = true
+	err = createRealm(objContext, store.Spec.Zone, "10.0.0.2:80")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"zone modify --rgw-zone=us-west --master --default",
+		"period update --commit",
+	}, commands)
+
+	// the keys are required to pull the realm
+	zoneExists = false
+	store.Spec.Zone.SystemUserSecretName = "missing"
+	err = createRealm(objContext, store.Spec.Zone, "10.0.0.2:80")
+	assert.NotNil(t, err)
+}
+
+func TestParseSyncStatus(t *testing.T) {
+	output := `          realm 1e7d5e0d-a1b5-4bd5-9c4b-6b5bfa2e4d42 (geo)
+      zonegroup 0d9a0b3c-2bd3-45f1-8e8b-4fe1a3e0c1a6 (us)
+           zone 9c1c4a8f-5f3a-4a0e-b7a1-e2c0f1e8d7b3 (us-west)
+  metadata sync syncing
+                full sync: 0/64 shards
+                incremental sync: 64/64 shards
+                metadata is caught up with master
+      data sync source: 4b8d1a5e-6a4f-4c1b-9f2d-3e5a7c9b1d0f (us-east)
+                        syncing
+                        full sync: 0/128 shards
+                        incremental sync: 128/128 shards
+                        data is behind on 2 shards
+`
+	status := parseSyncStatus(output)
+	assert.Equal(t, "caught up with master", status.Metadata)
+	assert.Equal(t, []cephv1.ZoneSyncSource{{Zone: "us-east", Status: "behind on 2 shards"}}, status.Data)
+
+	status = parseSyncStatus("  metadata sync no sync (zone is master)\n")
+	assert.Equal(t, "no sync (zone is master)", status.Metadata)
+	assert.Equal(t, 0, len(status.Data))
+}
+
+func TestUpdateSyncStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			assert.Equal(t, "sync status --rgw-zone=us-west", adminArgs(args))
+			return "metadata is caught up with master\n", nil
+		},
+	}
+	multisite := &cephv1.CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "us-west", Namespace: "ns"},
+		Spec:       cephv1.ObjectStoreSpec{Zone: cephv1.ZoneSpec{Realm: "geo"}},
+	}
+	single := &cephv1.CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "single", Namespace: "ns"},
+		Status:     cephv1.CephObjectStoreStatus{Sync: &cephv1.ZoneSyncStatus{Metadata: "stale"}},
+	}
+	context := &clusterd.Context{Executor: executor, RookClientset: rookfake.NewSimpleClientset(multisite, single)}
+
+	newSyncChecker(context, "ns").checkStores()
+	store, err := context.RookClientset.CephV1().CephObjectStores("ns").Get("us-west", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "caught up with master", store.Status.Sync.Metadata)
+	assert.NotEqual(t, "", store.Status.Sync.LastChanged)
+
+	// the sync status of a store that is not multisite is cleared
+	store, err = context.RookClientset.CephV1().CephObjectStores("ns").Get("single", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, store.Status.Sync)
+}
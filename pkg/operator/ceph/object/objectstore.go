@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/daemon/ceph/model"
 )
@@ -53,46 +54,93 @@ type realmType struct {
 	Realms []string `json:"realms"`
 }
 
-func createObjectStore(context *Context, metadataSpec, dataSpec model.Pool, serviceIP string, port int32) error {
+func createObjectStore(context *Context, metadataSpec, dataSpec model.Pool, zone cephv1.ZoneSpec, endpoint string) error {
 	err := createPools(context, metadataSpec, dataSpec)
 	if err != nil {
 		return fmt.Errorf("failed to create object pools. %+v", err)
 	}
 
-	err = createRealm(context, serviceIP, port)
+	err = createRealm(context, zone, endpoint)
 	if err != nil {
 		return fmt.Errorf("failed to create object store realm. %+v", err)
 	}
 	return nil
 }
 
-func deleteRealmAndPools(context *Context) error {
+func deleteRealmAndPools(context *Context, spec cephv1.ObjectStoreSpec) error {
 	stores, err := getObjectStores(context)
 	if err != nil {
 		return fmt.Errorf("failed to detect object stores during deletion. %+v", err)
 	}
 	logger.Infof("Found stores %v when deleting store %s", stores, context.Name)
 
-	err = deleteRealm(context)
+	// the realm is kept if other zones joined it, whether or not it is named after the object store.
+	// If the zones can't be checked, the realm is kept since other sites may still use it.
+	sharedRealm, err := realmHasOtherZones(context)
 	if err != nil {
-		return fmt.Errorf("failed to delete realm. %+v", err)
+		logger.Warningf("failed to check for other zones in realm %s, only removing zone %s. %+v", context.Realm, context.Name, err)
+		sharedRealm = true
 	}
 
 	lastStore := false
-	if len(stores) == 1 && stores[0] == context.Name {
-		lastStore = true
+	if sharedRealm {
+		// the realm is shared with other zones, only the zone of this object store is removed
+		removeZone(context)
+	} else {
+		err = deleteRealm(context)
+		if err != nil {
+			return fmt.Errorf("failed to delete realm. %+v", err)
+		}
+		if len(stores) == 1 && stores[0] == context.Realm {
+			lastStore = true
+		}
 	}
 
-	err = deletePools(context, lastStore)
+	err = deletePools(context, spec, lastStore)
 	if err != nil {
 		return fmt.Errorf("failed to delete object store pools. %+v", err)
 	}
 	return nil
 }
 
-func createRealm(context *Context, serviceIP string, port int32) error {
+// createRealm creates the realm, zonegroup and zone of the object store. If a pull endpoint is set,
+// the realm is pulled from the master zone instead and the zone joins it as a secondary zone.
+func createRealm(context *Context, zone cephv1.ZoneSpec, endpoint string) error {
+	var user *systemUser
+	if zone.SystemUserSecretName != "" {
+		var err error
+		user, err = getSystemUser(context, zone.SystemUserSecretName)
+		if err != nil {
+			return err
+		}
+	}
+
+	if zone.PullEndpoint != "" {
+		if err := joinRealm(context, zone.PullEndpoint, endpoint, user); err != nil {
+			return fmt.Errorf("failed to join realm %s. %+v", context.Realm, err)
+		}
+	} else {
+		if err := createMasterZone(context, endpoint); err != nil {
+			return err
+		}
+		if user != nil {
+			if err := configureSystemUser(context, user); err != nil {
+				return fmt.Errorf("failed to configure the system user of realm %s. %+v", context.Realm, err)
+			}
+		}
+	}
+
+	if zone.Master {
+		if err := promoteZone(context); err != nil {
+			return fmt.Errorf("failed to promote zone %s to master. %+v", context.Name, err)
+		}
+	}
+	return nil
+}
+
+func createMasterZone(context *Context, endpoint string) error {
 	zoneArg := fmt.Sprintf("--rgw-zone=%s", context.Name)
-	endpointArg := fmt.Sprintf("--endpoints=%s:%d", serviceIP, port)
+	endpointArg := fmt.Sprintf("--endpoints=%s", endpoint)
 	updatePeriod := false
 
 	// The first realm must be marked as the default
@@ -105,13 +153,16 @@ func createRealm(context *Context, serviceIP string, port int32) error {
 		defaultArg = "--default"
 	}
 
-	// create the realm if it doesn't exist yet
+	// create the realm if it doesn't exist yet. The zonegroup and zone are only the masters if they
+	// are the first in the realm.
+	masterArg := ""
 	output, err := runAdminCommand(context, "realm", "get")
 	if err != nil {
 		updatePeriod = true
+		masterArg = "--master"
 		output, err = runAdminCommand(context, "realm", "create", defaultArg)
 		if err != nil {
-			return fmt.Errorf("failed to create rgw realm %s. %+v", context.Name, err)
+			return fmt.Errorf("failed to create rgw realm %s. %+v", context.Realm, err)
 		}
 	}
 
@@ -124,10 +175,11 @@ func createRealm(context *Context, serviceIP string, port int32) error {
 	output, err = runAdminCommand(context, "zonegroup", "get")
 	if err != nil {
 		updatePeriod = true
-		output, err = runAdminCommand(context, "zonegroup", "create", "--master", endpointArg, defaultArg)
+		output, err = runAdminCommand(context, "zonegroup", "create", masterArg, endpointArg, defaultArg)
 		if err != nil {
-			return fmt.Errorf("failed to create rgw zonegroup for %s. %+v", context.Name, err)
+			return fmt.Errorf("failed to create rgw zonegroup %s. %+v", context.ZoneGroup, err)
 		}
+		masterArg = "--master"
 	}
 
 	zoneGroupID, err := decodeID(output)
@@ -139,9 +191,9 @@ func createRealm(context *Context, serviceIP string, port int32) error {
 	output, err = runAdminCommand(context, "zone", "get", zoneArg)
 	if err != nil {
 		updatePeriod = true
-		output, err = runAdminCommand(context, "zone", "create", "--master", endpointArg, zoneArg, defaultArg)
+		output, err = runAdminCommand(context, "zone", "create", masterArg, endpointArg, zoneArg, defaultArg)
 		if err != nil {
-			return fmt.Errorf("failed to create rgw zonegroup for %s. %+v", context.Name, err)
+			return fmt.Errorf("failed to create rgw zone for %s. %+v", context.Name, err)
 		}
 	}
 	zoneID, err := decodeID(output)
@@ -151,9 +203,8 @@ func createRealm(context *Context, serviceIP string, port int32) error {
 
 	if updatePeriod {
 		// the period will help notify other zones of changes if there are multi-zones
-		_, err := runAdminCommandNoRealm(context, "period", "update", "--commit")
-		if err != nil {
-			return fmt.Errorf("failed to update period. %+v", err)
+		if err := commitPeriod(context); err != nil {
+			return err
 		}
 	}
 
@@ -162,15 +213,14 @@ func createRealm(context *Context, serviceIP string, port int32) error {
 }
 
 func deleteRealm(context *Context) error {
-	//  <name>
-	_, err := runAdminCommand(context, "realm", "delete", "--rgw-realm", context.Name)
+	_, err := runAdminCommand(context, "realm", "delete")
 	if err != nil {
-		logger.Warningf("failed to delete rgw realm %s. %+v", context.Name, err)
+		logger.Warningf("failed to delete rgw realm %s. %+v", context.Realm, err)
 	}
 
-	_, err = runAdminCommand(context, "zonegroup", "delete", "--rgw-zonegroup", context.Name)
+	_, err = runAdminCommand(context, "zonegroup", "delete")
 	if err != nil {
-		logger.Warningf("failed to delete rgw zonegroup %s. %+v", context.Name, err)
+		logger.Warningf("failed to delete rgw zonegroup %s. %+v", context.ZoneGroup, err)
 	}
 
 	_, err = runAdminCommand(context, "zone", "delete", "--rgw-zone", context.Name)
@@ -209,15 +259,24 @@ func getObjectStores(context *Context) ([]string, error) {
 	return r.Realms, nil
 }
 
-func deletePools(context *Context, lastStore bool) error {
+func deletePools(context *Context, spec cephv1.ObjectStoreSpec, lastStore bool) error {
 	pools := append(metadataPools, dataPools...)
 	if lastStore {
 		pools = append(pools, rootPool)
 	}
 
+	// the crush rules of the pools on a device class are named after the class in the spec
+	deviceClasses := map[string]string{}
+	for _, pool := range dataPools {
+		deviceClasses[pool] = spec.DataPool.DeviceClass
+	}
 	for _, pool := range pools {
 		name := poolName(context.Name, pool)
-		if err := ceph.DeletePool(context.context, context.ClusterName, name); err != nil {
+		deviceClass, ok := deviceClasses[pool]
+		if !ok {
+			deviceClass = spec.MetadataPool.DeviceClass
+		}
+		if err := ceph.DeletePool(context.context, context.ClusterName, name, deviceClass); err != nil {
 			logger.Warningf("failed to delete pool %s. %+v", name, err)
 		}
 	}
@@ -260,7 +319,7 @@ func createSimilarPools(context *Context, pools []string, poolSpec model.Pool) e
 	if isECPool {
 		// create a new erasure code profile for the new pool
 		if err := ceph.CreateErasureCodeProfile(context.context, context.ClusterName, poolSpec.ErasureCodedConfig, cephConfig.ErasureCodeProfile,
-			poolSpec.FailureDomain, poolSpec.CrushRoot); err != nil {
+			poolSpec.FailureDomain, poolSpec.CrushRoot, poolSpec.DeviceClass); err != nil {
 			return fmt.Errorf("failed to create erasure code profile for object store %s: %+v", context.Name, err)
 		}
 	}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"testing"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
@@ -57,26 +58,33 @@ func TestCreateRealm(t *testing.T) {
 	context := &clusterd.Context{Executor: executor}
 	objContext := NewContext(context, storeName, "mycluster")
 	// create the first realm, marked as default
-	err := createRealm(objContext, "1.2.3.4", 80)
+	err := createRealm(objContext, cephv1.ZoneSpec{}, "1.2.3.4:80")
 	assert.Nil(t, err)
 
 	// create the second realm, not marked as default
 	defaultStore = false
-	err = createRealm(objContext, "2.3.4.5", 80)
+	err = createRealm(objContext, cephv1.ZoneSpec{}, "2.3.4.5:80")
 	assert.Nil(t, err)
 }
 
 func TestDeleteStore(t *testing.T) {
-	deleteStore(t, "myobj", `"mystore","myobj"`, false)
-	deleteStore(t, "myobj", `"myobj"`, true)
+	deleteStore(t, "myobj", `"mystore","myobj"`, false, false, false)
+	deleteStore(t, "myobj", `"myobj"`, true, false, false)
+
+	// the realm is kept when other zones joined it
+	deleteStore(t, "myobj", `"myobj"`, false, true, false)
+
+	// the realm is kept when the zones of the realm can't be checked
+	deleteStore(t, "myobj", `"myobj"`, false, false, true)
 }
 
-func deleteStore(t *testing.T, name string, existingStores string, expectedDeleteRootPool bool) {
+func deleteStore(t *testing.T, name string, existingStores string, expectedDeleteRootPool, otherZones, periodFails bool) {
+	sharedRealm := otherZones || periodFails
 	realmDeleted := false
 	zoneDeleted := false
 	zoneGroupDeleted := false
 	poolsDeleted := 0
-	rulesDeleted := 0
+	var rulesDeleted []string
 	executor := &exectest.MockExecutor{}
 	deletedRootPool := false
 	deletedErasureCodeProfile := false
@@ -98,7 +106,7 @@ func deleteStore(t *testing.T, name string, existingStores string, expectedDelet
 			if args[1] == "crush" {
 				assert.Equal(t, "rule", args[2])
 				assert.Equal(t, "rm", args[3])
-				rulesDeleted++
+				rulesDeleted = append(rulesDeleted, args[4])
 				return "", nil
 			}
 			if args[1] == "erasure-code-profile" {
@@ -128,7 +136,25 @@ func deleteStore(t *testing.T, name string, existingStores string, expectedDelet
 				return fmt.Sprintf(`{"realms":[%s]}`, existingStores), nil
 			}
 		}
+		if args[0] == "period" {
+			if args[1] == "get" {
+				if periodFails {
+					return "", fmt.Errorf("failed to get period")
+				}
+				zones := `{"name":"myobj"}`
+				if otherZones {
+					zones += `,{"name":"otherzone"}`
+				}
+				return fmt.Sprintf(`{"period_map":{"zonegroups":[{"name":"myobj","zones":[%s]}]}}`, zones), nil
+			}
+			assert.Equal(t, "update", args[1])
+			return "", nil
+		}
 		if args[0] == "zonegroup" {
+			if args[1] == "remove" {
+				assert.True(t, sharedRealm)
+				return "", nil
+			}
 			assert.Equal(t, "delete", args[1])
 			zoneGroupDeleted = true
 			return "", nil
@@ -142,19 +168,23 @@ func deleteStore(t *testing.T, name string, existingStores string, expectedDelet
 	}
 	executor.MockExecuteCommandWithOutput = executorFunc
 	executor.MockExecuteCommandWithCombinedOutput = executorFunc
-	context := &Context{context: &clusterd.Context{Executor: executor}, Name: "myobj", ClusterName: "ns"}
+	context := NewContext(&clusterd.Context{Executor: executor}, name, "ns")
 
 	// Delete an object store
-	err := deleteRealmAndPools(context)
+	// the data pool is on a device class
+	spec := cephv1.ObjectStoreSpec{DataPool: cephv1.PoolSpec{DeviceClass: "ssd"}}
+	err := deleteRealmAndPools(context, spec)
 	assert.Nil(t, err)
 	expectedPoolsDeleted := 5
 	if expectedDeleteRootPool {
 		expectedPoolsDeleted++
 	}
 	assert.Equal(t, expectedPoolsDeleted, poolsDeleted)
-	assert.Equal(t, expectedPoolsDeleted, rulesDeleted)
-	assert.True(t, realmDeleted)
-	assert.True(t, zoneGroupDeleted)
+	// the rules of the pools and the rule of the device class of the data pool are deleted
+	assert.Equal(t, expectedPoolsDeleted+1, len(rulesDeleted))
+	assert.Contains(t, rulesDeleted, name+".rgw.buckets.data_ssd")
+	assert.Equal(t, !sharedRealm, realmDeleted)
+	assert.Equal(t, !sharedRealm, zoneGroupDeleted)
 	assert.True(t, zoneDeleted)
 	assert.Equal(t, expectedDeleteRootPool, deletedRootPool)
 	assert.Equal(t, true, deletedErasureCodeProfile)
@@ -25,6 +25,7 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -53,7 +54,7 @@ func (c *clusterConfig) updateStore() error {
 
 func (c *clusterConfig) createOrUpdate(update bool) error {
 	// validate the object store settings
-	if err := validateStore(c.context, c.store); err != nil {
+	if err := validateStore(c.context, c.clusterInfo.CephVersion, &c.store); err != nil {
 		return fmt.Errorf("invalid object store %s arguments. %+v", c.store.Name, err)
 	}
 
@@ -76,8 +77,12 @@ func (c *clusterConfig) createOrUpdate(update bool) error {
 	}
 
 	// create the ceph artifacts for the object store
-	objContext := NewContext(c.context, c.store.Name, c.store.Namespace)
-	err = createObjectStore(objContext, *c.store.Spec.MetadataPool.ToModel(""), *c.store.Spec.DataPool.ToModel(""), serviceIP, c.store.Spec.Gateway.Port)
+	objContext := NewStoreContext(c.context, &c.store)
+	endpoint := c.store.Spec.Zone.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("%s:%d", serviceIP, c.store.Spec.Gateway.Port)
+	}
+	err = createObjectStore(objContext, *c.store.Spec.MetadataPool.ToModel(""), *c.store.Spec.DataPool.ToModel(""), c.store.Spec.Zone, endpoint)
 	if err != nil {
 		return fmt.Errorf("failed to create pools. %+v", err)
 	}
@@ -187,8 +192,8 @@ func (c *clusterConfig) deleteStore() error {
 	}
 
 	// Delete the realm and pools
-	objContext := NewContext(c.context, c.store.Name, c.store.Namespace)
-	err = deleteRealmAndPools(objContext)
+	objContext := NewStoreContext(c.context, &c.store)
+	err = deleteRealmAndPools(objContext, c.store.Spec)
 	if err != nil {
 		return fmt.Errorf("failed to delete the realm and pools. %+v", err)
 	}
@@ -226,19 +231,27 @@ func (c *clusterConfig) instanceName() string {
 }
 
 // Validate the object store arguments
-func validateStore(context *clusterd.Context, s cephv1.CephObjectStore) error {
+func validateStore(context *clusterd.Context, cephVersion cephver.CephVersion, s *cephv1.CephObjectStore) error {
 	if s.Name == "" {
 		return fmt.Errorf("missing name")
 	}
 	if s.Namespace == "" {
 		return fmt.Errorf("missing namespace")
 	}
-	if err := pool.ValidatePoolSpec(context, s.Namespace, &s.Spec.MetadataPool); err != nil {
+	if err := pool.ValidatePoolSpec(context, s.Namespace, cephVersion, &s.Spec.MetadataPool); err != nil {
 		return fmt.Errorf("invalid metadata pool spec. %+v", err)
 	}
-	if err := pool.ValidatePoolSpec(context, s.Namespace, &s.Spec.DataPool); err != nil {
+	if err := pool.ValidatePoolSpec(context, s.Namespace, cephVersion, &s.Spec.DataPool); err != nil {
 		return fmt.Errorf("invalid data pool spec. %+v", err)
 	}
+	if s.Spec.Zone.PullEndpoint != "" {
+		if s.Spec.Zone.Realm == "" {
+			return fmt.Errorf("the realm must be set to pull it from %s", s.Spec.Zone.PullEndpoint)
+		}
+		if s.Spec.Zone.SystemUserSecretName == "" {
+			return fmt.Errorf("the system user secret must be set to pull the realm %s", s.Spec.Zone.Realm)
+		}
+	}
 
 	return nil
 }
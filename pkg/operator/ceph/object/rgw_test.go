@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	testop "github.com/rook/rook/pkg/operator/test"
@@ -46,7 +47,7 @@ func TestStartRGW(t *testing.T) {
 	configDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(configDir)
 	info := testop.CreateConfigDir(1)
-	context := &clusterd.Context{Clientset: clientset, Executor: executor, ConfigDir: configDir}
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(), Executor: executor, ConfigDir: configDir}
 	store := simpleStore()
 	version := "v1.1.0"
 	data := cephconfig.NewStatelessDaemonDataPathMap(cephconfig.RgwType, "my-fs", "rook-ceph", "/var/lib/rook/")
@@ -111,7 +112,7 @@ func TestCreateObjectStore(t *testing.T) {
 
 	store := simpleStore()
 	clientset := testop.New(3)
-	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookfake.NewSimpleClientset()}
 	info := testop.CreateConfigDir(1)
 	data := cephconfig.NewStatelessDaemonDataPathMap(cephconfig.RgwType, "my-fs", "rook-ceph", "/var/lib/rook/")
 
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	cephtest "github.com/rook/rook/pkg/operator/ceph/test"
@@ -103,34 +104,34 @@ func TestSSLPodSpec(t *testing.T) {
 }
 
 func TestValidateSpec(t *testing.T) {
-	context := &clusterd.Context{Executor: &exectest.MockExecutor{}}
+	context := &clusterd.Context{Executor: &exectest.MockExecutor{}, RookClientset: rookfake.NewSimpleClientset()}
 
 	// valid store
 	s := simpleStore()
-	err := validateStore(context, s)
+	err := validateStore(context, cephver.Nautilus, &s)
 	assert.Nil(t, err)
 
 	// no name
 	s.Name = ""
-	err = validateStore(context, s)
+	err = validateStore(context, cephver.Nautilus, &s)
 	assert.NotNil(t, err)
 	s.Name = "default"
-	err = validateStore(context, s)
+	err = validateStore(context, cephver.Nautilus, &s)
 	assert.Nil(t, err)
 
 	// no namespace
 	s.Namespace = ""
-	err = validateStore(context, s)
+	err = validateStore(context, cephver.Nautilus, &s)
 	assert.NotNil(t, err)
 	s.Namespace = "mycluster"
-	err = validateStore(context, s)
+	err = validateStore(context, cephver.Nautilus, &s)
 	assert.Nil(t, err)
 
 	// no replication or EC
 	s.Spec.MetadataPool.Replicated.Size = 0
-	err = validateStore(context, s)
+	err = validateStore(context, cephver.Nautilus, &s)
 	assert.NotNil(t, err)
 	s.Spec.MetadataPool.Replicated.Size = 1
-	err = validateStore(context, s)
+	err = validateStore(context, cephver.Nautilus, &s)
 	assert.Nil(t, err)
 }
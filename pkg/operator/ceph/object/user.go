@@ -19,7 +19,8 @@ package object
 import (
 	"encoding/json"
 	"fmt"
-
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -29,15 +30,31 @@ const (
 	RGWErrorNotFound
 	RGWErrorBadData
 	RGWErrorParse
+	RGWErrorAlreadyExists
 )
 
-// An ObjectUser defines the details of an object store user.
+// An ObjectUser defines the details of an object store user. The settings that are nil are left
+// unchanged by UpdateUser.
 type ObjectUser struct {
-	UserID      string  `json:"userId"`
-	DisplayName *string `json:"displayName"`
-	Email       *string `json:"email"`
-	AccessKey   *string `json:"accessKey"`
-	SecretKey   *string `json:"secretKey"`
+	UserID      string            `json:"userId"`
+	DisplayName *string           `json:"displayName"`
+	Email       *string           `json:"email"`
+	AccessKey   *string           `json:"accessKey"`
+	SecretKey   *string           `json:"secretKey"`
+	MaxBuckets  *int              `json:"maxBuckets"`
+	UserQuota   *ObjectUserQuota  `json:"userQuota"`
+	BucketQuota *ObjectUserQuota  `json:"bucketQuota"`
+	Suspended   *bool             `json:"suspended"`
+	Caps        map[string]string `json:"caps"`
+	Subusers    map[string]string `json:"subusers"`
+	// RotateKeys regenerates the S3 keys of the user when updating it
+	RotateKeys bool `json:"-"`
+}
+
+// An ObjectUserQuota defines a quota of an object store user. A value of zero means no limit.
+type ObjectUserQuota struct {
+	MaxBytes   int64 `json:"maxBytes"`
+	MaxObjects int64 `json:"maxObjects"`
 }
 
 // ListUsers lists the object pool users.
@@ -59,10 +76,48 @@ type rgwUserInfo struct {
 	UserID      string `json:"user_id"`
 	DisplayName string `json:"display_name"`
 	Email       string `json:"email"`
+	Suspended   int    `json:"suspended"`
+	MaxBuckets  int    `json:"max_buckets"`
 	Keys        []struct {
 		AccessKey string `json:"access_key"`
 		SecretKey string `json:"secret_key"`
 	}
+	Subusers []struct {
+		ID          string `json:"id"`
+		Permissions string `json:"permissions"`
+	} `json:"subusers"`
+	Caps []struct {
+		Type string `json:"type"`
+		Perm string `json:"perm"`
+	} `json:"caps"`
+	UserQuota   rgwQuota `json:"user_quota"`
+	BucketQuota rgwQuota `json:"bucket_quota"`
+}
+
+type rgwQuota struct {
+	Enabled    bool  `json:"enabled"`
+	MaxSize    int64 `json:"max_size"`
+	MaxObjects int64 `json:"max_objects"`
+}
+
+// toQuota converts the quota reported by rgw, where a negative value means no limit
+func (q rgwQuota) toQuota() *ObjectUserQuota {
+	quota := &ObjectUserQuota{}
+	if q.Enabled && q.MaxSize > 0 {
+		quota.MaxBytes = q.MaxSize
+	}
+	if q.Enabled && q.MaxObjects > 0 {
+		quota.MaxObjects = q.MaxObjects
+	}
+	return quota
+}
+
+// the permissions of subusers as reported by rgw and the matching --access values
+var subuserAccess = map[string]string{
+	"read":         "read",
+	"write":        "write",
+	"read-write":   "readwrite",
+	"full-control": "full",
 }
 
 func decodeUser(data string) (*ObjectUser, int, error) {
@@ -72,12 +127,35 @@ func decodeUser(data string) (*ObjectUser, int, error) {
 		return nil, RGWErrorParse, fmt.Errorf("Failed to unmarshal json: %+v", err)
 	}
 
-	rookUser := ObjectUser{UserID: user.UserID, DisplayName: &user.DisplayName, Email: &user.Email}
+	suspended := user.Suspended != 0
+	rookUser := ObjectUser{
+		UserID:      user.UserID,
+		DisplayName: &user.DisplayName,
+		Email:       &user.Email,
+		MaxBuckets:  &user.MaxBuckets,
+		UserQuota:   user.UserQuota.toQuota(),
+		BucketQuota: user.BucketQuota.toQuota(),
+		Suspended:   &suspended,
+		Caps:        map[string]string{},
+		Subusers:    map[string]string{},
+	}
 
 	if len(user.Keys) > 0 {
 		rookUser.AccessKey = &user.Keys[0].AccessKey
 		rookUser.SecretKey = &user.Keys[0].SecretKey
 	}
+	for _, c := range user.Caps {
+		rookUser.Caps[c.Type] = c.Perm
+	}
+	for _, u := range user.Subusers {
+		// the subuser ID is <uid>:<name>
+		name := strings.TrimPrefix(u.ID, user.UserID+":")
+		access, ok := subuserAccess[u.Permissions]
+		if !ok {
+			access = u.Permissions
+		}
+		rookUser.Subusers[name] = access
+	}
 
 	return &rookUser, RGWErrorNone, nil
 }
@@ -125,7 +203,7 @@ func CreateUser(c *Context, user ObjectUser) (*ObjectUser, int, error) {
 	}
 
 	if strings.HasPrefix(result, "could not create user: unable to create user, user: ") && strings.HasSuffix(result, " exists") {
-		return nil, RGWErrorBadData, fmt.Errorf("user already exists")
+		return nil, RGWErrorAlreadyExists, fmt.Errorf("user already exists")
 	}
 
 	if strings.HasPrefix(result, "could not create user: unable to create user, email: ") && strings.HasSuffix(result, " is the email address an existing user") {
@@ -135,7 +213,8 @@ func CreateUser(c *Context, user ObjectUser) (*ObjectUser, int, error) {
 	return decodeUser(result)
 }
 
-// UpdateUser updates the user whose ID matches the user.
+// UpdateUser updates the user whose ID matches the user. Only the settings that differ from the
+// current settings of the user are changed.
 func UpdateUser(c *Context, user ObjectUser) (*ObjectUser, int, error) {
 	logger.Infof("Updating user: %s", user.UserID)
 
@@ -147,6 +226,9 @@ func UpdateUser(c *Context, user ObjectUser) (*ObjectUser, int, error) {
 	if user.Email != nil {
 		args = append(args, "--email", *user.Email)
 	}
+	if user.MaxBuckets != nil {
+		args = append(args, "--max-buckets", strconv.Itoa(*user.MaxBuckets))
+	}
 
 	body, err := runAdminCommand(c, args...)
 	if err != nil {
@@ -157,7 +239,155 @@ func UpdateUser(c *Context, user ObjectUser) (*ObjectUser, int, error) {
 		return nil, RGWErrorNotFound, fmt.Errorf("user not found")
 	}
 
-	return decodeUser(body)
+	current, rgwerr, err := decodeUser(body)
+	if err != nil {
+		return nil, rgwerr, err
+	}
+
+	if user.Suspended != nil && *user.Suspended != *current.Suspended {
+		action := "enable"
+		if *user.Suspended {
+			action = "suspend"
+		}
+		if _, err := runAdminCommand(c, "user", action, "--uid", user.UserID); err != nil {
+			return nil, RGWErrorUnknown, fmt.Errorf("failed to %s user: %+v", action, err)
+		}
+	}
+	if user.UserQuota != nil && *user.UserQuota != *current.UserQuota {
+		if err := setQuota(c, user.UserID, "user", *user.UserQuota); err != nil {
+			return nil, RGWErrorUnknown, err
+		}
+	}
+	if user.BucketQuota != nil && *user.BucketQuota != *current.BucketQuota {
+		if err := setQuota(c, user.UserID, "bucket", *user.BucketQuota); err != nil {
+			return nil, RGWErrorUnknown, err
+		}
+	}
+	if user.Caps != nil {
+		if err := setCaps(c, user.UserID, current.Caps, user.Caps); err != nil {
+			return nil, RGWErrorUnknown, err
+		}
+	}
+	if user.Subusers != nil {
+		if err := setSubusers(c, user.UserID, current.Subusers, user.Subusers); err != nil {
+			return nil, RGWErrorUnknown, err
+		}
+	}
+	if user.RotateKeys {
+		if err := rotateKeys(c, user.UserID, current.AccessKey); err != nil {
+			return nil, RGWErrorUnknown, err
+		}
+	}
+
+	return GetUser(c, user.UserID)
+}
+
+// setQuota sets and enables the quota of the given scope, or disables it if there is no limit
+func setQuota(c *Context, id, scope string, quota ObjectUserQuota) error {
+	scopeArg := fmt.Sprintf("--quota-scope=%s", scope)
+	if quota.MaxBytes == 0 && quota.MaxObjects == 0 {
+		if _, err := runAdminCommand(c, "quota", "disable", "--uid", id, scopeArg); err != nil {
+			return fmt.Errorf("failed to disable %s quota: %+v", scope, err)
+		}
+		return nil
+	}
+
+	// rgw takes a negative value as no limit
+	maxSize, maxObjects := quota.MaxBytes, quota.MaxObjects
+	if maxSize == 0 {
+		maxSize = -1
+	}
+	if maxObjects == 0 {
+		maxObjects = -1
+	}
+	_, err := runAdminCommand(c, "quota", "set", "--uid", id, scopeArg,
+		fmt.Sprintf("--max-size=%d", maxSize), fmt.Sprintf("--max-objects=%d", maxObjects))
+	if err != nil {
+		return fmt.Errorf("failed to set %s quota: %+v", scope, err)
+	}
+	if _, err := runAdminCommand(c, "quota", "enable", "--uid", id, scopeArg); err != nil {
+		return fmt.Errorf("failed to enable %s quota: %+v", scope, err)
+	}
+	return nil
+}
+
+// setCaps removes the caps that are not desired or have another permission and adds the missing caps
+func setCaps(c *Context, id string, current, desired map[string]string) error {
+	var remove, add []string
+	for capType, perm := range current {
+		if desired[capType] != perm {
+			remove = append(remove, fmt.Sprintf("%s=%s", capType, perm))
+		}
+	}
+	for capType, perm := range desired {
+		if perm != "" && current[capType] != perm {
+			add = append(add, fmt.Sprintf("%s=%s", capType, perm))
+		}
+	}
+	sort.Strings(remove)
+	sort.Strings(add)
+
+	if len(remove) > 0 {
+		if _, err := runAdminCommand(c, "caps", "rm", "--uid", id, "--caps", strings.Join(remove, ";")); err != nil {
+			return fmt.Errorf("failed to remove caps: %+v", err)
+		}
+	}
+	if len(add) > 0 {
+		if _, err := runAdminCommand(c, "caps", "add", "--uid", id, "--caps", strings.Join(add, ";")); err != nil {
+			return fmt.Errorf("failed to add caps: %+v", err)
+		}
+	}
+	return nil
+}
+
+// setSubusers creates, modifies and removes the subusers of the user to match the desired subusers
+func setSubusers(c *Context, id string, current, desired map[string]string) error {
+	var names []string
+	for name := range current {
+		names = append(names, name)
+	}
+	for name := range desired {
+		if _, ok := current[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		subuserArg := fmt.Sprintf("--subuser=%s:%s", id, name)
+		access, ok := desired[name]
+		currentAccess, exists := current[name]
+		switch {
+		case !ok:
+			if _, err := runAdminCommand(c, "subuser", "rm", "--uid", id, subuserArg, "--purge-keys"); err != nil {
+				return fmt.Errorf("failed to remove subuser %s: %+v", name, err)
+			}
+		case !exists:
+			if _, err := runAdminCommand(c, "subuser", "create", "--uid", id, subuserArg, "--access", access, "--key-type=swift", "--gen-secret"); err != nil {
+				return fmt.Errorf("failed to create subuser %s: %+v", name, err)
+			}
+		case access != currentAccess:
+			if _, err := runAdminCommand(c, "subuser", "modify", "--uid", id, subuserArg, "--access", access); err != nil {
+				return fmt.Errorf("failed to modify subuser %s: %+v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rotateKeys generates a new S3 key for the user and removes the previous key
+func rotateKeys(c *Context, id string, accessKey *string) error {
+	logger.Infof("rotating the keys of user %s", id)
+	if _, err := runAdminCommand(c, "key", "create", "--uid", id, "--key-type=s3", "--gen-access-key", "--gen-secret"); err != nil {
+		return fmt.Errorf("failed to create key: %+v", err)
+	}
+	if accessKey == nil || *accessKey == "" {
+		return nil
+	}
+	if _, err := runAdminCommand(c, "key", "rm", "--uid", id, "--key-type=s3", "--access-key", *accessKey); err != nil {
+		return fmt.Errorf("failed to remove the previous key: %+v", err)
+	}
+	return nil
 }
 
 // DeleteUser deletes the user with the given ID.
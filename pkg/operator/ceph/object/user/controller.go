@@ -30,6 +30,7 @@ import (
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 )
@@ -89,13 +90,34 @@ func (c *ObjectStoreUserController) onAdd(obj interface{}) {
 		return
 	}
 
-	if err = c.createUser(c.context, user); err != nil {
+	err = c.createUser(c.context, user)
+	if err != nil {
 		logger.Errorf("failed to create object store user %s. %+v", user.Name, err)
 	}
+	updateUserStatus(c.context, user, err)
 }
 
 func (c *ObjectStoreUserController) onUpdate(oldObj, newObj interface{}) {
-	// TODO: Add update code here after features are added which require updates.
+	oldUser, err := getObjectStoreUserObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old objectstoreuser object: %+v", err)
+		return
+	}
+	user, err := getObjectStoreUserObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new objectstoreuser object: %+v", err)
+		return
+	}
+	if reflect.DeepEqual(oldUser.Spec, user.Spec) {
+		logger.Debugf("object store user %s did not change", user.Name)
+		return
+	}
+
+	err = c.updateUser(c.context, oldUser, user)
+	if err != nil {
+		logger.Errorf("failed to update object store user %s. %+v", user.Name, err)
+	}
+	updateUserStatus(c.context, user, err)
 }
 
 func (c *ObjectStoreUserController) onDelete(obj interface{}) {
@@ -110,6 +132,30 @@ func (c *ObjectStoreUserController) onDelete(obj interface{}) {
 	}
 }
 
+// updateUserStatus sets the UserReady condition of the user from the result of createUser or updateUser
+func updateUserStatus(context *clusterd.Context, u *cephv1.CephObjectStoreUser, createErr error) {
+	latest, err := context.RookClientset.CephV1().CephObjectStoreUsers(u.Namespace).Get(u.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get object store user %s to update its status. %+v", u.Name, err)
+		return
+	}
+
+	latest.Status.ObservedGeneration = u.Generation
+	if createErr != nil {
+		latest.Status.SetCondition(cephv1.ConditionUserReady, v1.ConditionFalse, cephv1.ReasonUserFailed, createErr.Error())
+	} else {
+		latest.Status.SetCondition(cephv1.ConditionUserReady, v1.ConditionTrue, cephv1.ReasonUserCreated, fmt.Sprintf("user %s was created in object store %s", u.Name, u.Spec.Store))
+		// the keys of the user are the keys of the rotation in the spec, either because they were
+		// rotated or because the user was created with them
+		latest.Status.KeysRotation = u.Spec.RotateKeys
+	}
+	latest.Status.SetPhase(cephv1.ConditionUserReady)
+
+	if _, err := context.RookClientset.CephV1().CephObjectStoreUsers(u.Namespace).UpdateStatus(latest); err != nil {
+		logger.Warningf("failed to update status of object store user %s. %+v", u.Name, err)
+	}
+}
+
 func (c *ObjectStoreUserController) ParentClusterChanged(cluster cephv1.ClusterSpec, clusterInfo *cephconfig.ClusterInfo) {
 	logger.Debugf("No need to update object store users after the parent cluster changed")
 }
@@ -150,14 +196,104 @@ func (c *ObjectStoreUserController) createUser(context *clusterd.Context, u *cep
 		UserID:      u.Name,
 		DisplayName: &displayName,
 	}
-	objContext := object.NewContext(context, u.Spec.Store, u.Namespace)
+	objContext := object.GetStoreContext(context, u.Spec.Store, u.Namespace)
+
+	// the user already exists when the operator restarts. its settings are applied the same way as for
+	// a new user, and its keys are rotated if a rotation was requested that was not applied yet.
+	exists := false
+	_, rgwerr, err := object.CreateUser(objContext, userConfig)
+	if err != nil {
+		if rgwerr != object.RGWErrorAlreadyExists {
+			return fmt.Errorf("failed to create user %s. RadosGW returned error %d: %+v", u.Name, rgwerr, err)
+		}
+		logger.Infof("user %s already exists", u.Name)
+		exists = true
+	}
+
+	// apply the quotas, caps and other settings of the user
+	userConfig = getUserConfig(u)
+	userConfig.RotateKeys = exists && keysRotationRequested(u)
+	user, rgwerr, err := object.UpdateUser(objContext, userConfig)
+	if err != nil {
+		return fmt.Errorf("failed to configure user %s. RadosGW returned error %d: %+v", u.Name, rgwerr, err)
+	}
+
+	if err := c.saveUserSecret(context, u, user); err != nil {
+		return err
+	}
+	logger.Infof("created user %s", u.Name)
+	return nil
+}
+
+// Update the settings of the user, and regenerate its keys if a key rotation was requested that was not
+// applied yet
+func (c *ObjectStoreUserController) updateUser(context *clusterd.Context, old, u *cephv1.CephObjectStoreUser) error {
+	if err := ValidateUser(context, u); err != nil {
+		return fmt.Errorf("invalid user %s arguments. %+v", u.Name, err)
+	}
+	if old.Spec.Store != u.Spec.Store {
+		return fmt.Errorf("the store of user %s can't be changed from %s to %s", u.Name, old.Spec.Store, u.Spec.Store)
+	}
 
-	user, rgwerr, err := object.CreateUser(objContext, userConfig)
+	logger.Infof("updating user %s in namespace %s", u.Name, u.Namespace)
+	userConfig := getUserConfig(u)
+	userConfig.RotateKeys = keysRotationRequested(u)
+	objContext := object.GetStoreContext(context, u.Spec.Store, u.Namespace)
+
+	user, rgwerr, err := object.UpdateUser(objContext, userConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create user %s. RadosGW returned error %d: %+v", u.Name, rgwerr, err)
+		return fmt.Errorf("failed to update user %s. RadosGW returned error %d: %+v", u.Name, rgwerr, err)
+	}
+
+	if err := c.saveUserSecret(context, u, user); err != nil {
+		return err
+	}
+	logger.Infof("updated user %s", u.Name)
+	return nil
+}
+
+// keysRotationRequested returns whether the spec requests a rotation of the keys that was not applied
+// yet. The rotation that was applied last is recorded in the status.
+func keysRotationRequested(u *cephv1.CephObjectStoreUser) bool {
+	return u.Spec.RotateKeys != "" && u.Spec.RotateKeys != u.Status.KeysRotation
+}
+
+// getUserConfig returns the settings of the user in the spec
+func getUserConfig(u *cephv1.CephObjectStoreUser) object.ObjectUser {
+	displayName := u.Spec.DisplayName
+	if len(displayName) == 0 {
+		displayName = u.Name
+	}
+	suspended := u.Spec.Suspended
+	caps := map[string]string{
+		"users":    u.Spec.Capabilities.Users,
+		"buckets":  u.Spec.Capabilities.Buckets,
+		"metadata": u.Spec.Capabilities.Metadata,
+		"usage":    u.Spec.Capabilities.Usage,
+		"zone":     u.Spec.Capabilities.Zone,
+	}
+	subusers := map[string]string{}
+	for _, subuser := range u.Spec.Subusers {
+		subusers[subuser.Name] = subuser.Access
+	}
+
+	return object.ObjectUser{
+		UserID:      u.Name,
+		DisplayName: &displayName,
+		MaxBuckets:  u.Spec.MaxBuckets,
+		UserQuota:   &object.ObjectUserQuota{MaxBytes: u.Spec.UserQuota.MaxBytes, MaxObjects: u.Spec.UserQuota.MaxObjects},
+		BucketQuota: &object.ObjectUserQuota{MaxBytes: u.Spec.BucketQuota.MaxBytes, MaxObjects: u.Spec.BucketQuota.MaxObjects},
+		Suspended:   &suspended,
+		Caps:        caps,
+		Subusers:    subusers,
 	}
+}
 
-	// Store the keys in a secret
+// Store the keys of the user in a secret
+func (c *ObjectStoreUserController) saveUserSecret(context *clusterd.Context, u *cephv1.CephObjectStoreUser, user *object.ObjectUser) error {
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return fmt.Errorf("user %s has no keys", u.Name)
+	}
 	secrets := map[string]string{
 		"AccessKey": *user.AccessKey,
 		"SecretKey": *user.SecretKey,
@@ -178,17 +314,21 @@ func (c *ObjectStoreUserController) createUser(context *clusterd.Context, u *cep
 	}
 	k8sutil.SetOwnerRef(context.Clientset, u.Namespace, &secret.ObjectMeta, &c.ownerRef)
 
-	_, err = context.Clientset.CoreV1().Secrets(u.Namespace).Create(secret)
+	_, err := context.Clientset.CoreV1().Secrets(u.Namespace).Create(secret)
 	if err != nil {
-		return fmt.Errorf("failed to save user %s secret. %+v", u.Name, err)
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to save user %s secret. %+v", u.Name, err)
+		}
+		if _, err := context.Clientset.CoreV1().Secrets(u.Namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update user %s secret. %+v", u.Name, err)
+		}
 	}
-	logger.Infof("created user %s", u.Name)
 	return nil
 }
 
 // Delete the user
 func deleteUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) error {
-	objContext := object.NewContext(context, u.Spec.Store, u.Namespace)
+	objContext := object.GetStoreContext(context, u.Spec.Store, u.Namespace)
 	_, rgwerr, err := object.DeleteUser(objContext, u.Name)
 	if err != nil {
 		if rgwerr == 3 {
@@ -218,5 +358,15 @@ func ValidateUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) erro
 	if u.Spec.Store == "" {
 		return fmt.Errorf("missing store")
 	}
+	for _, subuser := range u.Spec.Subusers {
+		if subuser.Name == "" {
+			return fmt.Errorf("missing subuser name")
+		}
+		switch subuser.Access {
+		case "read", "write", "readwrite", "full":
+		default:
+			return fmt.Errorf("invalid access %s of subuser %s", subuser.Access, subuser.Name)
+		}
+	}
 	return nil
 }
@@ -18,10 +18,17 @@ limitations under the License.
 package objectuser
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetObjectStoreUserObject(t *testing.T) {
@@ -35,3 +42,97 @@ func TestGetObjectStoreUserObject(t *testing.T) {
 	assert.Nil(t, objectuser)
 	assert.NotNil(t, err)
 }
+
+func TestUpdateUser(t *testing.T) {
+	accessKey := "access"
+	userExists := false
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			cmd := strings.Join(args[:2], " ")
+			commands = append(commands, cmd)
+			if cmd == "key create" {
+				accessKey = "rotated"
+			}
+			if cmd == "user create" && userExists {
+				return "could not create user: unable to create user, user: alice exists", nil
+			}
+			return fmt.Sprintf(`{"user_id":"alice","display_name":"alice","keys":[{"access_key":"%s","secret_key":"secret"}]}`, accessKey), nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(1), RookClientset: rookfake.NewSimpleClientset()}
+	c := NewObjectStoreUserController(context, "ns", metav1.OwnerReference{})
+	user := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "ns"},
+		Spec:       cephv1.ObjectStoreUserSpec{Store: "my-store", RotateKeys: "2019-01-01"},
+	}
+	err := c.createUser(context, user)
+	assert.Nil(t, err)
+	secret, err := context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-object-user-my-store-alice", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "access", secret.StringData["AccessKey"])
+
+	// the keys are not rotated when other settings change
+	updated := user.DeepCopy()
+	updated.Status.KeysRotation = "2019-01-01"
+	updated.Spec.Suspended = true
+	commands = nil
+	err = c.updateUser(context, user, updated)
+	assert.Nil(t, err)
+	assert.Contains(t, commands, "user suspend")
+	assert.NotContains(t, commands, "key create")
+
+	// the keys are rotated and the secret is updated when the trigger changes
+	rotated := updated.DeepCopy()
+	rotated.Spec.RotateKeys = "2019-04-01"
+	commands = nil
+	err = c.updateUser(context, updated, rotated)
+	assert.Nil(t, err)
+	assert.Contains(t, commands, "key create")
+	assert.Contains(t, commands, "key rm")
+	secret, err = context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-object-user-my-store-alice", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "rotated", secret.StringData["AccessKey"])
+
+	// the rotation is not repeated once it is recorded in the status
+	rotated.Status.KeysRotation = "2019-04-01"
+	commands = nil
+	err = c.updateUser(context, rotated, rotated)
+	assert.Nil(t, err)
+	assert.NotContains(t, commands, "key create")
+
+	// the user that already exists after a restart of the operator is configured, and the keys are only
+	// rotated if the rotation was not applied yet
+	userExists = true
+	commands = nil
+	err = c.createUser(context, rotated)
+	assert.Nil(t, err)
+	assert.Contains(t, commands, "user suspend")
+	assert.NotContains(t, commands, "key create")
+	pending := rotated.DeepCopy()
+	pending.Spec.RotateKeys = "2019-07-01"
+	commands = nil
+	err = c.createUser(context, pending)
+	assert.Nil(t, err)
+	assert.Contains(t, commands, "key create")
+
+	// the store can't be changed
+	moved := rotated.DeepCopy()
+	moved.Spec.Store = "other-store"
+	err = c.updateUser(context, rotated, moved)
+	assert.NotNil(t, err)
+}
+
+func TestValidateUserSubusers(t *testing.T) {
+	user := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "ns"},
+		Spec: cephv1.ObjectStoreUserSpec{
+			Store:    "my-store",
+			Subusers: []cephv1.ObjectSubuserSpec{{Name: "swift", Access: "full"}},
+		},
+	}
+	assert.Nil(t, ValidateUser(nil, user))
+
+	user.Spec.Subusers[0].Access = "admin"
+	assert.NotNil(t, ValidateUser(nil, user))
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const userInfo = `{
+	"user_id": "alice",
+	"display_name": "alice",
+	"email": "",
+	"suspended": 0,
+	"max_buckets": 1000,
+	"subusers": [{"id": "alice:swift", "permissions": "full-control"}, {"id": "alice:old", "permissions": "read"}],
+	"keys": [{"user": "alice", "access_key": "access", "secret_key": "secret"}],
+	"caps": [{"type": "users", "perm": "*"}, {"type": "buckets", "perm": "read"}],
+	"bucket_quota": {"enabled": false, "max_size": -1, "max_objects": -1},
+	"user_quota": {"enabled": true, "max_size": 1024, "max_objects": -1}
+}`
+
+func TestDecodeUser(t *testing.T) {
+	user, _, err := decodeUser(userInfo)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", user.UserID)
+	assert.Equal(t, "access", *user.AccessKey)
+	assert.Equal(t, 1000, *user.MaxBuckets)
+	assert.False(t, *user.Suspended)
+	assert.Equal(t, ObjectUserQuota{MaxBytes: 1024}, *user.UserQuota)
+	assert.Equal(t, ObjectUserQuota{}, *user.BucketQuota)
+	assert.Equal(t, map[string]string{"users": "*", "buckets": "read"}, user.Caps)
+	assert.Equal(t, map[string]string{"swift": "full", "old": "read"}, user.Subusers)
+}
+
+func TestUpdateUser(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			cmd := adminArgs(args)
+			if !strings.HasPrefix(cmd, "user info") {
+				commands = append(commands, cmd)
+			}
+			return userInfo, nil
+		},
+	}
+	objContext := NewContext(&clusterd.Context{Executor: executor}, "my-store", "ns")
+
+	// only the settings that changed are applied
+	maxBuckets := 10
+	suspended := true
+	user := ObjectUser{
+		UserID:      "alice",
+		MaxBuckets:  &maxBuckets,
+		UserQuota:   &ObjectUserQuota{MaxBytes: 1024},
+		BucketQuota: &ObjectUserQuota{MaxObjects: 100},
+		Suspended:   &suspended,
+		Caps:        map[string]string{"users": "*", "buckets": "*", "usage": "read"},
+		Subusers:    map[string]string{"swift": "full", "new": "readwrite"},
+		RotateKeys:  true,
+	}
+	result, _, err := UpdateUser(objContext, user)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", result.UserID)
+	assert.Equal(t, []string{
+		"user modify --uid alice --max-buckets 10",
+		"user suspend --uid alice",
+		"quota set --uid alice --quota-scope=bucket --max-size=-1 --max-objects=100",
+		"quota enable --uid alice --quota-scope=bucket",
+		"caps rm --uid alice --caps buckets=read",
+		"caps add --uid alice --caps buckets=*;usage=read",
+		"subuser create --uid alice --subuser=alice:new --access readwrite --key-type=swift --gen-secret",
+		"subuser rm --uid alice --subuser=alice:old --purge-keys",
+		"key create --uid alice --key-type=s3 --gen-access-key --gen-secret",
+		"key rm --uid alice --key-type=s3 --access-key access",
+	}, commands)
+
+	// the quotas are disabled when there is no limit
+	commands = nil
+	_, _, err = UpdateUser(objContext, ObjectUser{UserID: "alice", UserQuota: &ObjectUserQuota{}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"user modify --uid alice",
+		"quota disable --uid alice --quota-scope=user",
+	}, commands)
+}
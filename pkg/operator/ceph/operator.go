@@ -33,6 +33,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/ceph/file"
 	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/ceph/object/bucket"
 	"github.com/rook/rook/pkg/operator/ceph/object/user"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
 	"github.com/rook/rook/pkg/operator/ceph/provisioner"
@@ -72,7 +73,7 @@ func New(context *clusterd.Context, volumeAttachmentWrapper attachment.Attachmen
 	clusterController := cluster.NewClusterController(context, rookImage, volumeAttachmentWrapper)
 
 	schemes := []opkit.CustomResource{cluster.ClusterResource, pool.PoolResource, object.ObjectStoreResource, objectuser.ObjectStoreUserResource,
-		file.FilesystemResource, attachment.VolumeResource}
+		file.FilesystemResource, attachment.VolumeResource, objectbucket.ObjectBucketClaimResource, objectbucket.ObjectBucketResource}
 	return &Operator{
 		context:           context,
 		clusterController: clusterController,
@@ -155,6 +156,12 @@ func (o *Operator) Run() error {
 	// watch for changes to the rook clusters
 	o.clusterController.StartWatch(namespaceToWatch, stopChan)
 
+	// watch for object bucket claims in the namespaces of the applications
+	bucketController := objectbucket.NewObjectBucketClaimController(o.context, namespaceToWatch)
+	if err := bucketController.StartWatch(stopChan); err != nil {
+		return fmt.Errorf("failed to start watching object bucket claims. %+v", err)
+	}
+
 	for {
 		select {
 		case <-signalChan:
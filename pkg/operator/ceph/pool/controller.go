@@ -20,6 +20,8 @@ package pool
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 
 	"github.com/coreos/pkg/capnslog"
 	opkit "github.com/rook/operator-kit"
@@ -29,6 +31,8 @@ import (
 	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/daemon/ceph/model"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,6 +43,10 @@ const (
 	replicatedType         = "replicated"
 	erasureCodeType        = "erasure-coded"
 	poolApplicationNameRBD = "rbd"
+
+	// the pools of a stretch cluster keep two replicas in each data zone
+	stretchPoolSize    = 4
+	stretchPoolMinSize = 2
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-pool")
@@ -64,15 +72,17 @@ var PoolResourceRookLegacy = opkit.CustomResource{
 
 // PoolController represents a controller object for pool custom resources
 type PoolController struct {
-	context   *clusterd.Context
-	namespace string
+	clusterInfo *cephconfig.ClusterInfo
+	context     *clusterd.Context
+	namespace   string
 }
 
 // NewPoolController create controller for watching pool custom resources created
-func NewPoolController(context *clusterd.Context, namespace string) *PoolController {
+func NewPoolController(clusterInfo *cephconfig.ClusterInfo, context *clusterd.Context, namespace string) *PoolController {
 	return &PoolController{
-		context:   context,
-		namespace: namespace,
+		clusterInfo: clusterInfo,
+		context:     context,
+		namespace:   namespace,
 	}
 }
 
@@ -92,6 +102,10 @@ func (c *PoolController) StartWatch(stopCh chan struct{}) error {
 	// watch for events on all legacy types too
 	c.watchLegacyPools(c.namespace, stopCh, resourceHandlerFuncs)
 
+	// periodically report the health of the mirrored pools
+	checker := newMirroringChecker(c.context, c.namespace)
+	go checker.checkMirroring(stopCh)
+
 	return nil
 }
 
@@ -109,10 +123,11 @@ func (c *PoolController) onAdd(obj interface{}) {
 		return
 	}
 
-	err = createPool(c.context, pool)
+	err = createPool(c.context, c.clusterInfo.CephVersion, pool)
 	if err != nil {
 		logger.Errorf("failed to create pool %s. %+v", pool.ObjectMeta.Name, err)
 	}
+	updatePoolStatus(c.context, pool, err)
 }
 
 func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
@@ -138,8 +153,9 @@ func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
 		logger.Errorf("failed to update pool %s. name update not allowed", pool.Name)
 		return
 	}
-	if pool.Spec.ErasureCoded.CodingChunks != 0 && pool.Spec.ErasureCoded.DataChunks != 0 {
+	if pool.Spec.ErasureCode() != nil && (oldPool.Spec.ErasureCoded != pool.Spec.ErasureCoded || oldPool.Spec.DeviceClass != pool.Spec.DeviceClass) {
 		logger.Errorf("failed to update pool %s. erasurecoded update not allowed", pool.Name)
+		setPoolStatus(c.context, pool, v1.ConditionFalse, cephv1.ReasonInvalidSpec, "erasure coded settings cannot be updated")
 		return
 	}
 	if !poolChanged(oldPool.Spec, pool.Spec) {
@@ -149,12 +165,21 @@ func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
 
 	// if the pool is modified, allow the pool to be created if it wasn't already
 	logger.Infof("updating pool %s", pool.Name)
-	if err := createPool(c.context, pool); err != nil {
+	err = createPool(c.context, c.clusterInfo.CephVersion, pool)
+	if err != nil {
 		logger.Errorf("failed to create (modify) pool %s. %+v", pool.ObjectMeta.Name, err)
+	} else if oldPool.Spec.DeviceClass != pool.Spec.DeviceClass {
+		// the pool was moved to the rule of the new device class, the rule of the old class is not used anymore
+		oldRule := ceph.GetCrushRuleForPool(pool.Name, oldPool.Spec.DeviceClass)
+		if err := ceph.DeleteCrushRule(c.context, pool.Namespace, oldRule); err != nil {
+			logger.Infof("did not delete crush rule %s. %+v", oldRule, err)
+		}
 	}
+	updatePoolStatus(c.context, pool, err)
 }
 
 func (c *PoolController) ParentClusterChanged(cluster cephv1.ClusterSpec, clusterInfo *cephconfig.ClusterInfo) {
+	c.clusterInfo = clusterInfo
 	logger.Debugf("No need to update the pool after the parent cluster changed")
 }
 
@@ -163,6 +188,34 @@ func poolChanged(old, new cephv1.PoolSpec) bool {
 		logger.Infof("pool replication changed from %d to %d", old.Replicated.Size, new.Replicated.Size)
 		return true
 	}
+	if old.Replicated.MinSize != new.Replicated.MinSize {
+		logger.Infof("pool min size changed from %d to %d", old.Replicated.MinSize, new.Replicated.MinSize)
+		return true
+	}
+	if old.CrushRule != new.CrushRule {
+		logger.Infof("pool crush rule changed from %q to %q", old.CrushRule, new.CrushRule)
+		return true
+	}
+	if old.Quotas != new.Quotas {
+		logger.Infof("pool quotas changed from %+v to %+v", old.Quotas, new.Quotas)
+		return true
+	}
+	if old.Compression != new.Compression {
+		logger.Infof("pool compression changed from %+v to %+v", old.Compression, new.Compression)
+		return true
+	}
+	if old.PlacementGroups != new.PlacementGroups {
+		logger.Infof("pool placement groups changed from %+v to %+v", old.PlacementGroups, new.PlacementGroups)
+		return true
+	}
+	if old.DeviceClass != new.DeviceClass {
+		logger.Infof("pool device class changed from %q to %q", old.DeviceClass, new.DeviceClass)
+		return true
+	}
+	if !reflect.DeepEqual(old.Mirroring, new.Mirroring) {
+		logger.Infof("pool mirroring changed from %+v to %+v", old.Mirroring, new.Mirroring)
+		return true
+	}
 	return false
 }
 
@@ -184,9 +237,9 @@ func (c *PoolController) onDelete(obj interface{}) {
 }
 
 // Create the pool
-func createPool(context *clusterd.Context, p *cephv1.CephBlockPool) error {
+func createPool(context *clusterd.Context, cephVersion cephver.CephVersion, p *cephv1.CephBlockPool) error {
 	// validate the pool settings
-	if err := ValidatePool(context, p); err != nil {
+	if err := ValidatePool(context, cephVersion, p); err != nil {
 		return fmt.Errorf("invalid pool %s arguments. %+v", p.Name, err)
 	}
 
@@ -196,14 +249,111 @@ func createPool(context *clusterd.Context, p *cephv1.CephBlockPool) error {
 		return fmt.Errorf("failed to create pool %s. %+v", p.Name, err)
 	}
 
+	if err := setPoolProperties(context, p.Namespace, p.Name, p.Spec); err != nil {
+		return fmt.Errorf("failed to set properties on pool %s. %+v", p.Name, err)
+	}
+
+	if err := configureMirroring(context, p.Namespace, p.Name, p.Spec.Mirroring); err != nil {
+		return fmt.Errorf("failed to configure mirroring on pool %s. %+v", p.Name, err)
+	}
+
 	logger.Infof("created pool %s", p.Name)
 	return nil
 }
 
+// setPoolProperties applies the quotas, compression and placement group settings of the spec to
+// the pool. Settings which are not specified are left alone, except for the quotas where zero
+// means no quota.
+func setPoolProperties(context *clusterd.Context, namespace, name string, spec cephv1.PoolSpec) error {
+	if err := ceph.SetPoolQuota(context, namespace, name, spec.Quotas.MaxBytes, spec.Quotas.MaxObjects); err != nil {
+		return err
+	}
+
+	properties := map[string]string{}
+	c := spec.Compression
+	if c.Mode != "" {
+		properties["compression_mode"] = c.Mode
+	}
+	if c.Algorithm != "" {
+		properties["compression_algorithm"] = c.Algorithm
+	}
+	if c.RequiredRatio != "" {
+		properties["compression_required_ratio"] = c.RequiredRatio
+	}
+	if c.MinBlobSize != 0 {
+		properties["compression_min_blob_size"] = strconv.FormatUint(c.MinBlobSize, 10)
+	}
+	if c.MaxBlobSize != 0 {
+		properties["compression_max_blob_size"] = strconv.FormatUint(c.MaxBlobSize, 10)
+	}
+
+	pg := spec.PlacementGroups
+	if pg.AutoscaleMode != "" {
+		properties["pg_autoscale_mode"] = pg.AutoscaleMode
+	}
+	if pg.TargetSizeRatio != "" {
+		properties["target_size_ratio"] = pg.TargetSizeRatio
+	}
+	for _, prop := range sortedKeys(properties) {
+		if err := ceph.SetPoolProperty(context, namespace, name, prop, properties[prop]); err != nil {
+			return err
+		}
+	}
+
+	// pg_num must be set before pgp_num since pgp_num cannot be larger than pg_num
+	if pg.PgNum != 0 {
+		if err := ceph.SetPoolProperty(context, namespace, name, "pg_num", strconv.FormatUint(uint64(pg.PgNum), 10)); err != nil {
+			return err
+		}
+		pgpNum := pg.PgpNum
+		if pgpNum == 0 {
+			pgpNum = pg.PgNum
+		}
+		if err := ceph.SetPoolProperty(context, namespace, name, "pgp_num", strconv.FormatUint(uint64(pgpNum), 10)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updatePoolStatus sets the PoolsReady condition of the pool from the result of createPool
+func updatePoolStatus(context *clusterd.Context, p *cephv1.CephBlockPool, createErr error) {
+	if createErr != nil {
+		setPoolStatus(context, p, v1.ConditionFalse, cephv1.ReasonPoolFailed, createErr.Error())
+		return
+	}
+	setPoolStatus(context, p, v1.ConditionTrue, cephv1.ReasonPoolCreated, fmt.Sprintf("pool %s was created", p.Name))
+}
+
+func setPoolStatus(context *clusterd.Context, p *cephv1.CephBlockPool, status v1.ConditionStatus, reason cephv1.ConditionReason, message string) {
+	// get the latest version of the pool so the status update does not conflict with spec updates
+	pool, err := context.RookClientset.CephV1().CephBlockPools(p.Namespace).Get(p.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get pool %s to update its status. %+v", p.Name, err)
+		return
+	}
+	pool.Status.ObservedGeneration = p.Generation
+	pool.Status.SetCondition(cephv1.ConditionPoolsReady, status, reason, message)
+	pool.Status.SetPhase(cephv1.ConditionPoolsReady)
+	if _, err := context.RookClientset.CephV1().CephBlockPools(p.Namespace).UpdateStatus(pool); err != nil {
+		logger.Warningf("failed to update status of pool %s. %+v", p.Name, err)
+	}
+}
+
 // Delete the pool
 func deletePool(context *clusterd.Context, p *cephv1.CephBlockPool) error {
 
-	if err := ceph.DeletePool(context, p.Namespace, p.Name); err != nil {
+	if err := ceph.DeletePool(context, p.Namespace, p.Name, p.Spec.DeviceClass); err != nil {
 		return fmt.Errorf("failed to delete pool '%s'. %+v", p.Name, err)
 	}
 
@@ -229,36 +379,62 @@ func ModelToSpec(pool model.Pool) cephv1.PoolSpec {
 	return cephv1.PoolSpec{
 		FailureDomain: pool.FailureDomain,
 		CrushRoot:     pool.CrushRoot,
+		DeviceClass:   pool.DeviceClass,
 		Replicated:    cephv1.ReplicatedSpec{Size: pool.ReplicatedConfig.Size},
 		ErasureCoded:  cephv1.ErasureCodedSpec{CodingChunks: ec.CodingChunkCount, DataChunks: ec.DataChunkCount, Algorithm: ec.Algorithm},
 	}
 }
 
 // Validate the pool arguments
-func ValidatePool(context *clusterd.Context, p *cephv1.CephBlockPool) error {
+func ValidatePool(context *clusterd.Context, cephVersion cephver.CephVersion, p *cephv1.CephBlockPool) error {
 	if p.Name == "" {
 		return fmt.Errorf("missing name")
 	}
 	if p.Namespace == "" {
 		return fmt.Errorf("missing namespace")
 	}
-	if err := ValidatePoolSpec(context, p.Namespace, &p.Spec); err != nil {
+	if err := ValidatePoolSpec(context, p.Namespace, cephVersion, &p.Spec); err != nil {
 		return err
 	}
 	return nil
 }
 
-func ValidatePoolSpec(context *clusterd.Context, namespace string, p *cephv1.PoolSpec) error {
+// ValidatePoolSpec validates the pool settings. The pools of a stretch cluster default to the settings
+// that keep them available when a data zone is lost. The settings that are not available in the running
+// ceph version are rejected.
+func ValidatePoolSpec(context *clusterd.Context, namespace string, cephVersion cephver.CephVersion, p *cephv1.PoolSpec) error {
+	stretchCluster, err := getStretchCluster(context, namespace)
+	if err != nil {
+		return err
+	}
+	if stretchCluster != nil {
+		if err := validateStretchPool(p); err != nil {
+			return err
+		}
+	}
+
 	if p.Replication() != nil && p.ErasureCode() != nil {
 		return fmt.Errorf("both replication and erasure code settings cannot be specified")
 	}
 	if p.Replication() == nil && p.ErasureCode() == nil {
 		return fmt.Errorf("neither replication nor erasure code settings were specified")
 	}
+	if p.ErasureCode() != nil && p.CrushRule != "" {
+		return fmt.Errorf("the crush rule can only be set on replicated pools")
+	}
+	if p.Replicated.MinSize > p.Replicated.Size {
+		return fmt.Errorf("the min size %d is larger than the size %d", p.Replicated.MinSize, p.Replicated.Size)
+	}
+
+	if err := validatePoolProperties(cephVersion, p); err != nil {
+		return err
+	}
+	if err := validateMirroringPeers(context, namespace, cephVersion, p.Mirroring); err != nil {
+		return err
+	}
 
 	var crush ceph.CrushMap
-	var err error
-	if p.FailureDomain != "" || p.CrushRoot != "" {
+	if p.FailureDomain != "" || p.CrushRoot != "" || p.DeviceClass != "" || (p.CrushRule != "" && stretchCluster == nil) {
 		crush, err = ceph.GetCrushMap(context, namespace)
 		if err != nil {
 			return fmt.Errorf("failed to get crush map. %+v", err)
@@ -293,9 +469,146 @@ func ValidatePoolSpec(context *clusterd.Context, namespace string, p *cephv1.Poo
 		}
 	}
 
+	// validate the device class if specified. the class exists once an osd of the class is in the crush map.
+	if p.DeviceClass != "" {
+		found := false
+		for _, d := range crush.Devices {
+			if d.Class == p.DeviceClass {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no osds found with device class %s", p.DeviceClass)
+		}
+	}
+
+	// validate the crush rule if specified. the rule of a stretch cluster is added when the cluster is orchestrated.
+	if p.CrushRule != "" && stretchCluster == nil {
+		found := false
+		for _, r := range crush.Rules {
+			if r.Name == p.CrushRule {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unrecognized crush rule %s", p.CrushRule)
+		}
+	}
+
+	return nil
+}
+
+// getStretchCluster returns the zones of the cluster in the namespace if the cluster is stretched across
+// two data centers, or nil if it is not
+func getStretchCluster(context *clusterd.Context, namespace string) (*cephv1.StretchClusterSpec, error) {
+	clusters, err := context.RookClientset.CephV1().CephClusters(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the cluster in namespace %s. %+v", namespace, err)
+	}
+	for _, cluster := range clusters.Items {
+		if cluster.Spec.Mon.StretchCluster != nil {
+			return cluster.Spec.Mon.StretchCluster, nil
+		}
+	}
+	return nil, nil
+}
+
+// validateStretchPool sets the defaults of a pool of a stretch cluster and checks that the pool stays
+// available without risking data loss when a data zone is lost. The stretch rule places two replicas
+// in each data zone, and the min size lets the two replicas of the remaining zone accept I/O.
+func validateStretchPool(p *cephv1.PoolSpec) error {
+	if p.ErasureCode() != nil {
+		return fmt.Errorf("the pools of a stretch cluster must be replicated")
+	}
+	if p.FailureDomain != "" || p.CrushRoot != "" || p.DeviceClass != "" {
+		return fmt.Errorf("the pools of a stretch cluster are placed with the crush rule %s. the failure domain, crush root and device class can't be set", ceph.StretchCrushRuleName)
+	}
+
+	if p.CrushRule == "" {
+		p.CrushRule = ceph.StretchCrushRuleName
+	}
+	if p.Replicated.Size == 0 {
+		p.Replicated.Size = stretchPoolSize
+	}
+	if p.Replicated.MinSize == 0 {
+		p.Replicated.MinSize = stretchPoolMinSize
+	}
+
+	if p.CrushRule != ceph.StretchCrushRuleName {
+		return fmt.Errorf("the pools of a stretch cluster must use the crush rule %s", ceph.StretchCrushRuleName)
+	}
+	if p.Replicated.Size != stretchPoolSize {
+		return fmt.Errorf("the pools of a stretch cluster must have %d replicas, two in each data zone", stretchPoolSize)
+	}
+	if p.Replicated.MinSize != stretchPoolMinSize {
+		return fmt.Errorf("the pools of a stretch cluster must have a min size of %d to accept I/O after the loss of a data zone", stretchPoolMinSize)
+	}
+	return nil
+}
+
+func validatePoolProperties(cephVersion cephver.CephVersion, p *cephv1.PoolSpec) error {
+	if !oneOf(p.Compression.Mode, "", "none", "passive", "aggressive", "force") {
+		return fmt.Errorf("unrecognized compression mode %s", p.Compression.Mode)
+	}
+	if !oneOf(p.Compression.Algorithm, "", "snappy", "zlib", "zstd", "lz4") {
+		return fmt.Errorf("unrecognized compression algorithm %s", p.Compression.Algorithm)
+	}
+	if p.Compression.RequiredRatio != "" {
+		ratio, err := strconv.ParseFloat(p.Compression.RequiredRatio, 64)
+		if err != nil || ratio <= 0 || ratio > 1 {
+			return fmt.Errorf("compression required ratio %s must be a number between 0 and 1", p.Compression.RequiredRatio)
+		}
+	}
+	if p.Compression.MaxBlobSize != 0 && p.Compression.MinBlobSize > p.Compression.MaxBlobSize {
+		return fmt.Errorf("compression min blob size %d is larger than the max blob size %d", p.Compression.MinBlobSize, p.Compression.MaxBlobSize)
+	}
+
+	pg := p.PlacementGroups
+	if !oneOf(pg.AutoscaleMode, "", "on", "off", "warn") {
+		return fmt.Errorf("unrecognized pg autoscale mode %s", pg.AutoscaleMode)
+	}
+	if (pg.AutoscaleMode != "" || pg.TargetSizeRatio != "") && !cephVersion.IsAtLeastNautilus() {
+		return fmt.Errorf("the pg autoscale mode and target size ratio require nautilus or newer, the cluster runs ceph %s", cephVersion.String())
+	}
+	if pg.AutoscaleMode == "on" && pg.PgNum != 0 {
+		return fmt.Errorf("pg_num %d cannot be set when the pg autoscaler is on, since the autoscaler changes it", pg.PgNum)
+	}
+	if pg.TargetSizeRatio != "" {
+		ratio, err := strconv.ParseFloat(pg.TargetSizeRatio, 64)
+		if err != nil || ratio < 0 {
+			return fmt.Errorf("target size ratio %s must be a positive number", pg.TargetSizeRatio)
+		}
+	}
+	if pg.PgpNum != 0 && pg.PgpNum > pg.PgNum {
+		return fmt.Errorf("pgp_num %d cannot be larger than pg_num %d", pg.PgpNum, pg.PgNum)
+	}
+
+	m := p.Mirroring
+	if !oneOf(m.Mode, "", ceph.MirroringModeDisabled, ceph.MirroringModePool, ceph.MirroringModeImage) {
+		return fmt.Errorf("unrecognized mirroring mode %s", m.Mode)
+	}
+	if len(m.Peers) > 0 && !mirroringEnabled(m) {
+		return fmt.Errorf("mirroring peers require the pool or image mirroring mode")
+	}
+	for _, peer := range m.Peers {
+		if peer.SecretName == "" {
+			return fmt.Errorf("missing secret name of mirroring peer")
+		}
+	}
 	return nil
 }
 
+func oneOf(val string, allowed ...string) bool {
+	for _, a := range allowed {
+		if val == a {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *PoolController) watchLegacyPools(namespace string, stopCh chan struct{}, resourceHandlerFuncs cache.ResourceEventHandlerFuncs) {
 	// watch for pool.rook.io/v1alpha1 events if the CRD exists
 	if _, err := c.context.RookClientset.CephV1beta1().Pools(namespace).List(metav1.ListOptions{}); err != nil {
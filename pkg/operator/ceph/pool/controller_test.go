@@ -24,29 +24,32 @@ import (
 	cephbeta "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
 	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestValidatePool(t *testing.T) {
-	context := &clusterd.Context{Executor: &exectest.MockExecutor{}}
+	context := &clusterd.Context{Executor: &exectest.MockExecutor{}, RookClientset: rookfake.NewSimpleClientset()}
 
 	// must specify some replication or EC settings
 	p := cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
-	err := ValidatePool(context, &p)
+	err := ValidatePool(context, cephver.Nautilus, &p)
 	assert.NotNil(t, err)
 
 	// must specify name
 	p = cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Namespace: "myns"}}
-	err = ValidatePool(context, &p)
+	err = ValidatePool(context, cephver.Nautilus, &p)
 	assert.NotNil(t, err)
 
 	// must specify namespace
 	p = cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool"}}
-	err = ValidatePool(context, &p)
+	err = ValidatePool(context, cephver.Nautilus, &p)
 	assert.NotNil(t, err)
 
 	// must not specify both replication and EC settings
@@ -54,30 +57,30 @@ func TestValidatePool(t *testing.T) {
 	p.Spec.Replicated.Size = 1
 	p.Spec.ErasureCoded.CodingChunks = 2
 	p.Spec.ErasureCoded.DataChunks = 3
-	err = ValidatePool(context, &p)
+	err = ValidatePool(context, cephver.Nautilus, &p)
 	assert.NotNil(t, err)
 
 	// succeed with replication settings
 	p = cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
 	p.Spec.Replicated.Size = 1
-	err = ValidatePool(context, &p)
+	err = ValidatePool(context, cephver.Nautilus, &p)
 	assert.Nil(t, err)
 
 	// succeed with ec settings
 	p = cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
 	p.Spec.ErasureCoded.CodingChunks = 1
 	p.Spec.ErasureCoded.DataChunks = 2
-	err = ValidatePool(context, &p)
+	err = ValidatePool(context, cephver.Nautilus, &p)
 	assert.Nil(t, err)
 }
 
 func TestValidateCrushProperties(t *testing.T) {
 	executor := &exectest.MockExecutor{}
-	context := &clusterd.Context{Executor: executor}
+	context := &clusterd.Context{Executor: executor, RookClientset: rookfake.NewSimpleClientset()}
 	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
 		logger.Infof("Command: %s %v", command, args)
 		if args[1] == "crush" && args[2] == "dump" {
-			return `{"types":[{"type_id": 0,"name": "osd"}],"buckets":[{"id": -1,"name":"default"},{"id": -2,"name":"good"}]}`, nil
+			return `{"devices":[{"id": 0,"name":"osd.0","class":"ssd"}],"types":[{"type_id": 0,"name": "osd"}],"buckets":[{"id": -1,"name":"default"},{"id": -2,"name":"good"}]}`, nil
 		}
 		return "", fmt.Errorf("unexpected ceph command '%v'", args)
 	}
@@ -90,24 +93,65 @@ func TestValidateCrushProperties(t *testing.T) {
 			FailureDomain: "osd",
 		},
 	}
-	err := ValidatePool(context, p)
+	err := ValidatePool(context, cephver.Nautilus, p)
 	assert.Nil(t, err)
 
 	// fail with a failure domain that doesn't exist
 	p.Spec.FailureDomain = "doesntexist"
-	err = ValidatePool(context, p)
+	err = ValidatePool(context, cephver.Nautilus, p)
 	assert.NotNil(t, err)
 
 	// fail with a crush root that doesn't exist
 	p.Spec.FailureDomain = "osd"
 	p.Spec.CrushRoot = "bad"
-	err = ValidatePool(context, p)
+	err = ValidatePool(context, cephver.Nautilus, p)
 	assert.NotNil(t, err)
 
 	// fail with a crush root that does exist
 	p.Spec.CrushRoot = "good"
-	err = ValidatePool(context, p)
+	err = ValidatePool(context, cephver.Nautilus, p)
+	assert.Nil(t, err)
+
+	// succeed with a device class of an osd
+	p.Spec.DeviceClass = "ssd"
+	err = ValidatePool(context, cephver.Nautilus, p)
 	assert.Nil(t, err)
+
+	// fail with a device class without osds
+	p.Spec.DeviceClass = "nvme"
+	err = ValidatePool(context, cephver.Nautilus, p)
+	assert.NotNil(t, err)
+}
+
+func TestValidateStretchPool(t *testing.T) {
+	cluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "myns"},
+		Spec: cephv1.ClusterSpec{Mon: cephv1.MonSpec{Count: 5, StretchCluster: &cephv1.StretchClusterSpec{
+			Zones: []cephv1.StretchClusterZoneSpec{{Name: "a"}, {Name: "b"}, {Name: "c", Arbiter: true}},
+		}}},
+	}
+	context := &clusterd.Context{Executor: &exectest.MockExecutor{}, RookClientset: rookfake.NewSimpleClientset(cluster)}
+
+	// the pool defaults to two replicas in each data zone
+	p := cephv1.PoolSpec{}
+	assert.Nil(t, ValidatePoolSpec(context, "myns", cephver.Nautilus, &p))
+	assert.Equal(t, cephv1.ReplicatedSpec{Size: 4, MinSize: 2}, p.Replicated)
+	assert.Equal(t, "stretch_cluster_rule", p.CrushRule)
+
+	// the pool must not lose writes or data when a data zone is lost
+	p = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	assert.NotNil(t, ValidatePoolSpec(context, "myns", cephver.Nautilus, &p))
+	p = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 4, MinSize: 1}}
+	assert.NotNil(t, ValidatePoolSpec(context, "myns", cephver.Nautilus, &p))
+	p = cephv1.PoolSpec{FailureDomain: "host"}
+	assert.NotNil(t, ValidatePoolSpec(context, "myns", cephver.Nautilus, &p))
+	p = cephv1.PoolSpec{ErasureCoded: cephv1.ErasureCodedSpec{CodingChunks: 2, DataChunks: 2}}
+	assert.NotNil(t, ValidatePoolSpec(context, "myns", cephver.Nautilus, &p))
+
+	// the pools of the clusters in other namespaces are not stretched
+	p = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	assert.Nil(t, ValidatePoolSpec(context, "otherns", cephver.Nautilus, &p))
+	assert.Equal(t, "", p.CrushRule)
 }
 
 func TestCreatePool(t *testing.T) {
@@ -119,25 +163,25 @@ func TestCreatePool(t *testing.T) {
 			return "", nil
 		},
 	}
-	context := &clusterd.Context{Executor: executor}
+	context := &clusterd.Context{Executor: executor, RookClientset: rookfake.NewSimpleClientset()}
 
 	p := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
 	p.Spec.Replicated.Size = 1
 
 	exists, err := poolExists(context, p)
 	assert.False(t, exists)
-	err = createPool(context, p)
+	err = createPool(context, cephver.Nautilus, p)
 	assert.Nil(t, err)
 
 	// fail if both replication and EC are specified
 	p.Spec.ErasureCoded.CodingChunks = 2
 	p.Spec.ErasureCoded.DataChunks = 2
-	err = createPool(context, p)
+	err = createPool(context, cephver.Nautilus, p)
 	assert.NotNil(t, err)
 
 	// succeed with EC
 	p.Spec.Replicated.Size = 0
-	err = createPool(context, p)
+	err = createPool(context, cephver.Nautilus, p)
 	assert.Nil(t, err)
 }
 
@@ -153,28 +197,130 @@ func TestUpdatePool(t *testing.T) {
 	new = cephv1.PoolSpec{FailureDomain: "osd", Replicated: cephv1.ReplicatedSpec{Size: 2}}
 	changed = poolChanged(old, new)
 	assert.True(t, changed)
+
+	// the quotas, compression and placement group settings are updatable
+	old = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}}
+	new = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}, Quotas: cephv1.QuotaSpec{MaxObjects: 1000}}
+	assert.True(t, poolChanged(old, new))
+	new = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}, Compression: cephv1.CompressionSpec{Mode: "aggressive"}}
+	assert.True(t, poolChanged(old, new))
+	new = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}, PlacementGroups: cephv1.PlacementGroupSpec{AutoscaleMode: "on"}}
+	assert.True(t, poolChanged(old, new))
+
+	// the pool is moved to the osds of another device class
+	new = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}, DeviceClass: "ssd"}
+	assert.True(t, poolChanged(old, new))
+
+	// the pool is placed with another crush rule or min size
+	new = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}, CrushRule: "myrule"}
+	assert.True(t, poolChanged(old, new))
+	new = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1, MinSize: 1}}
+	assert.True(t, poolChanged(old, new))
+}
+
+func TestValidatePoolProperties(t *testing.T) {
+	p := &cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}}
+	assert.Nil(t, validatePoolProperties(cephver.Nautilus, p))
+
+	p.Compression = cephv1.CompressionSpec{Mode: "aggressive", Algorithm: "zstd", RequiredRatio: "0.7", MinBlobSize: 8192, MaxBlobSize: 65536}
+	p.PlacementGroups = cephv1.PlacementGroupSpec{PgNum: 64, PgpNum: 32, AutoscaleMode: "warn", TargetSizeRatio: "0.2"}
+	assert.Nil(t, validatePoolProperties(cephver.Nautilus, p))
+
+	p.Compression.Mode = "sometimes"
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, p))
+	p.Compression.Mode = "force"
+	p.Compression.Algorithm = "gzip"
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, p))
+	p.Compression.Algorithm = "lz4"
+	p.Compression.RequiredRatio = "1.5"
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, p))
+	p.Compression.RequiredRatio = "0.875"
+	p.Compression.MinBlobSize = 131072
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, p))
+	p.Compression.MinBlobSize = 0
+
+	p.PlacementGroups.AutoscaleMode = "auto"
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, p))
+	p.PlacementGroups.AutoscaleMode = "on"
+	p.PlacementGroups.TargetSizeRatio = "a lot"
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, p))
+	p.PlacementGroups.TargetSizeRatio = ""
+	p.PlacementGroups.PgpNum = 128
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, p))
+
+	// the autoscaler changes the pg_num it was given
+	p.PlacementGroups = cephv1.PlacementGroupSpec{PgNum: 64, AutoscaleMode: "on"}
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, p))
+	p.PlacementGroups = cephv1.PlacementGroupSpec{AutoscaleMode: "on", TargetSizeRatio: "0.2"}
+	assert.Nil(t, validatePoolProperties(cephver.Nautilus, p))
+
+	// the autoscaler is only available in nautilus
+	assert.NotNil(t, validatePoolProperties(cephver.Mimic, p))
+	p.PlacementGroups = cephv1.PlacementGroupSpec{TargetSizeRatio: "0.2"}
+	assert.NotNil(t, validatePoolProperties(cephver.Mimic, p))
+	p.PlacementGroups = cephv1.PlacementGroupSpec{PgNum: 64}
+	assert.Nil(t, validatePoolProperties(cephver.Mimic, p))
+}
+
+func TestSetPoolProperties(t *testing.T) {
+	props := map[string]string{}
+	quotas := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outfile string, args ...string) (string, error) {
+			if args[1] == "pool" && args[2] == "set" {
+				props[args[4]] = args[5]
+			} else if args[1] == "pool" && args[2] == "set-quota" {
+				quotas[args[4]] = args[5]
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	spec := cephv1.PoolSpec{
+		Replicated:      cephv1.ReplicatedSpec{Size: 1},
+		Quotas:          cephv1.QuotaSpec{MaxBytes: 1024},
+		Compression:     cephv1.CompressionSpec{Mode: "passive", MinBlobSize: 4096},
+		PlacementGroups: cephv1.PlacementGroupSpec{PgNum: 128, AutoscaleMode: "off"},
+	}
+	err := setPoolProperties(context, "myns", "mypool", spec)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"max_bytes": "1024", "max_objects": "0"}, quotas)
+	assert.Equal(t, map[string]string{
+		"compression_mode":          "passive",
+		"compression_min_blob_size": "4096",
+		"pg_autoscale_mode":         "off",
+		"pg_num":                    "128",
+		"pgp_num":                   "128",
+	}, props)
 }
 
 func TestDeletePool(t *testing.T) {
+	var rulesDeleted []string
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outfile string, args ...string) (string, error) {
 			if command == "ceph" && args[1] == "lspools" {
 				return `[{"poolnum":1,"poolname":"mypool"}]`, nil
 			} else if command == "ceph" && args[1] == "pool" && args[2] == "get" {
-				return `{"pool": "mypool","pool_id": 1,"size":1}`, nil
+				return `{"pool": "mypool","pool_id": 1,"size":1,"crush_rule":"mypool_other"}`, nil
+			} else if command == "ceph" && args[1] == "crush" && args[2] == "rule" && args[3] == "rm" {
+				rulesDeleted = append(rulesDeleted, args[4])
 			}
 			return "", nil
 		},
 	}
 	context := &clusterd.Context{Executor: executor}
 
-	// delete a pool that exists
+	// delete a pool that exists. only the rule of the device class in the spec is deleted, not
+	// another rule with the name of the pool as prefix
 	p := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.DeviceClass = "ssd"
 	exists, err := poolExists(context, p)
 	assert.Nil(t, err)
 	assert.True(t, exists)
 	err = deletePool(context, p)
 	assert.Nil(t, err)
+	assert.Equal(t, []string{"mypool", "mypool_ssd"}, rulesDeleted)
 
 	// succeed even if the pool doesn't exist
 	p = &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "otherpool", Namespace: "myns"}}
@@ -220,7 +366,7 @@ func TestMigratePoolObject(t *testing.T) {
 		Clientset:     clientset,
 		RookClientset: rookfake.NewSimpleClientset(legacyPool),
 	}
-	controller := NewPoolController(context, legacyPool.Namespace)
+	controller := NewPoolController(&cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}, context, legacyPool.Namespace)
 
 	// convert the legacy pool object in memory and assert that a migration is needed
 	convertedPool, migrationNeeded, err := getPoolObject(legacyPool)
@@ -281,3 +427,35 @@ func TestConvertLegacyPool(t *testing.T) {
 
 	assert.Equal(t, expectedPool, *convertRookLegacyPool(&legacyPool))
 }
+
+func TestUpdatePoolStatus(t *testing.T) {
+	p := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns", Generation: 2},
+		Spec:       cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}},
+	}
+	context := &clusterd.Context{RookClientset: rookfake.NewSimpleClientset(p)}
+
+	// a failure to create the pool is reported in the condition
+	updatePoolStatus(context, p, fmt.Errorf("mock failure"))
+	pool, err := context.RookClientset.CephV1().CephBlockPools(p.Namespace).Get(p.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ResourcePhaseFailure, pool.Status.Phase)
+	assert.Equal(t, int64(2), pool.Status.ObservedGeneration)
+	c := pool.Status.GetCondition(cephv1.ConditionPoolsReady)
+	assert.NotNil(t, c)
+	assert.Equal(t, v1.ConditionFalse, c.Status)
+	assert.Equal(t, cephv1.ReasonPoolFailed, c.Reason)
+	assert.Equal(t, "mock failure", c.Message)
+
+	// the condition is replaced after the pool is created
+	updatePoolStatus(context, p, nil)
+	pool, err = context.RookClientset.CephV1().CephBlockPools(p.Namespace).Get(p.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ResourcePhaseReady, pool.Status.Phase)
+	assert.Equal(t, 1, len(pool.Status.Conditions))
+	assert.Equal(t, cephv1.ReasonPoolCreated, pool.Status.Conditions[0].Reason)
+	assert.True(t, pool.Status.IsConditionTrue(cephv1.ConditionPoolsReady))
+
+	// a missing pool is ignored
+	updatePoolStatus(context, &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "myns"}}, nil)
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// keys of the secret describing a mirroring peer
+	peerClusterNameKey = "clusterName"
+	peerMonHostKey     = "monHost"
+	peerKeyKey         = "key"
+	peerClientKey      = "client"
+
+	defaultPeerClient = "client.admin"
+
+	defaultMirroringCheckInterval = 60 * time.Second
+)
+
+// mirroringPeer is a remote cluster read from the secret of a mirroring peer
+type mirroringPeer struct {
+	clusterName string
+	client      string
+	monHost     string
+	key         string
+}
+
+func mirroringEnabled(m cephv1.MirroringSpec) bool {
+	return m.Mode == ceph.MirroringModePool || m.Mode == ceph.MirroringModeImage
+}
+
+// configureMirroring sets the mirroring mode of the pool and reconciles its peers with the spec.
+// Peers on the pool that are not in the spec are removed. If no mode is set, the mirroring of the
+// pool is left alone.
+func configureMirroring(context *clusterd.Context, namespace, poolName string, spec cephv1.MirroringSpec) error {
+	if spec.Mode == "" {
+		return nil
+	}
+
+	info, err := ceph.GetPoolMirroringInfo(context, namespace, poolName)
+	if err != nil {
+		return err
+	}
+
+	if !mirroringEnabled(spec) {
+		if info.Mode == ceph.MirroringModeDisabled {
+			return nil
+		}
+		// the peers must be removed before mirroring can be disabled
+		for _, peer := range info.Peers {
+			if err := ceph.RemovePoolMirrorPeer(context, namespace, poolName, peer.UUID); err != nil {
+				return err
+			}
+		}
+		return ceph.DisablePoolMirroring(context, namespace, poolName)
+	}
+
+	if info.Mode != spec.Mode {
+		if err := ceph.EnablePoolMirroring(context, namespace, poolName, spec.Mode); err != nil {
+			return err
+		}
+	}
+
+	desired := map[string]bool{}
+	for _, p := range spec.Peers {
+		peer, err := getMirroringPeer(context, namespace, p.SecretName)
+		if err != nil {
+			return err
+		}
+		desired[peer.name()] = true
+		if hasPeer(info.Peers, peer) {
+			continue
+		}
+		if err := addPeer(context, namespace, poolName, peer); err != nil {
+			return err
+		}
+	}
+
+	for _, peer := range info.Peers {
+		if !desired[fmt.Sprintf("%s@%s", peer.ClientName, peer.ClusterName)] {
+			if err := ceph.RemovePoolMirrorPeer(context, namespace, poolName, peer.UUID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getMirroringPeer(context *clusterd.Context, namespace, secretName string) (*mirroringPeer, error) {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirroring peer secret %s. %+v", secretName, err)
+	}
+
+	peer := &mirroringPeer{
+		clusterName: string(secret.Data[peerClusterNameKey]),
+		client:      string(secret.Data[peerClientKey]),
+		monHost:     string(secret.Data[peerMonHostKey]),
+		key:         string(secret.Data[peerKeyKey]),
+	}
+	if peer.clusterName == "" {
+		return nil, fmt.Errorf("mirroring peer secret %s is missing the %s", secretName, peerClusterNameKey)
+	}
+	if peer.client == "" {
+		peer.client = defaultPeerClient
+	}
+	return peer, nil
+}
+
+// validateMirroringPeers checks that the secrets of the peers exist. The mon hosts and the key of a
+// peer can only be stored with the peer in nautilus or newer.
+func validateMirroringPeers(context *clusterd.Context, namespace string, cephVersion cephver.CephVersion, m cephv1.MirroringSpec) error {
+	if !mirroringEnabled(m) {
+		return nil
+	}
+	for _, p := range m.Peers {
+		peer, err := getMirroringPeer(context, namespace, p.SecretName)
+		if err != nil {
+			return err
+		}
+		if (peer.monHost != "" || peer.key != "") && !cephVersion.IsAtLeastNautilus() {
+			return fmt.Errorf("the %s and %s of mirroring peer secret %s require nautilus or newer, the cluster runs ceph %s", peerMonHostKey, peerKeyKey, p.SecretName, cephVersion.String())
+		}
+	}
+	return nil
+}
+
+func (p *mirroringPeer) name() string {
+	return fmt.Sprintf("%s@%s", p.client, p.clusterName)
+}
+
+func hasPeer(peers []ceph.MirrorPeer, peer *mirroringPeer) bool {
+	for _, p := range peers {
+		if p.ClusterName == peer.clusterName && p.ClientName == peer.client {
+			return true
+		}
+	}
+	return false
+}
+
+// addPeer adds the peer to the pool. The key is passed to rbd in a temporary file that is removed
+// after the peer is added.
+func addPeer(context *clusterd.Context, namespace, poolName string, peer *mirroringPeer) error {
+	keyFile := ""
+	if peer.key != "" {
+		f, err := ioutil.TempFile(context.ConfigDir, "peer-key")
+		if err != nil {
+			return fmt.Errorf("failed to create key file for peer %s. %+v", peer.name(), err)
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(peer.key)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write key file for peer %s. %+v", peer.name(), err)
+		}
+		keyFile = f.Name()
+	}
+	return ceph.AddPoolMirrorPeer(context, namespace, poolName, peer.client, peer.clusterName, peer.monHost, keyFile)
+}
+
+// mirroringChecker periodically reports the mirroring health of the pools in the pool status
+type mirroringChecker struct {
+	context   *clusterd.Context
+	namespace string
+	interval  time.Duration
+}
+
+func newMirroringChecker(context *clusterd.Context, namespace string) *mirroringChecker {
+	return &mirroringChecker{context: context, namespace: namespace, interval: defaultMirroringCheckInterval}
+}
+
+func (c *mirroringChecker) checkMirroring(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("Stopping monitoring of pool mirroring")
+			return
+
+		case <-time.After(c.interval):
+			c.checkPools()
+		}
+	}
+}
+
+// checkPools updates the mirroring status of every pool that has mirroring enabled
+func (c *mirroringChecker) checkPools() {
+	pools, err := c.context.RookClientset.CephV1().CephBlockPools(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Errorf("failed to list pools to check the mirroring health. %+v", err)
+		return
+	}
+
+	for i := range pools.Items {
+		pool := &pools.Items[i]
+		if !mirroringEnabled(pool.Spec.Mirroring) {
+			if pool.Status.Mirroring != nil {
+				// clear the health of a pool that is no longer mirrored
+				pool.Status.Mirroring = nil
+				if _, err := c.context.RookClientset.CephV1().CephBlockPools(c.namespace).UpdateStatus(pool); err != nil {
+					logger.Warningf("failed to clear the mirroring status of pool %s. %+v", pool.Name, err)
+				}
+			}
+			continue
+		}
+		if err := c.updateMirroringStatus(pool); err != nil {
+			logger.Warningf("failed to update the mirroring status of pool %s. %+v", pool.Name, err)
+		}
+	}
+}
+
+func (c *mirroringChecker) updateMirroringStatus(pool *cephv1.CephBlockPool) error {
+	status, err := ceph.GetPoolMirroringStatus(c.context, c.namespace, pool.Name)
+	if err != nil {
+		return err
+	}
+	info, err := ceph.GetPoolMirroringInfo(c.context, c.namespace, pool.Name)
+	if err != nil {
+		return err
+	}
+
+	mirroring := &cephv1.MirroringStatus{
+		Health: status.Summary.Health,
+		States: status.Summary.States,
+	}
+	for _, peer := range info.Peers {
+		mirroring.Peers = append(mirroring.Peers, fmt.Sprintf("%s@%s", peer.ClientName, peer.ClusterName))
+	}
+
+	// only write the status when the health changed to avoid updating the pool every interval
+	if pool.Status.Mirroring != nil {
+		mirroring.LastChanged = pool.Status.Mirroring.LastChanged
+		if reflect.DeepEqual(pool.Status.Mirroring, mirroring) {
+			return nil
+		}
+	}
+	mirroring.LastChanged = time.Now().UTC().Format(time.RFC3339)
+	pool.Status.Mirroring = mirroring
+	if _, err := c.context.RookClientset.CephV1().CephBlockPools(c.namespace).UpdateStatus(pool); err != nil {
+		return fmt.Errorf("failed to update status. %+v", err)
+	}
+	return nil
+}
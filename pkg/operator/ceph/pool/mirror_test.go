@@ -0,0 +1,205 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigureMirroring(t *testing.T) {
+	mirrorInfo := `{"mode":"disabled","peers":[]}`
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			assert.Equal(t, "rbd", command)
+			cmd := strings.Join(args[0:4], " ")
+			if cmd == "mirror pool info mypool" {
+				return mirrorInfo, nil
+			}
+			if cmd == "mirror pool peer add" {
+				// the key is passed in a file that exists while the command runs
+				for i, arg := range args {
+					if arg == "--remote-key-file" {
+						key, err := ioutil.ReadFile(args[i+1])
+						assert.Nil(t, err)
+						assert.Equal(t, "AQBsecret==", string(key))
+					}
+				}
+			}
+			commands = append(commands, strings.Join(rbdArgs(args), " "))
+			return "", nil
+		},
+	}
+	clientset := testop.New(1)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "site-b", Namespace: "ns"},
+		Data: map[string][]byte{
+			"clusterName": []byte("site-b"),
+			"monHost":     []byte("10.0.0.1:6789"),
+			"key":         []byte("AQBsecret=="),
+		},
+	}
+	_, err := clientset.CoreV1().Secrets("ns").Create(secret)
+	assert.Nil(t, err)
+
+	// nothing is done if the mode is not set
+	err = configureMirroring(context, "ns", "mypool", cephv1.MirroringSpec{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(commands))
+
+	// enable mirroring and add the peer
+	spec := cephv1.MirroringSpec{Mode: "pool", Peers: []cephv1.MirroringPeerSpec{{SecretName: "site-b"}}}
+	err = configureMirroring(context, "ns", "mypool", spec)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"mirror pool enable mypool pool",
+		"mirror pool peer add mypool client.admin@site-b --remote-mon-host 10.0.0.1:6789",
+	}, commands)
+
+	// the peer already exists and a peer that is not in the spec is removed
+	commands = nil
+	mirrorInfo = `{"mode":"pool","peers":[{"uuid":"1234","cluster_name":"site-b","client_name":"client.admin"},` +
+		`{"uuid":"5678","cluster_name":"site-c","client_name":"client.admin"}]}`
+	err = configureMirroring(context, "ns", "mypool", spec)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mirror pool peer remove mypool 5678"}, commands)
+
+	// disabling the mirroring removes the peers first
+	commands = nil
+	err = configureMirroring(context, "ns", "mypool", cephv1.MirroringSpec{Mode: "disabled"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"mirror pool peer remove mypool 1234",
+		"mirror pool peer remove mypool 5678",
+		"mirror pool disable mypool",
+	}, commands)
+
+	// a missing secret fails
+	spec.Peers[0].SecretName = "missing"
+	err = configureMirroring(context, "ns", "mypool", spec)
+	assert.NotNil(t, err)
+}
+
+// rbdArgs returns the args of the rbd command without the temporary key file and the cluster config
+func rbdArgs(args []string) []string {
+	for i, arg := range args {
+		if arg == "--remote-key-file" || strings.HasPrefix(arg, "--cluster") {
+			return args[:i]
+		}
+	}
+	return args
+}
+
+func TestValidateMirroring(t *testing.T) {
+	p := cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Mode: "image"}}
+	assert.Nil(t, validatePoolProperties(cephver.Nautilus, &p))
+
+	p.Mirroring.Mode = "journal"
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, &p))
+
+	p.Mirroring = cephv1.MirroringSpec{Peers: []cephv1.MirroringPeerSpec{{SecretName: "peer"}}}
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, &p))
+
+	p.Mirroring.Mode = "pool"
+	assert.Nil(t, validatePoolProperties(cephver.Nautilus, &p))
+
+	p.Mirroring.Peers[0].SecretName = ""
+	assert.NotNil(t, validatePoolProperties(cephver.Nautilus, &p))
+}
+
+func TestValidateMirroringPeers(t *testing.T) {
+	clientset := testop.New(1)
+	context := &clusterd.Context{Clientset: clientset}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "site-b", Namespace: "ns"},
+		Data:       map[string][]byte{"clusterName": []byte("site-b")},
+	}
+	_, err := clientset.CoreV1().Secrets("ns").Create(secret)
+	assert.Nil(t, err)
+
+	m := cephv1.MirroringSpec{Mode: "pool", Peers: []cephv1.MirroringPeerSpec{{SecretName: "site-b"}}}
+	assert.Nil(t, validateMirroringPeers(context, "ns", cephver.Mimic, m))
+
+	// the mon hosts and key of the peer are only stored by nautilus
+	secret.Data["monHost"] = []byte("10.0.0.1:6789")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.Nil(t, err)
+	assert.NotNil(t, validateMirroringPeers(context, "ns", cephver.Mimic, m))
+	assert.Nil(t, validateMirroringPeers(context, "ns", cephver.Nautilus, m))
+
+	// the secret must exist
+	m.Peers[0].SecretName = "missing"
+	assert.NotNil(t, validateMirroringPeers(context, "ns", cephver.Nautilus, m))
+}
+
+func TestUpdateMirroringStatus(t *testing.T) {
+	health := "WARNING"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			switch strings.Join(args[0:3], " ") {
+			case "mirror pool status":
+				return fmt.Sprintf(`{"summary":{"health":"%s","states":{"replaying":2}}}`, health), nil
+			case "mirror pool info":
+				return `{"mode":"pool","peers":[{"uuid":"1234","cluster_name":"site-b","client_name":"client.admin"}]}`, nil
+			}
+			return "", fmt.Errorf("unexpected command %v", args)
+		},
+	}
+	mirrored := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Namespace: "ns"},
+		Spec:       cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Mode: "pool"}},
+	}
+	unmirrored := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "unmirrored", Namespace: "ns"},
+		Status:     cephv1.CephBlockPoolStatus{Mirroring: &cephv1.MirroringStatus{Health: "OK"}},
+	}
+	context := &clusterd.Context{Executor: executor, RookClientset: rookfake.NewSimpleClientset(mirrored, unmirrored)}
+	checker := newMirroringChecker(context, "ns")
+
+	checker.checkPools()
+	pool, err := context.RookClientset.CephV1().CephBlockPools("ns").Get("mirrored", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "WARNING", pool.Status.Mirroring.Health)
+	assert.Equal(t, 2, pool.Status.Mirroring.States["replaying"])
+	assert.Equal(t, []string{"client.admin@site-b"}, pool.Status.Mirroring.Peers)
+	assert.NotEqual(t, "", pool.Status.Mirroring.LastChanged)
+
+	// the status of a pool that is not mirrored is cleared
+	pool, err = context.RookClientset.CephV1().CephBlockPools("ns").Get("unmirrored", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, pool.Status.Mirroring)
+
+	health = "OK"
+	checker.checkPools()
+	pool, err = context.RookClientset.CephV1().CephBlockPools("ns").Get("mirrored", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "OK", pool.Status.Mirroring.Health)
+}
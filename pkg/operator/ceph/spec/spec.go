@@ -20,6 +20,7 @@ package spec
 import (
 	"fmt"
 	"path"
+	"strconv"
 
 	"github.com/coreos/pkg/capnslog"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
@@ -35,6 +36,13 @@ const (
 	// in all Ceph pods.
 	ConfigInitContainerName = "config-init"
 	logVolumeName           = "rook-ceph-log"
+	daemonDataVolumeName    = "ceph-daemon-data"
+	// the sub directory of a PVC the daemon data is stored in, away from the lost+found directory
+	daemonDataPVCSubPath = "data"
+
+	// DefaultMemoryHeadroomRatio is the fraction of the memory limit of a pod that is kept free of the
+	// memory target of the daemon by default
+	DefaultMemoryHeadroomRatio = 0.2
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "ceph-spec")
@@ -85,7 +93,21 @@ func DaemonVolumes(dataPaths *config.DataPathMap, keyringResourceName string) []
 	if dataPaths.PersistData {
 		src = v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: dataPaths.HostDataDir}}
 	}
-	return append(vols, v1.Volume{Name: "ceph-daemon-data", VolumeSource: src})
+	return append(vols, v1.Volume{Name: daemonDataVolumeName, VolumeSource: src})
+}
+
+// DaemonVolumesPVC returns the pod volumes used by a Ceph daemon whose data is stored on the given
+// PVC instead of the host.
+func DaemonVolumesPVC(dataPaths *config.DataPathMap, keyringResourceName, pvcName string) []v1.Volume {
+	vols := DaemonVolumes(dataPaths, keyringResourceName)
+	for i := range vols {
+		if vols[i].Name == daemonDataVolumeName {
+			vols[i].VolumeSource = v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+			}
+		}
+	}
+	return vols
 }
 
 // DaemonVolumeMounts returns volume mounts which correspond to the DaemonVolumes. These
@@ -100,10 +122,22 @@ func DaemonVolumeMounts(dataPaths *config.DataPathMap, keyringResourceName strin
 		return mounts
 	}
 	return append(mounts,
-		v1.VolumeMount{Name: "ceph-daemon-data", MountPath: dataPaths.ContainerDataDir},
+		v1.VolumeMount{Name: daemonDataVolumeName, MountPath: dataPaths.ContainerDataDir},
 	)
 }
 
+// DaemonVolumeMountsPVC returns volume mounts which correspond to the DaemonVolumesPVC. The data is
+// mounted from a sub directory of the PVC since a new filesystem is not empty.
+func DaemonVolumeMountsPVC(dataPaths *config.DataPathMap, keyringResourceName string) []v1.VolumeMount {
+	mounts := DaemonVolumeMounts(dataPaths, keyringResourceName)
+	for i := range mounts {
+		if mounts[i].Name == daemonDataVolumeName {
+			mounts[i].SubPath = daemonDataPVCSubPath
+		}
+	}
+	return mounts
+}
+
 // DaemonFlags returns the command line flags used by all Ceph daemons.
 func DaemonFlags(cluster *cephconfig.ClusterInfo, daemonID string) []string {
 	return append(
@@ -202,3 +236,36 @@ func StoredLogVolumeMount() v1.VolumeMount {
 		MountPath: config.VarLogCephDir,
 	}
 }
+
+// ValidateMemoryHeadroomRatio returns an error if the headroom ratio is set and is not a fraction
+// between 0 and 1
+func ValidateMemoryHeadroomRatio(headroomRatio string) error {
+	if headroomRatio == "" {
+		return nil
+	}
+	ratio, err := strconv.ParseFloat(headroomRatio, 64)
+	if err != nil || ratio < 0 || ratio >= 1 {
+		return fmt.Errorf("invalid memory headroom ratio %q. must be at least 0 and less than 1", headroomRatio)
+	}
+	return nil
+}
+
+// MemoryTarget returns the number of bytes of memory the daemon of a pod can target, which is the
+// memory limit of the pod less the headroom given by the ratio. Zero is returned if the pod has no
+// memory limit. The default ratio is used if the ratio is not set or is invalid.
+func MemoryTarget(resources v1.ResourceRequirements, headroomRatio string) uint64 {
+	limit := resources.Limits.Memory()
+	if limit.IsZero() {
+		return 0
+	}
+
+	ratio := DefaultMemoryHeadroomRatio
+	if headroomRatio != "" {
+		if err := ValidateMemoryHeadroomRatio(headroomRatio); err != nil {
+			logger.Warningf("%+v. using the default ratio %.1f", err, DefaultMemoryHeadroomRatio)
+		} else {
+			ratio, _ = strconv.ParseFloat(headroomRatio, 64)
+		}
+	}
+	return uint64(float64(limit.Value()) * (1 - ratio))
+}
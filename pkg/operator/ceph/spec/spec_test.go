@@ -21,6 +21,9 @@ import (
 
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestPodVolumes(t *testing.T) {
@@ -42,3 +45,28 @@ func TestMountsMatchVolumes(t *testing.T) {
 	}
 	volsMountsTestDef.TestMountsMatchVolumes(t)
 }
+
+func TestMemoryTarget(t *testing.T) {
+	limited := v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("10Gi")},
+	}
+
+	// no memory limit, no memory target
+	assert.Equal(t, uint64(0), MemoryTarget(v1.ResourceRequirements{}, ""))
+
+	// the default headroom is left when the ratio is not set or is invalid
+	assert.Equal(t, uint64(8589934592), MemoryTarget(limited, ""))
+	assert.Equal(t, uint64(8589934592), MemoryTarget(limited, "1.5"))
+
+	assert.Equal(t, uint64(10737418240), MemoryTarget(limited, "0"))
+	assert.Equal(t, uint64(7516192768), MemoryTarget(limited, "0.3"))
+}
+
+func TestValidateMemoryHeadroomRatio(t *testing.T) {
+	assert.Nil(t, ValidateMemoryHeadroomRatio(""))
+	assert.Nil(t, ValidateMemoryHeadroomRatio("0"))
+	assert.Nil(t, ValidateMemoryHeadroomRatio("0.25"))
+	assert.NotNil(t, ValidateMemoryHeadroomRatio("1"))
+	assert.NotNil(t, ValidateMemoryHeadroomRatio("-0.1"))
+	assert.NotNil(t, ValidateMemoryHeadroomRatio("20%"))
+}
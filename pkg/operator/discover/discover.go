@@ -299,9 +299,11 @@ func ListDevicesInUse(context *clusterd.Context, namespace, nodeName string) ([]
 }
 
 // GetAvailableDevices conducts outer join using input filters with free devices that a node has. It marks the devices from join result as in-use.
-func GetAvailableDevices(context *clusterd.Context, nodeName, clusterName string, devices []rookalpha.Device, filter string, useAllDevices bool) ([]rookalpha.Device, error) {
+// The devices listed in the selection take precedence over the device filter, the device path filter and all devices, in that order.
+// The selected devices must also match the device property filter.
+func GetAvailableDevices(context *clusterd.Context, nodeName, clusterName string, selection rookalpha.Selection) ([]rookalpha.Device, error) {
 	results := []rookalpha.Device{}
-	if len(devices) == 0 && len(filter) == 0 && !useAllDevices {
+	if len(selection.Devices) == 0 && selection.DeviceFilter == "" && selection.DevicePathFilter == "" && !selection.GetUseAllDevices() {
 		return results, nil
 	}
 	namespace := os.Getenv(k8sutil.PodNamespaceEnvVar)
@@ -334,33 +336,13 @@ func GetAvailableDevices(context *clusterd.Context, nodeName, clusterName string
 	}
 	claimedDevices := []sys.LocalDisk{}
 	// now those left are free to use
-	if len(devices) > 0 {
-		for i := range devices {
-			for j := range nodeDevices {
-				if devices[i].Name == nodeDevices[j].Name {
-					results = append(results, devices[i])
-					claimedDevices = append(claimedDevices, nodeDevices[j])
-				}
-			}
-		}
-	} else if len(filter) >= 0 {
-		for i := range nodeDevices {
-			//TODO support filter based on other keys
-			matched, err := regexp.Match(filter, []byte(nodeDevices[i].Name))
-			if err == nil && matched {
-				d := rookalpha.Device{
-					Name: nodeDevices[i].Name,
-				}
-				claimedDevices = append(claimedDevices, nodeDevices[i])
-				results = append(results, d)
-			}
+	for i := range nodeDevices {
+		device, err := selectDevice(&nodeDevices[i], selection)
+		if err != nil {
+			return results, fmt.Errorf("failed to select devices on node %s. %+v", nodeName, err)
 		}
-	} else if useAllDevices {
-		for i := range nodeDevices {
-			d := rookalpha.Device{
-				Name: nodeDevices[i].Name,
-			}
-			results = append(results, d)
+		if device != nil {
+			results = append(results, *device)
 			claimedDevices = append(claimedDevices, nodeDevices[i])
 		}
 	}
@@ -398,3 +380,52 @@ func GetAvailableDevices(context *clusterd.Context, nodeName, clusterName string
 	}
 	return results, nil
 }
+
+// selectDevice returns the device of the storage selection that is the given node device, or nil if the node device is not selected.
+// The name of the returned device is the name of the node device, even when it was selected by one of its /dev/disk links.
+func selectDevice(nodeDevice *sys.LocalDisk, selection rookalpha.Selection) (*rookalpha.Device, error) {
+	var selected *rookalpha.Device
+	if len(selection.Devices) > 0 {
+		for _, device := range selection.Devices {
+			name := device.Name
+			if device.FullPath != "" {
+				name = device.FullPath
+			}
+			if clusterd.DeviceMatchesName(nodeDevice, name) {
+				selected = &rookalpha.Device{Name: nodeDevice.Name, FullPath: device.FullPath, Config: device.Config}
+				break
+			}
+		}
+	} else if selection.DeviceFilter != "" {
+		matched, err := regexp.MatchString(selection.DeviceFilter, nodeDevice.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device filter %s. %+v", selection.DeviceFilter, err)
+		}
+		if matched {
+			selected = &rookalpha.Device{Name: nodeDevice.Name}
+		}
+	} else if selection.DevicePathFilter != "" {
+		matched, err := clusterd.DeviceMatchesPathFilter(nodeDevice, selection.DevicePathFilter)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			selected = &rookalpha.Device{Name: nodeDevice.Name}
+		}
+	} else if selection.GetUseAllDevices() {
+		selected = &rookalpha.Device{Name: nodeDevice.Name}
+	}
+
+	if selected == nil {
+		return nil, nil
+	}
+	matched, err := clusterd.DeviceMatchesProperties(nodeDevice, selection.DevicePropertyFilter)
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		logger.Infof("skipping device %s that does not match the device property filter", nodeDevice.Name)
+		return nil, nil
+	}
+	return selected, nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -109,11 +110,46 @@ func TestGetAvailableDevices(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(nodeDevices))
 
-	devices, err := GetAvailableDevices(context, nodeName, ns, d, "^sd.", false)
+	devices, err := GetAvailableDevices(context, nodeName, ns, rookalpha.Selection{Devices: d, DeviceFilter: "^sd."})
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(devices))
 	// devices should be in use now, 2nd try gets the same list
-	devices, err = GetAvailableDevices(context, nodeName, ns, d, "^sd.", false)
+	devices, err = GetAvailableDevices(context, nodeName, ns, rookalpha.Selection{Devices: d, DeviceFilter: "^sd."})
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(devices))
+
+	// devices can be selected by their by-id and by-path links
+	d = []rookalpha.Device{
+		{FullPath: "/dev/disk/by-id/nvme-eui.002538c5710091a7"},
+		{FullPath: "/dev/disk/by-path/ip-127.0.0.1:3260-iscsi-iqn.2016-06.world.srv:storage.target01-lun-3"},
+	}
+	devices, err = GetAvailableDevices(context, nodeName, ns, rookalpha.Selection{Devices: d})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(devices))
+	assert.Equal(t, rookalpha.Device{Name: "sdb", FullPath: d[1].FullPath}, devices[0])
+	assert.Equal(t, rookalpha.Device{Name: "nvme0n1", FullPath: d[0].FullPath}, devices[1])
+
+	devices, err = GetAvailableDevices(context, nodeName, ns, rookalpha.Selection{DevicePathFilter: "-lun-[0-1]$"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(devices))
+	assert.Equal(t, "sdd", devices[0].Name)
+	assert.Equal(t, "sda", devices[1].Name)
+
+	// all the devices are filtered by their properties
+	useAllDevices := true
+	rotational := true
+	minSize := resource.MustParse("8Gi")
+	selection := rookalpha.Selection{
+		UseAllDevices:        &useAllDevices,
+		DevicePropertyFilter: &rookalpha.DevicePropertyFilter{Rotational: &rotational, MinSize: &minSize, Vendor: "^LIO"},
+	}
+	devices, err = GetAvailableDevices(context, nodeName, ns, selection)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(devices))
+	assert.Equal(t, "sdd", devices[0].Name)
+	assert.Equal(t, "sda", devices[1].Name)
+
+	selection.DevicePropertyFilter = &rookalpha.DevicePropertyFilter{Model: "("}
+	_, err = GetAvailableDevices(context, nodeName, ns, selection)
+	assert.NotNil(t, err)
 }
@@ -126,8 +126,7 @@ func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, resurrect bool)
 		rookSystemNS := os.Getenv(k8sutil.PodNamespaceEnvVar)
 		nodeDevices, _ := discover.ListDevices(c.context, rookSystemNS, n.Name)
 
-		availDevs, deviceErr := discover.GetAvailableDevices(c.context, n.Name, c.Namespace,
-			n.Devices, n.Selection.DeviceFilter, n.Selection.GetUseAllDevices())
+		availDevs, deviceErr := discover.GetAvailableDevices(c.context, n.Name, c.Namespace, n.Selection)
 
 		if deviceErr != nil {
 			// Devices were specified but we couldn't find any.
@@ -40,3 +40,27 @@ func Retry(maxRetries int, delay time.Duration, f func() error) error {
 		<-time.After(delay)
 	}
 }
+
+// RetryWithStop is the same as Retry, but it gives up when the stop channel is closed. A nil stop
+// channel is never closed.
+func RetryWithStop(maxRetries int, delay time.Duration, stopCh <-chan struct{}, f func() error) error {
+	tries := 0
+	for {
+		err := f()
+		if err == nil {
+			return nil
+		}
+
+		tries++
+		if tries > maxRetries {
+			return fmt.Errorf("max retries exceeded, last err: %+v", err)
+		}
+
+		logger.Infof("retrying after %v, last error: %+v", delay, err)
+		select {
+		case <-time.After(delay):
+		case <-stopCh:
+			return fmt.Errorf("stopped retrying, last err: %+v", err)
+		}
+	}
+}
@@ -64,6 +64,8 @@ type LocalDisk struct {
 	Rotational bool `json:"rotational"`
 	// ReadOnly is the boolean whether the device is readonly
 	Readonly bool `json:"readOnly"`
+	// Transport is the transport of the device, such as sata, sas, nvme or usb
+	Transport string `json:"transport"`
 	// Partitions is a partition slice
 	Partitions []Partition
 	// Filesystem is the filesystem currently on the device
@@ -149,7 +151,7 @@ func GetDeviceProperties(device string, executor exec.Executor) (map[string]stri
 func GetDevicePropertiesFromPath(devicePath string, executor exec.Executor) (map[string]string, error) {
 	cmd := fmt.Sprintf("lsblk %s", devicePath)
 	output, err := executor.ExecuteCommandWithOutput(false, cmd, "lsblk", devicePath,
-		"--bytes", "--nodeps", "--pairs", "--output", "SIZE,ROTA,RO,TYPE,PKNAME")
+		"--bytes", "--nodeps", "--pairs", "--output", "SIZE,ROTA,RO,TYPE,PKNAME,TRAN")
 	if err != nil {
 		// try to get more information about the command error
 		cmdErr, ok := err.(*exec.CommandError)
@@ -164,6 +164,8 @@ spec:
     singular: cephfilesystem
   scope: Namespaced
   version: v1
+  subresources:
+    status: {}
   additionalPrinterColumns:
     - name: MdsCount
       type: string
@@ -172,6 +174,10 @@ spec:
     - name: Age
       type: date
       JSONPath: .metadata.creationTimestamp
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
 ---
 apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
@@ -188,6 +194,134 @@ spec:
     - nfs
   scope: Namespaced
   version: v1
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephnfsexports.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephNFSExport
+    listKind: CephNFSExportList
+    plural: cephnfsexports
+    singular: cephnfsexport
+    shortNames:
+    - nfsexport
+  scope: Namespaced
+  version: v1
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+    - name: Server
+      type: string
+      description: The CephNFS serving the export
+      JSONPath: .spec.server
+    - name: Pseudo
+      type: string
+      description: Path of the export in the NFSv4 pseudo filesystem
+      JSONPath: .spec.pseudoPath
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephcrushmaps.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephCrushMap
+    listKind: CephCrushMapList
+    plural: cephcrushmaps
+    singular: cephcrushmap
+  scope: Namespaced
+  version: v1
+  subresources:
+    status: {}
+  validation:
+    openAPIV3Schema:
+      properties:
+        spec:
+          properties:
+            buckets:
+              type: array
+            osds:
+              type: array
+            rules:
+              type: array
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephosdreplacements.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephOSDReplacement
+    listKind: CephOSDReplacementList
+    plural: cephosdreplacements
+    singular: cephosdreplacement
+    shortNames:
+    - osdreplacement
+  scope: Namespaced
+  version: v1
+  subresources:
+    status: {}
+  validation:
+    openAPIV3Schema:
+      properties:
+        spec:
+          properties:
+            osdID:
+              type: integer
+              minimum: 0
+            devicePath:
+              type: string
+              pattern: ^/dev/
+            migrate:
+              type: boolean
+          required:
+          - osdID
+  additionalPrinterColumns:
+    - name: OSD
+      type: integer
+      description: The id of the replaced osd
+      JSONPath: .spec.osdID
+    - name: Node
+      type: string
+      description: The node of the replaced osd
+      JSONPath: .status.node
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
 ---
 apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
@@ -202,6 +336,16 @@ spec:
     singular: cephobjectstore
   scope: Namespaced
   version: v1
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
 ---
 apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
@@ -216,6 +360,77 @@ spec:
     singular: cephobjectstoreuser
   scope: Namespaced
   version: v1
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: objectbucketclaims.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: ObjectBucketClaim
+    listKind: ObjectBucketClaimList
+    plural: objectbucketclaims
+    singular: objectbucketclaim
+    shortNames:
+    - obc
+  scope: Namespaced
+  version: v1
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+    - name: StorageClass
+      type: string
+      JSONPath: .spec.storageClassName
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: objectbuckets.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: ObjectBucket
+    listKind: ObjectBucketList
+    plural: objectbuckets
+    singular: objectbucket
+    shortNames:
+    - ob
+  scope: Cluster
+  version: v1
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+    - name: StorageClass
+      type: string
+      JSONPath: .spec.storageClassName
+    - name: ReclaimPolicy
+      type: string
+      JSONPath: .spec.reclaimPolicy
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
 ---
 apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
@@ -230,6 +445,16 @@ spec:
     singular: cephblockpool
   scope: Namespaced
   version: v1
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Current Phase
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
 ---
 apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
@@ -921,6 +1146,9 @@ rules:
 - apiGroups: [""]
   resources: ["configmaps"]
   verbs: [ "get", "list", "watch", "create", "update", "delete" ]
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: [ "get", "create", "update" ]
 ---
 # Aspects of ceph-mgr that operate within the cluster's namespace
 kind: Role